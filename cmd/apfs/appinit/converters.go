@@ -9,10 +9,11 @@ import (
 	"github.com/apfs-io/apfs/internal/storage/converters"
 	"github.com/apfs-io/apfs/internal/workflow"
 	"github.com/apfs-io/apfs/libs/converters/image"
+	"github.com/apfs-io/apfs/libs/converters/probe"
 	"github.com/apfs-io/apfs/libs/converters/proc"
 )
 
-var allDefaultConvs = []string{"image", "procedure"}
+var allDefaultConvs = []string{"image", "procedure", "probe"}
 
 // ProcStore loads the procedure store from config. Returns nil (and logs a
 // warning) when no procedure directory is configured.
@@ -42,7 +43,7 @@ func Converters(ctx context.Context, conf *appcontext.StorageConfig, logger *zap
 		switch convName {
 		case "image":
 			convs = append(convs, image.NewDefaultConverter())
-		case "procedure", "shell", "exec", "docker":
+		case "procedure", "shell", "exec", "docker", "probe":
 			// Handled by the workflow StepRunner (see StepRunners). No
 			// legacy converter bridge is needed.
 		default:
@@ -67,6 +68,8 @@ func StepRunners(ctx context.Context, conf *appcontext.StorageConfig, logger *za
 			reg.Register(image.NewDefaultConverter().StepRunner())
 		case "procedure", "shell", "exec", "docker":
 			reg.Register(proc.New(store))
+		case "probe":
+			reg.Register(probe.New())
 		default:
 			logger.Fatal("undefined converter", zap.String("name", convName))
 		}
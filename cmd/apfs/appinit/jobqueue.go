@@ -0,0 +1,35 @@
+package appinit
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/cmd/apfs/appcontext"
+	"github.com/apfs-io/apfs/internal/jobqueue"
+	jobqueuebadger "github.com/apfs-io/apfs/internal/jobqueue/badger"
+	jobqueueredis "github.com/apfs-io/apfs/internal/jobqueue/redis"
+)
+
+// jobQueue opens the workflow job queue of the connection, nil if not configured
+func jobQueue(conf *appcontext.EventstreamConfig) (jobqueue.Queue, error) {
+	var (
+		conn = conf.JobqueueConnect
+		opts = []jobqueue.Option{
+			jobqueue.WithVisibilityTimeout(conf.JobqueueVisibilityTimeout),
+			jobqueue.WithMaxDeliveries(conf.JobqueueMaxDeliveries),
+		}
+	)
+	switch {
+	case conn == "":
+		return nil, nil
+	case conn == "memory" || strings.HasPrefix(conn, "memory://"):
+		return jobqueuebadger.NewInMemory(opts...)
+	case strings.HasPrefix(conn, "badger://"):
+		return jobqueuebadger.New(conn, opts...)
+	case strings.HasPrefix(conn, "redis://"):
+		return jobqueueredis.New(conn, opts...)
+	default:
+		return nil, errors.Wrap(jobqueue.ErrUndefinedQueueScheme, conn)
+	}
+}
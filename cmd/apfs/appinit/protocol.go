@@ -8,33 +8,50 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/apfs-io/apfs/cmd/apfs/appcontext"
+	"github.com/apfs-io/apfs/internal/driver/tiered"
 	api "github.com/apfs-io/apfs/internal/server/v1"
 	"github.com/apfs-io/apfs/internal/stream"
+	"github.com/apfs-io/apfs/libs/converters/image"
+	"github.com/apfs-io/apfs/models"
 )
 
 // EventStreamName for income events
 const EventStreamName = "events"
 
-// ProtocolAPIObject inites the API implementation
-func ProtocolAPIObject(ctx context.Context, eventsConf *appcontext.EventstreamConfig, storageConf *appcontext.StorageConfig, workerTags []string, logger *zap.Logger) (api.ServiceServer, error) {
+// ProtocolAPIObject inites the API implementation, the options are applied
+// after the ones of the configuration
+func ProtocolAPIObject(ctx context.Context, eventsConf *appcontext.EventstreamConfig, storageConf *appcontext.StorageConfig, workerTags []string, logger *zap.Logger, opts ...api.Option) (api.ServiceServer, error) {
 	// Register the notification stream
-	events, err := registerStream(ctx, EventStreamName, eventsConf.Connect)
+	events, err := registerStream(ctx, EventStreamName, eventsConf.Connect, eventsConf.Format)
 	if err != nil {
 		return nil, err
 	}
+	locker := jobLocker(storageConf)
+	queue, err := jobQueue(eventsConf)
+	if err != nil {
+		return nil, errors.Wrap(err, "job queue")
+	}
 	srvLogic, err := api.NewServer(ctx,
 		storageConf.MetadbConnect,
 		storageConf.Connect,
 		storageConf.StateConnect,
-		api.WithStageProcessingLimit(storageConf.ProcessingStageLimit),
-		api.WithTaskProcessingLimit(storageConf.ProcessingTaskLimit),
-		api.WithEventstream(events),
-		api.WithUpdateState(updateLocker(storageConf)),
-		api.WithStorageConverters(Converters(ctx, storageConf, logger)),
-		api.WithWorkflowExecutor(StepRunners(ctx, storageConf, logger)),
-		api.WithWorkerTags(workerTags),
-		api.WithRetries(storageConf.ProcessingMaxRetries),
-		api.WithWorkflowsBootstrap(storageConf.WorkflowsDir, storageConf.WorkflowsReconfigure),
+		append([]api.Option{
+			api.WithStageProcessingLimit(storageConf.ProcessingStageLimit),
+			api.WithTaskProcessingLimit(storageConf.ProcessingTaskLimit),
+			api.WithEventstream(events),
+			api.WithUpdateState(updateLocker(storageConf, locker)),
+			api.WithJobLocker(locker, storageConf.ProcessingLockTTL),
+			api.WithJobQueue(queue),
+			api.WithStepLogs(int(models.ParseSize(storageConf.ProcessingStepLogSize)), storageConf.ProcessingStepLogFlush),
+			api.WithStorageConverters(Converters(ctx, storageConf, logger)),
+			api.WithWorkflowExecutor(StepRunners(ctx, storageConf, logger)),
+			api.WithWorkerTags(workerTags),
+			api.WithTransformer(image.NewDefaultConverter()),
+			api.WithRetries(storageConf.ProcessingMaxRetries),
+			api.WithWorkflowsBootstrap(storageConf.WorkflowsDir, storageConf.WorkflowsReconfigure),
+			api.WithStorageTiers(storageTiers(storageConf), storageConf.TiersMoveInterval),
+			api.WithStorageCache(storageConf.CacheDir, models.ParseSize(storageConf.CacheSize)),
+		}, opts...)...,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "server create")
@@ -42,8 +59,25 @@ func ProtocolAPIObject(ctx context.Context, eventsConf *appcontext.EventstreamCo
 	return srvLogic, nil
 }
 
-func registerStream(ctx context.Context, name, connect string) (nc.Publisher, error) {
-	stream, err := stream.NewWriter(ctx, connect)
+func storageTiers(storageConf *appcontext.StorageConfig) []api.StorageTier {
+	tiers := make([]api.StorageTier, 0, len(storageConf.Tiers))
+	for _, tier := range storageConf.Tiers {
+		tiers = append(tiers, api.StorageTier{
+			Name:    tier.Name,
+			Connect: tier.Connect,
+			Rule: tiered.Rule{
+				Roles:   tier.Roles,
+				MinSize: models.ParseSize(tier.MinSize),
+				MaxSize: models.ParseSize(tier.MaxSize),
+				MinAge:  tier.MinAge,
+			},
+		})
+	}
+	return tiers
+}
+
+func registerStream(ctx context.Context, name, connect, format string) (nc.Publisher, error) {
+	stream, err := stream.NewWriter(ctx, connect, stream.WithFormat(format))
 	if err != nil {
 		return nil, errors.Wrap(err, "connect to: "+connect)
 	}
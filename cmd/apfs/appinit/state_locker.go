@@ -1,54 +1,70 @@
 package appinit
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/demdxx/gocast/v2"
-	"github.com/demdxx/interlock/redislock"
-	lru "github.com/hashicorp/golang-lru/v2"
-	"github.com/pkg/errors"
 
 	"github.com/apfs-io/apfs/cmd/apfs/appcontext"
 	api "github.com/apfs-io/apfs/internal/server/v1"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/badger"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/redis"
 )
 
-func updateLocker(conf *appcontext.StorageConfig) api.UpdateStateFunc {
+// updateLocker returns the update interlock of the objects over the job lease
+// locker, so the update is released when the processing ends and fenced by
+// the same backend
+func updateLocker(conf *appcontext.StorageConfig, locker kvaccessor.Locker) api.UpdateStateLease {
+	return leaseLocker(locker, conf.ProcessingLifetime)
+}
+
+// jobLocker returns the workflow job lease locker of the interlock connection
+func jobLocker(conf *appcontext.StorageConfig) kvaccessor.Locker {
 	conn := conf.ProcessingInterlockConnect
 	switch {
 	case strings.HasPrefix(conn, "redis://"):
-		return redisLocker(conn, conf.ProcessingLifetime)
+		locker, err := redis.New(conn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return locker
+	case strings.HasPrefix(conn, "badger://"):
+		locker, err := badger.New(conn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return locker
 	case conn == "memory" || conn == "":
-		return lruLocker(conf.ProcessingLifetime)
+		return memory.NewLocker()
 	default:
 		panic(fmt.Errorf("invalid interlock option: %s", conf.ProcessingInterlockConnect))
 	}
 }
 
-func redisLocker(conn string, lifetime time.Duration) api.UpdateStateFunc {
-	rlock, err := redislock.NewByURL(conn, lifetime)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return func(key any) bool {
-		return rlock.TryLock(key) != nil
-	}
-}
-
-func lruLocker(lifetime time.Duration) api.UpdateStateFunc {
-	cache, err := lru.New[string, any](1024)
-	if err != nil {
-		panic(errors.Wrap(err, `init LRU cache`))
-	}
-	return func(key any) bool {
-		skey := gocast.Str(key)
-		tm, ok := cache.Get(skey)
-		if !ok || tm == nil || time.Since(tm.(time.Time)) > lifetime {
-			cache.Add(skey, time.Now())
+// leaseLocker holds the update lease of the key until the processing of the
+// object is finished, but not longer than the lifetime
+func leaseLocker(locker kvaccessor.Locker, lifetime time.Duration) api.UpdateStateLease {
+	var leases sync.Map
+	return api.UpdateStateLease{
+		Begin: func(key any) bool {
+			lease, err := locker.Acquire(context.Background(), "apfs:update:"+gocast.Str(key), "state", lifetime)
+			if err != nil {
+				return false
+			}
+			leases.Store(lease.Key, lease)
 			return true
-		}
-		return false
+		},
+		End: func(key any) {
+			if lease, ok := leases.LoadAndDelete("apfs:update:" + gocast.Str(key)); ok {
+				_ = locker.Release(context.Background(), lease.(*kvaccessor.Lease))
+			}
+		},
 	}
 }
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"strings"
 
 	"github.com/demdxx/goconfig"
 )
@@ -14,7 +15,8 @@ type ICommand interface {
 	Run(ctx context.Context, args []string) error
 }
 
-// CommandFunc is a function that can be executed by the command line
+// CommandFunc is a function that can be executed by the command line,
+// args are the positional arguments of the command
 type CommandFunc[T any] func(ctx context.Context, args []string, config *T) error
 
 // Command is a command that can be executed by the command line
@@ -41,7 +43,11 @@ func (c *Command[T]) Help() string {
 
 // Run the command with the given context and arguments
 func (c *Command[T]) Run(ctx context.Context, args []string) error {
-	var config T
+	var (
+		config     T
+		positional []string
+	)
+	positional, args = SplitArgs(args)
 	// Parse config from args and environment
 	err := goconfig.Load(
 		&config,
@@ -52,5 +58,17 @@ func (c *Command[T]) Run(ctx context.Context, args []string) error {
 	if err != nil {
 		return err
 	}
-	return c.Exec(ctx, args, &config)
+	return c.Exec(ctx, positional, &config)
+}
+
+// SplitArgs returns the leading positional arguments and the flags
+//
+//	apfs stats avatars --api=tcp://apfs:8081
+func SplitArgs(args []string) (positional, flags []string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return args[:i], args[i:]
+		}
+	}
+	return args, nil
 }
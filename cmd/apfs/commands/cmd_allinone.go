@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/cmd/apfs/appcontext"
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/stream/local"
+)
+
+// defaultAllInOneStream is the event stream of the all-in-one command
+// without EVENTSTREAM_CONNECT
+const defaultAllInOneStream = "mem://events"
+
+// allInOneConfig holds the configuration for the all-in-one command.
+type allInOneConfig struct {
+	Server      appcontext.ServerConfig      `json:"server" yaml:"server"`
+	Storage     appcontext.StorageConfig     `json:"storage" yaml:"storage"`
+	Eventstream appcontext.EventstreamConfig `json:"eventstream" yaml:"eventstream"`
+	Worker      appcontext.WorkerConfig      `json:"worker" yaml:"worker"`
+}
+
+// AllInOneCommand defines the CLI command running the server and the
+// processor in one process without the external broker.
+var AllInOneCommand = &Command[allInOneConfig]{
+	Name:     "all-in-one",
+	HelpDesc: "Run server and processor in one process sharing the in-process event stream",
+	Exec:     allInOneCommandExec,
+}
+
+// allInOneCommandExec runs the server with the embedded processor. Both use
+// the same in-process stream (mem:// or badger://) of the process.
+func allInOneCommandExec(ctx context.Context, args []string, config *allInOneConfig) error {
+	if config.Eventstream.Connect == "" {
+		config.Eventstream.Connect = defaultAllInOneStream
+	}
+	if !local.IsLocal(config.Eventstream.Connect) {
+		ctxlogger.Get(ctx).Warn("all-in-one with the external event stream",
+			zap.String("connect", config.Eventstream.Connect))
+	}
+	return serverCommandExec(ctx, args, &serverConfig{
+		Processing:  true,
+		Server:      config.Server,
+		Storage:     config.Storage,
+		Eventstream: config.Eventstream,
+		Worker:      config.Worker,
+	})
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	api "github.com/apfs-io/apfs/internal/server/v1"
+	"github.com/apfs-io/apfs/internal/storage/migrate"
+)
+
+var errMigrateStoragesRequired = errors.New("source and destination are required: apfs migrate --from=<connect> --to=<connect>")
+
+// migrateConfig defines the configuration of the migrate command.
+type migrateConfig struct {
+	From        string `cli:"from"`  // Source storage connection
+	To          string `cli:"to"`    // Destination storage connection
+	Group       string `cli:"group"` // Migrate the single group only
+	Concurrency int    `cli:"concurrency" default:"4"`
+	Checkpoint  string `cli:"checkpoint" default:"apfs-migrate.checkpoint"`
+	DryRun      bool   `cli:"dry-run"`
+}
+
+// MigrateCommand copies the objects between the storage drivers.
+var MigrateCommand = &Command[migrateConfig]{
+	Name:     "migrate",
+	HelpDesc: "Copy objects between storages: apfs migrate --from=<connect> --to=<connect> [--group=g] [--concurrency=4] [--checkpoint=file] [--dry-run]",
+	Exec:     migrateCommandExec,
+}
+
+func migrateCommandExec(ctx context.Context, _ []string, config *migrateConfig) error {
+	if config.From == "" || config.To == "" {
+		return errMigrateStoragesRequired
+	}
+	src, err := api.NewStorageDriver(ctx, config.From)
+	if err != nil {
+		return err
+	}
+	dst, err := api.NewStorageDriver(ctx, config.To)
+	if err != nil {
+		return err
+	}
+	report, err := migrate.New(src, dst,
+		migrate.WithGroup(config.Group),
+		migrate.WithConcurrency(config.Concurrency),
+		migrate.WithCheckpoint(config.Checkpoint),
+		migrate.WithDryRun(config.DryRun),
+	).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if config.DryRun {
+		_, _ = fmt.Fprintln(w, "Dry run, nothing copied")
+	}
+	_, _ = fmt.Fprintf(w, "Workflows:\t%d\n", report.Workflows)
+	_, _ = fmt.Fprintf(w, "Objects:\t%d\n", report.Objects)
+	_, _ = fmt.Fprintf(w, "Skipped:\t%d\n", report.Skipped)
+	_, _ = fmt.Fprintf(w, "Files:\t%d\n", report.Files)
+	_, _ = fmt.Fprintf(w, "Bytes:\t%d\n", report.Bytes)
+	_, _ = fmt.Fprintf(w, "Failed:\t%d\n", len(report.Failed))
+	for _, id := range report.Failed {
+		_, _ = fmt.Fprintf(w, "  %s\n", id)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d objects failed, run the command again to retry", len(report.Failed))
+	}
+	return nil
+}
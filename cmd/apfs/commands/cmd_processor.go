@@ -3,16 +3,22 @@ package commands
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"runtime"
+	"time"
 
 	nc "github.com/geniusrabbit/notificationcenter/v2"
 	"github.com/geniusrabbit/notificationcenter/v2/wrappers/concurrency"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 
 	"github.com/apfs-io/apfs/cmd/apfs/appcontext"
 	"github.com/apfs-io/apfs/cmd/apfs/appinit"
 	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/notify"
+	api "github.com/apfs-io/apfs/internal/server/v1"
+	"github.com/apfs-io/apfs/internal/server/v1/tools"
 	"github.com/apfs-io/apfs/internal/stream"
 )
 
@@ -43,9 +49,17 @@ func processorCommandExec(ctx context.Context, args []string, config *processorC
 		&config.Eventstream, &config.Storage, config.Worker.Tags, logger)
 	fatalError(err, "protocol initialization")
 
+	// Expose the workflow execution metrics, the webhook deliveries and the
+	// health of the worker. The endpoints outlive the drain on the shutdown.
+	if config.Worker.MetricsListen != "" {
+		httpCtx, stopHTTP := context.WithCancel(context.WithoutCancel(ctx))
+		defer stopHTTP()
+		go runMetricsServer(httpCtx, config.Worker.MetricsListen, protoAPI, logger)
+	}
+
 	// Execute the processor logic.
 	return runProcessor(ctx, &config.Eventstream, &config.Storage,
-		protoAPI.(nc.Receiver), logger)
+		&config.Worker, protoAPI.(nc.Receiver), logger)
 }
 
 // runProcessor handles the core processing logic for the processor command.
@@ -53,6 +67,7 @@ func runProcessor(
 	ctx context.Context,
 	eventsConf *appcontext.EventstreamConfig,
 	storageConf *appcontext.StorageConfig,
+	workerConf *appcontext.WorkerConfig,
 	reveiver nc.Receiver,
 	logger *zap.Logger,
 ) error {
@@ -94,7 +109,92 @@ func runProcessor(
 		return errors.Wrap(err, "subscribe processor handler")
 	}
 
-	// Start listening for events.
+	// Run the workflow job queue consumers if the queue is configured.
+	if consumer, ok := reveiver.(api.JobConsumer); ok && eventsConf.JobqueueConnect != "" {
+		fmt.Println("Run job consumer:", eventsConf.JobqueueConnect)
+		go func() {
+			if err := consumer.ConsumeJobs(ctx, eventsConf.Concurrency); err != nil && ctx.Err() == nil {
+				logger.Error(`job consumer`, zap.Error(err))
+			}
+		}()
+	}
+
+	// Recover the jobs left running by the lost workers.
+	if reaper, ok := reveiver.(api.JobReaper); ok && workerConf.ReaperInterval > 0 {
+		fmt.Println("Run stuck job reaper:", workerConf.ReaperInterval)
+		go func() {
+			if err := reaper.ReapJobs(ctx, workerConf.ReaperInterval, workerConf.StaleJobTimeout); err != nil && ctx.Err() == nil {
+				logger.Error(`stuck job reaper`, zap.Error(err))
+			}
+		}()
+	}
+
+	// Start listening for events. The shutdown signal doesn't cancel the
+	// events in process, they are drained.
 	fmt.Println("Run listener:", eventsConf.Connect)
-	return nc.Listen(ctx)
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- nc.Listen(context.WithoutCancel(ctx)) }()
+	select {
+	case err = <-listenErr:
+		return err
+	case <-ctx.Done():
+	}
+	return drainProcessor(ctx, events, reveiver, workerConf.ShutdownGrace)
+}
+
+// drainProcessor stops consuming the events and waits for the running
+// events and jobs up to the grace period, then interrupts the running jobs
+// and reschedules them for the other workers.
+func drainProcessor(ctx context.Context, events nc.Subscriber, reveiver nc.Receiver, grace time.Duration) error {
+	logger := ctxlogger.Get(ctx)
+	fmt.Println("Drain processor:", grace)
+
+	// Stop consuming the new events
+	if err := events.Close(); err != nil {
+		logger.Warn(`close event stream`, zap.Error(err))
+	}
+	drainer, ok := reveiver.(api.Drainer)
+	if !ok {
+		return nil
+	}
+	if err := drainer.Drain(context.WithoutCancel(ctx), grace); err != nil {
+		return errors.Wrap(err, "drain processor")
+	}
+	return nil
+}
+
+// runMetricsServer serves the Prometheus metrics, the health endpoints and
+// the webhook delivery log of the processor until the context is done.
+func runMetricsServer(ctx context.Context, listen string, srv any, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", tools.HealthCheck)
+	mux.HandleFunc("/readyz", readinessHandler(srv))
+	if webhooks, ok := srv.(api.WebhookLogger); ok {
+		mux.Handle("/webhooks/deliveries", notify.LogHandler(webhooks.WebhookLog()))
+	}
+	httpSrv := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = httpSrv.Close()
+	}()
+	fmt.Println("Run metrics server:", listen)
+	if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error(`metrics server`, zap.Error(err))
+	}
+}
+
+// readinessHandler reports whether the processor accepts the new work,
+// it's not ready while draining
+func readinessHandler(srv any) http.HandlerFunc {
+	drainer, _ := srv.(api.Drainer)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if drainer != nil && !drainer.Ready() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"status":"draining"}`))
+			return
+		}
+		tools.HealthCheck(w, r)
+	}
 }
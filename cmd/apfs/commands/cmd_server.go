@@ -37,15 +37,20 @@ func serverCommandExec(ctx context.Context, args []string, config *serverConfig)
 
 	// Initialize the protocol API object with eventstream, storage, and logger configurations.
 	protoAPI, err := appinit.ProtocolAPIObject(ctx,
-		&config.Eventstream, &config.Storage, config.Worker.Tags, logger)
+		&config.Eventstream, &config.Storage, config.Worker.Tags, logger,
+		v1.WithTrustedProxies(config.Server.TrustedProxies))
 	fatalError(err, "protocol initialization")
 
 	// Run the processor if the Processing flag is set.
+	processorDone := make(chan struct{})
 	if config.Processing {
 		go func() {
+			defer close(processorDone)
 			fatalError(runProcessor(ctx,
-				&config.Eventstream, &config.Storage, protoAPI.(nc.Receiver), logger))
+				&config.Eventstream, &config.Storage, &config.Worker, protoAPI.(nc.Receiver), logger))
 		}()
+	} else {
+		close(processorDone)
 	}
 
 	// Wrap the protocol API with an HTTP wrapper.
@@ -73,5 +78,11 @@ func serverCommandExec(ctx context.Context, args []string, config *serverConfig)
 	}
 
 	// Run the server with the specified HTTP and gRPC listen addresses.
-	return srv.Run(ctx, config.Server.HTTP.Listen, config.Server.GRPC.Listen)
+	err = srv.Run(ctx, config.Server.HTTP.Listen, config.Server.GRPC.Listen)
+
+	// Wait for the drain of the embedded processor on the shutdown
+	if ctx.Err() != nil {
+		<-processorDone
+	}
+	return err
 }
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/apfs-io/apfs/libs/client"
+	"github.com/apfs-io/apfs/models"
+)
+
+var errStatsGroupRequired = errors.New("group name is required: apfs stats <group>")
+
+// statsConfig defines the configuration of the stats command.
+type statsConfig struct {
+	// API is the gRPC address of the apfs server
+	API    string `cli:"api" env:"APFS_API" default:"tcp://127.0.0.1:8081"`
+	Format string `cli:"format" default:"text"` // text or json
+}
+
+// StatsCommand prints the usage statistics of the group.
+var StatsCommand = &Command[statsConfig]{
+	Name:     "stats",
+	HelpDesc: "Print group statistics: apfs stats <group> [--api=tcp://host:8081] [--format=json]",
+	Exec:     statsCommandExec,
+}
+
+func statsCommandExec(ctx context.Context, args []string, config *statsConfig) error {
+	if len(args) < 1 || args[0] == "" {
+		return errStatsGroupRequired
+	}
+	cli, err := client.Connect(ctx, config.API)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	stats, err := cli.Group(args[0]).Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	return printGroupStats(os.Stdout, stats)
+}
+
+// processingStatusOrder of the stats output
+var processingStatusOrder = []models.ProcessingStatus{
+	models.ProcessingStatusPending,
+	models.ProcessingStatusRunning,
+	models.ProcessingStatusCompleted,
+	models.ProcessingStatusPartial,
+	models.ProcessingStatusFailed,
+	models.ProcessingStatusCancelled,
+}
+
+func printGroupStats(out io.Writer, stats *client.GroupStats) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Group:\t%s\n", stats.Group)
+	_, _ = fmt.Fprintf(w, "Objects:\t%d\n", stats.Objects)
+	_, _ = fmt.Fprintf(w, "Original bytes:\t%d\n", stats.OriginalBytes)
+	_, _ = fmt.Fprintf(w, "Derived bytes:\t%d\n", stats.DerivedBytes)
+	_, _ = fmt.Fprintf(w, "Total bytes:\t%d\n", stats.TotalBytes)
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "STATUS\tOBJECTS")
+	for _, status := range processingStatusOrder {
+		_, _ = fmt.Fprintf(w, "%s\t%d\n", status, stats.Processing[status])
+	}
+	if len(stats.Jobs) > 0 {
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "JOB\tCOMPLETED\tFAILED\tAVG DURATION")
+		for _, job := range stats.Jobs {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", job.ID, job.Completed, job.Failed, job.AverageDuration)
+		}
+	}
+	return w.Flush()
+}
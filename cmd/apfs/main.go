@@ -14,6 +14,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 
 	"github.com/demdxx/goconfig"
 	"go.uber.org/zap"
@@ -27,6 +28,7 @@ import (
 	"github.com/apfs-io/apfs/internal/context/ctxlogger"
 	"github.com/apfs-io/apfs/internal/context/version"
 	"github.com/apfs-io/apfs/internal/profiler"
+	"github.com/apfs-io/apfs/internal/tracing"
 	"github.com/apfs-io/apfs/internal/zlogger"
 )
 
@@ -41,6 +43,9 @@ var (
 var cmdList = commands.ICommands{
 	commands.ServerCommand,
 	commands.ProcessorCommand,
+	commands.AllInOneCommand,
+	commands.StatsCommand,
+	commands.MigrateCommand,
 }
 
 func init() {
@@ -58,7 +63,7 @@ func init() {
 
 	args := os.Args
 	if len(args) > 1 {
-		args = args[2:]
+		_, args = commands.SplitArgs(args[2:])
 	}
 
 	fatalError(goconfig.Load(
@@ -89,7 +94,7 @@ func init() {
 func main() {
 	var (
 		logger      = zap.L()
-		ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt)
+		ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	)
 	defer cancel()
 
@@ -120,6 +125,11 @@ func main() {
 		return
 	}
 
+	// Export the traces of the uploads, events and jobs
+	shutdownTracing, err := tracing.Init(ctx, config.ServiceName, buildVersion,
+		config.Tracing.Exporter, config.Tracing.SampleRatio)
+	fatalError(err, "tracing initialization")
+
 	// Profiling server of collector
 	profiler.Run(config.Server.Profile.Mode,
 		config.Server.Profile.Listen, logger)
@@ -129,7 +139,11 @@ func main() {
 	fmt.Println("░█ Run command:\x1b[31m", icmd.Cmd(), "\x1b[0m")
 	fmt.Println()
 
-	fatalError(icmd.Run(ctx, os.Args[2:]), "command execution")
+	err = icmd.Run(ctx, os.Args[2:])
+
+	// Flush the buffered spans before exit
+	_ = shutdownTracing(context.WithoutCancel(ctx))
+	fatalError(err, "command execution")
 }
 
 func printCommandsUsage() {
@@ -167,6 +167,16 @@ func (s *GRPCServer) RunHTTP(ctx context.Context, address string) error {
 	mux.Get("/object/*", s.API.GetHTTPHandler)
 	mux.Post("/object", s.API.UploadHTTPHandler)
 	mux.Post("/object/{group}", s.API.UploadHTTPHandler)
+	mux.Get("/v1/logs/*", s.API.GetStepLogsHTTPHandler)
+	mux.Get("/v1/objects", s.API.ListObjectsHTTPHandler)
+	mux.Get("/v1/{group}/*", func(w http.ResponseWriter, r *http.Request) {
+		// Transformations share the /v1 prefix with the gateway routes
+		if v1.IsTransformPath(r.URL.Path) {
+			s.API.TransformHTTPHandler(w, r)
+		} else {
+			gw.ServeHTTP(w, r)
+		}
+	})
 	mux.Handle("/swagger/", s.swaggerHandler())
 	mux.HandleFunc("/health", tools.HealthCheck)
 	mux.Handle("/metrics", promhttp.Handler())
@@ -26,6 +26,8 @@ type (
 	ProcessingCounters = client.ProcessingCounters
 	JobState        = client.JobState
 	StepState       = client.StepState
+	GroupStats      = client.GroupStats
+	JobStats        = client.JobStats
 
 	// Model types
 	ObjectType        = models.ObjectType
@@ -0,0 +1,44 @@
+package ctxcorrelation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+var (
+	// CtxCorrelationID reference to the correlation ID of the events
+	CtxCorrelationID = struct{ s string }{"correlation_id"}
+)
+
+// Get correlation ID of the context, returns empty string if not set
+func Get(ctx context.Context) string {
+	id, _ := ctx.Value(CtxCorrelationID).(string)
+	return id
+}
+
+// WithCorrelationID puts the correlation ID of the events to context,
+// the context is returned as is if the ID is empty
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, CtxCorrelationID, id)
+}
+
+// Ensure returns the context with the correlation ID, the new ID is
+// generated if the context has none
+func Ensure(ctx context.Context) (context.Context, string) {
+	if id := Get(ctx); id != "" {
+		return ctx, id
+	}
+	id := NewID()
+	return WithCorrelationID(ctx, id), id
+}
+
+// NewID returns the new random correlation ID
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,21 @@
+package ctxcorrelation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrelationID(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", Get(ctx))
+	assert.Equal(t, ctx, WithCorrelationID(ctx, ""))
+
+	ctx, id := Ensure(ctx)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, Get(ctx))
+
+	_, same := Ensure(ctx)
+	assert.Equal(t, id, same)
+}
@@ -0,0 +1,21 @@
+package ctxprincipal
+
+import (
+	"context"
+)
+
+var (
+	// CtxPrincipalObject reference to the caller identity
+	CtxPrincipalObject = struct{ s string }{"principal"}
+)
+
+// Get principal of the request, returns empty string if not set
+func Get(ctx context.Context) string {
+	principal, _ := ctx.Value(CtxPrincipalObject).(string)
+	return principal
+}
+
+// WithPrincipal puts the caller identity (user, token or address) to context
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, CtxPrincipalObject, principal)
+}
@@ -0,0 +1,15 @@
+package ctxprincipal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", Get(ctx))
+	ctx = WithPrincipal(ctx, "127.0.0.1")
+	assert.Equal(t, "127.0.0.1", Get(ctx))
+}
@@ -0,0 +1,96 @@
+package azblob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sharedKeyHeaders are the standard headers of the string to sign
+var sharedKeyHeaders = []string{
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-MD5",
+	"Content-Type",
+	"Date",
+	"If-Modified-Since",
+	"If-Match",
+	"If-None-Match",
+	"If-Unmodified-Since",
+	"Range",
+}
+
+// signSharedKey authorizes the request by the account key
+// https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key
+func signSharedKey(req *http.Request, account string, key []byte) {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(stringToSign(req, account)))
+	req.Header.Set("Authorization", "SharedKey "+account+":"+
+		base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func stringToSign(req *http.Request, account string) string {
+	var buf strings.Builder
+	buf.WriteString(req.Method)
+	buf.WriteByte('\n')
+	for _, name := range sharedKeyHeaders {
+		value := req.Header.Get(name)
+		if name == "Content-Length" {
+			value = ""
+			if req.ContentLength > 0 {
+				value = strconv.FormatInt(req.ContentLength, 10)
+			}
+		}
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+
+	// Canonicalized headers
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+	for _, name := range msHeaders {
+		buf.WriteString(name)
+		buf.WriteByte(':')
+		buf.WriteString(strings.TrimSpace(req.Header.Get(name)))
+		buf.WriteByte('\n')
+	}
+
+	// Canonicalized resource
+	buf.WriteString("/" + account + req.URL.EscapedPath())
+	query := canonicalQuery(req.URL.Query())
+	for _, name := range sortedKeys(query) {
+		values := query[name]
+		sort.Strings(values)
+		buf.WriteString("\n" + name + ":" + strings.Join(values, ","))
+	}
+	return buf.String()
+}
+
+func canonicalQuery(query url.Values) url.Values {
+	resp := make(url.Values, len(query))
+	for name, values := range query {
+		lower := strings.ToLower(name)
+		resp[lower] = append(resp[lower], values...)
+	}
+	return resp
+}
+
+func sortedKeys(values url.Values) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
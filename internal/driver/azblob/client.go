@@ -0,0 +1,262 @@
+package azblob
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/driver/blob"
+)
+
+const (
+	// apiVersion of the Blob service REST API
+	apiVersion = "2021-08-06"
+
+	// copyPollInterval of the pending copy status
+	copyPollInterval = 200 * time.Millisecond
+)
+
+// ErrCopyFailed is returned if the server side copy is failed or aborted
+var ErrCopyFailed = errors.New("[azblob] copy failed")
+
+// Client of the container over the Blob service REST API
+type Client struct {
+	httpClient *http.Client
+	endpoint   *url.URL
+	container  string
+
+	// Shared key authorization, the SAS token is used if the key is empty
+	account string
+	key     []byte
+	sas     url.Values
+}
+
+// NewClient of the container. The endpoint is the blob service URL of the
+// account: https://<account>.blob.core.windows.net or
+// http://127.0.0.1:10000/devstoreaccount1 for Azurite.
+func NewClient(httpClient *http.Client, endpoint *url.URL, container, account string, key []byte, sas url.Values) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		httpClient: httpClient,
+		endpoint:   endpoint,
+		container:  container,
+		account:    account,
+		key:        key,
+		sas:        sas,
+	}
+}
+
+// Get implements blob.Client
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.blobURL(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Put implements blob.Client
+func (c *Client) Put(ctx context.Context, key string, data io.ReadSeeker, contentType string) error {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return c.doClose(ctx, http.MethodPut, c.blobURL(key), data, http.Header{
+		"Content-Type":   {contentType},
+		"X-Ms-Blob-Type": {"BlockBlob"},
+	})
+}
+
+// Delete implements blob.Client
+func (c *Client) Delete(ctx context.Context, key string) error {
+	err := c.doClose(ctx, http.MethodDelete, c.blobURL(key), nil, nil)
+	if blob.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// List implements blob.Client
+func (c *Client) List(ctx context.Context, prefix string) ([]*blob.Info, error) {
+	var (
+		list  []*blob.Info
+		query = url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+	)
+	for {
+		var page struct {
+			Blobs []struct {
+				Name       string `xml:"Name"`
+				Properties struct {
+					ContentLength int64 `xml:"Content-Length"`
+				} `xml:"Properties"`
+			} `xml:"Blobs>Blob"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		resp, err := c.do(ctx, http.MethodGet, c.containerURL(query), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Blobs {
+			list = append(list, &blob.Info{Key: item.Name, Size: item.Properties.ContentLength})
+		}
+		if page.NextMarker == "" {
+			return list, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+// Copy implements blob.Client, waits for the copy if it is pending
+func (c *Client) Copy(ctx context.Context, srcKey, dstKey string) error {
+	source := c.blobURL(srcKey)
+	if c.key == nil && len(c.sas) > 0 {
+		source.RawQuery = c.sas.Encode()
+	}
+	resp, err := c.do(ctx, http.MethodPut, c.blobURL(dstKey), nil,
+		http.Header{"X-Ms-Copy-Source": {source.String()}})
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	status := resp.Header.Get("X-Ms-Copy-Status")
+	for status == "pending" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(copyPollInterval):
+		}
+		if resp, err = c.do(ctx, http.MethodHead, c.blobURL(dstKey), nil, nil); err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		status = resp.Header.Get("X-Ms-Copy-Status")
+	}
+	if status != "" && status != "success" {
+		return errors.Wrap(ErrCopyFailed, status)
+	}
+	return nil
+}
+
+// CreateContainer if it does not exist
+func (c *Client) CreateContainer(ctx context.Context) error {
+	err := c.doClose(ctx, http.MethodPut, c.containerURL(url.Values{"restype": {"container"}}), nil, nil)
+	var respErr *ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) containerURL(query url.Values) *url.URL {
+	target := c.endpoint.JoinPath(c.container)
+	target.RawQuery = query.Encode()
+	return target
+}
+
+func (c *Client) blobURL(key string) *url.URL {
+	return c.endpoint.JoinPath(c.container, key)
+}
+
+func (c *Client) doClose(ctx context.Context, method string, target *url.URL, body io.ReadSeeker, header http.Header) error {
+	resp, err := c.do(ctx, method, target, body, header)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func (c *Client) do(ctx context.Context, method string, target *url.URL, body io.ReadSeeker, header http.Header) (*http.Response, error) {
+	var (
+		size   int64
+		reader io.Reader = http.NoBody
+		err    error
+	)
+	if body != nil {
+		if size, err = blob.ContentSize(body); err != nil {
+			return nil, err
+		}
+		if size > 0 {
+			reader = body
+		}
+	}
+	if c.key == nil && len(c.sas) > 0 {
+		query := target.Query()
+		for name, values := range c.sas {
+			query[name] = values
+		}
+		target.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", apiVersion)
+	if c.key != nil {
+		signSharedKey(req, c.account, c.key)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// ResponseError of the Blob service
+type ResponseError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("[azblob] %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func responseError(resp *http.Response) error {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = xml.Unmarshal(data, &body)
+	if body.Code == "" {
+		body.Code = resp.Header.Get("X-Ms-Error-Code")
+	}
+	if body.Message == "" {
+		body.Message = http.StatusText(resp.StatusCode)
+	}
+	err := &ResponseError{
+		StatusCode: resp.StatusCode,
+		Code:       body.Code,
+		Message:    strings.TrimSpace(strings.SplitN(body.Message, "\n", 2)[0]),
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.Wrap(blob.ErrNotFound, err.Error())
+	}
+	return err
+}
+
+var _ blob.Client = (*Client)(nil)
@@ -0,0 +1,42 @@
+// Package azblob implements file storage collection on Azure Blob Storage
+package azblob
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/apfs-io/apfs/internal/driver/blob"
+)
+
+// NewStorage of the objects in the Azure Blob Storage container. The container
+// is created if it does not exist and the account key is configured, the SAS
+// token usually has no permission for that.
+func NewStorage(ctx context.Context, options ...Options) (*blob.Storage, error) {
+	conf := optionConfig{httpClient: http.DefaultClient}
+	for _, opt := range options {
+		if err := opt(&conf); err != nil {
+			return nil, err
+		}
+	}
+	if conf.container == "" {
+		return nil, ErrContainerIsRequired
+	}
+	account, key, sas, err := conf.credentials()
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := conf.endpointURL(account)
+	if err != nil {
+		return nil, err
+	}
+	client := NewClient(conf.httpClient, endpoint, conf.container, account, key, sas)
+	if key != nil {
+		if err = client.CreateContainer(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return blob.NewStorage(client,
+		blob.WithPrefix(conf.prefix),
+		blob.WithFilepathGenerator(conf.genpattern),
+	), nil
+}
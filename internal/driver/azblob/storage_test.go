@@ -0,0 +1,279 @@
+package azblob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/blob"
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+const (
+	testAccount = "apfs"
+	testKey     = "c2VjcmV0LWtleQ==" // secret-key
+)
+
+// fakeServer is the subset of the Blob service API used by the client
+type fakeServer struct {
+	mx         sync.Mutex
+	containers map[string]map[string][]byte
+	pageSize   int
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, _ := base64.StdEncoding.DecodeString(testKey)
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(stringToSign(r, testAccount)))
+	if r.Header.Get("Authorization") != "SharedKey "+testAccount+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<Error><Code>AuthenticationFailed</Code><Message>Invalid signature</Message></Error>`))
+		return
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	container, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	if name == "" && query.Get("restype") == "container" {
+		switch {
+		case r.Method == http.MethodPut:
+			if _, ok := s.containers[container]; ok {
+				http.Error(w, "", http.StatusConflict)
+				return
+			}
+			s.containers[container] = map[string][]byte{}
+			w.WriteHeader(http.StatusCreated)
+		case query.Get("comp") == "list":
+			s.list(w, query, s.containers[container])
+		}
+		return
+	}
+	blobs, ok := s.containers[container]
+	if !ok {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		if source := r.Header.Get("X-Ms-Copy-Source"); source != "" {
+			sourceURL, _ := url.Parse(source)
+			_, srcName, _ := strings.Cut(strings.TrimPrefix(sourceURL.Path, "/"), "/")
+			blobs[name] = blobs[srcName]
+			w.Header().Set("X-Ms-Copy-Status", "success")
+		} else {
+			blobs[name], _ = io.ReadAll(r.Body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		data, ok := blobs[name]
+		if !ok {
+			w.Header().Set("X-Ms-Error-Code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		if _, ok := blobs[name]; !ok {
+			http.Error(w, "", http.StatusNotFound)
+			return
+		}
+		delete(blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (s *fakeServer) list(w http.ResponseWriter, query url.Values, blobs map[string][]byte) {
+	type item struct {
+		Name   string `xml:"Name"`
+		Length int64  `xml:"Properties>Content-Length"`
+	}
+	var names []string
+	for name := range blobs {
+		if strings.HasPrefix(name, query.Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	offset, _ := strconv.Atoi(query.Get("marker"))
+	names = names[min(offset, len(names)):]
+	var resp struct {
+		XMLName    xml.Name `xml:"EnumerationResults"`
+		Blobs      []item   `xml:"Blobs>Blob"`
+		NextMarker string   `xml:"NextMarker"`
+	}
+	if len(names) > s.pageSize {
+		names = names[:s.pageSize]
+		resp.NextMarker = strconv.Itoa(offset + s.pageSize)
+	}
+	for _, name := range names {
+		resp.Blobs = append(resp.Blobs, item{Name: name, Length: int64(len(blobs[name]))})
+	}
+	_ = xml.NewEncoder(w).Encode(resp)
+}
+
+func TestStringToSign(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut,
+		"http://127.0.0.1/apfs/assets/images/a%20b.txt?timeout=10&Comp=block", strings.NewReader("data"))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Ms-Version", apiVersion)
+	req.Header.Set("X-Ms-Date", "Mon, 19 Oct 2026 10:00:00 GMT")
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	assert.Equal(t, "PUT\n\n\n4\n\ntext/plain\n\n\n\n\n\n\n"+
+		"x-ms-blob-type:BlockBlob\n"+
+		"x-ms-date:Mon, 19 Oct 2026 10:00:00 GMT\n"+
+		"x-ms-version:2021-08-06\n"+
+		"/apfs/apfs/assets/images/a%20b.txt\ncomp:block\ntimeout:10",
+		stringToSign(req, "apfs"))
+}
+
+// TestSignSharedKey checks the signature of the request from the example of
+// https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key
+// The signature is computed apart from the driver by the well-known Azurite key:
+//
+//	printf '<string to sign>' | openssl dgst -sha256 -mac HMAC -macopt hexkey:<key> -binary | base64
+func TestSignSharedKey(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet,
+		"https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=metadata&timeout=20", nil)
+	req.Header.Set("X-Ms-Date", "Fri, 26 Jun 2015 23:39:12 GMT")
+	req.Header.Set("X-Ms-Version", "2015-02-21")
+	assert.Equal(t, "GET\n\n\n\n\n\n\n\n\n\n\n\n"+
+		"x-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\n"+
+		"x-ms-version:2015-02-21\n"+
+		"/myaccount/mycontainer\ncomp:metadata\nrestype:container\ntimeout:20",
+		stringToSign(req, "myaccount"))
+
+	key, err := base64.StdEncoding.DecodeString(
+		"Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==")
+	require.NoError(t, err)
+	signSharedKey(req, "myaccount", key)
+	assert.Equal(t, "SharedKey myaccount:1u9lui2jDxj0+fpbHjQ5m5NnastJRSYM+PSmfi8TXx4=",
+		req.Header.Get("Authorization"))
+}
+
+func TestStorage(t *testing.T) {
+	var (
+		ctx  = context.TODO()
+		fake = &fakeServer{containers: map[string]map[string][]byte{}, pageSize: 2}
+		srv  = httptest.NewServer(fake)
+	)
+	defer srv.Close()
+
+	store, err := NewStorage(ctx, WithAzureFromURL("azblob://"+testAccount+":"+
+		url.QueryEscape(testKey)+"@assets/apfs?endpoint="+srv.URL))
+	require.NoError(t, err)
+	require.Contains(t, fake.containers, "assets")
+
+	obj, err := store.Create(ctx, "images", nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename,
+		strings.NewReader("data"), nil))
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		require.NoError(t, store.WriteFile(ctx, obj, "items/"+name, strings.NewReader(name), nil))
+	}
+
+	// The list is paginated by 2 items
+	files, err := store.ListFiles(ctx, obj, "items/*")
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	require.NoError(t, store.MoveFile(ctx, obj, "items/a.txt", "items/d.txt"))
+	reader, err := store.ReadFile(ctx, obj, "items/d.txt")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "a.txt", string(data))
+
+	opened, err := store.Open(ctx, obj)
+	require.NoError(t, err)
+	reader, err = store.Read(ctx, opened, models.OriginalFilename)
+	require.NoError(t, err)
+	data, _ = io.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "data", string(data))
+
+	require.NoError(t, store.Remove(ctx, obj))
+	assert.Empty(t, fake.containers["assets"])
+
+	// Invalid signature
+	endpoint, _ := url.Parse(srv.URL)
+	_, err = NewClient(nil, endpoint, "assets", testAccount, []byte("wrong"), nil).List(ctx, "")
+	var respErr *ResponseError
+	require.ErrorAs(t, err, &respErr)
+	assert.Equal(t, http.StatusForbidden, respErr.StatusCode)
+	assert.Equal(t, "AuthenticationFailed", respErr.Code)
+
+	key, _ := base64.StdEncoding.DecodeString(testKey)
+	_, err = NewClient(nil, endpoint, "assets", testAccount, key, nil).Get(ctx, "none")
+	assert.True(t, blob.IsNotFound(err), err)
+}
+
+func TestCredentials(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "env")
+	t.Setenv("AZURE_STORAGE_KEY", "")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2021-08-06&sig=abc")
+
+	conf := optionConfig{}
+	require.NoError(t, WithAzureFromURL("azblob://assets/prefix")(&conf))
+	account, key, sas, err := conf.credentials()
+	require.NoError(t, err)
+	assert.Equal(t, "env", account)
+	assert.Nil(t, key)
+	assert.Equal(t, "abc", sas.Get("sig"))
+
+	endpoint, err := conf.endpointURL(account)
+	require.NoError(t, err)
+	assert.Equal(t, "https://env.blob.core.windows.net", endpoint.String())
+
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	_, err = NewStorage(context.TODO(), WithContainer("assets"))
+	assert.ErrorIs(t, err, ErrAccountIsRequired)
+}
+
+// TestEmulator runs against Azurite:
+//
+//	docker run --rm -p 10000:10000 mcr.microsoft.com/azure-storage/azurite azurite-blob --blobHost 0.0.0.0
+//	TEST_AZBLOB_ENDPOINT=http://127.0.0.1:10000/devstoreaccount1 go test ./internal/driver/azblob/
+func TestEmulator(t *testing.T) {
+	endpoint := os.Getenv("TEST_AZBLOB_ENDPOINT")
+	if endpoint == "" {
+		t.SkipNow()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	// The well-known Azurite development account
+	store, err := NewStorage(ctx, WithEndpoint(endpoint), WithContainer("apfs-test"),
+		WithSharedKey("devstoreaccount1",
+			"Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="))
+	require.NoError(t, err)
+
+	obj, err := store.Create(ctx, "assets", storio.ObjectIDType("assets/emulator"), true, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename,
+		strings.NewReader("data"), &models.ItemMeta{ContentType: "text/plain"}))
+	reader, err := store.Read(ctx, obj, models.OriginalFilename)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "data", string(data))
+	assert.NoError(t, store.Remove(ctx, obj))
+}
@@ -0,0 +1,50 @@
+package blob
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned by the client if the blob does not exist
+var ErrNotFound = errors.New("[blob] not found")
+
+// Info of the stored blob
+type Info struct {
+	Key  string
+	Size int64
+}
+
+// Client of the single bucket (container) of the blobs
+type Client interface {
+	// Get returns the content of the blob, ErrNotFound if the blob does not exist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Put stores the content of the blob, the existing blob is replaced
+	Put(ctx context.Context, key string, data io.ReadSeeker, contentType string) error
+
+	// Delete the blob, no error if the blob does not exist
+	Delete(ctx context.Context, key string) error
+
+	// List returns all blobs with the key prefix
+	List(ctx context.Context, prefix string) ([]*Info, error)
+
+	// Copy the blob to the new key
+	Copy(ctx context.Context, srcKey, dstKey string) error
+}
+
+// IsNotFound returns true if the blob does not exist
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// ContentSize returns the size of the data and rewinds it to the beginning
+func ContentSize(data io.Seeker) (int64, error) {
+	size, err := data.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	_, err = data.Seek(0, io.SeekStart)
+	return size, err
+}
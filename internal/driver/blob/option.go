@@ -0,0 +1,22 @@
+package blob
+
+import (
+	"strings"
+)
+
+// Option property modification
+type Option func(storage *Storage)
+
+// WithPrefix of all keys inside the bucket, so the bucket could be shared
+func WithPrefix(prefix string) Option {
+	return func(storage *Storage) {
+		storage.prefix = strings.Trim(prefix, "/ ")
+	}
+}
+
+// WithFilepathGenerator pattern of the new object paths
+func WithFilepathGenerator(pattern string) Option {
+	return func(storage *Storage) {
+		storage.genpattern = pattern
+	}
+}
@@ -0,0 +1,606 @@
+// Package blob implements file storage collection over the flat blob stores
+// (Google Cloud Storage, Azure Blob storage). The groups and the objects are
+// the key prefixes inside the single bucket (container):
+//
+//	[prefix/]<group>/manifest.yaml
+//	[prefix/]<group>/<object path>/meta.json
+//	[prefix/]<group>/<object path>/state.json
+//	[prefix/]<group>/<object path>/<file>
+package blob
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/object"
+	datalib "github.com/apfs-io/apfs/internal/storage/data"
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/internal/storio/objectpath"
+	"github.com/apfs-io/apfs/internal/utils"
+	workflowparser "github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/libs/storerrors"
+	"github.com/apfs-io/apfs/models"
+)
+
+const (
+	workflowFileName = "manifest.yaml"
+	metaFileName     = "meta.json"
+	stateFileName    = "state.json"
+)
+
+// Errors list...
+var (
+	ErrUnsupportedContentType   = errors.New("[blob] content-type is not supported")
+	ErrCustomObjectIDIsNotValid = errors.New("[blob] invalid custom object ID")
+	ErrObjectAlreadyExists      = errors.New("[blob] object already exists")
+	ErrFileNotDefined           = errors.New("[blob] object file not defined in the manifest")
+)
+
+// Storage of the objects in the blob bucket
+type Storage struct {
+	client Client
+
+	// Prefix of all keys inside the bucket
+	prefix string
+
+	genpattern string
+	pathgen    objectpath.Generator
+}
+
+// NewStorage of the objects over the blob client
+func NewStorage(client Client, options ...Option) *Storage {
+	store := &Storage{client: client}
+	for _, opt := range options {
+		opt(store)
+	}
+	pattern := store.genpattern
+	if pattern == "" {
+		pattern = "{{year}}/{{month}}/{{md5:1}}/{{md5:2}}/{{md5}}"
+	}
+	store.pathgen = objectpath.NewBasePathgenerator(pattern,
+		objectpath.WithChecker(func(fullpath string) bool {
+			exists, err := store.exists(context.Background(), fullpath)
+			return err == nil && !exists
+		}))
+	return store
+}
+
+// Client of the bucket
+func (c *Storage) Client() Client {
+	return c.client
+}
+
+// ReadWorkflow reads the bucket-level workflow manifest from manifest.yaml.
+func (c *Storage) ReadWorkflow(ctx context.Context, bucket string) (*models.Workflow, error) {
+	data, err := c.readAll(ctx, c.key(bucket, workflowFileName))
+	if IsNotFound(err) {
+		return &models.Workflow{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return workflowparser.ParseWorkflow(data)
+}
+
+// UpdateWorkflow writes the bucket-level workflow manifest as manifest.yaml.
+func (c *Storage) UpdateWorkflow(ctx context.Context, bucket string, workflow *models.Workflow) error {
+	if workflow == nil {
+		return nil
+	}
+	data, err := workflow.MarshalYAML()
+	if err != nil {
+		return err
+	}
+	return c.client.Put(ctx, c.key(bucket, workflowFileName),
+		bytes.NewReader(data), "application/yaml")
+}
+
+// Create new file object
+func (c *Storage) Create(ctx context.Context, bucket string, id storio.ObjectID, overwrite bool, params url.Values) (storio.Object, error) {
+	objectPath, exists, err := c.newObjectPath(ctx, bucket, id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if !overwrite {
+			return nil, errors.Wrap(ErrObjectAlreadyExists, objectPath)
+		}
+		if err = c.deletePrefix(ctx, c.key(bucket, objectPath)+"/"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Init new object container
+	obj := object.NewObject(newObjectID(bucket, objectPath), bucket, objectPath)
+
+	// Load bucket-level workflow
+	if err = c.loadWorkflow(ctx, obj); err != nil {
+		return nil, err
+	}
+
+	// Update meta information of the object
+	if len(params) > 0 {
+		tags := params["tags"]
+		params.Del("tags")
+		meta := obj.MetaOrNew()
+		meta.Tags = tags
+		meta.Params = params
+		if err = c.saveMeta(ctx, obj); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// UpdateParams in the object. If name is present then update only params linked with the subobject
+func (c *Storage) UpdateParams(ctx context.Context, id storio.ObjectID, params url.Values) error {
+	obj, err := c._ID2Object(ctx, id)
+	if err != nil {
+		return err
+	}
+	meta := obj.MetaOrNew()
+	if params != nil {
+		tags := params["tags"]
+		params.Del("tags")
+		meta.Tags = tags
+		meta.Params = params
+	} else {
+		meta.Tags = nil
+		meta.Params = nil
+	}
+	return c.saveMeta(ctx, obj)
+}
+
+// Open existing object
+func (c *Storage) Open(ctx context.Context, id storio.ObjectID) (storio.Object, error) {
+	obj := objectFromID(id)
+	if obj.Bucket() == "" || obj.Path() == "" {
+		return nil, storerrors.WrapNotFound(id.ID().String(), ErrNotFound)
+	}
+
+	// Load object meta information, the object without meta exists
+	// while it has any file
+	if err := c.loadMeta(ctx, obj); err != nil {
+		if !IsNotFound(err) {
+			return nil, err
+		}
+		exists, err := c.exists(ctx, obj.ID().String())
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, storerrors.WrapNotFound(obj.ID().String(), ErrNotFound)
+		}
+	}
+
+	// Load bucket-level workflow
+	if err := c.loadWorkflow(ctx, obj); err != nil {
+		return nil, errors.Wrap(err, workflowFileName)
+	}
+	return obj, nil
+}
+
+// Update data of the object file
+func (c *Storage) Update(ctx context.Context, id storio.ObjectID, name string, reader io.Reader, meta *models.ItemMeta) error {
+	obj, err := c._ID2Object(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Update only meta information of the object
+	if reader == nil {
+		return c.saveObjectMeta(ctx, obj, meta)
+	}
+
+	// The extension of the new file could differ from the previous one
+	var prevName string
+	if item := obj.MetaOrNew().ItemByName(name); item != nil {
+		prevName = item.Fullname()
+	}
+
+	// Extract meta information from the reader
+	data, meta, err := c.extractObjectMetaInfo(name, reader, obj.Workflow(), meta)
+	if err != nil {
+		return err
+	}
+	if err = c.client.Put(ctx, c.key(obj.Bucket(), obj.Path(), meta.Fullname()), data, meta.ContentType); err != nil {
+		return err
+	}
+	if prevName != "" && prevName != meta.Fullname() {
+		if err = c.client.Delete(ctx, c.key(obj.Bucket(), obj.Path(), prevName)); err != nil {
+			return err
+		}
+	}
+	return c.saveObjectMeta(ctx, obj, meta)
+}
+
+// UpdateMeta data of the specific subobject in the storage
+func (c *Storage) UpdateMeta(ctx context.Context, id storio.ObjectID, name string, meta *models.ItemMeta) error {
+	obj, err := c._ID2Object(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.saveObjectMeta(ctx, obj, meta)
+}
+
+// Remove the object or its files by name
+func (c *Storage) Remove(ctx context.Context, id storio.ObjectID, names ...string) error {
+	if len(names) == 0 {
+		return c.deletePrefix(ctx, c.key(id.ID().String())+"/")
+	}
+	obj, err := c._ID2Object(ctx, id)
+	if err != nil {
+		return err
+	}
+	metaUpdated := false
+	for _, name := range names {
+		fullname, _ := fileFullname(obj, name)
+		if fullname == "" {
+			fullname = name
+		}
+		if err = c.client.Delete(ctx, c.key(obj.Bucket(), obj.Path(), fullname)); err != nil {
+			break
+		}
+		if obj.MetaOrNew().RemoveItemByName(fullname) {
+			metaUpdated = true
+		}
+	}
+	if metaUpdated {
+		if err2 := c.saveMeta(ctx, obj); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+// Read returns reader of the specific object file
+func (c *Storage) Read(ctx context.Context, id storio.ObjectID, name string) (io.ReadCloser, error) {
+	obj, err := c._ID2Object(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, `object read`)
+	}
+	fullname, err := fileFullname(obj, name)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := c.client.Get(ctx, c.key(obj.Bucket(), obj.Path(), fullname))
+	if IsNotFound(err) {
+		return nil, storerrors.WrapNotFound(name, err)
+	}
+	return reader, err
+}
+
+// Clean removes all files of the object except the original and the meta
+func (c *Storage) Clean(ctx context.Context, id storio.ObjectID) error {
+	obj, err := c._ID2Object(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, `Clean`)
+	}
+	prefix := c.key(obj.Bucket(), obj.Path()) + "/"
+	blobs, err := c.client.List(ctx, prefix)
+	if err != nil {
+		return errors.Wrap(err, `Clean`)
+	}
+	for _, blob := range blobs {
+		name := strings.TrimPrefix(blob.Key, prefix)
+		if name == metaFileName || obj.IsOriginal(name) {
+			continue
+		}
+		if err = c.client.Delete(ctx, blob.Key); err != nil {
+			return errors.Wrap(err, `Clean`)
+		}
+	}
+	// Clean all sub-items from meta
+	obj.MetaOrNew().CleanSubItems()
+	return c.saveMeta(ctx, obj)
+}
+
+// Scan storage by pattern
+//
+//	pattern: search type equals to glob https://golang.org/pkg/path/filepath/#Glob
+func (c *Storage) Scan(ctx context.Context, pattern string, walkf storio.WalkStorageFunc) error {
+	prefix := c.key(storio.ScanPrefix(pattern))
+	if prefix != "" {
+		prefix += "/"
+	}
+	blobs, err := c.client.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, blob := range blobs {
+		name := c.keyName(blob.Key)
+		if storio.MatchScanPattern(pattern, name) {
+			if err = walkf(name, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteFile implements ObjectFileAccessor.
+func (c *Storage) WriteFile(ctx context.Context, id storio.ObjectID, path string, data io.Reader, meta *models.ItemMeta) error {
+	content, err := datalib.ToReadSeeker(data)
+	if err != nil {
+		return err
+	}
+	var contentType string
+	if meta != nil {
+		contentType = meta.ContentType
+	}
+	return c.client.Put(ctx, c.key(id.ID().String(), path), content, contentType)
+}
+
+// ReadFile implements ObjectFileAccessor.
+func (c *Storage) ReadFile(ctx context.Context, id storio.ObjectID, path string) (io.ReadCloser, error) {
+	reader, err := c.client.Get(ctx, c.key(id.ID().String(), path))
+	if IsNotFound(err) {
+		return nil, storerrors.WrapNotFound(path, err)
+	}
+	return reader, err
+}
+
+// ListFiles implements ObjectFileAccessor.
+func (c *Storage) ListFiles(ctx context.Context, id storio.ObjectID, pattern string) ([]*storio.FileInfo, error) {
+	prefix := c.key(id.ID().String()) + "/"
+	blobs, err := c.client.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var files []*storio.FileInfo
+	for _, blob := range blobs {
+		rel := strings.TrimPrefix(blob.Key, prefix)
+		if pattern != "" && pattern != "*" {
+			if ok, _ := filepath.Match(pattern, rel); !ok {
+				continue
+			}
+		}
+		files = append(files, &storio.FileInfo{Path: rel, Size: blob.Size})
+	}
+	return files, nil
+}
+
+// DeleteFiles implements ObjectFileAccessor.
+func (c *Storage) DeleteFiles(ctx context.Context, id storio.ObjectID, paths ...string) error {
+	for _, p := range paths {
+		if err := c.client.Delete(ctx, c.key(id.ID().String(), p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveFile implements ObjectFileAccessor.
+func (c *Storage) MoveFile(ctx context.Context, id storio.ObjectID, srcPath, dstPath string) error {
+	srcKey := c.key(id.ID().String(), srcPath)
+	if err := c.client.Copy(ctx, srcKey, c.key(id.ID().String(), dstPath)); err != nil {
+		return err
+	}
+	return c.client.Delete(ctx, srcKey)
+}
+
+// ReadState implements ObjectStateAccessor.
+func (c *Storage) ReadState(ctx context.Context, id storio.ObjectID) (*models.ProcessingState, error) {
+	data, err := c.readAll(ctx, c.key(id.ID().String(), stateFileName))
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state models.ProcessingState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// WriteState implements ObjectStateAccessor.
+func (c *Storage) WriteState(ctx context.Context, id storio.ObjectID, state *models.ProcessingState) error {
+	return c.putJSON(ctx, c.key(id.ID().String(), stateFileName), state)
+}
+
+func (c *Storage) _ID2Object(ctx context.Context, id storio.ObjectID) (storio.Object, error) {
+	switch obj := id.(type) {
+	case storio.Object:
+		return obj, nil
+	default:
+		return c.Open(ctx, id)
+	}
+}
+
+// newObjectPath returns the custom object path or generates the new one
+func (c *Storage) newObjectPath(ctx context.Context, bucket string, id storio.ObjectID) (string, bool, error) {
+	if id != nil {
+		if subPath := subPathFromID(bucket, id); subPath != "" {
+			if !isValidPath(subPath) {
+				return "", false, errors.Wrap(ErrCustomObjectIDIsNotValid, subPath)
+			}
+			exists, err := c.exists(ctx, path.Join(bucket, subPath))
+			return subPath, exists, err
+		}
+	}
+	objectPath, err := c.pathgen.Generate(bucket)
+	return objectPath, false, err
+}
+
+// exists returns true if the object has any file
+func (c *Storage) exists(ctx context.Context, fullpath string) (bool, error) {
+	blobs, err := c.client.List(ctx, c.key(fullpath)+"/")
+	return len(blobs) > 0, err
+}
+
+func (c *Storage) deletePrefix(ctx context.Context, prefix string) error {
+	blobs, err := c.client.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, blob := range blobs {
+		if err = c.client.Delete(ctx, blob.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Storage) loadWorkflow(ctx context.Context, obj storio.Object) error {
+	wf, err := c.ReadWorkflow(ctx, obj.Bucket())
+	if err != nil {
+		return err
+	}
+	if wf != nil {
+		*obj.WorkflowOrNew() = *wf
+	}
+	return nil
+}
+
+func (c *Storage) loadMeta(ctx context.Context, obj storio.Object) error {
+	data, err := c.readAll(ctx, c.key(obj.Bucket(), obj.Path(), metaFileName))
+	if err != nil {
+		return err
+	}
+	meta := obj.MetaOrNew()
+	if err = json.Unmarshal(data, meta); err != nil {
+		return err
+	}
+	if meta.CreatedAt.IsZero() {
+		meta.CreatedAt = time.Now()
+	}
+	if meta.UpdatedAt.IsZero() {
+		meta.UpdatedAt = meta.CreatedAt
+	}
+	return nil
+}
+
+func (c *Storage) saveMeta(ctx context.Context, obj storio.Object) error {
+	meta := obj.MetaOrNew()
+	meta.UpdatedAt = time.Now()
+	if meta.CreatedAt.IsZero() {
+		meta.CreatedAt = meta.UpdatedAt
+	}
+	return c.putJSON(ctx, c.key(obj.Bucket(), obj.Path(), metaFileName), meta)
+}
+
+func (c *Storage) saveObjectMeta(ctx context.Context, obj storio.Object, meta *models.ItemMeta) error {
+	meta.UpdatedAt = time.Now()
+	obj.MetaOrNew().SetItem(meta)
+	return c.saveMeta(ctx, obj)
+}
+
+func (c *Storage) putJSON(ctx context.Context, key string, item any) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return c.client.Put(ctx, key, bytes.NewReader(data), "application/json")
+}
+
+func (c *Storage) readAll(ctx context.Context, key string) ([]byte, error) {
+	reader, err := c.client.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
+func (c *Storage) extractObjectMetaInfo(name string, reader io.Reader, workflow *models.Workflow, meta *models.ItemMeta) (io.ReadSeeker, *models.ItemMeta, error) {
+	data, err := datalib.ToReadSeeker(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	contentType, err := datalib.ContentTypeByReadSeeker(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if models.IsOriginal(name) && !workflow.IsValidContentType(contentType) {
+		return nil, nil, errors.Wrap(ErrUnsupportedContentType, contentType)
+	}
+	if models.IsOriginal(name) {
+		name = models.OriginalFilename
+	}
+	if path.Ext(name) == "" {
+		name += datalib.ExtensionByContentType(contentType)
+	}
+	if meta == nil {
+		meta = &models.ItemMeta{}
+	}
+	meta.ContentType = contentType
+	if meta, err = utils.CollectReadSeekerInfo(meta, data, name, ""); err != nil {
+		return nil, nil, err
+	}
+	if _, err = data.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	return data, meta, nil
+}
+
+// key of the blob inside the bucket
+func (c *Storage) key(parts ...string) string {
+	return path.Join(append([]string{c.prefix}, parts...)...)
+}
+
+// keyName returns the key without the storage prefix
+func (c *Storage) keyName(key string) string {
+	if c.prefix == "" {
+		return key
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(key, c.prefix), "/")
+}
+
+// fileFullname resolves the full filename (with extension) of the object file
+func fileFullname(obj storio.Object, name string) (string, error) {
+	if obj.IsOriginal(name) {
+		return obj.PrepareName(name), nil
+	}
+	if item := obj.Meta().ItemByName(name); item != nil {
+		return item.Fullname(), nil
+	}
+	if obj.Workflow().HasTarget(name) || models.IsTransformTarget(name) {
+		return name, nil
+	}
+	return "", errors.Wrap(ErrFileNotDefined, name)
+}
+
+func newObjectID(bucket, objectPath string) storio.ObjectIDType {
+	return storio.ObjectIDType(
+		strings.Trim(bucket, "/") + "/" + strings.Trim(objectPath, "/"),
+	)
+}
+
+func objectFromID(id storio.ObjectID) storio.Object {
+	bucket, objectPath, ok := strings.Cut(strings.Trim(id.ID().String(), "/"), "/")
+	if !ok {
+		return object.NewObject("", "", "")
+	}
+	return object.NewObject(id.ID(), bucket, objectPath)
+}
+
+func subPathFromID(bucket string, id storio.ObjectID) string {
+	subPath := strings.Trim(id.ID().String(), " \t\n/\\")
+	if bucket != "" {
+		subPath = strings.TrimPrefix(subPath, bucket)
+		subPath = strings.Trim(subPath, " \t\n/\\")
+	}
+	return subPath
+}
+
+func isValidPath(objectPath string) bool {
+	for part := range strings.SplitSeq(objectPath, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+var _ storio.StorageAccessor = (*Storage)(nil)
@@ -0,0 +1,160 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/libs/storerrors"
+	"github.com/apfs-io/apfs/models"
+)
+
+type memoryClient struct {
+	mx    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (c *memoryClient) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	data, ok := c.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (c *memoryClient) Put(_ context.Context, key string, data io.ReadSeeker, _ string) error {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.blobs[key] = content
+	return nil
+}
+
+func (c *memoryClient) Delete(_ context.Context, key string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	delete(c.blobs, key)
+	return nil
+}
+
+func (c *memoryClient) List(_ context.Context, prefix string) ([]*Info, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	var list []*Info
+	for key, data := range c.blobs {
+		if strings.HasPrefix(key, prefix) {
+			list = append(list, &Info{Key: key, Size: int64(len(data))})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list, nil
+}
+
+func (c *memoryClient) Copy(_ context.Context, srcKey, dstKey string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	data, ok := c.blobs[srcKey]
+	if !ok {
+		return ErrNotFound
+	}
+	c.blobs[dstKey] = data
+	return nil
+}
+
+func TestStorage(t *testing.T) {
+	var (
+		ctx    = context.TODO()
+		client = &memoryClient{blobs: map[string][]byte{}}
+		store  = NewStorage(client, WithPrefix("/apfs/"))
+	)
+
+	// Workflow
+	require.NoError(t, store.UpdateWorkflow(ctx, "docs", &models.Workflow{
+		ContentTypes: []string{"text/*"},
+	}))
+	assert.Contains(t, client.blobs, "apfs/docs/manifest.yaml")
+	wf, err := store.ReadWorkflow(ctx, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"text/*"}, wf.ContentTypes)
+	wf, err = store.ReadWorkflow(ctx, "unknown")
+	require.NoError(t, err)
+	assert.True(t, wf.IsEmpty())
+
+	// Create and upload the original
+	obj, err := store.Create(ctx, "docs", storio.ObjectIDType("docs/a/b"), false,
+		url.Values{"tags": {"x"}})
+	require.NoError(t, err)
+	assert.Equal(t, "docs/a/b", obj.ID().String())
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename,
+		strings.NewReader("hello world"), nil))
+	assert.Contains(t, client.blobs, "apfs/docs/a/b/"+models.OriginalFilename+".txt")
+
+	_, err = store.Create(ctx, "docs", storio.ObjectIDType("docs/a/b"), false, nil)
+	assert.ErrorIs(t, err, ErrObjectAlreadyExists)
+	_, err = store.Create(ctx, "docs", storio.ObjectIDType("docs/../b"), false, nil)
+	assert.ErrorIs(t, err, ErrCustomObjectIDIsNotValid)
+
+	// Open and read
+	opened, err := store.Open(ctx, storio.ObjectIDType("docs/a/b"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x"}, opened.Meta().Tags)
+	assert.Equal(t, "txt", opened.Meta().Main.NameExt)
+	reader, err := store.Read(ctx, opened, models.OriginalFilename)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	assert.Equal(t, "hello world", string(data))
+
+	_, err = store.Open(ctx, storio.ObjectIDType("docs/none"))
+	assert.True(t, storerrors.IsNotFound(err), err)
+
+	// Object files and the state
+	id := storio.ObjectIDType("docs/a/b")
+	require.NoError(t, store.WriteFile(ctx, id, "thumbs/1.txt", strings.NewReader("1"), nil))
+	require.NoError(t, store.MoveFile(ctx, id, "thumbs/1.txt", "thumbs/2.txt"))
+	files, err := store.ListFiles(ctx, id, "thumbs/*")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "thumbs/2.txt", files[0].Path)
+	_, err = store.ReadFile(ctx, id, "thumbs/1.txt")
+	assert.True(t, storerrors.IsNotFound(err), err)
+
+	state, err := store.ReadState(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, state)
+	require.NoError(t, store.WriteState(ctx, id, models.NewProcessingState("docs/a/b", "1", []string{"thumb"})))
+	state, err = store.ReadState(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "docs/a/b", state.ObjectID)
+
+	var scanned []string
+	require.NoError(t, store.Scan(ctx, "docs/a/b/*.json", func(path string, _ error) error {
+		scanned = append(scanned, path)
+		return nil
+	}))
+	assert.Equal(t, []string{"docs/a/b/meta.json", "docs/a/b/state.json"}, scanned)
+
+	// Clean keeps the original and the meta
+	require.NoError(t, store.Clean(ctx, opened))
+	files, err = store.ListFiles(ctx, id, "")
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	require.NoError(t, store.Remove(ctx, id))
+	files, err = store.ListFiles(ctx, id, "")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
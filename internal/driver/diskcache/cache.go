@@ -0,0 +1,251 @@
+package diskcache
+
+import (
+	"container/list"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCacheSize is returned if the cache size is not positive
+var ErrInvalidCacheSize = errors.New("[diskcache] cache size must be positive")
+
+// cacheSubdir owned by the cache inside of the configured directory
+const cacheSubdir = "apfs-diskcache"
+
+// fillPrefix of the files being downloaded into the cache
+const fillPrefix = ".fill-"
+
+type entry struct {
+	key      string
+	objectID string
+	name     string
+	size     int64
+}
+
+// Cache of the files on the local disk bounded by the total size.
+// The least recently used files are evicted first.
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mx       sync.Mutex
+	size     int64
+	lru      *list.List
+	entries  map[string]*list.Element
+	objects  map[string]map[string]struct{}
+	inflight map[string]chan struct{}
+}
+
+// NewCache in the own subdirectory of the directory. The cache files left by
+// the previous run are removed, other files of the directory are kept.
+func NewCache(dir string, maxSize int64) (*Cache, error) {
+	if maxSize <= 0 {
+		return nil, ErrInvalidCacheSize
+	}
+	dir = filepath.Join(dir, cacheSubdir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := removeCacheFiles(dir); err != nil {
+		return nil, err
+	}
+	return &Cache{
+		dir:      dir,
+		maxSize:  maxSize,
+		lru:      list.New(),
+		entries:  map[string]*list.Element{},
+		objects:  map[string]map[string]struct{}{},
+		inflight: map[string]chan struct{}{},
+	}, nil
+}
+
+// Size returns the total size of the cached files
+func (c *Cache) Size() int64 {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.size
+}
+
+// Len returns the number of the cached files
+func (c *Cache) Len() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.lru.Len()
+}
+
+// Get returns the cached file of the object or fetches it once for all
+// concurrent readers. The fetched data is cached only if its MD5 matches the
+// hash, otherwise it is returned as the temporary file.
+func (c *Cache) Get(ctx context.Context, objectID, name, hash string, fetch func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	key := cacheKey(objectID, name, hash)
+	for {
+		c.mx.Lock()
+		if elm := c.entries[key]; elm != nil {
+			c.lru.MoveToFront(elm)
+			c.mx.Unlock()
+			if file, err := os.Open(c.filename(key)); err == nil {
+				return file, nil
+			}
+			c.mx.Lock()
+			c.remove(elm)
+			c.mx.Unlock()
+			continue
+		}
+		if wait, ok := c.inflight[key]; ok {
+			c.mx.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-wait:
+			}
+			continue
+		}
+		done := make(chan struct{})
+		c.inflight[key] = done
+		c.mx.Unlock()
+
+		file, err := c.fill(key, objectID, name, hash, fetch)
+
+		c.mx.Lock()
+		delete(c.inflight, key)
+		close(done)
+		c.mx.Unlock()
+		return file, err
+	}
+}
+
+// Invalidate the cached files of the object, all of them if no names
+func (c *Cache) Invalidate(objectID string, names ...string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	for key := range c.objects[objectID] {
+		elm := c.entries[key]
+		if len(names) == 0 || containsName(names, elm.Value.(*entry).name) {
+			c.remove(elm)
+		}
+	}
+}
+
+func (c *Cache) fill(key, objectID, name, hash string, fetch func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	reader, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	file, err := os.CreateTemp(c.dir, fillPrefix+"*")
+	if err != nil {
+		return nil, err
+	}
+	sum := md5.New()
+	size, err := io.Copy(io.MultiWriter(file, sum), reader)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+	if hex.EncodeToString(sum.Sum(nil)) != hash {
+		// The file was changed since the meta was loaded
+		return &tempFile{File: file}, nil
+	}
+	if err = os.Rename(file.Name(), c.filename(key)); err != nil {
+		return &tempFile{File: file}, nil
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.entries[key] = c.lru.PushFront(&entry{key: key, objectID: objectID, name: name, size: size})
+	if c.objects[objectID] == nil {
+		c.objects[objectID] = map[string]struct{}{}
+	}
+	c.objects[objectID][key] = struct{}{}
+	c.size += size
+	for c.size > c.maxSize && c.lru.Len() > 0 {
+		// The open files stay readable after the eviction
+		c.remove(c.lru.Back())
+	}
+	return file, nil
+}
+
+func (c *Cache) remove(elm *list.Element) {
+	item := elm.Value.(*entry)
+	c.lru.Remove(elm)
+	delete(c.entries, item.key)
+	if keys := c.objects[item.objectID]; keys != nil {
+		delete(keys, item.key)
+		if len(keys) == 0 {
+			delete(c.objects, item.objectID)
+		}
+	}
+	c.size -= item.size
+	_ = os.Remove(c.filename(item.key))
+}
+
+func (c *Cache) filename(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+func cacheKey(objectID, name, hash string) string {
+	sum := sha256.Sum256([]byte(objectID + "\x00" + name + "\x00" + hash))
+	return hex.EncodeToString(sum[:])
+}
+
+// removeCacheFiles of the previous run from the directory
+func removeCacheFiles(dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.Type().IsRegular() && isCacheFile(file.Name()) {
+			if err := os.Remove(filepath.Join(dir, file.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isCacheFile returns true for the names of the cached and filled files
+func isCacheFile(name string) bool {
+	if strings.HasPrefix(name, fillPrefix) {
+		return true
+	}
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if baseName(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tempFile is removed on close
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
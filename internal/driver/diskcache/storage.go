@@ -0,0 +1,100 @@
+// Package diskcache implements the read-through cache of the object files on
+// the local disk in front of the remote storage drivers.
+//
+// The files are keyed by the object ID, the item name and its HashID, so the
+// updated file never matches the cached one. The fetched data is cached only
+// if its MD5 equals the HashID of the meta.
+package diskcache
+
+import (
+	"context"
+	"io"
+	"path"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// Storage caches the reads of the files of the next storage
+type Storage struct {
+	storio.StorageAccessor
+
+	cache *Cache
+}
+
+// NewStorage wraps the storage with the cache
+func NewStorage(next storio.StorageAccessor, cache *Cache) *Storage {
+	return &Storage{StorageAccessor: next, cache: cache}
+}
+
+// Cache returns the file cache of the storage
+func (s *Storage) Cache() *Cache {
+	return s.cache
+}
+
+// Read the file from the cache or the next storage
+func (s *Storage) Read(ctx context.Context, id storio.ObjectID, name string) (io.ReadCloser, error) {
+	obj, err := s.object(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := obj.MetaOrNew().ItemByName(name)
+	if item == nil || item.HashID == "" || item.Size > s.cache.maxSize {
+		return s.StorageAccessor.Read(ctx, obj, name)
+	}
+	return s.cache.Get(ctx, obj.ID().String(), baseName(item.Name), item.HashID,
+		func() (io.ReadCloser, error) { return s.StorageAccessor.Read(ctx, obj, name) })
+}
+
+// Update the file and drop its cached copy
+func (s *Storage) Update(ctx context.Context, id storio.ObjectID, name string, data io.Reader, meta *models.ItemMeta) error {
+	defer s.cache.Invalidate(id.ID().String(), name)
+	return s.StorageAccessor.Update(ctx, id, name, data, meta)
+}
+
+// Remove the files and drop their cached copies
+func (s *Storage) Remove(ctx context.Context, id storio.ObjectID, names ...string) error {
+	defer s.cache.Invalidate(id.ID().String(), names...)
+	return s.StorageAccessor.Remove(ctx, id, names...)
+}
+
+// Clean the derived files and drop the cached copies of the object
+func (s *Storage) Clean(ctx context.Context, id storio.ObjectID) error {
+	defer s.cache.Invalidate(id.ID().String())
+	return s.StorageAccessor.Clean(ctx, id)
+}
+
+// WriteFile and drop the cached copies of the object
+func (s *Storage) WriteFile(ctx context.Context, id storio.ObjectID, path string, data io.Reader, meta *models.ItemMeta) error {
+	defer s.cache.Invalidate(id.ID().String())
+	return s.StorageAccessor.WriteFile(ctx, id, path, data, meta)
+}
+
+// DeleteFiles and drop the cached copies of the object
+func (s *Storage) DeleteFiles(ctx context.Context, id storio.ObjectID, paths ...string) error {
+	defer s.cache.Invalidate(id.ID().String())
+	return s.StorageAccessor.DeleteFiles(ctx, id, paths...)
+}
+
+// MoveFile and drop the cached copies of the object
+func (s *Storage) MoveFile(ctx context.Context, id storio.ObjectID, srcPath, dstPath string) error {
+	defer s.cache.Invalidate(id.ID().String())
+	return s.StorageAccessor.MoveFile(ctx, id, srcPath, dstPath)
+}
+
+func (s *Storage) object(ctx context.Context, id storio.ObjectID) (storio.Object, error) {
+	if obj, ok := id.(storio.Object); ok {
+		return obj, nil
+	}
+	return s.StorageAccessor.Open(ctx, id)
+}
+
+// baseName of the file without extension, the same for all original names
+func baseName(name string) string {
+	if models.IsOriginal(name) {
+		return models.OriginalFilename
+	}
+	return name[:len(name)-len(path.Ext(name))]
+}
+
+var _ storio.StorageAccessor = (*Storage)(nil)
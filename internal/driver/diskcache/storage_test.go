@@ -0,0 +1,150 @@
+package diskcache
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/fs"
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// countingStorage counts the reads of the files
+type countingStorage struct {
+	storio.StorageAccessor
+	reads atomic.Int32
+}
+
+func (s *countingStorage) Read(ctx context.Context, id storio.ObjectID, name string) (io.ReadCloser, error) {
+	s.reads.Add(1)
+	return s.StorageAccessor.Read(ctx, id, name)
+}
+
+func newTestStorage(t *testing.T, maxSize int64) (*Storage, *countingStorage) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "data"), 0o755))
+	primary, err := fs.NewStorage(filepath.Join(root, "data"))
+	require.NoError(t, err)
+	cache, err := NewCache(filepath.Join(root, "cache"), maxSize)
+	require.NoError(t, err)
+	counting := &countingStorage{StorageAccessor: primary}
+	return NewStorage(counting, cache), counting
+}
+
+func readString(t *testing.T, store storio.StorageAccessor, id storio.ObjectID, name string) string {
+	reader, err := store.Read(context.TODO(), id, name)
+	require.NoError(t, err)
+	defer func() { _ = reader.Close() }()
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestStorageReadThrough(t *testing.T) {
+	var (
+		ctx             = context.TODO()
+		store, counting = newTestStorage(t, 1024)
+	)
+	obj, err := store.Create(ctx, "media", nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename, strings.NewReader("original"), nil))
+
+	// The concurrent readers share the single fetch
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "original", readString(t, store, obj.ID(), models.OriginalFilename))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "original", readString(t, store, obj, "@"))
+	assert.Equal(t, int32(1), counting.reads.Load())
+	assert.Equal(t, 1, store.Cache().Len())
+
+	// The update drops the cached copy
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename, strings.NewReader("updated"), nil))
+	assert.Equal(t, 0, store.Cache().Len())
+	assert.Equal(t, "updated", readString(t, store, obj.ID(), models.OriginalFilename))
+	assert.Equal(t, int32(2), counting.reads.Load())
+
+	require.NoError(t, store.Remove(ctx, obj))
+	assert.Equal(t, 0, store.Cache().Len())
+	assert.Zero(t, store.Cache().Size())
+}
+
+func TestCacheEviction(t *testing.T) {
+	var (
+		ctx   = context.TODO()
+		cache = newCache(t, 10)
+		fetch = func(data string) func() (io.ReadCloser, error) {
+			return func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(data)), nil }
+		}
+	)
+	for _, name := range []string{"a", "b", "c"} {
+		reader, err := cache.Get(ctx, "group/obj", name, md5hex("1234"), fetch("1234"))
+		require.NoError(t, err)
+		_ = reader.Close()
+	}
+	// The least recently used "a" is evicted
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, int64(8), cache.Size())
+
+	// The data not matched the hash is not cached
+	reader, err := cache.Get(ctx, "group/obj", "d", md5hex("other"), fetch("1234"))
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "1234", string(data))
+	assert.Equal(t, 2, cache.Len())
+
+	cache.Invalidate("group/obj", "b.txt")
+	assert.Equal(t, 1, cache.Len())
+	cache.Invalidate("group/obj")
+	assert.Equal(t, 0, cache.Len())
+	files, _ := os.ReadDir(cache.dir)
+	assert.Empty(t, files)
+}
+
+func TestNewCacheKeepsForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keep.txt"), []byte("data"), 0o600))
+
+	cache, err := NewCache(dir, 1024)
+	require.NoError(t, err)
+	key := cacheKey("group/obj", "a.txt", md5hex("abc"))
+	require.NoError(t, os.WriteFile(cache.filename(key), []byte("abc"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(cache.dir, fillPrefix+"123"), []byte("ab"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(cache.dir, "notes.txt"), []byte("x"), 0o600))
+
+	// The restart removes the cache files only
+	cache, err = NewCache(dir, 1024)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "keep.txt"))
+	assert.FileExists(t, filepath.Join(cache.dir, "notes.txt"))
+	assert.NoFileExists(t, cache.filename(key))
+	assert.NoFileExists(t, filepath.Join(cache.dir, fillPrefix+"123"))
+}
+
+func newCache(t *testing.T, size int64) *Cache {
+	cache, err := NewCache(filepath.Join(t.TempDir(), "cache"), size)
+	require.NoError(t, err)
+	return cache
+}
+
+func md5hex(data string) string {
+	sum := md5.Sum([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
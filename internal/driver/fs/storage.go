@@ -178,14 +178,25 @@ func (c *Storage) UpdateParams(ctx context.Context, id storio.ObjectID, params u
 //
 //	pattern: search type equals to glob https://golang.org/pkg/path/filepath/#Glob
 func (c *Storage) Scan(ctx context.Context, pattern string, walkf storio.WalkStorageFunc) error {
-	return filepath.Walk(c.root, func(path string, info os.FileInfo, err error) error {
+	root := filepath.Join(c.root, filepath.FromSlash(storio.ScanPrefix(pattern)))
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == root && os.IsNotExist(err) {
+				return nil
+			}
+			return walkf(path, err)
+		}
 		if info.IsDir() {
 			return nil
 		}
-		if ok, _ := filepath.Match(pattern, path); !ok {
+		name, err := filepath.Rel(c.root, path)
+		if err != nil {
+			return walkf(path, err)
+		}
+		if name = filepath.ToSlash(name); !storio.MatchScanPattern(pattern, name) {
 			return nil
 		}
-		return walkf(path, nil)
+		return walkf(name, nil)
 	})
 }
 
@@ -267,7 +278,8 @@ func (c *Storage) Update(ctx context.Context, id storio.ObjectID, name string, d
 		fileExt = datalib.ExtensionByContentType(contentType)
 	}
 
-	if !obj.Workflow().IsValidContentType(contentType) {
+	// The allow-list restricts the uploads only, the derived items are produced by the processor
+	if models.IsOriginal(name) && !obj.Workflow().IsValidContentType(contentType) {
 		return errors.Wrap(ErrUnsupportedContentType, contentType)
 	}
 
@@ -362,7 +374,7 @@ func (c *Storage) FileFullname(obj storio.Object, name string) (string, error) {
 		return item.Fullname(), nil
 	}
 	// Accept the name if the workflow declares it as a target.
-	if obj.Workflow().HasTarget(name) {
+	if obj.Workflow().HasTarget(name) || models.IsTransformTarget(name) {
 		return name, nil
 	}
 	return "", errors.Wrap(ErrCollectionFileNotDefinedInManifest, name)
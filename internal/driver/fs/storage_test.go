@@ -1,15 +1,19 @@
 package fs
 
 import (
+	"bytes"
 	"context"
+	"image"
 	_ "image/gif"  // Register GIF format
 	_ "image/jpeg" // Register JPEG format
-	_ "image/png"  // Register PNG format
+	"image/png"
 	"net/url"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	storio "github.com/apfs-io/apfs/internal/storio"
 	"github.com/apfs-io/apfs/models"
 )
@@ -80,3 +84,19 @@ func TestDiskCollectionOpen(t *testing.T) {
 		t.Error("invalid codename")
 	}
 }
+
+// TestDiskCollectionContentTypes tests that the allow-list restricts the original only.
+func TestDiskCollectionContentTypes(t *testing.T) {
+	ctx := context.TODO()
+	coll, err := NewStorage(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, coll.UpdateWorkflow(ctx, "images", &models.Workflow{ContentTypes: []string{"image/jpeg"}}))
+	file, err := coll.Create(ctx, "images", nil, false, nil)
+	require.NoError(t, err)
+
+	var data bytes.Buffer
+	require.NoError(t, png.Encode(&data, image.NewGray(image.Rect(0, 0, 2, 2))))
+	err = coll.Update(ctx, file, models.OriginalFilename, bytes.NewReader(data.Bytes()), nil)
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+	require.NoError(t, coll.Update(ctx, file, "_transform-2x2-cover.png", bytes.NewReader(data.Bytes()), nil))
+}
@@ -0,0 +1,195 @@
+package gcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	storageScope     = "https://www.googleapis.com/auth/devstorage.read_write"
+	defaultTokenURI  = "https://oauth2.googleapis.com/token"
+	metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// tokenExpiryDelta refreshes the token before it expires
+	tokenExpiryDelta = time.Minute
+)
+
+// ErrInvalidCredentials of the service account
+var ErrInvalidCredentials = errors.New("[gcs] invalid service account credentials")
+
+// TokenSource returns the OAuth2 access token of the requests
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is the access token issued outside of the driver
+type StaticToken string
+
+// Token implements TokenSource
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// cachedToken keeps the token until it expires
+type cachedToken struct {
+	mx      sync.Mutex
+	token   string
+	expires time.Time
+	fetch   func(ctx context.Context) (*tokenResponse, error)
+}
+
+func (c *cachedToken) Token(ctx context.Context) (string, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+	resp, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = resp.AccessToken
+	c.expires = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - tokenExpiryDelta)
+	return c.token, nil
+}
+
+// serviceAccount key file of the Google Cloud
+type serviceAccount struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// NewServiceAccountTokenSource returns the tokens of the service account key file,
+// the token is exchanged for the signed JWT assertion
+func NewServiceAccountTokenSource(filename string, httpClient *http.Client) (TokenSource, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var account serviceAccount
+	if err = json.Unmarshal(data, &account); err != nil {
+		return nil, errors.Wrap(ErrInvalidCredentials, err.Error())
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, ErrInvalidCredentials
+	}
+	key, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+	return &cachedToken{fetch: func(ctx context.Context) (*tokenResponse, error) {
+		assertion, err := signJWT(key, account.ClientEmail, account.TokenURI, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		form := url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			account.TokenURI, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return doTokenRequest(httpClient, req)
+	}}, nil
+}
+
+// NewMetadataTokenSource returns the tokens of the default service account
+// of the Compute Engine / GKE instance
+func NewMetadataTokenSource(httpClient *http.Client) TokenSource {
+	return &cachedToken{fetch: func(ctx context.Context) (*tokenResponse, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		return doTokenRequest(httpClient, req)
+	}}
+}
+
+func doTokenRequest(httpClient *http.Client, req *http.Request) (*tokenResponse, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	var token tokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("[gcs] empty access token")
+	}
+	return &token, nil
+}
+
+// signJWT returns the RS256 signed assertion of the token request
+func signJWT(key *rsa.PrivateKey, email, audience string, now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, err := json.Marshal(map[string]any{
+		"iss":   email,
+		"scope": storageScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	payload := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(payload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + enc.EncodeToString(signature), nil
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Wrap(ErrInvalidCredentials, "private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+			return rsaKey, nil
+		}
+		return nil, errors.Wrap(ErrInvalidCredentials, "private key is not RSA")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidCredentials, err.Error())
+	}
+	return key, nil
+}
@@ -0,0 +1,241 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/driver/blob"
+)
+
+// DefaultEndpoint of the Google Cloud Storage JSON API
+const DefaultEndpoint = "https://storage.googleapis.com"
+
+// Client of the bucket over the Cloud Storage JSON API
+type Client struct {
+	httpClient *http.Client
+	endpoint   string
+	bucket     string
+	tokens     TokenSource
+}
+
+// NewClient of the bucket, the requests are anonymous if tokens is nil
+// (e.g. the fake-gcs-server emulator)
+func NewClient(httpClient *http.Client, endpoint, bucket string, tokens TokenSource) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	return &Client{
+		httpClient: httpClient,
+		endpoint:   endpoint,
+		bucket:     bucket,
+		tokens:     tokens,
+	}
+}
+
+// Get implements blob.Client
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.objectURL(key, "")+"?alt=media", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Put implements blob.Client
+func (c *Client) Put(ctx context.Context, key string, data io.ReadSeeker, contentType string) error {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	query := url.Values{"uploadType": {"media"}, "name": {key}}
+	target := c.endpoint + "/upload/storage/v1/b/" + url.PathEscape(c.bucket) + "/o?" + query.Encode()
+	return c.doClose(ctx, http.MethodPost, target, data, contentType)
+}
+
+// Delete implements blob.Client
+func (c *Client) Delete(ctx context.Context, key string) error {
+	err := c.doClose(ctx, http.MethodDelete, c.objectURL(key, ""), nil, "")
+	if blob.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// List implements blob.Client
+func (c *Client) List(ctx context.Context, prefix string) ([]*blob.Info, error) {
+	var (
+		list  []*blob.Info
+		query = url.Values{"prefix": {prefix}, "fields": {"items(name,size),nextPageToken"}}
+	)
+	for {
+		var page struct {
+			Items []struct {
+				Name string      `json:"name"`
+				Size json.Number `json:"size"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		target := c.endpoint + "/storage/v1/b/" + url.PathEscape(c.bucket) + "/o?" + query.Encode()
+		if err := c.doJSON(ctx, http.MethodGet, target, nil, &page); err != nil {
+			if blob.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for _, item := range page.Items {
+			size, _ := item.Size.Int64()
+			list = append(list, &blob.Info{Key: item.Name, Size: size})
+		}
+		if page.NextPageToken == "" {
+			return list, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// Copy implements blob.Client, the large objects are rewritten in several calls
+func (c *Client) Copy(ctx context.Context, srcKey, dstKey string) error {
+	var (
+		target = c.objectURL(srcKey, "/rewriteTo/b/"+url.PathEscape(c.bucket)+"/o/"+url.PathEscape(dstKey))
+		token  string
+	)
+	for {
+		var resp struct {
+			Done         bool   `json:"done"`
+			RewriteToken string `json:"rewriteToken"`
+		}
+		reqURL := target
+		if token != "" {
+			reqURL += "?" + url.Values{"rewriteToken": {token}}.Encode()
+		}
+		if err := c.doJSON(ctx, http.MethodPost, reqURL, bytes.NewReader([]byte("{}")), &resp); err != nil {
+			return err
+		}
+		if resp.Done || resp.RewriteToken == "" {
+			return nil
+		}
+		token = resp.RewriteToken
+	}
+}
+
+// CreateBucket in the project if it does not exist
+func (c *Client) CreateBucket(ctx context.Context, project string) error {
+	err := c.doClose(ctx, http.MethodGet, c.endpoint+"/storage/v1/b/"+url.PathEscape(c.bucket), nil, "")
+	if !blob.IsNotFound(err) {
+		return err
+	}
+	body, _ := json.Marshal(map[string]string{"name": c.bucket})
+	target := c.endpoint + "/storage/v1/b?" + url.Values{"project": {project}}.Encode()
+	err = c.doClose(ctx, http.MethodPost, target, bytes.NewReader(body), "application/json")
+	var respErr *ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) objectURL(key, suffix string) string {
+	return c.endpoint + "/storage/v1/b/" + url.PathEscape(c.bucket) + "/o/" + url.PathEscape(key) + suffix
+}
+
+func (c *Client) doJSON(ctx context.Context, method, target string, body io.ReadSeeker, result any) error {
+	contentType := ""
+	if body != nil {
+		contentType = "application/json"
+	}
+	resp, err := c.do(ctx, method, target, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (c *Client) doClose(ctx context.Context, method, target string, body io.ReadSeeker, contentType string) error {
+	resp, err := c.do(ctx, method, target, body, contentType)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func (c *Client) do(ctx context.Context, method, target string, body io.ReadSeeker, contentType string) (*http.Response, error) {
+	var (
+		size   int64
+		reader io.Reader
+		err    error
+	)
+	if body != nil {
+		if size, err = blob.ContentSize(body); err != nil {
+			return nil, err
+		}
+		reader = body
+		if size == 0 {
+			reader = http.NoBody
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "[gcs] access token")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// ResponseError of the Cloud Storage API
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("[gcs] %d %s", e.StatusCode, e.Message)
+}
+
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := http.StatusText(resp.StatusCode)
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		message = body.Error.Message
+	}
+	err := &ResponseError{StatusCode: resp.StatusCode, Message: message}
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.Wrap(blob.ErrNotFound, err.Error())
+	}
+	return err
+}
+
+var _ blob.Client = (*Client)(nil)
@@ -0,0 +1,139 @@
+package gcs
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Errors list
+var (
+	ErrBucketIsRequired = errors.New("[gcs] bucket name is required")
+)
+
+type optionConfig struct {
+	httpClient      *http.Client
+	endpoint        string
+	bucket          string
+	prefix          string
+	genpattern      string
+	tokens          TokenSource
+	credentialsFile string
+	anonymous       bool
+	project         string
+}
+
+// Options configuration type
+type Options func(conf *optionConfig) error
+
+// WithBucket of the objects
+func WithBucket(bucket string) Options {
+	return func(conf *optionConfig) error {
+		conf.bucket = strings.Trim(bucket, "/ ")
+		return nil
+	}
+}
+
+// WithPrefix of all keys inside the bucket
+func WithPrefix(prefix string) Options {
+	return func(conf *optionConfig) error {
+		conf.prefix = strings.Trim(prefix, "/ ")
+		return nil
+	}
+}
+
+// WithFilepathGenerator updates path generator option
+func WithFilepathGenerator(pattern string) Options {
+	return func(conf *optionConfig) error {
+		conf.genpattern = pattern
+		return nil
+	}
+}
+
+// WithEndpoint of the JSON API, e.g. the fake-gcs-server emulator.
+// The requests to the custom endpoint are anonymous if no credentials are set.
+func WithEndpoint(endpoint string) Options {
+	return func(conf *optionConfig) error {
+		conf.endpoint = strings.TrimRight(endpoint, "/")
+		conf.anonymous = conf.endpoint != ""
+		return nil
+	}
+}
+
+// WithHTTPClient of the API requests
+func WithHTTPClient(httpClient *http.Client) Options {
+	return func(conf *optionConfig) error {
+		conf.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithTokenSource of the access tokens
+func WithTokenSource(tokens TokenSource) Options {
+	return func(conf *optionConfig) error {
+		conf.tokens = tokens
+		return nil
+	}
+}
+
+// WithCredentialsFile of the service account key
+func WithCredentialsFile(filename string) Options {
+	return func(conf *optionConfig) error {
+		conf.credentialsFile = filename
+		return nil
+	}
+}
+
+// WithCreateBucket creates the bucket in the project if it does not exist
+func WithCreateBucket(project string) Options {
+	return func(conf *optionConfig) error {
+		conf.project = project
+		return nil
+	}
+}
+
+// WithGCSFromURL parse URL and fill the config
+//
+//	gs://bucket/prefix?credentials=/etc/apfs/sa.json&project=apfs&endpoint=http://gcs:4443
+func WithGCSFromURL(connect string) Options {
+	return func(conf *optionConfig) error {
+		urlParsed, err := url.Parse(connect)
+		if err != nil {
+			return err
+		}
+		query := urlParsed.Query()
+		conf.bucket = urlParsed.Host
+		conf.prefix = strings.Trim(urlParsed.Path, "/")
+		conf.project = query.Get("project")
+		if endpoint := query.Get("endpoint"); endpoint != "" {
+			_ = WithEndpoint(endpoint)(conf)
+		}
+		if credentials := query.Get("credentials"); credentials != "" {
+			conf.credentialsFile = credentials
+		}
+		if token := query.Get("token"); token != "" {
+			conf.tokens = StaticToken(token)
+		}
+		return nil
+	}
+}
+
+// tokenSource returns the configured tokens, the service account of
+// GOOGLE_APPLICATION_CREDENTIALS or the metadata server of the instance
+func (conf *optionConfig) tokenSource() (TokenSource, error) {
+	switch {
+	case conf.tokens != nil:
+		return conf.tokens, nil
+	case conf.credentialsFile != "":
+		return NewServiceAccountTokenSource(conf.credentialsFile, conf.httpClient)
+	case conf.anonymous:
+		return nil, nil
+	case os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "":
+		return NewServiceAccountTokenSource(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), conf.httpClient)
+	default:
+		return NewMetadataTokenSource(conf.httpClient), nil
+	}
+}
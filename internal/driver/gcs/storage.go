@@ -0,0 +1,49 @@
+// Package gcs implements file storage collection on Google Cloud Storage
+package gcs
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/apfs-io/apfs/internal/driver/blob"
+)
+
+// NewStorage of the objects in the Cloud Storage bucket. The STORAGE_EMULATOR_HOST
+// environment variable points the storage to the emulator like the Google SDKs do.
+func NewStorage(ctx context.Context, options ...Options) (*blob.Storage, error) {
+	conf := optionConfig{httpClient: http.DefaultClient}
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		_ = WithEndpoint(emulatorEndpoint(host))(&conf)
+	}
+	for _, opt := range options {
+		if err := opt(&conf); err != nil {
+			return nil, err
+		}
+	}
+	if conf.bucket == "" {
+		return nil, ErrBucketIsRequired
+	}
+	tokens, err := conf.tokenSource()
+	if err != nil {
+		return nil, err
+	}
+	client := NewClient(conf.httpClient, conf.endpoint, conf.bucket, tokens)
+	if conf.project != "" {
+		if err = client.CreateBucket(ctx, conf.project); err != nil {
+			return nil, err
+		}
+	}
+	return blob.NewStorage(client,
+		blob.WithPrefix(conf.prefix),
+		blob.WithFilepathGenerator(conf.genpattern),
+	), nil
+}
+
+func emulatorEndpoint(host string) string {
+	if strings.HasPrefix(host, "http://") || strings.HasPrefix(host, "https://") {
+		return host
+	}
+	return "http://" + host
+}
@@ -0,0 +1,258 @@
+package gcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/blob"
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// fakeServer is the subset of the Cloud Storage JSON API used by the client
+type fakeServer struct {
+	mx       sync.Mutex
+	buckets  map[string]map[string][]byte
+	token    string
+	pageSize int
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		http.Error(w, `{"error":{"message":"unauthorized"}}`, http.StatusUnauthorized)
+		return
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	path := r.URL.EscapedPath()
+	switch {
+	case r.Method == http.MethodPost && path == "/storage/v1/b":
+		var body struct{ Name string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := s.buckets[body.Name]; ok {
+			http.Error(w, `{"error":{"message":"exists"}}`, http.StatusConflict)
+			return
+		}
+		s.buckets[body.Name] = map[string][]byte{}
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/upload/storage/v1/b/"):
+		objects := s.bucket(w, strings.TrimSuffix(strings.TrimPrefix(path, "/upload/storage/v1/b/"), "/o"))
+		if objects != nil {
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Query().Get("name")] = data
+			_, _ = w.Write([]byte(`{}`))
+		}
+	case strings.HasPrefix(path, "/storage/v1/b/"):
+		bucketName, rest, _ := strings.Cut(strings.TrimPrefix(path, "/storage/v1/b/"), "/")
+		objects := s.bucket(w, bucketName)
+		if objects == nil {
+			return
+		}
+		switch {
+		case rest == "":
+			_, _ = w.Write([]byte(`{}`))
+		case rest == "o":
+			s.list(w, r, objects)
+		default:
+			s.object(w, r, objects, strings.TrimPrefix(rest, "o/"))
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *fakeServer) bucket(w http.ResponseWriter, name string) map[string][]byte {
+	objects, ok := s.buckets[name]
+	if !ok {
+		http.Error(w, `{"error":{"message":"no bucket"}}`, http.StatusNotFound)
+	}
+	return objects
+}
+
+func (s *fakeServer) list(w http.ResponseWriter, r *http.Request, objects map[string][]byte) {
+	var names []string
+	for name := range objects {
+		if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	offset, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	names = names[min(offset, len(names)):]
+	resp := map[string]any{}
+	if len(names) > s.pageSize {
+		names = names[:s.pageSize]
+		resp["nextPageToken"] = strconv.Itoa(offset + s.pageSize)
+	}
+	var items []map[string]string
+	for _, name := range names {
+		items = append(items, map[string]string{"name": name, "size": strconv.Itoa(len(objects[name]))})
+	}
+	resp["items"] = items
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *fakeServer) object(w http.ResponseWriter, r *http.Request, objects map[string][]byte, escaped string) {
+	escapedName, rewrite, _ := strings.Cut(escaped, "/rewriteTo/b/")
+	name, _ := url.PathUnescape(escapedName)
+	data, ok := objects[name]
+	if !ok {
+		http.Error(w, `{"error":{"message":"no object"}}`, http.StatusNotFound)
+		return
+	}
+	switch {
+	case rewrite != "":
+		_, dst, _ := strings.Cut(rewrite, "/o/")
+		dstName, _ := url.PathUnescape(dst)
+		objects[dstName] = data
+		_, _ = w.Write([]byte(`{"done":true}`))
+	case r.Method == http.MethodDelete:
+		delete(objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Query().Get("alt") == "media":
+		_, _ = w.Write(data)
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]string{"name": name})
+	}
+}
+
+func TestStorage(t *testing.T) {
+	var (
+		ctx  = context.TODO()
+		fake = &fakeServer{buckets: map[string]map[string][]byte{}, token: "secret", pageSize: 2}
+		srv  = httptest.NewServer(fake)
+	)
+	defer srv.Close()
+
+	store, err := NewStorage(ctx,
+		WithGCSFromURL("gs://assets/apfs?project=test&token=secret&endpoint="+srv.URL))
+	require.NoError(t, err)
+	require.Contains(t, fake.buckets, "assets")
+
+	obj, err := store.Create(ctx, "images", nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename,
+		strings.NewReader("data"), nil))
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		require.NoError(t, store.WriteFile(ctx, obj, "items/"+name, strings.NewReader(name), nil))
+	}
+
+	// The list is paginated by 2 items
+	files, err := store.ListFiles(ctx, obj, "items/*")
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	require.NoError(t, store.MoveFile(ctx, obj, "items/a.txt", "items/d.txt"))
+	reader, err := store.ReadFile(ctx, obj, "items/d.txt")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "a.txt", string(data))
+
+	opened, err := store.Open(ctx, obj)
+	require.NoError(t, err)
+	reader, err = store.Read(ctx, opened, models.OriginalFilename)
+	require.NoError(t, err)
+	data, _ = io.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "data", string(data))
+
+	require.NoError(t, store.Remove(ctx, obj))
+	assert.Empty(t, fake.buckets["assets"])
+
+	// Unauthorized requests
+	client := NewClient(nil, srv.URL, "assets", StaticToken("wrong"))
+	_, err = client.List(ctx, "")
+	var respErr *ResponseError
+	require.ErrorAs(t, err, &respErr)
+	assert.Equal(t, http.StatusUnauthorized, respErr.StatusCode)
+	_, err = NewClient(nil, srv.URL, "assets", StaticToken("secret")).Get(ctx, "none")
+	assert.True(t, blob.IsNotFound(err), err)
+}
+
+func TestServiceAccountTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if !assert.Len(t, parts, 3) {
+			return
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature))
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		assert.Contains(t, string(claims), `"iss":"apfs@test.iam.gserviceaccount.com"`)
+		_, _ = w.Write([]byte(`{"access_token":"token-1","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	account, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "apfs@test.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    srv.URL,
+	})
+	filename := filepath.Join(t.TempDir(), "sa.json")
+	require.NoError(t, os.WriteFile(filename, account, 0o600))
+
+	tokens, err := NewServiceAccountTokenSource(filename, http.DefaultClient)
+	require.NoError(t, err)
+	token, err := tokens.Token(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+}
+
+// TestEmulator runs against fake-gcs-server:
+//
+//	docker run --rm -p 4443:4443 fsouza/fake-gcs-server -scheme http
+//	TEST_GCS_ENDPOINT=http://127.0.0.1:4443 go test ./internal/driver/gcs/
+func TestEmulator(t *testing.T) {
+	endpoint := os.Getenv("TEST_GCS_ENDPOINT")
+	if endpoint == "" {
+		t.SkipNow()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	store, err := NewStorage(ctx, WithEndpoint(endpoint),
+		WithBucket("apfs-test"), WithCreateBucket("test"))
+	require.NoError(t, err)
+
+	obj, err := store.Create(ctx, "assets", storio.ObjectIDType("assets/emulator"), true, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename,
+		strings.NewReader("data"), &models.ItemMeta{ContentType: "text/plain"}))
+	reader, err := store.Read(ctx, obj, models.OriginalFilename)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "data", string(data))
+	assert.NoError(t, store.Remove(ctx, obj))
+}
@@ -17,9 +17,7 @@ import (
 	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
 	awss3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/pkg/errors"
-	"go.uber.org/zap"
 
-	"github.com/apfs-io/apfs/internal/context/ctxlogger"
 	datalib "github.com/apfs-io/apfs/internal/storage/data"
 	storio "github.com/apfs-io/apfs/internal/storio"
 	"github.com/apfs-io/apfs/internal/storio/objectpath"
@@ -38,6 +36,7 @@ var (
 	ErrUnsupportedContentType   = errors.New("content-type is not supported")
 	ErrCustomObjectIDIsNotValid = errors.New("invalid custom object ID or taken")
 	ErrObjectAlreadyExists      = errors.New("object already exists")
+	ErrScanGroupRequired        = errors.New("scan pattern must start with the group if no bucket defined")
 )
 
 // Storage to manage S3 type
@@ -353,21 +352,33 @@ func (c *Storage) Clean(ctx context.Context, id storio.ObjectID) error {
 //
 //	pattern: search type equals to glob https://golang.org/pkg/path/filepath/#Glob
 func (c *Storage) Scan(ctx context.Context, pattern string, walkf storio.WalkStorageFunc) error {
-	pattern = strings.TrimLeft(pattern, "/")
-	arr := strings.SplitN(pattern, "/", 2)
-	bucket, prefix := arr[0], arr[1]
-	output, err := c.c.ListObjects(ctx, &awss3.ListObjectsInput{
-		Bucket: c._bucketName(bucket),
-		Prefix: c._bucketFilenameBasic(bucket, prefix),
-	})
-	if err != nil {
-		return err
+	bucket, prefix := c.bucketName, storio.ScanPrefix(pattern)
+	if bucket == "" {
+		// Every group is the separate bucket
+		group, rest, _ := strings.Cut(prefix, "/")
+		if group == "" {
+			return ErrScanGroupRequired
+		}
+		bucket, prefix = group, rest
 	}
-	for _, o := range output.Contents {
-		name := c._keyName(ctx, *o.Key)
-		if ok, _ := filepath.Match(pattern, name); ok {
-			if err = walkf(name, nil); err != nil {
-				return err
+	paginator := awss3.NewListObjectsV2Paginator(c.c, &awss3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, o := range page.Contents {
+			name := aws.ToString(o.Key)
+			if c.bucketName == "" {
+				name = bucket + "/" + name
+			}
+			if storio.MatchScanPattern(pattern, name) {
+				if err = walkf(name, nil); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -560,18 +571,6 @@ func (c *Storage) _bucketName(name string) *string {
 	return &name
 }
 
-func (c *Storage) _keyName(ctx context.Context, name string) string {
-	name = strings.TrimLeft(name, "/")
-	if len(c.bucketName) > 0 {
-		if strings.HasPrefix(name, c.bucketName) {
-			name = strings.TrimLeft(strings.TrimPrefix(name, c.bucketName), "/")
-		} else {
-			ctxlogger.Get(ctx).Debug("invalid key to scan", zap.String("key", name))
-		}
-	}
-	return name
-}
-
 // _bucketFilename returns path inside the bucket or
 func (c *Storage) _bucketFilename(object storio.Object, name string) *string {
 	return c._bucketFilenameBasic(object.Bucket(), object.PrepareName(name))
@@ -0,0 +1,110 @@
+package tiered
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	storio "github.com/apfs-io/apfs/internal/storio"
+)
+
+// DefaultBatchSize of the object pages listed by the mover
+const DefaultBatchSize = 100
+
+// ObjectLister returns the page of the objects to check the placement of
+// listed after the cursor, and the cursor of the page end. The empty cursor
+// starts from the first object, the empty page stops the iteration.
+// The cursor keeps the position when the objects are added or removed
+// between the pages, unlike the offset.
+type ObjectLister func(ctx context.Context, cursor string, limit int) (ids []storio.ObjectID, next string, err error)
+
+// MoverOption configures the mover
+type MoverOption func(m *Mover)
+
+// WithBatchSize of the object pages
+func WithBatchSize(size int) MoverOption {
+	return func(m *Mover) {
+		if size > 0 {
+			m.batchSize = size
+		}
+	}
+}
+
+// WithOnMoved hook is called after the files of the object were moved,
+// e.g. to refresh the cached object meta
+func WithOnMoved(fn func(ctx context.Context, id storio.ObjectID)) MoverOption {
+	return func(m *Mover) {
+		m.onMoved = fn
+	}
+}
+
+// Mover migrates the files of the aged objects between the tiers in background
+type Mover struct {
+	storage   *Storage
+	list      ObjectLister
+	batchSize int
+	onMoved   func(ctx context.Context, id storio.ObjectID)
+}
+
+// NewMover of the objects listed by the lister
+func NewMover(storage *Storage, list ObjectLister, options ...MoverOption) *Mover {
+	mover := &Mover{storage: storage, list: list, batchSize: DefaultBatchSize}
+	for _, opt := range options {
+		opt(mover)
+	}
+	return mover
+}
+
+// Run relocates the objects every interval until the context is done
+func (m *Mover) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		moved, err := m.RunOnce(ctx)
+		if err != nil {
+			ctxlogger.Get(ctx).Error("tiered storage relocation", zap.Error(err))
+		} else if moved > 0 {
+			ctxlogger.Get(ctx).Info("tiered storage relocation", zap.Int("moved", moved))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks all listed objects and returns the number of moved files.
+// The errors of the single objects are logged and do not stop the iteration.
+func (m *Mover) RunOnce(ctx context.Context) (int, error) {
+	var (
+		total  int
+		cursor string
+	)
+	for {
+		ids, next, err := m.list(ctx, cursor, m.batchSize)
+		if err != nil || len(ids) == 0 {
+			return total, err
+		}
+		cursor = next
+		for _, id := range ids {
+			if err = ctx.Err(); err != nil {
+				return total, err
+			}
+			moved, err := m.storage.Relocate(ctx, id)
+			if err != nil {
+				ctxlogger.Get(ctx).Error("relocate object files",
+					zap.String("object_id", id.ID().String()), zap.Error(err))
+			}
+			if moved > 0 && m.onMoved != nil {
+				m.onMoved(ctx, id)
+			}
+			total += moved
+		}
+		if len(ids) < m.batchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package tiered
+
+import (
+	"slices"
+	"time"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// Rule of the file placement. Empty fields are not checked, so the empty
+// rule matches all files.
+type Rule struct {
+	// Roles of the files: the job IDs produced the artifacts or "original"
+	Roles []string
+
+	// MinSize and MaxSize of the file in bytes
+	MinSize int64
+	MaxSize int64
+
+	// MinAge of the file since the last update
+	MinAge time.Duration
+}
+
+// Match returns true if the file of the role fits the rule
+func (r *Rule) Match(role string, item *models.ItemMeta, age time.Duration) bool {
+	switch {
+	case len(r.Roles) > 0 && !slices.Contains(r.Roles, role),
+		r.MinSize > 0 && item.Size < r.MinSize,
+		r.MaxSize > 0 && item.Size > r.MaxSize,
+		r.MinAge > 0 && age < r.MinAge:
+		return false
+	}
+	return true
+}
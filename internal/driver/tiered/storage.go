@@ -0,0 +1,304 @@
+// Package tiered implements the composite storage which places the files of
+// the objects on the different backends (tiers) by the role, size and age rules.
+//
+// The default storage keeps the workflows, meta and state of the objects and
+// all files which do not match any tier. The tier of the file is recorded in
+// the "tier" attribute of its ItemMeta, so the reads are resolved transparently.
+package tiered
+
+import (
+	"context"
+	"io"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/libs/storerrors"
+	"github.com/apfs-io/apfs/models"
+)
+
+// ErrUnknownTier is returned if the file is placed on the tier which is not configured
+var ErrUnknownTier = errors.New("[tiered] unknown tier")
+
+// Tier of the file placement
+type Tier struct {
+	Name  string
+	Files storio.ObjectFileAccessor
+	Rule  Rule
+}
+
+// Storage places the files on the first tier whose rule matches the file.
+// New files are written to the default storage and moved to the tier right
+// after the update, the aged files are moved by the Mover.
+type Storage struct {
+	storio.StorageAccessor
+
+	tiers []*Tier
+	now   func() time.Time
+}
+
+// NewStorage of the tiers over the default storage
+func NewStorage(primary storio.StorageAccessor, tiers ...*Tier) *Storage {
+	return &Storage{StorageAccessor: primary, tiers: tiers, now: time.Now}
+}
+
+// Tiers returns the list of configured tiers
+func (s *Storage) Tiers() []*Tier {
+	return s.tiers
+}
+
+// Update writes the file to the default storage and places it by the rules
+func (s *Storage) Update(ctx context.Context, id storio.ObjectID, name string, data io.Reader, meta *models.ItemMeta) error {
+	obj, err := s.object(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item := obj.MetaOrNew().ItemByName(name); item != nil && item.Tier() != "" {
+		if err = s.deleteFile(ctx, obj, item, item.Tier()); err != nil {
+			return err
+		}
+	}
+	if meta == nil {
+		meta = &models.ItemMeta{}
+	} else {
+		delete(meta.Attributes, models.AttributeTier)
+	}
+	if err = s.StorageAccessor.Update(ctx, obj, name, data, meta); err != nil {
+		return err
+	}
+	if item := obj.MetaOrNew().ItemByName(meta.Name); item != nil {
+		_, err = s.place(ctx, obj, item)
+	}
+	return err
+}
+
+// UpdateMeta keeps the tier of the file if the new meta does not define it
+func (s *Storage) UpdateMeta(ctx context.Context, id storio.ObjectID, name string, meta *models.ItemMeta) error {
+	obj, err := s.object(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item := obj.MetaOrNew().ItemByName(name); item != nil && item.Tier() != "" && meta.Tier() == "" {
+		meta.SetAttribute(models.AttributeTier, item.Tier())
+	}
+	return s.StorageAccessor.UpdateMeta(ctx, obj, name, meta)
+}
+
+// Read the file from the tier it is placed on
+func (s *Storage) Read(ctx context.Context, id storio.ObjectID, name string) (io.ReadCloser, error) {
+	obj, err := s.object(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := s.read(ctx, obj, name)
+	if err == nil {
+		return reader, nil
+	}
+	// The object could be loaded from the cache before the file was moved
+	fresh, openErr := s.StorageAccessor.Open(ctx, obj.ID())
+	if openErr != nil || tierOf(fresh, name) == tierOf(obj, name) {
+		return nil, err
+	}
+	return s.read(ctx, fresh, name)
+}
+
+// Remove the files from the tiers and the default storage
+func (s *Storage) Remove(ctx context.Context, id storio.ObjectID, names ...string) error {
+	obj, err := s.object(ctx, id)
+	if storerrors.IsNotFound(err) {
+		return s.StorageAccessor.Remove(ctx, id, names...)
+	}
+	if err != nil {
+		return err
+	}
+	items := files(obj)
+	if len(names) > 0 {
+		items = items[:0]
+		for _, name := range names {
+			if item := obj.MetaOrNew().ItemByName(name); item != nil {
+				items = append(items, item)
+			}
+		}
+	}
+	for _, item := range items {
+		if item.Tier() == "" {
+			continue
+		}
+		if err = s.deleteFile(ctx, obj, item, item.Tier()); err != nil {
+			return err
+		}
+	}
+	return s.StorageAccessor.Remove(ctx, obj, names...)
+}
+
+// Clean removes the derived files from the tiers and the default storage
+func (s *Storage) Clean(ctx context.Context, id storio.ObjectID) error {
+	obj, err := s.object(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, item := range obj.MetaOrNew().Items {
+		if item.Tier() == "" {
+			continue
+		}
+		if err = s.deleteFile(ctx, obj, item, item.Tier()); err != nil {
+			return err
+		}
+	}
+	return s.StorageAccessor.Clean(ctx, obj)
+}
+
+// Relocate moves the files of the object to the tiers matched by the rules.
+// Returns the number of moved files.
+func (s *Storage) Relocate(ctx context.Context, id storio.ObjectID) (int, error) {
+	obj, err := s.StorageAccessor.Open(ctx, id.ID())
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, item := range files(obj) {
+		moved, err := s.place(ctx, obj, item)
+		if err != nil {
+			return count, errors.Wrap(err, item.Name)
+		}
+		if moved {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// place moves the file to the tier matched by the rules
+func (s *Storage) place(ctx context.Context, obj storio.Object, item *models.ItemMeta) (bool, error) {
+	from, to := item.Tier(), s.target(obj, item)
+	if from == to {
+		return false, nil
+	}
+	return true, s.move(ctx, obj, item, from, to)
+}
+
+// target returns the name of the first tier matched the file or "" for the default storage
+func (s *Storage) target(obj storio.Object, item *models.ItemMeta) string {
+	role := item.Role
+	if role == "" && models.IsOriginal(item.Name) {
+		role = models.RoleOriginal
+	}
+	createdAt := obj.MetaOrNew().CreatedAt
+	if createdAt.IsZero() {
+		createdAt = obj.CreatedAt()
+	}
+	age := s.now().Sub(createdAt)
+	for _, tier := range s.tiers {
+		if tier.Rule.Match(role, item, age) {
+			return tier.Name
+		}
+	}
+	return ""
+}
+
+// move copies the file to the target, switches the meta to it and deletes the source
+func (s *Storage) move(ctx context.Context, obj storio.Object, item *models.ItemMeta, from, to string) error {
+	reader, err := s.readFile(ctx, obj, item, from)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reader.Close() }()
+
+	moved := *item
+	moved.Attributes = maps.Clone(item.Attributes)
+	if to == "" {
+		delete(moved.Attributes, models.AttributeTier)
+		err = s.StorageAccessor.Update(ctx, obj, item.Name, reader, &moved)
+	} else {
+		tier := s.tier(to)
+		if err = tier.Files.WriteFile(ctx, obj, fileFullname(obj, item), reader, &moved); err != nil {
+			return err
+		}
+		moved.SetAttribute(models.AttributeTier, to)
+		err = s.StorageAccessor.UpdateMeta(ctx, obj, item.Name, &moved)
+	}
+	if err != nil {
+		return err
+	}
+	return s.deleteFile(ctx, obj, &moved, from)
+}
+
+func (s *Storage) read(ctx context.Context, obj storio.Object, name string) (io.ReadCloser, error) {
+	item := obj.MetaOrNew().ItemByName(name)
+	if item == nil || item.Tier() == "" {
+		return s.StorageAccessor.Read(ctx, obj, name)
+	}
+	return s.readFile(ctx, obj, item, item.Tier())
+}
+
+func (s *Storage) readFile(ctx context.Context, obj storio.Object, item *models.ItemMeta, tierName string) (io.ReadCloser, error) {
+	if tierName == "" {
+		return s.StorageAccessor.Read(ctx, obj, item.Name)
+	}
+	tier := s.tier(tierName)
+	if tier == nil {
+		return nil, errors.Wrap(ErrUnknownTier, tierName)
+	}
+	fullname := fileFullname(obj, item)
+	reader, err := tier.Files.ReadFile(ctx, obj, fullname)
+	if os.IsNotExist(err) {
+		return nil, storerrors.WrapNotFound(fullname, err)
+	}
+	return reader, err
+}
+
+func (s *Storage) deleteFile(ctx context.Context, obj storio.Object, item *models.ItemMeta, tierName string) error {
+	if tierName == "" {
+		return s.StorageAccessor.DeleteFiles(ctx, obj, fileFullname(obj, item))
+	}
+	if tier := s.tier(tierName); tier != nil {
+		return tier.Files.DeleteFiles(ctx, obj, fileFullname(obj, item))
+	}
+	return nil
+}
+
+func (s *Storage) tier(name string) *Tier {
+	for _, tier := range s.tiers {
+		if tier.Name == name {
+			return tier
+		}
+	}
+	return nil
+}
+
+func (s *Storage) object(ctx context.Context, id storio.ObjectID) (storio.Object, error) {
+	if obj, ok := id.(storio.Object); ok {
+		return obj, nil
+	}
+	return s.StorageAccessor.Open(ctx, id)
+}
+
+// files returns the original and the derived files of the object
+func files(obj storio.Object) []*models.ItemMeta {
+	meta := obj.MetaOrNew()
+	items := make([]*models.ItemMeta, 0, len(meta.Items)+1)
+	if !meta.Main.IsEmpty() {
+		items = append(items, &meta.Main)
+	}
+	return append(items, meta.Items...)
+}
+
+func tierOf(obj storio.Object, name string) string {
+	if item := obj.MetaOrNew().ItemByName(name); item != nil {
+		return item.Tier()
+	}
+	return ""
+}
+
+// fileFullname of the file inside the object the same as the storage drivers use
+func fileFullname(obj storio.Object, item *models.ItemMeta) string {
+	if models.IsOriginal(item.Name) {
+		return obj.PrepareName(item.Name)
+	}
+	return item.Fullname()
+}
+
+var _ storio.StorageAccessor = (*Storage)(nil)
@@ -0,0 +1,112 @@
+package tiered
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/fs"
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+func newTestStorage(t *testing.T) (*Storage, string, string) {
+	var (
+		root     = t.TempDir()
+		hotDir   = filepath.Join(root, "hot")
+		coldDir  = filepath.Join(root, "cold")
+		primary  = newFS(t, filepath.Join(root, "default"))
+		hotFiles = newFS(t, hotDir)
+		cold     = newFS(t, coldDir)
+	)
+	store := NewStorage(primary,
+		&Tier{Name: "cold", Files: cold, Rule: Rule{MinAge: 30 * 24 * time.Hour}},
+		&Tier{Name: "hot", Files: hotFiles, Rule: Rule{Roles: []string{"thumb"}, MaxSize: 1024}},
+	)
+	return store, hotDir, coldDir
+}
+
+func newFS(t *testing.T, dir string) *fs.Storage {
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	store, err := fs.NewStorage(dir)
+	require.NoError(t, err)
+	return store
+}
+
+func readString(t *testing.T, store storio.StorageAccessor, id storio.ObjectID, name string) string {
+	reader, err := store.Read(context.TODO(), id, name)
+	require.NoError(t, err)
+	defer func() { _ = reader.Close() }()
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRuleMatch(t *testing.T) {
+	item := &models.ItemMeta{Size: 100}
+	assert.True(t, (&Rule{}).Match("thumb", item, 0))
+	assert.True(t, (&Rule{Roles: []string{"thumb"}, MaxSize: 100}).Match("thumb", item, 0))
+	assert.False(t, (&Rule{Roles: []string{"thumb"}}).Match("original", item, 0))
+	assert.False(t, (&Rule{MinSize: 101}).Match("thumb", item, 0))
+	assert.False(t, (&Rule{MinAge: time.Hour}).Match("thumb", item, time.Minute))
+}
+
+func TestStoragePlacement(t *testing.T) {
+	var (
+		ctx                    = context.TODO()
+		store, hotDir, coldDir = newTestStorage(t)
+	)
+	require.NoError(t, store.UpdateWorkflow(ctx, "media", &models.Workflow{
+		Jobs: map[string]*models.WorkflowJob{"thumb": {Steps: []*models.WorkflowStep{
+			{Uses: "image/resize", With: map[string]any{"target": "thumb.txt"}},
+		}}},
+	}))
+	obj, err := store.Create(ctx, "media", nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Update(ctx, obj, models.OriginalFilename,
+		strings.NewReader("original data"), nil))
+	require.NoError(t, store.Update(ctx, obj, "thumb.txt",
+		strings.NewReader("thumb data"), &models.ItemMeta{Role: "thumb"}))
+
+	// The thumbnail is moved to the hot tier right after the update
+	thumb := obj.Meta().ItemByName("thumb.txt")
+	require.NotNil(t, thumb)
+	assert.Equal(t, "hot", thumb.Tier())
+	assert.Equal(t, "", obj.Meta().Main.Tier())
+	assert.FileExists(t, filepath.Join(hotDir, obj.ID().String(), "thumb.txt"))
+	assert.Equal(t, "thumb data", readString(t, store, obj.ID(), "thumb.txt"))
+	assert.Equal(t, "original data", readString(t, store, obj.ID(), models.OriginalFilename))
+
+	// The object gets old and all files go to the cold tier
+	store.now = func() time.Time { return time.Now().Add(31 * 24 * time.Hour) }
+	moved, err := NewMover(store, func(_ context.Context, cursor string, _ int) ([]storio.ObjectID, string, error) {
+		if cursor != "" {
+			return nil, "", nil
+		}
+		return []storio.ObjectID{obj.ID()}, obj.ID().String(), nil
+	}).RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, moved)
+	assert.NoFileExists(t, filepath.Join(hotDir, obj.ID().String(), "thumb.txt"))
+	assert.FileExists(t, filepath.Join(coldDir, obj.ID().String(), "thumb.txt"))
+
+	// The stale object still resolves the moved files
+	assert.Equal(t, "thumb data", readString(t, store, obj, "thumb.txt"))
+	assert.Equal(t, "original data", readString(t, store, obj.ID(), models.OriginalFilename))
+
+	// Nothing to move on the next run
+	moved, err = store.Relocate(ctx, obj.ID())
+	require.NoError(t, err)
+	assert.Zero(t, moved)
+
+	// The tiers are cleaned with the object
+	require.NoError(t, store.Remove(ctx, obj.ID()))
+	assert.NoFileExists(t, filepath.Join(coldDir, obj.ID().String(), "thumb.txt"))
+}
@@ -0,0 +1,435 @@
+// Package badger implements jobqueue.Queue on top of the embedded BadgerDB
+// for the single-node deployments without NATS or Kafka. The on-disk queue
+// survives the process restarts, the in-memory one is used by tests and
+// the all-in-one mode.
+package badger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demdxx/gocast/v2"
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/jobqueue"
+)
+
+// Key prefixes of the queue records
+//
+//	msg/<id>                           the message record
+//	ready/<visible at><seq>            label and id of the queued message ordered by visibility
+//	dead/<label>/<seq>                 the dead message
+const (
+	msgKeyPrefix   = `msg/`
+	readyKeyPrefix = `ready/`
+	deadKeyPrefix  = `dead/`
+	seqKey         = `seq`
+)
+
+type messageRecord struct {
+	Job *jobqueue.Job `json:"job"`
+
+	// Index is the current ready/ key of the message. It's changed on every
+	// delivery, so the stale delivery can't acknowledge the message.
+	Index []byte `json:"index"`
+}
+
+// Queue is the BadgerDB implementation of jobqueue.Queue
+type Queue struct {
+	mx   sync.Mutex
+	db   *badger.DB
+	seq  *badger.Sequence
+	opts jobqueue.Options
+
+	// wake is closed and replaced when a message becomes visible
+	wake   chan struct{}
+	closed chan struct{}
+}
+
+// New opens the queue database by URL
+// connection: badger:///var/lib/apfs/jobs?sync=true or badger://jobs?inmemory=true
+func New(connection string, opts ...jobqueue.Option) (*Queue, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimPrefix(connection, `badger://`)
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	dbOpts := badger.DefaultOptions(path).
+		WithValueLogFileSize(16 * 1024 * 1024).
+		WithLogger(nil)
+	if gocast.Bool(u.Query().Get(`sync`)) {
+		dbOpts = dbOpts.WithSyncWrites(true)
+	}
+	if gocast.Bool(u.Query().Get(`inmemory`)) {
+		dbOpts = dbOpts.WithInMemory(true).WithDir("").WithValueDir("")
+	}
+	db, err := badger.Open(dbOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "open badger job queue")
+	}
+	return NewWithDB(db, opts...)
+}
+
+// NewInMemory returns the queue which keeps the messages in memory only
+func NewInMemory(opts ...jobqueue.Option) (*Queue, error) {
+	db, err := badger.Open(badger.DefaultOptions("").
+		WithInMemory(true).
+		WithLogger(nil))
+	if err != nil {
+		return nil, errors.Wrap(err, "open memory job queue")
+	}
+	return NewWithDB(db, opts...)
+}
+
+// NewWithDB returns the queue over the opened database
+func NewWithDB(db *badger.DB, opts ...jobqueue.Option) (*Queue, error) {
+	seq, err := db.GetSequence([]byte(seqKey), 1000)
+	if err != nil {
+		return nil, errors.Wrap(err, "job queue sequence")
+	}
+	return &Queue{
+		db:     db,
+		seq:    seq,
+		opts:   jobqueue.NewOptions(opts...),
+		wake:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}, nil
+}
+
+// Enqueue the jobs, a job with the ID already in the queue is ignored
+func (q *Queue) Enqueue(ctx context.Context, jobs ...*jobqueue.Job) error {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if q.isClosed() {
+		return jobqueue.ErrClosed
+	}
+	err := q.db.Update(func(txn *badger.Txn) error {
+		now := time.Now()
+		for _, job := range jobs {
+			rec, err := getMessage(txn, job.ID)
+			if err != nil {
+				return err
+			}
+			if rec != nil {
+				continue
+			}
+			msg := *job
+			msg.Label = jobqueue.Label(msg.Label)
+			msg.EnqueuedAt = now
+			if err = q.setIndex(txn, &messageRecord{Job: &msg}, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		q.notify()
+	}
+	return err
+}
+
+// Dequeue blocks until a job of one of the labels is available
+func (q *Queue) Dequeue(ctx context.Context, labels []string) (jobqueue.Delivery, error) {
+	for {
+		q.mx.Lock()
+		if q.isClosed() {
+			q.mx.Unlock()
+			return nil, jobqueue.ErrClosed
+		}
+		wake := q.wake
+		delivery, err := q.claim(labels)
+		q.mx.Unlock()
+		if err != nil || delivery != nil {
+			return delivery, err
+		}
+		timer := time.NewTimer(q.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-q.closed:
+			timer.Stop()
+			return nil, jobqueue.ErrClosed
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// DeadLetters returns the dead jobs of the label
+func (q *Queue) DeadLetters(ctx context.Context, label string) ([]*jobqueue.Job, error) {
+	var jobs []*jobqueue.Job
+	err := q.db.View(func(txn *badger.Txn) error {
+		prefix := []byte(deadKeyPrefix + jobqueue.Label(label) + "/")
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, Prefix: prefix})
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var job jobqueue.Job
+			if err := it.Item().Value(func(data []byte) error {
+				return json.Unmarshal(data, &job)
+			}); err != nil {
+				return err
+			}
+			jobs = append(jobs, &job)
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// Close the queue database
+func (q *Queue) Close() error {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if q.isClosed() {
+		return nil
+	}
+	close(q.closed)
+	_ = q.seq.Release()
+	return q.db.Close()
+}
+
+// claim delivers the first visible message of the labels
+func (q *Queue) claim(labels []string) (*delivery, error) {
+	var res *delivery
+	err := q.db.Update(func(txn *badger.Txn) error {
+		now := time.Now()
+		id, err := firstVisible(txn, labels, now)
+		if err != nil || id == "" {
+			return err
+		}
+		rec, err := getMessage(txn, id)
+		if err != nil || rec == nil {
+			return err
+		}
+		rec.Job.Deliveries++
+		if err = q.setIndex(txn, rec, now.Add(q.opts.VisibilityTimeout)); err != nil {
+			return err
+		}
+		job := *rec.Job
+		res = &delivery{queue: q, job: &job, index: rec.Index}
+		return nil
+	})
+	return res, err
+}
+
+// firstVisible returns the ID of the first message of the labels visible at the time
+func firstVisible(txn *badger.Txn, labels []string, now time.Time) (string, error) {
+	it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, Prefix: []byte(readyKeyPrefix)})
+	defer it.Close()
+	var id string
+	for it.Rewind(); it.Valid() && id == ""; it.Next() {
+		if visibleAt(it.Item().Key()).After(now) {
+			break
+		}
+		err := it.Item().Value(func(data []byte) error {
+			if label, msgID, ok := bytes.Cut(data, []byte{0}); ok && jobqueue.MatchLabel(labels, string(label)) {
+				id = string(msgID)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// update the delivered message if it's still held by the delivery
+func (q *Queue) update(d *delivery, fn func(txn *badger.Txn, rec *messageRecord) error) error {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if q.isClosed() {
+		return jobqueue.ErrClosed
+	}
+	return q.db.Update(func(txn *badger.Txn) error {
+		rec, err := getMessage(txn, d.job.ID)
+		if err != nil {
+			return err
+		}
+		if rec == nil || !bytes.Equal(rec.Index, d.index) {
+			return jobqueue.ErrStaleDelivery
+		}
+		return fn(txn, rec)
+	})
+}
+
+// setIndex moves the message to the ready position visible at the time
+func (q *Queue) setIndex(txn *badger.Txn, rec *messageRecord, visible time.Time) error {
+	seq, err := q.seq.Next()
+	if err != nil {
+		return err
+	}
+	if rec.Index != nil {
+		if err = txn.Delete(rec.Index); err != nil {
+			return err
+		}
+	}
+	rec.Index = readyKey(visible, seq)
+	if err = txn.Set(rec.Index, append([]byte(rec.Job.Label+"\x00"), rec.Job.ID...)); err != nil {
+		return err
+	}
+	return setMessage(txn, rec)
+}
+
+// notify the waiting consumers
+func (q *Queue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+func (q *Queue) isClosed() bool {
+	select {
+	case <-q.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// delivery of the message held by the worker
+type delivery struct {
+	queue *Queue
+	job   *jobqueue.Job
+	index []byte
+}
+
+func (d *delivery) Job() *jobqueue.Job { return d.job }
+
+func (d *delivery) Deadline() time.Time {
+	d.queue.mx.Lock()
+	defer d.queue.mx.Unlock()
+	return visibleAt(d.index)
+}
+
+// Extend moves the visibility deadline by the queue visibility timeout
+func (d *delivery) Extend(ctx context.Context) error {
+	var index []byte
+	err := d.queue.update(d, func(txn *badger.Txn, rec *messageRecord) error {
+		err := d.queue.setIndex(txn, rec, time.Now().Add(d.queue.opts.VisibilityTimeout))
+		index = rec.Index
+		return err
+	})
+	if err == nil {
+		d.queue.mx.Lock()
+		d.index = index
+		d.queue.mx.Unlock()
+	}
+	return err
+}
+
+// Ack removes the message from the queue
+func (d *delivery) Ack(ctx context.Context) error {
+	return d.queue.update(d, func(txn *badger.Txn, rec *messageRecord) error {
+		if err := txn.Delete(rec.Index); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(msgKeyPrefix + rec.Job.ID))
+	})
+}
+
+// Nack returns the message to the queue or moves it to the dead-letter list
+func (d *delivery) Nack(ctx context.Context, cause error, delay time.Duration) error {
+	q := d.queue
+	err := q.update(d, func(txn *badger.Txn, rec *messageRecord) error {
+		if cause != nil {
+			rec.Job.Error = cause.Error()
+		}
+		if rec.Job.Deliveries < q.opts.MaxDeliveries {
+			return q.setIndex(txn, rec, time.Now().Add(delay))
+		}
+		return q.deadLetter(txn, rec)
+	})
+	if err == nil && delay <= 0 {
+		q.mx.Lock()
+		q.notify()
+		q.mx.Unlock()
+	}
+	return err
+}
+
+// Reschedule returns the message to the queue visible at the time
+func (d *delivery) Reschedule(ctx context.Context, cause error, at time.Time) error {
+	q := d.queue
+	err := q.update(d, func(txn *badger.Txn, rec *messageRecord) error {
+		if cause != nil {
+			rec.Job.Error = cause.Error()
+		}
+		rec.Job.Deliveries = max(rec.Job.Deliveries-1, 0)
+		return q.setIndex(txn, rec, at)
+	})
+	if err == nil && !at.After(time.Now()) {
+		q.mx.Lock()
+		q.notify()
+		q.mx.Unlock()
+	}
+	return err
+}
+
+// deadLetter moves the message to the dead-letter list
+func (q *Queue) deadLetter(txn *badger.Txn, rec *messageRecord) error {
+	seq, err := q.seq.Next()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec.Job)
+	if err != nil {
+		return err
+	}
+	key := binary.BigEndian.AppendUint64([]byte(deadKeyPrefix+rec.Job.Label+"/"), seq)
+	if err = txn.Set(key, data); err != nil {
+		return err
+	}
+	if err = txn.Delete(rec.Index); err != nil {
+		return err
+	}
+	return txn.Delete([]byte(msgKeyPrefix + rec.Job.ID))
+}
+
+func getMessage(txn *badger.Txn, id string) (*messageRecord, error) {
+	item, err := txn.Get([]byte(msgKeyPrefix + id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec messageRecord
+	err = item.Value(func(data []byte) error {
+		return json.Unmarshal(data, &rec)
+	})
+	return &rec, err
+}
+
+func setMessage(txn *badger.Txn, rec *messageRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return txn.Set([]byte(msgKeyPrefix+rec.Job.ID), data)
+}
+
+// readyKey orders the messages by the visibility time and the sequence
+func readyKey(visible time.Time, seq uint64) []byte {
+	key := binary.BigEndian.AppendUint64([]byte(readyKeyPrefix), uint64(visible.UnixNano()))
+	return binary.BigEndian.AppendUint64(key, seq)
+}
+
+func visibleAt(key []byte) time.Time {
+	key = bytes.TrimPrefix(key, []byte(readyKeyPrefix))
+	if len(key) < 8 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8])))
+}
@@ -0,0 +1,164 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+)
+
+// Redelivery delay limits of the failed jobs
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+)
+
+// Handler executes the delivered job. The message is acknowledged when
+// the handler returns nil and returned to the queue otherwise.
+type Handler func(ctx context.Context, job *Job) error
+
+// Consume runs concurrency workers which handle the jobs of the labels
+// until the context is canceled or the queue is closed. The running jobs
+// are not canceled with the context, Consume returns once they finish.
+func Consume(ctx context.Context, queue Queue, labels []string, concurrency int, handler Handler) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			consumeLoop(ctx, queue, labels, handler)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func consumeLoop(ctx context.Context, queue Queue, labels []string, handler Handler) {
+	var (
+		log     = ctxlogger.Get(ctx)
+		workCtx = context.WithoutCancel(ctx)
+	)
+	for {
+		delivery, err := queue.Dequeue(ctx, labels)
+		switch {
+		case ctx.Err() != nil || errors.Is(err, ErrClosed):
+			return
+		case err != nil:
+			log.Error("dequeue job", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(minRetryDelay):
+			}
+			continue
+		}
+		handleDelivery(workCtx, delivery, handler)
+	}
+}
+
+// handleDelivery executes the job holding the message invisible for the
+// other workers and acknowledges it according to the result
+func handleDelivery(ctx context.Context, delivery Delivery, handler Handler) {
+	job := delivery.Job()
+	log := ctxlogger.Get(ctx).With(
+		zap.String("object_id", job.ObjectID),
+		zap.String("job_id", job.JobID),
+		zap.String("label", job.Label),
+		zap.Int("deliveries", job.Deliveries),
+	)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	extended := make(chan struct{})
+	go func() {
+		defer close(extended)
+		keepVisible(jobCtx, delivery, log)
+	}()
+	err := callHandler(jobCtx, handler, job)
+	cancel()
+	<-extended
+
+	var retryErr *ScheduledRetry
+	switch {
+	case err == nil:
+		err = delivery.Ack(ctx)
+	case errors.As(err, &retryErr):
+		log.Info("job delivery rescheduled", zap.Time("at", retryErr.At), zap.Error(retryErr.Err))
+		err = delivery.Reschedule(ctx, retryErr.Err, retryErr.At)
+	default:
+		log.Warn("job delivery failed", zap.Error(err))
+		err = delivery.Nack(ctx, err, RetryDelay(job.Deliveries))
+	}
+	if err != nil {
+		log.Error("job acknowledgement", zap.Error(err))
+	}
+}
+
+// keepVisible extends the delivery deadline until the context is done
+func keepVisible(ctx context.Context, delivery Delivery, log *zap.Logger) {
+	for {
+		timer := time.NewTimer(max(time.Until(delivery.Deadline())/2, 10*time.Millisecond))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := delivery.Extend(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Error("extend job delivery", zap.Error(err))
+			}
+			if errors.Is(err, ErrStaleDelivery) {
+				return
+			}
+		}
+	}
+}
+
+func callHandler(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("job handler panic: %v", rec)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// ScheduledRetry is returned by the handler to deliver the job again at
+// the time. Unlike the failure it doesn't count towards the delivery limit.
+type ScheduledRetry struct {
+	At  time.Time
+	Err error
+}
+
+// RetryAt returns the handler error which schedules the job delivery at the time
+func RetryAt(err error, at time.Time) error {
+	return &ScheduledRetry{At: at, Err: err}
+}
+
+func (e *ScheduledRetry) Error() string {
+	if e.Err == nil {
+		return "job is rescheduled"
+	}
+	return "job is rescheduled: " + e.Err.Error()
+}
+
+func (e *ScheduledRetry) Unwrap() error { return e.Err }
+
+// RetryDelay returns the redelivery delay of the failed message
+func RetryDelay(deliveries int) time.Duration {
+	if deliveries <= 1 {
+		return minRetryDelay
+	}
+	if deliveries > 7 {
+		return maxRetryDelay
+	}
+	return min(minRetryDelay<<(deliveries-1), maxRetryDelay)
+}
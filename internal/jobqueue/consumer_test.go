@@ -0,0 +1,77 @@
+package jobqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/jobqueue"
+	"github.com/apfs-io/apfs/internal/jobqueue/badger"
+)
+
+func TestLabels(t *testing.T) {
+	assert.Equal(t, jobqueue.AnyLabel, jobqueue.Label(""))
+	assert.Equal(t, "gpu", jobqueue.Label("label:gpu"))
+	assert.Equal(t, []string{jobqueue.AllLabels}, jobqueue.WorkerLabels(nil))
+	assert.Equal(t, []string{jobqueue.AllLabels}, jobqueue.WorkerLabels([]string{"gpu", "any"}))
+	assert.Equal(t, []string{jobqueue.AnyLabel, "gpu", "ffmpeg-6"},
+		jobqueue.WorkerLabels([]string{"gpu", "label:ffmpeg-6"}))
+	assert.True(t, jobqueue.MatchLabel([]string{jobqueue.AllLabels}, "gpu"))
+	assert.False(t, jobqueue.MatchLabel([]string{jobqueue.AnyLabel, "cpu"}, "gpu"))
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, jobqueue.RetryDelay(1))
+	assert.Equal(t, 4*time.Second, jobqueue.RetryDelay(3))
+	assert.Equal(t, time.Minute, jobqueue.RetryDelay(100))
+}
+
+func TestConsume(t *testing.T) {
+	queue, err := badger.NewInMemory(
+		jobqueue.WithPollInterval(10*time.Millisecond),
+		jobqueue.WithVisibilityTimeout(40*time.Millisecond),
+		jobqueue.WithMaxDeliveries(1))
+	require.NoError(t, err)
+	defer func() { _ = queue.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, queue.Enqueue(ctx,
+		jobqueue.NewJob("obj", "slow", ""),
+		jobqueue.NewJob("obj", "broken", ""),
+		jobqueue.NewJob("obj", "panic", "")))
+
+	var (
+		handled atomic.Int32
+		slow    atomic.Int32
+	)
+	go func() {
+		_ = jobqueue.Consume(ctx, queue, []string{jobqueue.AnyLabel}, 2, func(ctx context.Context, job *jobqueue.Job) error {
+			defer handled.Add(1)
+			switch job.JobID {
+			case "slow":
+				slow.Add(1)
+				// Longer than the visibility timeout, the delivery is extended
+				time.Sleep(150 * time.Millisecond)
+				return nil
+			case "broken":
+				return errors.New("broken job")
+			default:
+				panic("unexpected")
+			}
+		})
+	}()
+
+	require.Eventually(t, func() bool { return handled.Load() == 3 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), slow.Load(), "the extended job must not be redelivered")
+
+	require.Eventually(t, func() bool {
+		dead, err := queue.DeadLetters(ctx, jobqueue.AnyLabel)
+		return err == nil && len(dead) == 2
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,66 @@
+package jobqueue
+
+import "time"
+
+// Default queue options
+const (
+	DefaultVisibilityTimeout = 5 * time.Minute
+	DefaultMaxDeliveries     = 5
+	DefaultPollInterval      = time.Second
+)
+
+// Options of the queue driver
+type Options struct {
+	// VisibilityTimeout is the time the delivered message is hidden from
+	// the other workers without Extend
+	VisibilityTimeout time.Duration
+
+	// MaxDeliveries before the message is moved to the dead-letter list
+	MaxDeliveries int
+
+	// PollInterval of the delayed messages
+	PollInterval time.Duration
+}
+
+// Option of the queue driver
+type Option func(*Options)
+
+// NewOptions returns the options with defaults
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		VisibilityTimeout: DefaultVisibilityTimeout,
+		MaxDeliveries:     DefaultMaxDeliveries,
+		PollInterval:      DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithVisibilityTimeout of the delivered messages
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		if timeout > 0 {
+			opts.VisibilityTimeout = timeout
+		}
+	}
+}
+
+// WithMaxDeliveries before the message is dead-lettered
+func WithMaxDeliveries(n int) Option {
+	return func(opts *Options) {
+		if n > 0 {
+			opts.MaxDeliveries = n
+		}
+	}
+}
+
+// WithPollInterval of the delayed messages
+func WithPollInterval(interval time.Duration) Option {
+	return func(opts *Options) {
+		if interval > 0 {
+			opts.PollInterval = interval
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package jobqueue dispatches single workflow jobs to the workers.
+//
+// Every ready job of the object workflow is enqueued as its own message
+// routed by the job runs-on label, so the worker consumes only the jobs it's
+// able to run. Messages are acknowledged after the job is finished; a message
+// of a crashed worker becomes visible again after the visibility timeout and
+// the message which failed too many times is moved to the dead-letter list.
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUndefinedQueueScheme is returned for unsupported connection URLs
+	ErrUndefinedQueueScheme = errors.New("undefined job queue scheme")
+
+	// ErrClosed is returned by the closed queue
+	ErrClosed = errors.New("job queue is closed")
+
+	// ErrStaleDelivery is returned when the delivery was redelivered to
+	// another worker after the visibility timeout or already acknowledged
+	ErrStaleDelivery = errors.New("job delivery is not held anymore")
+)
+
+// Label values with the special meaning
+const (
+	// AnyLabel is the label of the jobs without runs-on affinity
+	AnyLabel = "any"
+
+	// AllLabels subscribes the worker to the jobs of every label
+	AllLabels = "*"
+)
+
+// Job message of the single workflow job execution
+type Job struct {
+	// ID of the message, at most one message with the same ID is queued
+	ID string `json:"id"`
+
+	ObjectID string `json:"object_id"`
+	JobID    string `json:"job_id"`
+
+	// Label is the normalized runs-on value of the job
+	Label string `json:"label"`
+
+	// Deliveries is the number of times the message was delivered
+	Deliveries int `json:"deliveries"`
+
+	EnqueuedAt time.Time `json:"enqueued_at"`
+
+	// TraceContext of the span which dispatched the job (W3C traceparent)
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+
+	// CorrelationID of the events which caused the job dispatch
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// Error of the last failed delivery
+	Error string `json:"error,omitempty"`
+}
+
+// NewJob returns the message of the object job
+func NewJob(objectID, jobID, runsOn string) *Job {
+	return &Job{
+		ID:       objectID + "/" + jobID,
+		ObjectID: objectID,
+		JobID:    jobID,
+		Label:    Label(runsOn),
+	}
+}
+
+// Delivery of the job message held by the worker until the visibility
+// deadline. The deadline is moved by Extend while the job is running.
+type Delivery interface {
+	// Job returns the delivered message
+	Job() *Job
+
+	// Deadline returns the time when the message becomes visible again
+	Deadline() time.Time
+
+	// Extend moves the visibility deadline by the queue visibility timeout
+	Extend(ctx context.Context) error
+
+	// Ack removes the message from the queue
+	Ack(ctx context.Context) error
+
+	// Nack returns the message to the queue after the delay or moves it
+	// to the dead-letter list when the delivery limit is reached
+	Nack(ctx context.Context, err error, delay time.Duration) error
+
+	// Reschedule returns the message to the queue visible at the time.
+	// The delivery is not counted towards the delivery limit.
+	Reschedule(ctx context.Context, err error, at time.Time) error
+}
+
+// Queue of the workflow jobs
+type Queue interface {
+	// Enqueue the jobs, a job with the ID already in the queue is ignored
+	Enqueue(ctx context.Context, jobs ...*Job) error
+
+	// Dequeue blocks until a job of one of the labels is available
+	Dequeue(ctx context.Context, labels []string) (Delivery, error)
+
+	// DeadLetters returns the dead jobs of the label
+	DeadLetters(ctx context.Context, label string) ([]*Job, error)
+
+	// Close the queue
+	Close() error
+}
+
+// Label returns the queue label of the job runs-on value
+func Label(runsOn string) string {
+	label := strings.TrimPrefix(strings.TrimSpace(runsOn), "label:")
+	if label == "" {
+		return AnyLabel
+	}
+	return label
+}
+
+// WorkerLabels returns the labels consumed by the worker with the tags.
+// The worker without tags or with the "any" tag consumes every label,
+// others consume the jobs of their tags and the jobs without affinity.
+func WorkerLabels(tags []string) []string {
+	if len(tags) == 0 {
+		return []string{AllLabels}
+	}
+	labels := []string{AnyLabel}
+	for _, tag := range tags {
+		label := Label(tag)
+		if label == AnyLabel {
+			return []string{AllLabels}
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+// MatchLabel returns true if the label is one of the consumed labels
+func MatchLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == AllLabels || l == label {
+			return true
+		}
+	}
+	return false
+}
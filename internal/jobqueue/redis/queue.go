@@ -0,0 +1,427 @@
+// Package redis implements jobqueue.Queue on top of redis, so the jobs are
+// dispatched to the workers of a multi-node cluster by their runs-on labels.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/apfs-io/apfs/internal/jobqueue"
+	kvredis "github.com/apfs-io/apfs/internal/storage/kvaccessor/redis"
+)
+
+// DefaultKeyPrefix of the queue records
+//
+//	<prefix>msg:<id>                   hash of the message: job, label, token, deliveries, error
+//	<prefix>ready:<label>              sorted set of the message IDs by the visibility time (ms)
+//	<prefix>dead:<label>               list of the dead messages
+//	<prefix>labels                     set of the queued labels
+//	<prefix>seq                        delivery token counter
+const DefaultKeyPrefix = "apfs:jobqueue:"
+
+// enqueueScript adds the message if the ID is not queued yet.
+// KEYS[1] - msg key, KEYS[2] - ready key, KEYS[3] - labels key
+// ARGV[1] - job JSON, ARGV[2] - label, ARGV[3] - ID, ARGV[4] - now ms
+var enqueueScript = goredis.NewScript(`
+if redis.call('exists', KEYS[1]) == 1 then
+  return 0
+end
+redis.call('hset', KEYS[1], 'job', ARGV[1], 'label', ARGV[2], 'token', 0, 'deliveries', 0, 'error', '')
+redis.call('zadd', KEYS[2], ARGV[4], ARGV[3])
+redis.call('sadd', KEYS[3], ARGV[2])
+return 1
+`)
+
+// claimScript delivers the earliest visible message of the ready sets and
+// hides it until the visibility deadline. Returns {id, token, job,
+// deliveries, error}, the empty id if the found message was orphaned, or
+// nil if no message is visible.
+// KEYS[1] - seq key, KEYS[2..] - ready keys
+// ARGV[1] - now ms, ARGV[2] - deadline ms, ARGV[3] - msg key prefix
+var claimScript = goredis.NewScript(`
+local id, readyKey, score
+for i = 2, #KEYS do
+  local r = redis.call('zrangebyscore', KEYS[i], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, 1)
+  if #r > 0 and (score == nil or tonumber(r[2]) < score) then
+    id, readyKey, score = r[1], KEYS[i], tonumber(r[2])
+  end
+end
+if id == nil then
+  return false
+end
+local msgKey = ARGV[3] .. id
+if redis.call('exists', msgKey) == 0 then
+  redis.call('zrem', readyKey, id)
+  return {'', 0, '', 0, ''}
+end
+local token = redis.call('incr', KEYS[1])
+local deliveries = redis.call('hincrby', msgKey, 'deliveries', 1)
+redis.call('hset', msgKey, 'token', token)
+redis.call('zadd', readyKey, ARGV[2], id)
+local rec = redis.call('hmget', msgKey, 'job', 'error')
+return {id, token, rec[1], deliveries, rec[2]}
+`)
+
+// extendScript moves the visibility deadline of the held message.
+// KEYS[1] - msg key, KEYS[2] - ready key, KEYS[3] - seq key
+// ARGV[1] - token, ARGV[2] - ID, ARGV[3] - deadline ms
+var extendScript = goredis.NewScript(`
+if redis.call('hget', KEYS[1], 'token') ~= ARGV[1] then
+  return 0
+end
+local token = redis.call('incr', KEYS[3])
+redis.call('hset', KEYS[1], 'token', token)
+redis.call('zadd', KEYS[2], ARGV[3], ARGV[2])
+return token
+`)
+
+// ackScript removes the held message.
+// KEYS[1] - msg key, KEYS[2] - ready key
+// ARGV[1] - token, ARGV[2] - ID
+var ackScript = goredis.NewScript(`
+if redis.call('hget', KEYS[1], 'token') ~= ARGV[1] then
+  return 0
+end
+redis.call('del', KEYS[1])
+redis.call('zrem', KEYS[2], ARGV[2])
+return 1
+`)
+
+// requeueScript returns the held message to the queue visible at the time
+// or moves it to the dead-letter list once the deliveries reach the max.
+// Returns 0 for the stale delivery, -1 for the dead message.
+// KEYS[1] - msg key, KEYS[2] - ready key, KEYS[3] - seq key, KEYS[4] - dead key
+// ARGV[1] - token, ARGV[2] - ID, ARGV[3] - visible at ms, ARGV[4] - error,
+// ARGV[5] - deliveries delta, ARGV[6] - max deliveries (0 never dead-letters)
+var requeueScript = goredis.NewScript(`
+if redis.call('hget', KEYS[1], 'token') ~= ARGV[1] then
+  return 0
+end
+if ARGV[4] ~= '' then
+  redis.call('hset', KEYS[1], 'error', ARGV[4])
+end
+local deliveries = tonumber(redis.call('hget', KEYS[1], 'deliveries')) + tonumber(ARGV[5])
+if deliveries < 0 then
+  deliveries = 0
+end
+redis.call('hset', KEYS[1], 'deliveries', deliveries)
+local max = tonumber(ARGV[6])
+if max > 0 and deliveries >= max then
+  local rec = redis.call('hmget', KEYS[1], 'job', 'error')
+  redis.call('rpush', KEYS[4], cjson.encode({job = rec[1], deliveries = deliveries, error = rec[2]}))
+  redis.call('del', KEYS[1])
+  redis.call('zrem', KEYS[2], ARGV[2])
+  return -1
+end
+local token = redis.call('incr', KEYS[3])
+redis.call('hset', KEYS[1], 'token', token)
+redis.call('zadd', KEYS[2], ARGV[3], ARGV[2])
+return token
+`)
+
+// releasedToken of the acknowledged or requeued delivery never matches the
+// token of the message (the new message has token 0)
+const releasedToken = -1
+
+// deadRecord of the dead-letter list
+type deadRecord struct {
+	Job        string `json:"job"`
+	Deliveries int    `json:"deliveries"`
+	Error      string `json:"error"`
+}
+
+// Queue is the redis implementation of jobqueue.Queue
+type Queue struct {
+	client *goredis.Client
+	prefix string
+	opts   jobqueue.Options
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// New connects to the redis server
+// connection: redis://localhost:6379/0?prefix=apfs:jobqueue:
+func New(connection string, opts ...jobqueue.Option) (*Queue, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kvredis.NewClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClient(client, u.Query().Get(`prefix`), opts...), nil
+}
+
+// NewWithClient returns the queue over the redis client, the client is
+// closed with the queue
+func NewWithClient(client *goredis.Client, prefix string, opts ...jobqueue.Option) *Queue {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return &Queue{
+		client: client,
+		prefix: prefix,
+		opts:   jobqueue.NewOptions(opts...),
+		closed: make(chan struct{}),
+	}
+}
+
+// Enqueue the jobs, a job with the ID already in the queue is ignored
+func (q *Queue) Enqueue(ctx context.Context, jobs ...*jobqueue.Job) error {
+	if q.isClosed() {
+		return jobqueue.ErrClosed
+	}
+	now := time.Now()
+	for _, job := range jobs {
+		msg := *job
+		msg.Label = jobqueue.Label(msg.Label)
+		msg.EnqueuedAt = now
+		data, err := json.Marshal(&msg)
+		if err != nil {
+			return err
+		}
+		err = enqueueScript.Run(ctx, q.client,
+			[]string{q.msgKey(msg.ID), q.readyKey(msg.Label), q.prefix + "labels"},
+			data, msg.Label, msg.ID, now.UnixMilli()).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dequeue blocks until a job of one of the labels is available. The queue
+// is polled every poll interval.
+func (q *Queue) Dequeue(ctx context.Context, labels []string) (jobqueue.Delivery, error) {
+	for {
+		if q.isClosed() {
+			return nil, jobqueue.ErrClosed
+		}
+		delivery, err := q.claim(ctx, labels)
+		if err != nil {
+			// The client is closed by the queue close during the claim
+			if q.isClosed() {
+				return nil, jobqueue.ErrClosed
+			}
+			return nil, err
+		}
+		if delivery != nil {
+			return delivery, nil
+		}
+		timer := time.NewTimer(q.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-q.closed:
+			timer.Stop()
+			return nil, jobqueue.ErrClosed
+		case <-timer.C:
+		}
+	}
+}
+
+// DeadLetters returns the dead jobs of the label
+func (q *Queue) DeadLetters(ctx context.Context, label string) ([]*jobqueue.Job, error) {
+	items, err := q.client.LRange(ctx, q.deadKey(jobqueue.Label(label)), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*jobqueue.Job, 0, len(items))
+	for _, item := range items {
+		var rec deadRecord
+		if err = json.Unmarshal([]byte(item), &rec); err != nil {
+			return nil, err
+		}
+		job, err := decodeJob(rec.Job, rec.Deliveries, rec.Error)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Close the queue and its redis client
+func (q *Queue) Close() (err error) {
+	q.closeOnce.Do(func() {
+		close(q.closed)
+		err = q.client.Close()
+	})
+	return err
+}
+
+// claim delivers the first visible message of the labels
+func (q *Queue) claim(ctx context.Context, labels []string) (*delivery, error) {
+	keys, err := q.readyKeys(ctx, labels)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	for {
+		now := time.Now()
+		deadline := now.Add(q.opts.VisibilityTimeout)
+		res, err := claimScript.Run(ctx, q.client, append([]string{q.prefix + "seq"}, keys...),
+			now.UnixMilli(), deadline.UnixMilli(), q.prefix+"msg:").Slice()
+		if errors.Is(err, goredis.Nil) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(res) != 5 {
+			return nil, jobqueue.ErrStaleDelivery
+		}
+		id, _ := res[0].(string)
+		if id == "" {
+			// The orphaned index is removed, check the next one
+			continue
+		}
+		data, _ := res[2].(string)
+		deliveries, _ := res[3].(int64)
+		errStr, _ := res[4].(string)
+		job, err := decodeJob(data, int(deliveries), errStr)
+		if err != nil {
+			return nil, err
+		}
+		token, _ := res[1].(int64)
+		return &delivery{queue: q, job: job, token: token, deadline: deadline}, nil
+	}
+}
+
+// readyKeys returns the ready sets of the labels, all known labels for AllLabels
+func (q *Queue) readyKeys(ctx context.Context, labels []string) ([]string, error) {
+	for _, label := range labels {
+		if label == jobqueue.AllLabels {
+			all, err := q.client.SMembers(ctx, q.prefix+"labels").Result()
+			if err != nil {
+				return nil, err
+			}
+			labels = all
+			break
+		}
+	}
+	keys := make([]string, 0, len(labels))
+	for _, label := range labels {
+		keys = append(keys, q.readyKey(label))
+	}
+	return keys, nil
+}
+
+func (q *Queue) msgKey(id string) string      { return q.prefix + "msg:" + id }
+func (q *Queue) readyKey(label string) string { return q.prefix + "ready:" + label }
+func (q *Queue) deadKey(label string) string  { return q.prefix + "dead:" + label }
+
+func (q *Queue) isClosed() bool {
+	select {
+	case <-q.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// delivery of the message held by the worker
+type delivery struct {
+	mx       sync.Mutex
+	queue    *Queue
+	job      *jobqueue.Job
+	token    int64
+	deadline time.Time
+}
+
+func (d *delivery) Job() *jobqueue.Job { return d.job }
+
+func (d *delivery) Deadline() time.Time {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.deadline
+}
+
+// Extend moves the visibility deadline by the queue visibility timeout
+func (d *delivery) Extend(ctx context.Context) error {
+	q := d.queue
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	deadline := time.Now().Add(q.opts.VisibilityTimeout)
+	token, err := extendScript.Run(ctx, q.client,
+		[]string{q.msgKey(d.job.ID), q.readyKey(d.job.Label), q.prefix + "seq"},
+		d.token, d.job.ID, deadline.UnixMilli()).Int64()
+	if err != nil {
+		return err
+	}
+	if token == 0 {
+		return jobqueue.ErrStaleDelivery
+	}
+	d.token, d.deadline = token, deadline
+	return nil
+}
+
+// Ack removes the message from the queue
+func (d *delivery) Ack(ctx context.Context) error {
+	q := d.queue
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	res, err := ackScript.Run(ctx, q.client,
+		[]string{q.msgKey(d.job.ID), q.readyKey(d.job.Label)}, d.token, d.job.ID).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return jobqueue.ErrStaleDelivery
+	}
+	d.token = releasedToken
+	return nil
+}
+
+// Nack returns the message to the queue or moves it to the dead-letter list
+func (d *delivery) Nack(ctx context.Context, cause error, delay time.Duration) error {
+	return d.requeue(ctx, cause, time.Now().Add(delay), 0, d.queue.opts.MaxDeliveries)
+}
+
+// Reschedule returns the message to the queue visible at the time
+func (d *delivery) Reschedule(ctx context.Context, cause error, at time.Time) error {
+	return d.requeue(ctx, cause, at, -1, 0)
+}
+
+func (d *delivery) requeue(ctx context.Context, cause error, at time.Time, delta, maxDeliveries int) error {
+	q := d.queue
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	var errStr string
+	if cause != nil {
+		errStr = cause.Error()
+	}
+	res, err := requeueScript.Run(ctx, q.client,
+		[]string{q.msgKey(d.job.ID), q.readyKey(d.job.Label), q.prefix + "seq", q.deadKey(d.job.Label)},
+		d.token, d.job.ID, at.UnixMilli(), errStr, delta, maxDeliveries).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return jobqueue.ErrStaleDelivery
+	}
+	// The delivery doesn't hold the message anymore
+	d.token = releasedToken
+	return nil
+}
+
+// decodeJob returns the job of the message record
+func decodeJob(data string, deliveries int, errStr string) (*jobqueue.Job, error) {
+	var job jobqueue.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, err
+	}
+	job.Deliveries = deliveries
+	job.Error = errStr
+	return &job, nil
+}
+
+var _ jobqueue.Queue = (*Queue)(nil)
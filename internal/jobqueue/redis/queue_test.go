@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/jobqueue"
+)
+
+func newTestQueue(t *testing.T, opts ...jobqueue.Option) *Queue {
+	t.Helper()
+	return newServerQueue(t, miniredis.RunT(t), opts...)
+}
+
+// newServerQueue connects the queue to the test redis server
+func newServerQueue(t *testing.T, server *miniredis.Miniredis, opts ...jobqueue.Option) *Queue {
+	t.Helper()
+	queue := NewWithClient(goredis.NewClient(&goredis.Options{Addr: server.Addr()}), "",
+		append([]jobqueue.Option{jobqueue.WithPollInterval(10 * time.Millisecond)}, opts...)...)
+	t.Cleanup(func() { _ = queue.Close() })
+	return queue
+}
+
+func dequeue(t *testing.T, queue *Queue, labels ...string) jobqueue.Delivery {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	delivery, err := queue.Dequeue(ctx, labels)
+	require.NoError(t, err)
+	return delivery
+}
+
+func TestQueueRouting(t *testing.T) {
+	ctx := context.TODO()
+	queue := newTestQueue(t)
+	require.NoError(t, queue.Enqueue(ctx,
+		jobqueue.NewJob("obj", "thumb", ""),
+		jobqueue.NewJob("obj", "encode", "label:gpu"),
+		jobqueue.NewJob("obj", "thumb", "")))
+
+	// The gpu worker gets the gpu job even behind the job without affinity
+	delivery := dequeue(t, queue, "gpu")
+	assert.Equal(t, "encode", delivery.Job().JobID)
+	assert.Equal(t, "gpu", delivery.Job().Label)
+	assert.Equal(t, 1, delivery.Job().Deliveries)
+	require.NoError(t, delivery.Ack(ctx))
+
+	delivery = dequeue(t, queue, jobqueue.WorkerLabels([]string{"cpu"})...)
+	assert.Equal(t, "thumb", delivery.Job().JobID)
+	require.NoError(t, delivery.Ack(ctx))
+
+	// The duplicate was ignored
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := queue.Dequeue(waitCtx, []string{jobqueue.AllLabels})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestQueueVisibilityTimeout(t *testing.T) {
+	ctx := context.TODO()
+	queue := newTestQueue(t, jobqueue.WithVisibilityTimeout(30*time.Millisecond))
+	require.NoError(t, queue.Enqueue(ctx, jobqueue.NewJob("obj", "job", "")))
+
+	crashed := dequeue(t, queue, jobqueue.AllLabels)
+
+	// The message of the crashed worker is delivered again
+	delivery := dequeue(t, queue, jobqueue.AllLabels)
+	assert.Equal(t, 2, delivery.Job().Deliveries)
+	assert.ErrorIs(t, crashed.Ack(ctx), jobqueue.ErrStaleDelivery)
+
+	deadline := delivery.Deadline()
+	require.NoError(t, delivery.Extend(ctx))
+	assert.True(t, delivery.Deadline().After(deadline))
+	require.NoError(t, delivery.Ack(ctx))
+	assert.ErrorIs(t, delivery.Ack(ctx), jobqueue.ErrStaleDelivery)
+}
+
+func TestQueueDeadLetter(t *testing.T) {
+	ctx := context.TODO()
+	queue := newTestQueue(t, jobqueue.WithMaxDeliveries(2))
+	require.NoError(t, queue.Enqueue(ctx, jobqueue.NewJob("obj", "job", "cpu")))
+
+	delivery := dequeue(t, queue, "cpu")
+	require.NoError(t, delivery.Nack(ctx, errors.New("first"), 0))
+	delivery = dequeue(t, queue, "cpu")
+	require.NoError(t, delivery.Nack(ctx, errors.New("second"), 0))
+
+	dead, err := queue.DeadLetters(ctx, "cpu")
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "job", dead[0].JobID)
+	assert.Equal(t, "second", dead[0].Error)
+	assert.Equal(t, 2, dead[0].Deliveries)
+
+	// The dead job can be dispatched again
+	require.NoError(t, queue.Enqueue(ctx, jobqueue.NewJob("obj", "job", "cpu")))
+	assert.Equal(t, 1, dequeue(t, queue, "cpu").Job().Deliveries)
+}
+
+func TestQueueNackDelay(t *testing.T) {
+	ctx := context.TODO()
+	queue := newTestQueue(t)
+	require.NoError(t, queue.Enqueue(ctx, jobqueue.NewJob("obj", "job", "")))
+
+	start := time.Now()
+	require.NoError(t, dequeue(t, queue, jobqueue.AnyLabel).Nack(ctx, errors.New("fail"), 50*time.Millisecond))
+	delivery := dequeue(t, queue, jobqueue.AnyLabel)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, "fail", delivery.Job().Error)
+}
+
+func TestQueueClose(t *testing.T) {
+	queue := newTestQueue(t)
+	done := make(chan error, 1)
+	go func() {
+		_, err := queue.Dequeue(context.TODO(), []string{jobqueue.AllLabels})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, queue.Close())
+	assert.ErrorIs(t, <-done, jobqueue.ErrClosed)
+}
+
+func TestQueueReschedule(t *testing.T) {
+	ctx := context.TODO()
+	queue := newTestQueue(t, jobqueue.WithMaxDeliveries(1))
+	require.NoError(t, queue.Enqueue(ctx, jobqueue.NewJob("obj", "thumb", "")))
+
+	delivery := dequeue(t, queue, jobqueue.AllLabels)
+	require.NoError(t, delivery.Reschedule(ctx, errors.New("exit status 75"), time.Now().Add(80*time.Millisecond)))
+
+	// The job is hidden until the time
+	waitCtx, cancel := context.WithTimeout(ctx, 40*time.Millisecond)
+	defer cancel()
+	_, err := queue.Dequeue(waitCtx, []string{jobqueue.AllLabels})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The rescheduled delivery is not counted, the job is not dead-lettered
+	delivery = dequeue(t, queue, jobqueue.AllLabels)
+	assert.Equal(t, "thumb", delivery.Job().JobID)
+	assert.Equal(t, 1, delivery.Job().Deliveries)
+	assert.Equal(t, "exit status 75", delivery.Job().Error)
+	require.NoError(t, delivery.Ack(ctx))
+}
+
+func TestQueueSharedByWorkers(t *testing.T) {
+	var (
+		ctx    = context.TODO()
+		server = miniredis.RunT(t)
+		first  = newServerQueue(t, server, jobqueue.WithVisibilityTimeout(30*time.Millisecond))
+		second = newServerQueue(t, server)
+	)
+	require.NoError(t, first.Enqueue(ctx, jobqueue.NewJob("obj", "job", "")))
+	_ = dequeue(t, first, jobqueue.AllLabels)
+
+	// The job of the crashed worker is delivered to the other node
+	delivery := dequeue(t, second, jobqueue.AllLabels)
+	assert.Equal(t, "obj/job", delivery.Job().ID)
+	assert.Equal(t, 2, delivery.Job().Deliveries)
+	require.NoError(t, delivery.Ack(ctx))
+	assert.False(t, server.Exists(first.msgKey("obj/job")))
+	assert.False(t, server.Exists(first.readyKey(jobqueue.Label(""))))
+}
+
+func TestQueueOrphanedIndex(t *testing.T) {
+	var (
+		ctx    = context.TODO()
+		server = miniredis.RunT(t)
+		queue  = newServerQueue(t, server)
+	)
+	require.NoError(t, queue.Enqueue(ctx,
+		jobqueue.NewJob("obj", "a-lost", ""),
+		jobqueue.NewJob("obj", "job", "")))
+	server.Del(queue.msgKey("obj/a-lost"))
+
+	// The index without the message is ordered first, skipped and removed
+	assert.Equal(t, "job", dequeue(t, queue, jobqueue.AllLabels).Job().JobID)
+	members, err := server.ZMembers(queue.readyKey(jobqueue.Label("")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"obj/job"}, members)
+}
+
+func TestNew(t *testing.T) {
+	server := miniredis.RunT(t)
+	queue, err := New("redis://" + server.Addr() + "/0?prefix=test:")
+	require.NoError(t, err)
+	defer func() { _ = queue.Close() }()
+	require.NoError(t, queue.Enqueue(context.TODO(), jobqueue.NewJob("obj", "job", "")))
+	assert.True(t, server.Exists("test:msg:obj/job"))
+}
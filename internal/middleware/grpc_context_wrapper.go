@@ -22,7 +22,7 @@ func init() {
 		Name: "grpc_request_count",
 		Help: "Count of requests by method",
 	}, []string{"method", "grpc_error"})
-	metricGRPCTiming = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	metricGRPCTiming = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "grpc",
 		Name:      "grpc_request_duration_seconds",
 		Help:      "Histogram of response time for handler in seconds",
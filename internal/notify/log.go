@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLogSize is the number of the deliveries kept by the MemoryLog
+const DefaultLogSize = 1000
+
+// Delivery is the record of one webhook request attempt
+type Delivery struct {
+	ID       string `json:"id"`
+	Event    string `json:"event"`
+	ObjectID string `json:"object_id"`
+	JobID    string `json:"job_id,omitempty"`
+	URL      string `json:"url"`
+
+	// Attempt number starting from 1
+	Attempt    int           `json:"attempt"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Delivered  bool          `json:"delivered"`
+	Duration   time.Duration `json:"duration"`
+	At         time.Time     `json:"at"`
+}
+
+// DeliveryLog keeps the records of the webhook deliveries
+type DeliveryLog interface {
+	// Add the record of the delivery attempt
+	Add(ctx context.Context, d *Delivery) error
+
+	// List returns the last records of the object (all objects if empty),
+	// the newest first
+	List(ctx context.Context, objectID string, limit int) ([]*Delivery, error)
+}
+
+// MemoryLog is the DeliveryLog of the last deliveries kept in memory
+type MemoryLog struct {
+	mx    sync.RWMutex
+	items []*Delivery
+	next  int
+	full  bool
+}
+
+// NewMemoryLog returns the log of the last size deliveries
+func NewMemoryLog(size int) *MemoryLog {
+	if size <= 0 {
+		size = DefaultLogSize
+	}
+	return &MemoryLog{items: make([]*Delivery, size)}
+}
+
+// Add implements DeliveryLog
+func (l *MemoryLog) Add(_ context.Context, d *Delivery) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.items[l.next] = d
+	l.next = (l.next + 1) % len(l.items)
+	l.full = l.full || l.next == 0
+	return nil
+}
+
+// List implements DeliveryLog
+func (l *MemoryLog) List(_ context.Context, objectID string, limit int) ([]*Delivery, error) {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+	count := l.next
+	if l.full {
+		count = len(l.items)
+	}
+	var list []*Delivery
+	for i := 1; i <= count && (limit <= 0 || len(list) < limit); i++ {
+		d := l.items[(l.next-i+len(l.items))%len(l.items)]
+		if objectID == "" || d.ObjectID == objectID {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+// LogHandler serves the records of the log as JSON,
+// the query parameters: object (object ID) and limit (100 by default)
+func LogHandler(log DeliveryLog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = 100
+		}
+		list, err := log.List(r.Context(), r.URL.Query().Get("object"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if list == nil {
+			list = []*Delivery{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(list)
+	})
+}
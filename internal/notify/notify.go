@@ -0,0 +1,263 @@
+// Package notify delivers the processing lifecycle events to the HTTP
+// webhooks of the workflow notify block. The payloads are signed with
+// HMAC-SHA256, the failed deliveries are retried with exponential backoff
+// and every attempt is recorded in the delivery log. The pending deliveries
+// are kept in the pending store to be resumed after the restart.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/models"
+)
+
+// Default backoff of the delivery retries
+const (
+	DefaultBackoffInitial = time.Second
+	DefaultBackoffMax     = 5 * time.Minute
+)
+
+// userAgent of the webhook requests
+const userAgent = "apfs-webhook/1"
+
+// Option of the Notifier
+type Option func(*Notifier)
+
+// WithHTTPClient sets the client of the webhook requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(n *Notifier) {
+		n.client = client
+	}
+}
+
+// WithDeliveryLog sets the log of the delivery attempts
+func WithDeliveryLog(log DeliveryLog) Option {
+	return func(n *Notifier) {
+		n.log = log
+	}
+}
+
+// WithPendingStore keeps the pending deliveries in the store, so the
+// deliveries of the stopped process are resumed by Resume of another one
+func WithPendingStore(store kvaccessor.Hash) Option {
+	return func(n *Notifier) {
+		n.pending = store
+	}
+}
+
+// WithPendingLease sets the time after the planned attempt when the pending
+// delivery of the stopped process is resumed
+func WithPendingLease(lease time.Duration) Option {
+	return func(n *Notifier) {
+		n.pendingLease = lease
+	}
+}
+
+// WithBackoff sets the delay of the first retry and the delay cap,
+// the delay is doubled after every failed attempt
+func WithBackoff(initial, max time.Duration) Option {
+	return func(n *Notifier) {
+		n.backoffInitial = initial
+		n.backoffMax = max
+	}
+}
+
+// Notifier sends the payloads to the webhooks
+type Notifier struct {
+	client         *http.Client
+	log            DeliveryLog
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+	pending        kvaccessor.Hash
+	pendingLease   time.Duration
+	owner          string
+	wg             sync.WaitGroup
+}
+
+// NewNotifier returns the notifier with the memory delivery log by default
+func NewNotifier(opts ...Option) *Notifier {
+	n := &Notifier{owner: newDeliveryID()}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if n.client == nil {
+		n.client = &http.Client{}
+	}
+	if n.log == nil {
+		n.log = NewMemoryLog(DefaultLogSize)
+	}
+	if n.backoffInitial <= 0 {
+		n.backoffInitial = DefaultBackoffInitial
+	}
+	if n.backoffMax <= 0 {
+		n.backoffMax = DefaultBackoffMax
+	}
+	if n.pendingLease <= 0 {
+		n.pendingLease = DefaultPendingLease
+	}
+	return n
+}
+
+// Log returns the delivery log
+func (n *Notifier) Log() DeliveryLog {
+	return n.log
+}
+
+// Notify sends the payload to the webhooks of the notify block subscribed
+// to its event in background. The deliveries are not canceled with the ctx.
+// With the pending store the deliveries are kept there until finished.
+func (n *Notifier) Notify(ctx context.Context, notify *models.WorkflowNotify, payload *Payload) {
+	if n == nil || payload == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for i, hook := range notify.Hooks(payload.Event) {
+		p := &pending{ID: payload.ID + "/" + strconv.Itoa(i), Hook: hook, Payload: payload, Attempt: 1}
+		n.save(ctx, p, time.Now())
+		n.start(ctx, p)
+	}
+}
+
+// Wait for the background deliveries
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// Deliver sends the payload to the webhook, the failed request is retried
+// up to the webhook max retries
+func (n *Notifier) Deliver(ctx context.Context, hook *models.WorkflowWebhook, payload *Payload) error {
+	return n.deliver(ctx, &pending{Hook: hook, Payload: payload, Attempt: 1})
+}
+
+// start the delivery in background
+func (n *Notifier) start(ctx context.Context, p *pending) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := n.deliver(ctx, p); err != nil {
+			ctxlogger.Get(ctx).Error("webhook delivery failed",
+				zap.String("url", p.Hook.URL),
+				zap.String("event", p.Payload.Event),
+				zap.String("object_id", p.Payload.ObjectID),
+				zap.String("delivery", p.Payload.ID),
+				zap.Error(err))
+		}
+	}()
+}
+
+// deliver makes the attempts of the delivery starting from its next attempt.
+// The delivery interrupted by the ctx is kept in the pending store.
+func (n *Notifier) deliver(ctx context.Context, p *pending) error {
+	body, err := json.Marshal(p.Payload)
+	if err != nil {
+		n.finish(ctx, p)
+		return errors.Wrap(err, "webhook payload")
+	}
+	if err = sleep(ctx, time.Until(p.NextAt)); err != nil {
+		return err
+	}
+	for {
+		retryAfter, err := n.send(ctx, p.Hook, p.Payload, body, p.Attempt)
+		if err == nil || retryAfter < 0 || p.Attempt > p.Hook.Retries() {
+			n.finish(ctx, p)
+			return err
+		}
+		delay := min(n.backoffInitial<<(p.Attempt-1), n.backoffMax)
+		if p.Attempt > 32 || delay <= 0 {
+			delay = n.backoffMax
+		}
+		delay = max(delay, min(retryAfter, n.backoffMax))
+		p.Attempt++
+		n.save(ctx, p, time.Now().Add(delay))
+		if sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// sleep for the delay, returns the error of the context if it's done before
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// send makes one delivery attempt and records it in the log. The returned
+// retryAfter is negative if the request must not be retried, the delay
+// requested by the endpoint otherwise.
+func (n *Notifier) send(ctx context.Context, hook *models.WorkflowWebhook, payload *Payload, body []byte, attempt int) (retryAfter time.Duration, err error) {
+	record := &Delivery{
+		ID:       payload.ID,
+		Event:    payload.Event,
+		ObjectID: payload.ObjectID,
+		JobID:    payload.JobID,
+		URL:      hook.URL,
+		Attempt:  attempt,
+		At:       time.Now(),
+	}
+	defer func() {
+		record.Duration = time.Since(record.At)
+		record.Delivered = err == nil
+		if err != nil {
+			record.Error = err.Error()
+		}
+		_ = n.log.Add(ctx, record)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, hook.RequestTimeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, errors.Wrap(err, "webhook request")
+	}
+	for name, value := range hook.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, payload.Event)
+	req.Header.Set(HeaderDelivery, payload.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(record.At.Unix(), 10))
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(hook.Secret, record.At, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	record.StatusCode = resp.StatusCode
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		if sec, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && sec > 0 {
+			retryAfter = time.Duration(sec) * time.Second
+		}
+		return retryAfter, errors.Errorf("webhook response status %d", resp.StatusCode)
+	}
+	return -1, errors.Errorf("webhook response status %d", resp.StatusCode)
+}
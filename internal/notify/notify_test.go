@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	"github.com/apfs-io/apfs/models"
+)
+
+// receiver is the webhook endpoint responding with the statuses in order
+type receiver struct {
+	mx       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newNotifier(log DeliveryLog) *Notifier {
+	return NewNotifier(WithDeliveryLog(log), WithBackoff(time.Millisecond, 5*time.Millisecond))
+}
+
+func TestNotifier_SignedDelivery(t *testing.T) {
+	var (
+		rcv    = &receiver{}
+		srv    = httptest.NewServer(rcv)
+		log    = NewMemoryLog(10)
+		state  = models.NewProcessingState("images/a.jpg", "2", []string{"thumb"})
+		notify = &models.WorkflowNotify{Webhooks: []*models.WorkflowWebhook{
+			{URL: srv.URL, Secret: "s3cr3t", Events: []string{"processed"}, Headers: map[string]string{"X-Tenant": "acme"}},
+			{URL: srv.URL + "/failed", Events: []string{"failed"}},
+		}}
+	)
+	defer srv.Close()
+
+	payload := EventPayload(&models.Event{Type: models.ProcessedEventType,
+		Object: &models.Object{ID: "images/a.jpg"}}, state)
+	require.NotNil(t, payload)
+	notifier := newNotifier(log)
+	notifier.Notify(context.Background(), notify, payload)
+	notifier.Wait()
+
+	require.Len(t, rcv.requests, 1)
+	req, body := rcv.requests[0], rcv.bodies[0]
+	assert.Equal(t, "/", req.URL.Path)
+	assert.Equal(t, "acme", req.Header.Get("X-Tenant"))
+	assert.Equal(t, models.NotifyProcessed, req.Header.Get(HeaderEvent))
+	assert.Equal(t, payload.ID, req.Header.Get(HeaderDelivery))
+	assert.True(t, Verify("s3cr3t", req.Header.Get(HeaderSignature), req.Header.Get(HeaderTimestamp), body, time.Minute))
+	assert.False(t, Verify("other", req.Header.Get(HeaderSignature), req.Header.Get(HeaderTimestamp), body, time.Minute))
+
+	var got Payload
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, models.NotifyProcessed, got.Event)
+	assert.Equal(t, "images/a.jpg", got.ObjectID)
+	require.NotNil(t, got.State)
+	assert.Contains(t, got.State.Jobs, "thumb")
+
+	list, err := log.List(context.Background(), "images/a.jpg", 0)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.True(t, list[0].Delivered)
+	assert.Equal(t, http.StatusNoContent, list[0].StatusCode)
+}
+
+func TestNotifier_RetryWithBackoff(t *testing.T) {
+	var (
+		rcv  = &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}}
+		srv  = httptest.NewServer(rcv)
+		log  = NewMemoryLog(10)
+		hook = &models.WorkflowWebhook{URL: srv.URL}
+	)
+	defer srv.Close()
+
+	payload := JobPayload("images/a.jpg", "thumb", &models.ProcessingState{
+		Jobs: map[string]*models.JobState{"thumb": {Status: models.JobStatusCompleted}},
+	})
+	require.NotNil(t, payload)
+	require.NoError(t, newNotifier(log).Deliver(context.Background(), hook, payload))
+
+	list, _ := log.List(context.Background(), "", 0)
+	require.Len(t, list, 3)
+	assert.Equal(t, []int{3, 2, 1}, []int{list[0].Attempt, list[1].Attempt, list[2].Attempt})
+	assert.True(t, list[0].Delivered)
+	assert.Equal(t, http.StatusTooManyRequests, list[1].StatusCode)
+	assert.Contains(t, list[2].Error, "503")
+	for _, d := range list {
+		assert.Equal(t, payload.ID, d.ID, "retries keep the delivery ID")
+		assert.Equal(t, "thumb", d.JobID)
+	}
+}
+
+func TestNotifier_RetriesExhausted(t *testing.T) {
+	var (
+		rcv = &receiver{statuses: []int{500, 500, 500, 500}}
+		srv = httptest.NewServer(rcv)
+	)
+	defer srv.Close()
+
+	payload := &Payload{ID: "d1", Event: models.NotifyDeleted, ObjectID: "images/a.jpg"}
+	err := newNotifier(NewMemoryLog(10)).Deliver(context.Background(),
+		&models.WorkflowWebhook{URL: srv.URL, MaxRetries: 2}, payload)
+	assert.Error(t, err)
+	assert.Len(t, rcv.requests, 3)
+
+	// The client errors are not retried
+	rcv.statuses = []int{http.StatusBadRequest}
+	rcv.requests = nil
+	err = newNotifier(NewMemoryLog(10)).Deliver(context.Background(), &models.WorkflowWebhook{URL: srv.URL}, payload)
+	assert.Error(t, err)
+	assert.Len(t, rcv.requests, 1)
+}
+
+func TestEventPayload(t *testing.T) {
+	obj := &models.Object{ID: "images/a.jpg"}
+	assert.Equal(t, models.NotifyFailed,
+		EventPayload(&models.Event{Type: models.ProcessedEventType, Object: obj, Error: "boom"}, nil).Event)
+	assert.Equal(t, models.NotifyDeleted,
+		EventPayload(&models.Event{Type: models.DeleteEventType, Object: obj}, nil).Event)
+	assert.Nil(t, EventPayload(&models.Event{Type: models.UpdateEventType, Object: obj}, nil))
+
+	state := &models.ProcessingState{Jobs: map[string]*models.JobState{
+		"failed":  {Status: models.JobStatusFailed, Error: "boom"},
+		"running": {Status: models.JobStatusRunning},
+	}}
+	assert.Equal(t, models.NotifyJobFailed, JobPayload("images/a.jpg", "failed", state).Event)
+	assert.Nil(t, JobPayload("images/a.jpg", "running", state))
+	assert.Nil(t, JobPayload("images/a.jpg", "unknown", state))
+}
+
+func TestMemoryLog_Ring(t *testing.T) {
+	log := NewMemoryLog(3)
+	for i := 1; i <= 5; i++ {
+		_ = log.Add(context.Background(), &Delivery{Attempt: i, ObjectID: "obj"})
+	}
+	list, _ := log.List(context.Background(), "obj", 2)
+	require.Len(t, list, 2)
+	assert.Equal(t, 5, list[0].Attempt)
+	assert.Equal(t, 4, list[1].Attempt)
+	list, _ = log.List(context.Background(), "", 0)
+	assert.Len(t, list, 3)
+}
+
+func TestNotifier_PendingDeliveries(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		rcv    = &receiver{statuses: []int{http.StatusServiceUnavailable}}
+		srv    = httptest.NewServer(rcv)
+		store  = memory.NewHash()
+		notify = &models.WorkflowNotify{Webhooks: []*models.WorkflowWebhook{{URL: srv.URL}}}
+	)
+	defer srv.Close()
+	payload := &Payload{ID: "d1", Event: models.NotifyDeleted, ObjectID: "images/a.jpg"}
+
+	// The failed attempt is kept with the next attempt planned after the backoff
+	owner := NewNotifier(WithPendingStore(store), WithBackoff(time.Hour, time.Hour))
+	owner.Notify(ctx, notify, payload)
+	var record pending
+	require.Eventually(t, func() bool {
+		records, _ := store.HGetAll(ctx, pendingKey)
+		return len(records) == 1 && json.Unmarshal([]byte(records["d1/0"]), &record) == nil && record.Attempt == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, srv.URL, record.Hook.URL)
+	assert.Equal(t, "images/a.jpg", record.Payload.ObjectID)
+
+	// The delivery of the live owner is not taken over
+	other := NewNotifier(WithPendingStore(store), WithDeliveryLog(NewMemoryLog(10)))
+	require.NoError(t, other.Resume(ctx))
+	other.Wait()
+	assert.Len(t, rcv.requests, 1)
+
+	// The owner is stopped, its lease is over
+	record.NextAt, record.Expires = time.Now().Add(-time.Minute), time.Now().Add(-time.Second)
+	data, _ := json.Marshal(&record)
+	require.NoError(t, store.HSet(ctx, pendingKey, "d1/0", string(data)))
+	require.NoError(t, other.Resume(ctx))
+	other.Wait()
+
+	require.Len(t, rcv.requests, 2)
+	assert.Equal(t, "d1", rcv.requests[1].Header.Get(HeaderDelivery))
+	list, _ := other.Log().List(ctx, "images/a.jpg", 0)
+	require.Len(t, list, 1)
+	assert.Equal(t, 2, list[0].Attempt)
+	assert.True(t, list[0].Delivered)
+	records, err := store.HGetAll(ctx, pendingKey)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// Payload is the JSON body of the webhook request
+type Payload struct {
+	// ID of the delivery, the same for all retries of the request
+	ID string `json:"id"`
+
+	// Event is the notification event, see models.Notify* constants
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+
+	ObjectID string         `json:"object_id"`
+	Object   *models.Object `json:"object,omitempty"`
+	Error    string         `json:"error,omitempty"`
+
+	// JobID and Job are set for the job events
+	JobID string           `json:"job_id,omitempty"`
+	Job   *models.JobState `json:"job,omitempty"`
+
+	// State of the object processing, nil if it's unknown
+	State *models.ProcessingState `json:"state,omitempty"`
+}
+
+// EventPayload returns the payload of the object event: processed, failed
+// or deleted. Returns nil for the internal events.
+func EventPayload(event *models.Event, state *models.ProcessingState) *Payload {
+	if event == nil {
+		return nil
+	}
+	var name string
+	switch event.Type {
+	case models.ProcessedEventType:
+		name = models.NotifyProcessed
+		if event.IsError() {
+			name = models.NotifyFailed
+		}
+	case models.DeleteEventType:
+		name = models.NotifyDeleted
+	default:
+		return nil
+	}
+	return &Payload{
+		ID:        newDeliveryID(),
+		Event:     name,
+		Timestamp: time.Now(),
+		ObjectID:  event.Object.ObjectID(),
+		Object:    event.Object,
+		Error:     event.Error,
+		State:     state,
+	}
+}
+
+// JobPayload returns the payload of the completed or failed job.
+// Returns nil if the job is not finished by itself.
+func JobPayload(objectID, jobID string, state *models.ProcessingState) *Payload {
+	if state == nil || state.Jobs[jobID] == nil {
+		return nil
+	}
+	js := state.Jobs[jobID]
+	var name string
+	switch js.Status {
+	case models.JobStatusCompleted:
+		name = models.NotifyJobCompleted
+	case models.JobStatusFailed:
+		name = models.NotifyJobFailed
+	default:
+		return nil
+	}
+	return &Payload{
+		ID:        newDeliveryID(),
+		Event:     name,
+		Timestamp: time.Now(),
+		ObjectID:  objectID,
+		Error:     js.Error,
+		JobID:     jobID,
+		Job:       js,
+		State:     state,
+	}
+}
+
+func newDeliveryID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/models"
+)
+
+// DefaultPendingLease is the time after the planned attempt when the pending
+// delivery of the stopped process is resumed by another one
+const DefaultPendingLease = time.Minute
+
+// pendingKey of the hash of the pending deliveries
+const pendingKey = "notify:pending"
+
+// pending is the delivery kept in the store until it's finished
+type pending struct {
+	ID      string                  `json:"id"`
+	Hook    *models.WorkflowWebhook `json:"hook"`
+	Payload *Payload                `json:"payload"`
+
+	// Attempt is the number of the next attempt made at NextAt
+	Attempt int       `json:"attempt"`
+	NextAt  time.Time `json:"next_at"`
+
+	// Owner process delivers the webhook until the Expires
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+// Persistent returns true if the pending deliveries are kept in the store
+func (n *Notifier) Persistent() bool {
+	return n != nil && n.pending != nil
+}
+
+// Resume starts the pending deliveries left by the stopped processes. The
+// delivery is taken over once the lease of its owner is over.
+func (n *Notifier) Resume(ctx context.Context) error {
+	if n == nil || n.pending == nil {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	records, err := n.pending.HGetAll(ctx, pendingKey)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for id, data := range records {
+		var p pending
+		if err := json.Unmarshal([]byte(data), &p); err != nil || p.Hook == nil || p.Payload == nil {
+			ctxlogger.Get(ctx).Error("drop the invalid pending webhook delivery",
+				zap.String("delivery", id), zap.Error(err))
+			_ = n.pending.HDel(ctx, pendingKey, id)
+			continue
+		}
+		if p.Owner == n.owner || now.Before(p.Expires) {
+			continue
+		}
+		n.save(ctx, &p, p.NextAt)
+		n.start(ctx, &p)
+	}
+	return nil
+}
+
+// save the delivery with the next attempt at the time, the delivery is
+// owned by the notifier until the attempt is over
+func (n *Notifier) save(ctx context.Context, p *pending, next time.Time) {
+	p.NextAt = next
+	if n.pending == nil || p.ID == "" {
+		return
+	}
+	p.Owner = n.owner
+	p.Expires = maxTime(next, time.Now()).Add(p.Hook.RequestTimeout() + n.pendingLease)
+	data, err := json.Marshal(p)
+	if err == nil {
+		err = n.pending.HSet(ctx, pendingKey, p.ID, string(data))
+	}
+	if err != nil {
+		ctxlogger.Get(ctx).Warn("save the pending webhook delivery",
+			zap.String("delivery", p.ID), zap.Error(err))
+	}
+}
+
+// finish removes the delivered or dropped delivery from the store
+func (n *Notifier) finish(ctx context.Context, p *pending) {
+	if n.pending == nil || p.ID == "" {
+		return
+	}
+	if err := n.pending.HDel(ctx, pendingKey, p.ID); err != nil {
+		ctxlogger.Get(ctx).Warn("remove the pending webhook delivery",
+			zap.String("delivery", p.ID), zap.Error(err))
+	}
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the webhook request
+const (
+	HeaderEvent     = "X-Apfs-Event"
+	HeaderDelivery  = "X-Apfs-Delivery"
+	HeaderTimestamp = "X-Apfs-Timestamp"
+	HeaderSignature = "X-Apfs-Signature"
+)
+
+// signaturePrefix of the signature header value
+const signaturePrefix = "sha256="
+
+// Sign returns the signature header value of the body sent at the timestamp:
+// "sha256=" + hex(HMAC-SHA256(secret, "<unix timestamp>.<body>"))
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and the timestamp headers of the received
+// body. The request older than tolerance is rejected, zero tolerance
+// disables the check.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	ts := time.Unix(sec, 0)
+	if tolerance > 0 && time.Since(ts).Abs() > tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body)))
+}
@@ -18,6 +18,8 @@ func MetaItemFromModel(metaItem *models.ItemMeta) *ItemMeta {
 		Bitrate:     metaItem.Bitrate,
 		Codec:       metaItem.Codec,
 		ExtJson:     metaItem.ExtJSON(),
+		Blurhash:    metaItem.BlurHash(),
+		Thumbhash:   metaItem.ThumbHash(),
 
 		UpdatedAt: metaItem.UpdatedAt.UnixNano(),
 	}
@@ -39,5 +41,11 @@ func (m *ItemMeta) ToModel() *models.ItemMeta {
 		Codec:       m.Codec,
 	}
 	_ = meta.FromExtJSON([]byte(m.GetExtJson()))
+	if m.GetBlurhash() != "" {
+		meta.SetAttribute(models.AttributeBlurHash, m.GetBlurhash())
+	}
+	if m.GetThumbhash() != "" {
+		meta.SetAttribute(models.AttributeThumbHash, m.GetThumbhash())
+	}
 	return meta
 }
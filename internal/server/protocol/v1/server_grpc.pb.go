@@ -30,6 +30,11 @@ const (
 	ServiceAPI_GetWorkflow_FullMethodName          = "/v1.ServiceAPI/GetWorkflow"
 	ServiceAPI_GetProcessingState_FullMethodName   = "/v1.ServiceAPI/GetProcessingState"
 	ServiceAPI_WatchProcessingState_FullMethodName = "/v1.ServiceAPI/WatchProcessingState"
+	ServiceAPI_GetStepLogs_FullMethodName          = "/v1.ServiceAPI/GetStepLogs"
+	ServiceAPI_RetryJob_FullMethodName             = "/v1.ServiceAPI/RetryJob"
+	ServiceAPI_SkipJob_FullMethodName              = "/v1.ServiceAPI/SkipJob"
+	ServiceAPI_CancelProcessing_FullMethodName     = "/v1.ServiceAPI/CancelProcessing"
+	ServiceAPI_GetGroupStats_FullMethodName        = "/v1.ServiceAPI/GetGroupStats"
 )
 
 // ServiceAPIClient is the client API for ServiceAPI service.
@@ -61,6 +66,18 @@ type ServiceAPIClient interface {
 	// WatchProcessingState streams processing state updates for an object.
 	// The stream ends when the object reaches a terminal state.
 	WatchProcessingState(ctx context.Context, in *ObjectID, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProcessingState], error)
+	// GetStepLogs streams the stdout/stderr log of the job step.
+	// With follow=true the stream ends when the job finishes.
+	GetStepLogs(ctx context.Context, in *StepLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StepLogChunk], error)
+	// RetryJob resets the failed or skipped job and its downstream jobs and
+	// schedules them again.
+	RetryJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*SimpleResponse, error)
+	// SkipJob marks the job as skipped so its downstream jobs proceed.
+	SkipJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*SimpleResponse, error)
+	// CancelProcessing stops the running jobs and skips the pending ones.
+	CancelProcessing(ctx context.Context, in *ObjectID, opts ...grpc.CallOption) (*SimpleResponse, error)
+	// GetGroupStats returns the usage statistics of the group.
+	GetGroupStats(ctx context.Context, in *ManifestGroup, opts ...grpc.CallOption) (*GroupStatsResponse, error)
 }
 
 type serviceAPIClient struct {
@@ -202,6 +219,65 @@ func (c *serviceAPIClient) WatchProcessingState(ctx context.Context, in *ObjectI
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ServiceAPI_WatchProcessingStateClient = grpc.ServerStreamingClient[ProcessingState]
 
+func (c *serviceAPIClient) GetStepLogs(ctx context.Context, in *StepLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StepLogChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ServiceAPI_ServiceDesc.Streams[3], ServiceAPI_GetStepLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StepLogsRequest, StepLogChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ServiceAPI_GetStepLogsClient = grpc.ServerStreamingClient[StepLogChunk]
+
+func (c *serviceAPIClient) RetryJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*SimpleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimpleResponse)
+	err := c.cc.Invoke(ctx, ServiceAPI_RetryJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAPIClient) SkipJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*SimpleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimpleResponse)
+	err := c.cc.Invoke(ctx, ServiceAPI_SkipJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAPIClient) CancelProcessing(ctx context.Context, in *ObjectID, opts ...grpc.CallOption) (*SimpleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimpleResponse)
+	err := c.cc.Invoke(ctx, ServiceAPI_CancelProcessing_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceAPIClient) GetGroupStats(ctx context.Context, in *ManifestGroup, opts ...grpc.CallOption) (*GroupStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GroupStatsResponse)
+	err := c.cc.Invoke(ctx, ServiceAPI_GetGroupStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ServiceAPIServer is the server API for ServiceAPI service.
 // All implementations must embed UnimplementedServiceAPIServer
 // for forward compatibility.
@@ -231,6 +307,18 @@ type ServiceAPIServer interface {
 	// WatchProcessingState streams processing state updates for an object.
 	// The stream ends when the object reaches a terminal state.
 	WatchProcessingState(*ObjectID, grpc.ServerStreamingServer[ProcessingState]) error
+	// GetStepLogs streams the stdout/stderr log of the job step.
+	// With follow=true the stream ends when the job finishes.
+	GetStepLogs(*StepLogsRequest, grpc.ServerStreamingServer[StepLogChunk]) error
+	// RetryJob resets the failed or skipped job and its downstream jobs and
+	// schedules them again.
+	RetryJob(context.Context, *JobRequest) (*SimpleResponse, error)
+	// SkipJob marks the job as skipped so its downstream jobs proceed.
+	SkipJob(context.Context, *JobRequest) (*SimpleResponse, error)
+	// CancelProcessing stops the running jobs and skips the pending ones.
+	CancelProcessing(context.Context, *ObjectID) (*SimpleResponse, error)
+	// GetGroupStats returns the usage statistics of the group.
+	GetGroupStats(context.Context, *ManifestGroup) (*GroupStatsResponse, error)
 	mustEmbedUnimplementedServiceAPIServer()
 }
 
@@ -274,6 +362,21 @@ func (UnimplementedServiceAPIServer) GetProcessingState(context.Context, *Object
 func (UnimplementedServiceAPIServer) WatchProcessingState(*ObjectID, grpc.ServerStreamingServer[ProcessingState]) error {
 	return status.Errorf(codes.Unimplemented, "method WatchProcessingState not implemented")
 }
+func (UnimplementedServiceAPIServer) GetStepLogs(*StepLogsRequest, grpc.ServerStreamingServer[StepLogChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetStepLogs not implemented")
+}
+func (UnimplementedServiceAPIServer) RetryJob(context.Context, *JobRequest) (*SimpleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryJob not implemented")
+}
+func (UnimplementedServiceAPIServer) SkipJob(context.Context, *JobRequest) (*SimpleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SkipJob not implemented")
+}
+func (UnimplementedServiceAPIServer) CancelProcessing(context.Context, *ObjectID) (*SimpleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelProcessing not implemented")
+}
+func (UnimplementedServiceAPIServer) GetGroupStats(context.Context, *ManifestGroup) (*GroupStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGroupStats not implemented")
+}
 func (UnimplementedServiceAPIServer) mustEmbedUnimplementedServiceAPIServer() {}
 func (UnimplementedServiceAPIServer) testEmbeddedByValue()                    {}
 
@@ -468,6 +571,89 @@ func _ServiceAPI_WatchProcessingState_Handler(srv interface{}, stream grpc.Serve
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ServiceAPI_WatchProcessingStateServer = grpc.ServerStreamingServer[ProcessingState]
 
+func _ServiceAPI_GetStepLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StepLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServiceAPIServer).GetStepLogs(m, &grpc.GenericServerStream[StepLogsRequest, StepLogChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ServiceAPI_GetStepLogsServer = grpc.ServerStreamingServer[StepLogChunk]
+
+func _ServiceAPI_RetryJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAPIServer).RetryJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAPI_RetryJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAPIServer).RetryJob(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAPI_SkipJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAPIServer).SkipJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAPI_SkipJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAPIServer).SkipJob(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAPI_CancelProcessing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAPIServer).CancelProcessing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAPI_CancelProcessing_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAPIServer).CancelProcessing(ctx, req.(*ObjectID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceAPI_GetGroupStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ManifestGroup)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceAPIServer).GetGroupStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceAPI_GetGroupStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceAPIServer).GetGroupStats(ctx, req.(*ManifestGroup))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ServiceAPI_ServiceDesc is the grpc.ServiceDesc for ServiceAPI service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -507,6 +693,22 @@ var ServiceAPI_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetProcessingState",
 			Handler:    _ServiceAPI_GetProcessingState_Handler,
 		},
+		{
+			MethodName: "RetryJob",
+			Handler:    _ServiceAPI_RetryJob_Handler,
+		},
+		{
+			MethodName: "SkipJob",
+			Handler:    _ServiceAPI_SkipJob_Handler,
+		},
+		{
+			MethodName: "CancelProcessing",
+			Handler:    _ServiceAPI_CancelProcessing_Handler,
+		},
+		{
+			MethodName: "GetGroupStats",
+			Handler:    _ServiceAPI_GetGroupStats_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -524,6 +726,11 @@ var ServiceAPI_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _ServiceAPI_WatchProcessingState_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetStepLogs",
+			Handler:       _ServiceAPI_GetStepLogs_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "v1/server.proto",
 }
@@ -31,6 +31,7 @@ func processingStateToProtoFull(s *models.ProcessingState, full bool) *Processin
 		Status:          processingStatusToProto(s.Status),
 		Progress:        float32(s.Progress),
 		ManifestVersion: s.ManifestVersion,
+		TraceId:         s.TraceID,
 		StartedAt:       s.StartedAt.UnixMilli(),
 		UpdatedAt:       s.UpdatedAt.UnixMilli(),
 		Counters: &ProcessingCounters{
@@ -45,6 +46,9 @@ func processingStateToProtoFull(s *models.ProcessingState, full bool) *Processin
 	if s.FinishedAt != nil {
 		p.FinishedAt = s.FinishedAt.UnixMilli()
 	}
+	if s.CancelledAt != nil {
+		p.CancelledAt = s.CancelledAt.UnixMilli()
+	}
 	if full {
 		for id, js := range s.Jobs {
 			job := jobStateToProto(js)
@@ -67,6 +71,8 @@ func processingStatusToProto(s models.ProcessingStatus) ProcessingStatus {
 		return ProcessingStatus_PROCESSING_PARTIAL
 	case models.ProcessingStatusFailed:
 		return ProcessingStatus_PROCESSING_FAILED
+	case models.ProcessingStatusCancelled:
+		return ProcessingStatus_PROCESSING_CANCELLED
 	default:
 		return ProcessingStatus_PROCESSING_PENDING
 	}
@@ -89,12 +95,17 @@ func jobStateToProto(js *models.JobState) *JobState {
 	if js.FinishedAt != nil {
 		p.FinishedAt = js.FinishedAt.UnixMilli()
 	}
+	if js.NextAttemptAt != nil {
+		p.NextAttemptAt = js.NextAttemptAt.UnixMilli()
+	}
 	for _, ss := range js.Steps {
 		p.Steps = append(p.Steps, &StepState{
 			Name:       ss.Name,
 			Status:     stepStatusToProto(ss.Status),
 			DurationMs: ss.DurationMs,
 			Error:      ss.Error,
+			Limit:      ss.Limit.String(),
+			LogPath:    ss.LogPath,
 		})
 	}
 	return p
@@ -125,6 +136,8 @@ func stepStatusToProto(s models.StepStatus) StepStatus {
 		return StepStatus_STEP_FAILED
 	case models.StepStatusSkipped:
 		return StepStatus_STEP_SKIPPED
+	case models.StepStatusLimitExceeded:
+		return StepStatus_STEP_LIMIT_EXCEEDED
 	default:
 		return StepStatus_STEP_PENDING
 	}
@@ -147,6 +160,10 @@ func ProtoToProcessingState(p *ProcessingState) *models.ProcessingState {
 		t := time.UnixMilli(p.GetFinishedAt())
 		s.FinishedAt = &t
 	}
+	if p.GetCancelledAt() > 0 {
+		t := time.UnixMilli(p.GetCancelledAt())
+		s.CancelledAt = &t
+	}
 	if len(p.GetJobs()) > 0 {
 		s.Jobs = make(map[string]*models.JobState, len(p.GetJobs()))
 		for _, jp := range p.GetJobs() {
@@ -168,6 +185,8 @@ func protoToProcessingStatus(s ProcessingStatus) models.ProcessingStatus {
 		return models.ProcessingStatusPartial
 	case ProcessingStatus_PROCESSING_FAILED:
 		return models.ProcessingStatusFailed
+	case ProcessingStatus_PROCESSING_CANCELLED:
+		return models.ProcessingStatusCancelled
 	default:
 		return models.ProcessingStatusPending
 	}
@@ -192,12 +211,18 @@ func protoToJobState(p *JobState) *models.JobState {
 		t := time.UnixMilli(p.GetFinishedAt())
 		js.FinishedAt = &t
 	}
+	if p.GetNextAttemptAt() > 0 {
+		t := time.UnixMilli(p.GetNextAttemptAt())
+		js.NextAttemptAt = &t
+	}
 	for _, sp := range p.GetSteps() {
 		js.Steps = append(js.Steps, &models.StepState{
 			Name:       sp.GetName(),
 			Status:     protoToStepStatus(sp.GetStatus()),
 			DurationMs: sp.GetDurationMs(),
 			Error:      sp.GetError(),
+			Limit:      models.StepLimit(sp.GetLimit()),
+			LogPath:    sp.GetLogPath(),
 		})
 	}
 	return js
@@ -228,6 +253,8 @@ func protoToStepStatus(s StepStatus) models.StepStatus {
 		return models.StepStatusFailed
 	case StepStatus_STEP_SKIPPED:
 		return models.StepStatusSkipped
+	case StepStatus_STEP_LIMIT_EXCEEDED:
+		return models.StepStatusLimitExceeded
 	default:
 		return models.StepStatusPending
 	}
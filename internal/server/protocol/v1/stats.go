@@ -0,0 +1,46 @@
+// Hand-written conversion helpers between stats.Stats and the
+// protobuf-generated GroupStats type.
+package v1
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/apfs-io/apfs/internal/storage/stats"
+)
+
+// GroupStatsFromModel converts the group statistics to the proto type.
+// The processing statuses and the jobs are sorted to keep the response stable.
+func GroupStatsFromModel(s *stats.Stats) *GroupStats {
+	if s == nil {
+		return nil
+	}
+	p := &GroupStats{
+		Group:         s.Group,
+		Objects:       s.Objects,
+		OriginalBytes: s.OriginalBytes,
+		DerivedBytes:  s.DerivedBytes,
+		TotalBytes:    s.TotalBytes(),
+	}
+	for status, count := range s.Processing {
+		p.Processing = append(p.Processing, &ProcessingStatusCount{
+			Status: processingStatusToProto(status),
+			Count:  count,
+		})
+	}
+	slices.SortFunc(p.Processing, func(a, b *ProcessingStatusCount) int {
+		return int(a.Status) - int(b.Status)
+	})
+	for id, job := range s.Jobs {
+		p.Jobs = append(p.Jobs, &JobStats{
+			Id:            id,
+			Completed:     job.Completed,
+			Failed:        job.Failed,
+			AvgDurationMs: job.AverageDuration().Milliseconds(),
+		})
+	}
+	slices.SortFunc(p.Jobs, func(a, b *JobStats) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return p
+}
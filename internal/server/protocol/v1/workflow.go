@@ -52,6 +52,15 @@ func WorkflowFromModel(w *models.Workflow) *Workflow {
 			OnFailure:      job.OnFailure,
 			IfExpr:         job.If,
 		}
+		if r := job.Retry; r != nil {
+			pj.Retry = &WorkflowRetry{
+				Max:      int32(r.Max),
+				Backoff:  r.Backoff,
+				Initial:  r.Initial,
+				MaxDelay: r.MaxDelay,
+				On:       append([]string{}, r.On...),
+			}
+		}
 		for _, step := range job.Steps {
 			if step == nil {
 				continue
@@ -115,6 +124,15 @@ func WorkflowToModel(p *Workflow) *models.Workflow {
 				OnFailure:      pj.GetOnFailure(),
 				If:             pj.GetIfExpr(),
 			}
+			if r := pj.GetRetry(); r != nil {
+				job.Retry = &models.WorkflowRetry{
+					Max:      int(r.GetMax()),
+					Backoff:  r.GetBackoff(),
+					Initial:  r.GetInitial(),
+					MaxDelay: r.GetMaxDelay(),
+					On:       append([]string{}, r.GetOn()...),
+				}
+			}
 			for _, ps := range pj.GetSteps() {
 				if ps == nil {
 					continue
@@ -3,35 +3,142 @@ package v1
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/apfs-io/apfs/internal/driver/azblob"
 	"github.com/apfs-io/apfs/internal/driver/fs"
+	"github.com/apfs-io/apfs/internal/driver/gcs"
 	"github.com/apfs-io/apfs/internal/driver/s3"
 	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
 	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
 	"github.com/apfs-io/apfs/internal/storage/kvaccessor/redis"
+	"github.com/apfs-io/apfs/internal/storage/metacache"
+	metabadger "github.com/apfs-io/apfs/internal/storage/metacache/badger"
+	metamemory "github.com/apfs-io/apfs/internal/storage/metacache/memory"
+	metaredis "github.com/apfs-io/apfs/internal/storage/metacache/redis"
+	"github.com/apfs-io/apfs/internal/storage/statestore"
+	statebadger "github.com/apfs-io/apfs/internal/storage/statestore/badger"
+	stateredis "github.com/apfs-io/apfs/internal/storage/statestore/redis"
 	"github.com/apfs-io/apfs/internal/storio"
 )
 
+// NewStorageDriver creates the storage driver of the connection string
+//
+//	fs:///data/apfs, s3://..., gs://..., azblob://...
+func NewStorageDriver(ctx context.Context, connect string) (storio.StorageAccessor, error) {
+	return newStorage(ctx, connect)
+}
+
 // newStorage creates the new accessor collection object
 func newStorage(ctx context.Context, connect string) (storio.StorageAccessor, error) {
-	var (
-		i      = strings.Index(connect, "://")
-		driver = connect[:i]
-	)
-	switch driver {
+	i := strings.Index(connect, "://")
+	if i < 0 {
+		return nil, fmt.Errorf("[storage] invalid connect: %s", connect)
+	}
+	switch driver := connect[:i]; driver {
 	case "s3":
 		return s3.NewStorage(ctx, s3.WithS3FromURL(connect))
+	case "gs", "gcs":
+		return gcs.NewStorage(ctx, gcs.WithGCSFromURL(connect))
+	case "azblob":
+		return azblob.NewStorage(ctx, azblob.WithAzureFromURL(connect))
 	case "disk", "file", "fs":
 		return fs.NewStorage(connect[i+3:])
+	default:
+		return nil, fmt.Errorf("[storage] invalid driver: %s", driver)
 	}
-	return nil, fmt.Errorf("[storage] invalid driver: %s", driver)
 }
 
 func newKVAccessor(connect string) (kvaccessor.KVAccessor, error) {
-	if connect == "memory" {
+	// The badger state is local to the single node as well as the memory one
+	if connect == "memory" || strings.HasPrefix(connect, "badger://") {
 		return memory.NewKVMemory(time.Minute), nil
 	}
 	return redis.New(connect)
 }
+
+// newStateStore creates the processing state store of the state connection.
+// Returns nil for the memory connection: the state is kept by the storage
+// driver beside the object.
+func newStateStore(connect string) (statestore.StateStore, error) {
+	switch {
+	case connect == "memory" || connect == "":
+		return nil, nil
+	case strings.HasPrefix(connect, "badger://"):
+		return statebadger.New(connect)
+	default:
+		return stateredis.New(connect)
+	}
+}
+
+// newStatsCounter creates the counters of the group statistics kept beside
+// the processing states of the state connection
+func newStatsCounter(connect string, stateStore statestore.StateStore) (kvaccessor.Counter, error) {
+	switch {
+	case connect == "memory" || connect == "":
+		return memory.NewCounter(), nil
+	case strings.HasPrefix(connect, "badger://"):
+		store, ok := stateStore.(*statebadger.Store)
+		if !ok {
+			return nil, fmt.Errorf("[stats] badger state store is required: %T", stateStore)
+		}
+		return store.Counter(), nil
+	default:
+		return redis.NewCounter(connect)
+	}
+}
+
+// newPendingStore creates the store of the pending webhook deliveries kept
+// beside the processing states. Returns nil for the memory connection: the
+// deliveries are kept by the process only.
+func newPendingStore(connect string, stateStore statestore.StateStore) (kvaccessor.Hash, error) {
+	switch {
+	case connect == "memory" || connect == "":
+		return nil, nil
+	case strings.HasPrefix(connect, "badger://"):
+		store, ok := stateStore.(*statebadger.Store)
+		if !ok {
+			return nil, fmt.Errorf("[notify] badger state store is required: %T", stateStore)
+		}
+		return store.Hash(), nil
+	default:
+		return redis.NewHash(connect)
+	}
+}
+
+// newMetaCache creates the meta cache declared by the metacache parameter of
+// the meta database connection and returns the connection without it
+//
+//	badger:///data/apfs.bdb?metacache=redis://redis:6379/1&metacache_ttl=5m
+func newMetaCache(connect string) (metacache.MetaCache, string, error) {
+	base, rawQuery, ok := strings.Cut(connect, "?")
+	if !ok {
+		return nil, connect, nil
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil || !query.Has("metacache") {
+		return nil, connect, nil
+	}
+	cacheConnect := query.Get("metacache")
+	ttl, _ := time.ParseDuration(query.Get("metacache_ttl"))
+	query.Del("metacache")
+	query.Del("metacache_ttl")
+	if len(query) > 0 {
+		base += "?" + query.Encode()
+	}
+
+	var cache metacache.MetaCache
+	switch {
+	case cacheConnect == "memory":
+		cache = metamemory.New(ttl)
+	case strings.HasPrefix(cacheConnect, "badger://"):
+		cache, err = metabadger.New(cacheConnect, ttl)
+	case strings.HasPrefix(cacheConnect, "redis://"), strings.HasPrefix(cacheConnect, "tcp://"):
+		cache, err = metaredis.New(cacheConnect, ttl)
+	default:
+		err = fmt.Errorf("[metacache] invalid driver: %s", cacheConnect)
+	}
+	return cache, base, err
+}
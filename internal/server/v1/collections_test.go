@@ -11,3 +11,43 @@ func TestCollections(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, accessor)
 }
+
+func TestNewMetaCache(t *testing.T) {
+	cache, connect, err := newMetaCache("badger:///data/apfs.bdb?sync=true")
+	assert.NoError(t, err)
+	assert.Nil(t, cache)
+	assert.Equal(t, "badger:///data/apfs.bdb?sync=true", connect)
+
+	cache, connect, err = newMetaCache("badger:///data/apfs.bdb?metacache=memory&metacache_ttl=1m&sync=true")
+	assert.NoError(t, err)
+	assert.NotNil(t, cache)
+	assert.Equal(t, "badger:///data/apfs.bdb?sync=true", connect)
+
+	_, _, err = newMetaCache("memory://?metacache=unknown://")
+	assert.Error(t, err)
+}
+
+func TestNewStateStore(t *testing.T) {
+	store, err := newStateStore("memory")
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	store, err = newStateStore("badger://state?inmemory=true")
+	assert.NoError(t, err)
+	assert.NotNil(t, store)
+}
+
+func TestNewStatsCounter(t *testing.T) {
+	counter, err := newStatsCounter("memory", nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, counter)
+
+	store, err := newStateStore("badger://stats?inmemory=true")
+	assert.NoError(t, err)
+	counter, err = newStatsCounter("badger://stats?inmemory=true", store)
+	assert.NoError(t, err)
+	assert.NotNil(t, counter)
+
+	_, err = newStatsCounter("badger://stats?inmemory=true", nil)
+	assert.Error(t, err)
+}
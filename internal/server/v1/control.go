@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	protocol "github.com/apfs-io/apfs/internal/server/protocol/v1"
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/libs/storerrors"
+	"github.com/apfs-io/apfs/models"
+)
+
+// RetryJob resets the failed or skipped job and its downstream jobs and
+// schedules the processing of the object again
+func (s *server) RetryJob(ctx context.Context, req *protocol.JobRequest) (*protocol.SimpleResponse, error) {
+	ctxlogger.Get(ctx).Info("Retry job PUT",
+		zap.String("object_id", req.GetId()), zap.String("job_id", req.GetJob()))
+	return s.controlProcessing(ctx, req.GetId(), "Job successfully retried", true,
+		func(wf *models.Workflow, state *models.ProcessingState) error {
+			return workflow.RetryJob(wf, state, req.GetJob())
+		})
+}
+
+// SkipJob marks the job as skipped so the downstream jobs proceed
+func (s *server) SkipJob(ctx context.Context, req *protocol.JobRequest) (*protocol.SimpleResponse, error) {
+	ctxlogger.Get(ctx).Info("Skip job PUT",
+		zap.String("object_id", req.GetId()), zap.String("job_id", req.GetJob()))
+	return s.controlProcessing(ctx, req.GetId(), "Job successfully skipped", true,
+		func(wf *models.Workflow, state *models.ProcessingState) error {
+			return workflow.SkipJob(wf, state, req.GetJob(), req.GetReason())
+		})
+}
+
+// CancelProcessing skips the pending jobs of the object, the running jobs
+// are stopped by the workers on the next state check
+func (s *server) CancelProcessing(ctx context.Context, obj *protocol.ObjectID) (*protocol.SimpleResponse, error) {
+	ctxlogger.Get(ctx).Info("Cancel processing PUT", zap.String("object_id", obj.GetId()))
+	return s.controlProcessing(ctx, obj.GetId(), "Processing successfully cancelled", false,
+		func(_ *models.Workflow, state *models.ProcessingState) error {
+			return workflow.CancelProcessing(state)
+		})
+}
+
+// controlProcessing applies the manual control operation to the processing
+// state of the object. With reschedule=true the object is processed again.
+func (s *server) controlProcessing(ctx context.Context, objectID, message string, reschedule bool,
+	op func(wf *models.Workflow, state *models.ProcessingState) error) (*protocol.SimpleResponse, error) {
+	sObject, err := s.store.Object(ctx, objectID)
+	if err != nil && !storerrors.IsNotFound(err) {
+		return &protocol.SimpleResponse{
+			Status:  protocol.ResponseStatusCode_FAILED,
+			Message: err.Error(),
+		}, nil
+	}
+	if sObject == nil || storerrors.IsNotFound(err) {
+		return &protocol.SimpleResponse{
+			Status:  protocol.ResponseStatusCode_NOT_FOUND,
+			Message: "Not found",
+		}, nil
+	}
+	wf := s.store.ObjectWorkflow(ctx, sObject)
+	if wf == nil {
+		wf = &models.Workflow{}
+	}
+	apply := func(state *models.ProcessingState) error {
+		if state == nil {
+			return storerrors.WrapNotFound(objectID, nil)
+		}
+		return op(wf, state)
+	}
+	if s.wfExecutor != nil {
+		// The state is changed under the lock of the object, so the results
+		// of the jobs finished meanwhile are not overwritten
+		err = s.wfExecutor.UpdateState(ctx, objectID, apply)
+	} else {
+		var state *models.ProcessingState
+		if state, err = s.store.GetProcessingState(ctx, objectID); err == nil {
+			if err = apply(state); err == nil {
+				err = s.store.SetProcessingState(ctx, objectID, state)
+			}
+		}
+	}
+	if err != nil {
+		status := protocol.ResponseStatusCode_FAILED
+		if storerrors.IsNotFound(err) {
+			status = protocol.ResponseStatusCode_NOT_FOUND
+		}
+		return &protocol.SimpleResponse{Status: status, Message: err.Error()}, nil
+	}
+	if reschedule {
+		s.updateObjectState(ctx, objectID)
+	}
+	return &protocol.SimpleResponse{
+		Status:  protocol.ResponseStatusCode_OK,
+		Message: message,
+	}, nil
+}
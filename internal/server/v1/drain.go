@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	nc "github.com/geniusrabbit/notificationcenter/v2"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/models"
+)
+
+// DrainInterruptTimeout is the time the interrupted jobs have to write
+// their state and release the leases after the drain grace period
+const DrainInterruptTimeout = 10 * time.Second
+
+// ErrDrainTimeout is returned by Drain if the running work doesn't stop
+// after the interruption
+var ErrDrainTimeout = errors.New("drain: running work is not stopped")
+
+// Drainer stops the processing of the worker gracefully
+type Drainer interface {
+	// Drain stops the processing of the new events and jobs and waits for
+	// the running ones up to the grace period. Then the running workflow
+	// jobs are interrupted and rescheduled for the retry by the other
+	// workers, their job leases are released.
+	Drain(ctx context.Context, grace time.Duration) error
+
+	// Ready reports whether the worker accepts the new events and jobs
+	Ready() bool
+}
+
+// workTracker counts the running events and jobs of the worker
+type workTracker struct {
+	mx       sync.Mutex
+	draining bool
+	running  sync.WaitGroup
+}
+
+// beginWork registers the new work, false if the worker is draining
+func (s *server) beginWork() bool {
+	s.work.mx.Lock()
+	defer s.work.mx.Unlock()
+	if s.work.draining {
+		return false
+	}
+	s.work.running.Add(1)
+	return true
+}
+
+// endWork marks the work registered by beginWork as finished
+func (s *server) endWork() {
+	s.work.running.Done()
+}
+
+// requeueEvent publishes the event refused by the draining worker back to
+// the stream unchanged and acknowledges the message. The event is kept
+// unacknowledged if it can't be published.
+func (s *server) requeueEvent(ctx context.Context, message nc.Message, event *models.Event) error {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.eventStream.Publish(ctx, event); err != nil {
+		ctxlogger.Get(ctx).Error("requeue the event of the draining worker",
+			zap.String("event", event.Type.String()),
+			zap.String("object_id", event.Object.ObjectID()),
+			zap.Error(err))
+		return nil
+	}
+	return message.Ack()
+}
+
+// Ready implements Drainer
+func (s *server) Ready() bool {
+	s.work.mx.Lock()
+	defer s.work.mx.Unlock()
+	return !s.work.draining
+}
+
+// Drain implements Drainer
+func (s *server) Drain(ctx context.Context, grace time.Duration) error {
+	log := ctxlogger.Get(ctx)
+	s.work.mx.Lock()
+	s.work.draining = true
+	s.work.mx.Unlock()
+
+	log.Info("drain the running work", zap.Duration("grace", grace))
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	select {
+	case <-waitDone(s.work.running.Wait):
+		log.Info("drain complete")
+	case <-ctx.Done():
+		return s.interruptWork(ctx)
+	case <-deadline.C:
+		return s.interruptWork(ctx)
+	}
+
+	// The webhook deliveries of the finished work are retried in background,
+	// the persisted ones are resumed by the other workers if not finished
+	if s.notifier != nil {
+		left := "dropped"
+		if s.notifier.Persistent() {
+			left = "resumed by the other workers"
+		}
+		select {
+		case <-waitDone(s.notifier.Wait):
+		case <-ctx.Done():
+			log.Warn("drain is canceled, pending webhook deliveries are " + left)
+		case <-deadline.C:
+			log.Warn("drain grace period is over, pending webhook deliveries are " + left)
+		}
+	}
+	return nil
+}
+
+// interruptWork interrupts the running jobs after the grace period and
+// waits for them to reschedule
+func (s *server) interruptWork(ctx context.Context) error {
+	log := ctxlogger.Get(ctx)
+	log.Warn("drain grace period is over, interrupt the running jobs")
+	if s.wfExecutor != nil {
+		s.wfExecutor.InterruptJobs()
+	}
+	interrupt := time.NewTimer(DrainInterruptTimeout)
+	defer interrupt.Stop()
+	select {
+	case <-waitDone(s.work.running.Wait):
+		log.Info("drain complete, the interrupted jobs are rescheduled")
+		return nil
+	case <-interrupt.C:
+		return ErrDrainTimeout
+	}
+}
+
+// waitDone returns the channel closed once the wait function returns
+func waitDone(wait func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxcorrelation"
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/tracing"
+	"github.com/apfs-io/apfs/models"
+)
+
+// publishEvent fills the envelope attributes of the event and publishes it
+// to the event stream. The event without the correlation ID of the context
+// starts the new correlation (e.g. the upload).
+func (s *server) publishEvent(ctx context.Context, event *models.Event) {
+	ctx, correlationID := ctxcorrelation.Ensure(ctx)
+	objectID := event.Object.ObjectID()
+	event.ID = newEventID()
+	event.Time = time.Now().UTC()
+	event.CorrelationID = correlationID
+
+	ctxlogger.Get(ctx).Info("sendEvent",
+		zap.String("event_type", event.Type.String()),
+		zap.String("object_id", objectID),
+		zap.String("correlation_id", correlationID))
+	ctx, span := tracing.Start(ctx, "event.publish "+event.Type.String(), trace.SpanKindProducer,
+		tracing.ObjectAttributes(objectID)...)
+	event.TraceContext = tracing.Inject(ctx)
+	err := s.eventStream.Publish(ctx, event)
+	tracing.End(span, err)
+	s.errorLog(ctx, err)
+}
+
+// sendJobEvent publishes the job event of the job state in the processing
+// state: job.started, job.completed or job.failed. The skipped jobs have
+// no events.
+func (s *server) sendJobEvent(ctx context.Context, w *models.Workflow, objectID, jobID string, state *models.ProcessingState) {
+	js := state.Jobs[jobID]
+	if js == nil {
+		return
+	}
+	var etype models.EventType
+	switch js.Status {
+	case models.JobStatusRunning:
+		etype = models.JobStartedEventType
+	case models.JobStatusCompleted:
+		etype = models.JobCompletedEventType
+	case models.JobStatusFailed:
+		etype = models.JobFailedEventType
+	default:
+		return
+	}
+	event := &models.Event{
+		Type:            etype,
+		Error:           js.Error,
+		Object:          &models.Object{ID: objectID},
+		JobID:           jobID,
+		WorkflowVersion: state.ManifestVersion,
+		State:           state,
+	}
+	if w != nil && w.Version != "" {
+		event.WorkflowVersion = w.Version
+	}
+	s.publishEvent(ctx, event)
+}
+
+// jobStarted is the executor callback of the started jobs
+func (s *server) jobStarted(ctx context.Context, w *models.Workflow, objectID, jobID string, state *models.ProcessingState) {
+	s.sendJobEvent(ctx, w, objectID, jobID, state)
+}
+
+// jobFinished is the executor callback of the finished jobs
+func (s *server) jobFinished(ctx context.Context, w *models.Workflow, objectID, jobID string, state *models.ProcessingState) {
+	s.sendJobEvent(ctx, w, objectID, jobID, state)
+	s.notifyJobFinished(ctx, w, objectID, jobID, state)
+}
+
+// newEventID returns the new random ID of the event
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/demdxx/gocast/v2"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/models"
+)
+
+const (
+	defaultObjectListLimit = 100
+	maxObjectListLimit     = 1000
+)
+
+type objectListResponse struct {
+	Objects []*models.Object `json:"objects"`
+	Next    string           `json:"next,omitempty"` // Cursor of the next page
+}
+
+// ListObjectsHTTPHandler lists the indexed objects ordered by the creation time.
+// The list params accept the comma separated values.
+// query params:
+//
+//	group:string          - group of the objects
+//	status:list           - object status
+//	processing:list       - processing state status: pending, running, completed, partial, failed, cancelled
+//	type:list             - object type
+//	content_type:string   - content type prefix, e.g. image/
+//	tags:list             - object must have all of the tags
+//	min_size,max_size:int - size range in bytes
+//	workflow_version:string
+//	limit:int             - page size, 100 by default
+//	after:string          - cursor of the page returned as the next value
+func (s *ServerHTTPWrapper) ListObjectsHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter, err := objectFilterFromQuery(r.URL.Query())
+	if err != nil {
+		errorResponseCode(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	objects, err := s.store.ListObjects(ctx, filter)
+	switch {
+	case errors.Is(err, storage.ErrStorageIndexNotSupported):
+		errorResponseCode(w, http.StatusNotImplemented, err.Error())
+		return
+	case err != nil:
+		ctxlogger.Get(ctx).Error("list objects", zap.Error(err))
+		errorResponse(w, err.Error())
+		return
+	}
+	resp := &objectListResponse{Objects: objects}
+	if resp.Objects == nil {
+		resp.Objects = []*models.Object{}
+	}
+	if len(objects) == filter.Limit {
+		resp.Next = encodeObjectCursor(storage.CursorOf(objects[len(objects)-1]))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func objectFilterFromQuery(query url.Values) (*storage.ObjectFilter, error) {
+	filter := &storage.ObjectFilter{
+		Group:           query.Get("group"),
+		ContentType:     query.Get("content_type"),
+		Tags:            queryList(query, "tags"),
+		MinSize:         gocast.Number[uint64](query.Get("min_size")),
+		MaxSize:         gocast.Number[uint64](query.Get("max_size")),
+		WorkflowVersion: query.Get("workflow_version"),
+		Limit:           gocast.Int(query.Get("limit")),
+	}
+	for _, status := range queryList(query, "status") {
+		filter.Status = append(filter.Status, models.ObjectStatus(status))
+	}
+	for _, status := range queryList(query, "processing") {
+		filter.Processing = append(filter.Processing, models.ProcessingStatus(status))
+	}
+	for _, tp := range queryList(query, "type") {
+		filter.Type = append(filter.Type, models.ObjectType(tp))
+	}
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = defaultObjectListLimit
+	case filter.Limit > maxObjectListLimit:
+		filter.Limit = maxObjectListLimit
+	}
+	if after := query.Get("after"); after != "" {
+		cursor, err := decodeObjectCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = cursor
+	}
+	return filter, nil
+}
+
+// queryList returns the values of the repeated or comma separated param
+func queryList(query url.Values, key string) []string {
+	var list []string
+	for _, value := range query[key] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func encodeObjectCursor(cursor *storage.ObjectCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeObjectCursor(value string) (*storage.ObjectCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid after cursor")
+	}
+	var cursor storage.ObjectCursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, errors.New("invalid after cursor")
+	}
+	return &cursor, nil
+}
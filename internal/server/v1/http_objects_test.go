@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/fs"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/internal/storage/database/memory"
+	statememory "github.com/apfs-io/apfs/internal/storage/statestore/memory"
+	"github.com/apfs-io/apfs/models"
+)
+
+func listObjects(t *testing.T, wrapper *ServerHTTPWrapper, query string) (int, *objectListResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	wrapper.ListObjectsHTTPHandler(rec, httptest.NewRequest(http.MethodGet, "/v1/objects?"+query, nil))
+	var resp objectListResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	}
+	return rec.Code, &resp
+}
+
+func objectIDs(objects []*models.Object) []string {
+	ids := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		ids = append(ids, obj.ID)
+	}
+	return ids
+}
+
+func TestListObjectsHTTPHandler(t *testing.T) {
+	var (
+		ctx = context.TODO()
+		now = time.Now()
+	)
+	db, err := memory.Connect(ctx, "memory://")
+	require.NoError(t, err)
+	driver, err := fs.NewStorage(t.TempDir())
+	require.NoError(t, err)
+	store := storage.NewStorage(
+		storage.WithDatabase(db),
+		storage.WithDriver(driver),
+		storage.WithStateStore(statememory.New()),
+	)
+	wrapper := &ServerHTTPWrapper{server: &server{store: store}}
+
+	for i, id := range []string{"images/1", "images/2", "images/3", "videos/1"} {
+		require.NoError(t, db.Set(&models.Object{ID: id, Bucket: id[:6], Status: models.StatusOK,
+			CreatedAt: now.Add(time.Duration(i) * time.Second)}))
+	}
+	for id, status := range map[string]models.ProcessingStatus{
+		"images/1": models.ProcessingStatusCompleted,
+		"images/2": models.ProcessingStatusFailed,
+		"images/3": models.ProcessingStatusFailed,
+		"videos/1": models.ProcessingStatusPartial,
+	} {
+		require.NoError(t, store.SetProcessingState(ctx, id, &models.ProcessingState{ObjectID: id, Status: status}))
+	}
+
+	t.Run("processing status", func(t *testing.T) {
+		code, resp := listObjects(t, wrapper, "processing=failed,partial")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"images/2", "images/3", "videos/1"}, objectIDs(resp.Objects))
+		assert.Empty(t, resp.Next)
+
+		code, resp = listObjects(t, wrapper, "processing=failed&group=images&limit=1")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"images/2"}, objectIDs(resp.Objects))
+		require.NotEmpty(t, resp.Next)
+
+		code, resp = listObjects(t, wrapper, "processing=failed&group=images&limit=1&after="+resp.Next)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"images/3"}, objectIDs(resp.Objects))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		code, resp := listObjects(t, wrapper, "processing=cancelled")
+		require.Equal(t, http.StatusOK, code)
+		assert.NotNil(t, resp.Objects)
+		assert.Empty(t, resp.Objects)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		code, _ := listObjects(t, wrapper, "after=broken")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		wrapper := &ServerHTTPWrapper{server: &server{store: storage.NewStorage(
+			storage.WithDatabase(&storage.DatabaseMock{}), storage.WithDriver(driver))}}
+		code, _ := listObjects(t, wrapper, "")
+		assert.Equal(t, http.StatusNotImplemented, code)
+	})
+}
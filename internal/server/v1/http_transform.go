@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/libs/storerrors"
+	"github.com/apfs-io/apfs/models"
+)
+
+// TransformPathSuffix of the on-the-fly transformation route
+//
+//	GET /v1/{group}/{id}/_transform?w=300&h=200&fit=cover&fmt=webp
+//	GET /v1/{group}/{id}/_transform?preset=card
+const TransformPathSuffix = "/_transform"
+
+// TransformRole of the derived artifacts produced by the transformation endpoint
+const TransformRole = "transform"
+
+// Transformer renders image derivatives of the original object file
+type Transformer interface {
+	Transform(src io.Reader, preset *models.TransformPreset, ext string) (io.ReadCloser, *models.ItemMeta, error)
+}
+
+// IsTransformPath returns true if the request path targets the transformation endpoint
+func IsTransformPath(path string) bool {
+	return strings.HasSuffix(path, TransformPathSuffix)
+}
+
+// TransformHTTPHandler returns the transformed original image.
+// The result is stored as a derived artifact of the object under the
+// deterministic name and all subsequent requests are served from the storage.
+// Only transformations declared in the workflow `transform.presets` are allowed.
+//
+// query params:
+//
+//	preset:string - preset name from the workflow
+//	w:int         - target width
+//	h:int         - target height
+//	fit:string    - cover (default), contain, fill
+//	fmt:string    - target format (jpeg, png, gif, tiff, bmp, webp)
+//	q:int         - JPEG quality
+func (s *ServerHTTPWrapper) TransformHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		group = chi.URLParam(r, "group")
+		id    = group + "/" + strings.TrimSuffix(chi.URLParam(r, "*"), TransformPathSuffix)
+		query = r.URL.Query()
+	)
+	log := ctxlogger.Get(ctx).With(zap.String("object_id", id))
+
+	if s.transformer == nil {
+		errorResponseCode(w, http.StatusNotImplemented, "transformations are disabled")
+		return
+	}
+
+	// Get object reference by ID
+	sObject, err := s.store.Object(ctx, id)
+	if err != nil && !storerrors.IsNotFound(err) {
+		log.Error("open object link by ID", zap.Error(err))
+		errorResponse(w, err.Error())
+		return
+	}
+	if sObject == nil || storerrors.IsNotFound(err) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	main := sObject.MetaOrNew().Main
+	if !main.Type.IsImage() {
+		errorResponseCode(w, http.StatusUnsupportedMediaType, "object is not an image")
+		return
+	}
+
+	// Resolve the preset from the allow-list of the workflow
+	preset := s.transformPreset(r, sObject.Bucket(), query)
+	if preset == nil {
+		errorResponseCode(w, http.StatusForbidden, "transformation is not allowed")
+		return
+	}
+	name := preset.TargetName(main.ObjectTypeExt())
+	log = log.With(zap.String("object_name", name))
+	if err := preset.Validate(); err != nil {
+		errorResponseCode(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	// Serve the cached derivative if it was already produced
+	if sObject.MetaOrNew().ItemByName(name) != nil {
+		_, data, err := s.store.OpenObject(ctx, sObject, name)
+		if err == nil {
+			defer func() { _ = data.Close() }()
+			writeTransformResponse(w, name, "hit", data, log)
+			return
+		}
+		if !storerrors.IsNotFound(err) {
+			log.Error("open transformed object", zap.Error(err))
+			errorResponse(w, err.Error())
+			return
+		}
+	}
+
+	// Render the derivative from the original
+	_, src, err := s.store.OpenObject(ctx, sObject, "")
+	if err != nil {
+		log.Error("open original object", zap.Error(err))
+		errorResponse(w, err.Error())
+		return
+	}
+	data, itemMeta, err := s.transformer.Transform(src, preset, filepath.Ext(name))
+	_ = src.Close()
+	if err != nil {
+		log.Error("transform object", zap.Error(err))
+		errorResponse(w, err.Error())
+		return
+	}
+	defer func() { _ = data.Close() }()
+
+	itemMeta.Role = TransformRole
+	itemMeta.UpdateName(name)
+	if _, err = s.store.UpdateObjectFile(ctx, sObject, name, data, itemMeta); err != nil {
+		// The client still receives the result, the next request will try to store it again
+		log.Error("store transformed object", zap.Error(err))
+	}
+	if seeker, ok := data.(io.Seeker); ok {
+		if _, err = seeker.Seek(0, io.SeekStart); err != nil {
+			errorResponse(w, err.Error())
+			return
+		}
+	}
+	writeTransformResponse(w, name, "miss", data, log)
+}
+
+// transformPreset returns the allowed preset which matches the request or nil
+func (s *ServerHTTPWrapper) transformPreset(r *http.Request, group string, query url.Values) *models.TransformPreset {
+	wf, _ := s.store.GetWorkflow(r.Context(), group)
+	if wf == nil || wf.Transform == nil {
+		return nil
+	}
+	if name := query.Get("preset"); name != "" {
+		return wf.Transform.Preset(name)
+	}
+	_, preset := wf.Transform.Match(&models.TransformPreset{
+		Width:   gocast.Int(query.Get("w")),
+		Height:  gocast.Int(query.Get("h")),
+		Fit:     query.Get("fit"),
+		Format:  query.Get("fmt"),
+		Quality: gocast.Int(query.Get("q")),
+	})
+	return preset
+}
+
+func writeTransformResponse(w http.ResponseWriter, name, cacheStatus string, data io.Reader, log *zap.Logger) {
+	w.Header().Add("Content-Type", mime.TypeByExtension(filepath.Ext(name)))
+	w.Header().Add("X-Transform-Cache", cacheStatus)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, data); err != nil {
+		log.Error("write response data", zap.Error(err))
+	}
+}
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+
+	"github.com/apfs-io/apfs/internal/driver/fs"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	imageconv "github.com/apfs-io/apfs/libs/converters/image"
+	"github.com/apfs-io/apfs/models"
+)
+
+// newTransformServer returns the router of the transformation endpoint over
+// the temporary fs storage
+func newTransformServer(t *testing.T, transformer Transformer) (http.Handler, *storage.Storage, *fs.Storage) {
+	t.Helper()
+	driver, err := fs.NewStorage(t.TempDir())
+	require.NoError(t, err)
+	store := storage.NewStorage(
+		storage.WithDatabase(&storage.DatabaseMock{}),
+		storage.WithDriver(driver),
+		storage.WithProcessingStatus(&memory.KVMemory{}),
+	)
+	wrapper := &ServerHTTPWrapper{server: &server{store: store, transformer: transformer}}
+	mux := chi.NewRouter()
+	mux.Get("/v1/{group}/*", wrapper.TransformHTTPHandler)
+	return mux, store, driver
+}
+
+func transformRequest(handler http.Handler, id, query string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/"+id+TransformPathSuffix+"?"+query, nil))
+	return rec
+}
+
+func TestTransformHTTPHandler(t *testing.T) {
+	var (
+		ctx                    = context.TODO()
+		handler, store, driver = newTransformServer(t, imageconv.NewDefaultConverter())
+		src                    bytes.Buffer
+	)
+	require.NoError(t, store.SetWorkflow(ctx, "images", &models.Workflow{
+		Transform: &models.WorkflowTransform{Presets: map[string]*models.TransformPreset{
+			"card":  {Width: 30, Height: 20, Format: "webp"},
+			"thumb": {Width: 8, Height: 8, Fit: models.TransformFitContain},
+		}},
+	}))
+	require.NoError(t, png.Encode(&src, image.NewGray(image.Rect(0, 0, 60, 30))))
+	img, err := store.Upload(ctx, "images", bytes.NewReader(src.Bytes()))
+	require.NoError(t, err)
+	text, err := store.Upload(ctx, "images", bytes.NewReader([]byte("plain text content")))
+	require.NoError(t, err)
+
+	t.Run("preset", func(t *testing.T) {
+		for _, cache := range []string{"miss", "hit"} {
+			rec := transformRequest(handler, img.ID().String(), "preset=card")
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, cache, rec.Header().Get("X-Transform-Cache"))
+			assert.Equal(t, "image/webp", rec.Header().Get("Content-Type"))
+			res, err := webp.Decode(rec.Body)
+			require.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, 30, 20), res.Bounds())
+		}
+
+		// The variant is stored in the object scope
+		obj, err := store.Object(ctx, img.ID().String())
+		require.NoError(t, err)
+		item := obj.MetaOrNew().ItemByName("_transform-30x20-cover.webp")
+		require.NotNil(t, item)
+		assert.Equal(t, TransformRole, item.Role)
+	})
+
+	t.Run("matched parameters", func(t *testing.T) {
+		rec := transformRequest(handler, img.ID().String(), "w=8&h=8&fit=contain")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "miss", rec.Header().Get("X-Transform-Cache"))
+		res, err := png.Decode(rec.Body)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 8, 4), res.Bounds())
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, transformRequest(handler, img.ID().String(), "w=9&h=8").Code)
+		assert.Equal(t, http.StatusForbidden, transformRequest(handler, img.ID().String(), "preset=unknown").Code)
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, transformRequest(handler, text.ID().String(), "preset=card").Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, transformRequest(handler, "images/unknown", "preset=card").Code)
+	})
+
+	t.Run("invalid preset", func(t *testing.T) {
+		broken := &models.Workflow{
+			Transform: &models.WorkflowTransform{Presets: map[string]*models.TransformPreset{
+				"broken": {Width: 10, Quality: 200},
+			}},
+		}
+		assert.Error(t, store.SetWorkflow(ctx, "images", broken))
+
+		// The workflow stored before the presets were validated
+		require.NoError(t, driver.UpdateWorkflow(ctx, "images", broken))
+		assert.Equal(t, http.StatusUnprocessableEntity, transformRequest(handler, img.ID().String(), "preset=broken").Code)
+	})
+}
+
+func TestTransformHTTPHandlerDisabled(t *testing.T) {
+	handler, _, _ := newTransformServer(t, nil)
+	assert.Equal(t, http.StatusNotImplemented, transformRequest(handler, "images/1", "preset=card").Code)
+}
@@ -51,7 +51,7 @@ func NewHTTPWrapper(s any) *ServerHTTPWrapper {
 //	overwrite:bool - overwrite with custom object ID
 func (s *ServerHTTPWrapper) UploadHTTPHandler(w http.ResponseWriter, r *http.Request) {
 	var (
-		ctx       = r.Context()
+		ctx       = s.server.trustedProxies.withHTTPPrincipal(r)
 		customID  = r.URL.Query().Get("id")
 		overwrite = gocast.Bool(r.URL.Query().Get("overwrite"))
 		group     = chi.URLParam(r, "group")
@@ -80,6 +80,9 @@ func (s *ServerHTTPWrapper) UploadHTTPHandler(w http.ResponseWriter, r *http.Req
 	nobj, err := s.UploadObject(ctx, group, customID, overwrite, tags, data)
 	if err != nil {
 		ctxlogger.Get(ctx).Error("upload to storage", zap.Error(err))
+		if httpQuotaError(w, err) {
+			return
+		}
 		errorResponse(w, "upload to storage error: "+err.Error())
 		return
 	}
@@ -280,8 +283,12 @@ func dataReader(r *http.Request) (io.Reader, io.Closer, error) {
 }
 
 func errorResponse(w http.ResponseWriter, err string) {
+	errorResponseCode(w, http.StatusInternalServerError, err)
+}
+
+func errorResponseCode(w http.ResponseWriter, code int, err string) {
 	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(code)
 	_ = json.NewEncoder(w).Encode(&protocol.SimpleObjectResponse{
 		Status:  protocol.ResponseStatusCode_FAILED,
 		Message: err,
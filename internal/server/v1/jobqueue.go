@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxcorrelation"
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/jobqueue"
+	"github.com/apfs-io/apfs/internal/tracing"
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/libs/storerrors"
+)
+
+// JobConsumer executes the workflow jobs dispatched to the job queue
+type JobConsumer interface {
+	// ConsumeJobs runs concurrency workers consuming the jobs of the worker
+	// tags until the context is canceled. Returns immediately if the job
+	// queue is not configured.
+	ConsumeJobs(ctx context.Context, concurrency int) error
+}
+
+// ConsumeJobs implements JobConsumer
+func (s *server) ConsumeJobs(ctx context.Context, concurrency int) error {
+	if s.jobQueue == nil || s.wfExecutor == nil {
+		return nil
+	}
+	labels := jobqueue.WorkerLabels(s.workerTags)
+	ctxlogger.Get(ctx).Info("consume workflow jobs", zap.Strings("labels", labels))
+	return jobqueue.Consume(ctx, s.jobQueue, labels, concurrency, s.receiveJob)
+}
+
+// receiveJob executes the dispatched job and sends the update event of the
+// object to dispatch the next ready jobs
+func (s *server) receiveJob(ctx context.Context, job *jobqueue.Job) (err error) {
+	// The draining worker leaves the job to the other workers
+	if !s.beginWork() {
+		return jobqueue.RetryAt(workflow.ErrWorkerShutdown, time.Now())
+	}
+	defer s.endWork()
+
+	// Continue the trace of the job dispatcher
+	ctx, span := tracing.Start(tracing.Extract(ctx, job.TraceContext),
+		"job.consume "+job.JobID, trace.SpanKindConsumer,
+		tracing.ObjectAttributes(job.ObjectID)...)
+	defer func() { tracing.End(span, err) }()
+	ctx = ctxcorrelation.WithCorrelationID(ctx, job.CorrelationID)
+
+	log := ctxlogger.Get(ctx).With(
+		zap.String("object_id", job.ObjectID),
+		zap.String("job_id", job.JobID))
+
+	cObject, err := s.store.Object(ctx, job.ObjectID)
+	if err != nil {
+		if storerrors.IsNotFound(err) {
+			log.Warn("job object not found", zap.Error(err))
+			return nil
+		}
+		return err
+	}
+	wf := s.store.ObjectWorkflow(ctx, cObject)
+	if wf == nil || wf.Version != "2" || wf.Jobs[job.JobID] == nil {
+		log.Warn("job is not defined in the object workflow")
+		return nil
+	}
+
+	err = s.wfExecutor.ExecuteJob(ctx, wf, job.ObjectID, job.JobID, s.workerTags)
+	switch {
+	case errors.Is(err, workflow.ErrWorkerShutdown):
+		// The interrupted job is delivered to another worker right away,
+		// the delivery is not counted as failed
+		log.Warn("job interrupted by the worker shutdown")
+		return jobqueue.RetryAt(err, time.Now())
+	case errors.Is(err, workflow.ErrJobLocked):
+		// The worker holding the lease dispatches the next jobs
+		log.Info("job is running on another worker")
+		return nil
+	case errors.Is(err, workflow.ErrJobRetry):
+		// The held message is delivered again when the retry is due, the
+		// dispatch of the pending job would be ignored while it's queued
+		at, _ := workflow.RetryAt(err)
+		log.Warn("job scheduled for retry", zap.Time("next_attempt_at", at), zap.Error(err))
+		return jobqueue.RetryAt(err, at)
+	case err != nil:
+		return err
+	}
+	s.updateObjectState(ctx, job.ObjectID)
+	return nil
+}
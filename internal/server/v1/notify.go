@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/notify"
+	"github.com/apfs-io/apfs/models"
+)
+
+// WebhookLogger exposes the log of the webhook deliveries
+type WebhookLogger interface {
+	// WebhookLog returns the log of the webhook deliveries
+	WebhookLog() notify.DeliveryLog
+}
+
+// WebhookLog implements WebhookLogger
+func (s *server) WebhookLog() notify.DeliveryLog {
+	return s.notifier.Log()
+}
+
+// runWebhookResumer resumes the pending webhook deliveries of the stopped
+// processes every interval until the ctx is done or the worker drains
+func (s *server) runWebhookResumer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if s.Ready() {
+			if err := s.notifier.Resume(ctx); err != nil {
+				ctxlogger.Get(ctx).Error("resume the pending webhook deliveries", zap.Error(err))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifyEvent sends the processed, failed and deleted events of the object
+// to the webhooks of the object workflow
+func (s *server) notifyEvent(ctx context.Context, event *models.Event) {
+	if event.Object == nil {
+		return
+	}
+	wf := s.eventWorkflow(ctx, event.Object)
+	if wf == nil || wf.Notify == nil {
+		return
+	}
+	state := event.State
+	if state == nil && event.Type != models.DeleteEventType {
+		state, _ = s.store.GetProcessingState(ctx, event.Object.ObjectID())
+	}
+	s.notifier.Notify(ctx, wf.Notify, notify.EventPayload(event, state))
+}
+
+// notifyJobFinished sends the completed and failed job events to the webhooks
+func (s *server) notifyJobFinished(ctx context.Context, w *models.Workflow, objectID, jobID string, state *models.ProcessingState) {
+	if w == nil || w.Notify == nil {
+		return
+	}
+	s.notifier.Notify(ctx, w.Notify, notify.JobPayload(objectID, jobID, state))
+}
+
+// eventWorkflow returns the workflow of the event object or of its group
+// if the event has no workflow (e.g. the object is deleted)
+func (s *server) eventWorkflow(ctx context.Context, obj *models.Object) *models.Workflow {
+	if obj.Workflow.Data != nil && !obj.Workflow.Data.IsEmpty() {
+		return obj.Workflow.Data
+	}
+	group := obj.Bucket
+	if group == "" {
+		group, _, _ = strings.Cut(obj.ObjectID(), "/")
+	}
+	wf, _ := s.store.GetWorkflow(ctx, group)
+	return wf
+}
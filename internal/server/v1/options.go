@@ -1,10 +1,18 @@
 package v1
 
 import (
+	"time"
+
+	"github.com/apfs-io/apfs/internal/jobqueue"
+	"github.com/apfs-io/apfs/internal/notify"
 	"github.com/apfs-io/apfs/internal/storage"
 	"github.com/apfs-io/apfs/internal/storage/converters"
 	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/internal/storage/metacache"
 	"github.com/apfs-io/apfs/internal/storage/processor"
+	"github.com/apfs-io/apfs/internal/storage/quota"
+	"github.com/apfs-io/apfs/internal/storage/statestore"
+	"github.com/apfs-io/apfs/internal/storage/stats"
 	"github.com/apfs-io/apfs/internal/storio"
 	"github.com/apfs-io/apfs/internal/workflow"
 
@@ -43,17 +51,54 @@ type Options struct {
 	// Worker tags for workflow job affinity
 	workerTags []string
 
+	// On-the-fly image transformer (optional)
+	transformer Transformer
+
+	// Workflow job lease locker (optional)
+	jobLocker  kvaccessor.Locker
+	jobLockTTL time.Duration
+
+	// Queue of the workflow jobs dispatched one by one (optional)
+	jobQueue jobqueue.Queue
+
+	// Step output logs of the workflow executor
+	stepLogLimit         int
+	stepLogFlushInterval time.Duration
+
 	// Workflows bootstrap from filesystem on startup
 	workflowsDir         string
 	workflowsReconfigure bool
+
+	// Tiered placement of the files (optional)
+	storageTiers      []StorageTier
+	tiersMoveInterval time.Duration
+
+	// Local disk cache of the file reads (optional)
+	cacheDir  string
+	cacheSize int64
+
+	// Webhooks notifier of the workflow notify blocks
+	notifier *notify.Notifier
+
+	// Proxies allowed to set the principal of the upload rate limits
+	trustedProxies []string
 }
 
-func (opts *Options) _storage(database storage.DB, driver storio.StorageAccessor, stateKV kvaccessor.KVAccessor) *storage.Storage {
+func (opts *Options) _storage(database storage.DB, driver storio.StorageAccessor, stateKV kvaccessor.KVAccessor,
+	stateStore statestore.StateStore, metaCache metacache.MetaCache, statsCounter kvaccessor.Counter) *storage.Storage {
 	if opts.store == nil {
+		var (
+			recorder = stats.NewRecorder(statsCounter)
+			rate, _  = statsCounter.(kvaccessor.WindowCounter)
+		)
 		opts.store = storage.NewStorage(
 			storage.WithDatabase(database),
 			storage.WithDriver(driver),
 			storage.WithProcessingStatus(stateKV),
+			storage.WithStateStore(stateStore),
+			storage.WithMetaCache(metaCache),
+			storage.WithStats(recorder),
+			storage.WithQuota(quota.NewLimiter(recorder, rate)),
 		)
 	}
 	return opts.store
@@ -74,6 +119,17 @@ func (opts *Options) _processor(driver storio.StorageAccessor, stateKV kvaccesso
 	return proc
 }
 
+func (opts *Options) _notifier(pending kvaccessor.Hash) *notify.Notifier {
+	if opts.notifier == nil {
+		var notifyOpts []notify.Option
+		if pending != nil {
+			notifyOpts = append(notifyOpts, notify.WithPendingStore(pending))
+		}
+		opts.notifier = notify.NewNotifier(notifyOpts...)
+	}
+	return opts.notifier
+}
+
 // WithStageProcessingLimit custom option
 func WithStageProcessingLimit(limit int) Option {
 	return func(opts *Options) {
@@ -144,3 +200,68 @@ func WithWorkflowsBootstrap(dir string, reconfigure bool) Option {
 		opts.workflowsReconfigure = reconfigure
 	}
 }
+
+// WithTransformer enables the on-the-fly image transformation endpoint.
+func WithTransformer(transformer Transformer) Option {
+	return func(opts *Options) {
+		opts.transformer = transformer
+	}
+}
+
+// WithJobLocker makes the workflow executor hold a lease of every job it runs
+func WithJobLocker(locker kvaccessor.Locker, ttl time.Duration) Option {
+	return func(opts *Options) {
+		opts.jobLocker = locker
+		opts.jobLockTTL = ttl
+	}
+}
+
+// WithStepLogs sets the size of the step output tail kept in the step log
+// and the flush interval of the log of the running step
+func WithStepLogs(limit int, flushInterval time.Duration) Option {
+	return func(opts *Options) {
+		opts.stepLogLimit = limit
+		opts.stepLogFlushInterval = flushInterval
+	}
+}
+
+// WithJobQueue dispatches every ready workflow job to the queue instead of
+// running the ready jobs of the whole object on the event
+func WithJobQueue(queue jobqueue.Queue) Option {
+	return func(opts *Options) {
+		opts.jobQueue = queue
+	}
+}
+
+// WithStorageTiers places the files on the additional storages by the rules.
+// The aged files are moved every interval, zero interval disables the mover.
+func WithStorageTiers(tiers []StorageTier, moveInterval time.Duration) Option {
+	return func(opts *Options) {
+		opts.storageTiers = tiers
+		opts.tiersMoveInterval = moveInterval
+	}
+}
+
+// WithStorageCache caches the file reads of the storage in the local directory
+// bounded by the size in bytes. Empty directory disables the cache.
+func WithStorageCache(dir string, size int64) Option {
+	return func(opts *Options) {
+		opts.cacheDir = dir
+		opts.cacheSize = size
+	}
+}
+
+// WithNotifier sets the notifier of the workflow webhooks
+func WithNotifier(notifier *notify.Notifier) Option {
+	return func(opts *Options) {
+		opts.notifier = notifier
+	}
+}
+
+// WithTrustedProxies honours the principal header of the upload rate limits
+// from the listed addresses and CIDR networks only
+func WithTrustedProxies(proxies []string) Option {
+	return func(opts *Options) {
+		opts.trustedProxies = proxies
+	}
+}
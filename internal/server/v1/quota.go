@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/apfs-io/apfs/internal/context/ctxprincipal"
+	"github.com/apfs-io/apfs/internal/storage/quota"
+)
+
+// Principal headers of the upload rate limits, honoured from the trusted
+// proxies only. The client address is used otherwise.
+const (
+	principalMetadataKey = "x-apfs-principal"
+	principalHeader      = "X-Apfs-Principal"
+)
+
+// trustedProxies which are allowed to set the principal of the request
+type trustedProxies []netip.Prefix
+
+// parseTrustedProxies from the list of the addresses and CIDR networks
+func parseTrustedProxies(proxies []string) (trustedProxies, error) {
+	prefixes := make(trustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, errors.Wrap(err, "trusted proxy")
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "trusted proxy")
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// trusts returns true if the host is one of the trusted proxies
+func (tp trustedProxies) trusts(host string) bool {
+	if len(tp) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range tp {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// withGRPCPrincipal puts the principal of the gRPC call to the context
+func (tp trustedProxies) withGRPCPrincipal(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ctx
+	}
+	host := hostOf(p.Addr.String())
+	if md, ok := metadata.FromIncomingContext(ctx); ok && tp.trusts(host) {
+		if vals := md.Get(principalMetadataKey); len(vals) > 0 && vals[0] != "" {
+			return ctxprincipal.WithPrincipal(ctx, vals[0])
+		}
+	}
+	return ctxprincipal.WithPrincipal(ctx, host)
+}
+
+// withHTTPPrincipal puts the principal of the HTTP request to the context
+func (tp trustedProxies) withHTTPPrincipal(r *http.Request) context.Context {
+	host := hostOf(r.RemoteAddr)
+	if principal := r.Header.Get(principalHeader); principal != "" && tp.trusts(host) {
+		return ctxprincipal.WithPrincipal(r.Context(), principal)
+	}
+	return ctxprincipal.WithPrincipal(r.Context(), host)
+}
+
+// grpcQuotaError converts the exceeded quota to ResourceExhausted status
+// with the retry-after header, other errors are returned as is
+func grpcQuotaError(stream grpc.ServerStream, err error) error {
+	qerr, ok := quota.IsExceeded(err)
+	if !ok {
+		return err
+	}
+	if qerr.RetryAfter > 0 {
+		_ = stream.SetHeader(metadata.Pairs("retry-after", retryAfterSeconds(qerr.RetryAfter)))
+	}
+	return status.Error(codes.ResourceExhausted, qerr.Error())
+}
+
+// httpQuotaError writes 429 response with Retry-After header if the quota is exceeded
+func httpQuotaError(w http.ResponseWriter, err error) bool {
+	qerr, ok := quota.IsExceeded(err)
+	if !ok {
+		return false
+	}
+	if qerr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", retryAfterSeconds(qerr.RetryAfter))
+	}
+	errorResponseCode(w, http.StatusTooManyRequests, qerr.Error())
+	return true
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/apfs-io/apfs/internal/context/ctxprincipal"
+)
+
+func TestHTTPPrincipal(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.10 "})
+	require.NoError(t, err)
+
+	tests := []struct {
+		remote, header, principal string
+	}{
+		{remote: "203.0.113.5:4000", principal: "203.0.113.5"},
+		{remote: "203.0.113.5:4000", header: "user-1", principal: "203.0.113.5"},
+		{remote: "10.1.2.3:4000", header: "user-1", principal: "user-1"},
+		{remote: "192.168.1.10:4000", header: "user-1", principal: "user-1"},
+		{remote: "192.168.1.11:4000", header: "user-1", principal: "192.168.1.11"},
+		{remote: "10.1.2.3:4000", principal: "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/object", nil)
+		r.RemoteAddr = tt.remote
+		if tt.header != "" {
+			r.Header.Set(principalHeader, tt.header)
+		}
+		assert.Equal(t, tt.principal, ctxprincipal.Get(proxies.withHTTPPrincipal(r)), tt.remote)
+	}
+
+	// The header is ignored without the trusted proxies
+	r := httptest.NewRequest("POST", "/object", nil)
+	r.RemoteAddr = "10.1.2.3:4000"
+	r.Header.Set(principalHeader, "user-1")
+	assert.Equal(t, "10.1.2.3", ctxprincipal.Get(trustedProxies(nil).withHTTPPrincipal(r)))
+}
+
+func TestGRPCPrincipal(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	call := func(addr string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 4000}})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(principalMetadataKey, "user-1"))
+	}
+	assert.Equal(t, "user-1", ctxprincipal.Get(proxies.withGRPCPrincipal(call("10.1.2.3"))))
+	assert.Equal(t, "203.0.113.5", ctxprincipal.Get(proxies.withGRPCPrincipal(call("203.0.113.5"))))
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	_, err := parseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = parseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
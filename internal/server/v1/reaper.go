@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+// reaperBatchSize is the number of the processing objects checked per query
+const reaperBatchSize = 100
+
+// JobReaper recovers the workflow jobs left running by the lost workers and
+// the scheduled retries lost by the restarted workers
+type JobReaper interface {
+	// ReapJobs checks the processing objects every interval until the
+	// context is canceled. The job without timeout is stuck after the
+	// staleTimeout if the job locker is not configured. The objects with the
+	// retries overdue for the interval are dispatched again. Returns
+	// immediately if the objects can't be listed.
+	ReapJobs(ctx context.Context, interval, staleTimeout time.Duration) error
+}
+
+// ReapJobs implements JobReaper
+func (s *server) ReapJobs(ctx context.Context, interval, staleTimeout time.Duration) error {
+	if s.wfExecutor == nil || interval <= 0 {
+		return nil
+	}
+	if staleTimeout <= 0 {
+		staleTimeout = workflow.DefaultStaleJobTimeout
+	}
+	if _, err := s.store.ListObjects(ctx, &storage.ObjectFilter{Limit: 1}); err != nil {
+		ctxlogger.Get(ctx).Warn("stuck job reaper is disabled", zap.Error(err))
+		return nil
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		res, err := s.reapJobsOnce(ctx, staleTimeout, interval)
+		if err != nil && ctx.Err() == nil {
+			ctxlogger.Get(ctx).Error("stuck job recovery", zap.Error(err))
+		} else if res.recovered > 0 || res.redispatched > 0 {
+			ctxlogger.Get(ctx).Info("stuck job recovery",
+				zap.Int("recovered", res.recovered),
+				zap.Int("redispatched", res.redispatched))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reapResult of the single reaper pass
+type reapResult struct {
+	recovered    int // stuck jobs taken over from the lost workers
+	redispatched int // objects with the overdue retries dispatched again
+}
+
+// reapJobsOnce recovers the stuck jobs of all processing objects and sends
+// the update events of the recovered objects and of the objects with the
+// retries overdue for more than overdue to dispatch their jobs again.
+// The errors of the single objects are logged and do not stop the iteration.
+func (s *server) reapJobsOnce(ctx context.Context, staleTimeout, overdue time.Duration) (reapResult, error) {
+	var (
+		res   reapResult
+		after *storage.ObjectCursor
+	)
+	for {
+		// The recovered objects change the status, so the pages are
+		// iterated by the cursor instead of the offset
+		objects, err := s.store.ListObjects(ctx, &storage.ObjectFilter{
+			Status: []models.ObjectStatus{models.StatusProcessing},
+			Limit:  reaperBatchSize,
+			After:  after,
+		})
+		if err != nil || len(objects) == 0 {
+			return res, err
+		}
+		after = storage.CursorOf(objects[len(objects)-1])
+		for _, obj := range objects {
+			if err = ctx.Err(); err != nil {
+				return res, err
+			}
+			cObject, err := s.store.Object(ctx, obj.ID)
+			if err != nil {
+				continue
+			}
+			wf := s.store.ObjectWorkflow(ctx, cObject)
+			if wf == nil || wf.Version != "2" {
+				continue
+			}
+			recovered, err := s.wfExecutor.RecoverStuckJobs(ctx, wf, obj.ID, staleTimeout)
+			if err != nil {
+				ctxlogger.Get(ctx).Error("recover stuck jobs",
+					zap.String("object_id", obj.ID), zap.Error(err))
+			}
+			switch {
+			case recovered > 0:
+				s.updateObjectState(ctx, obj.ID)
+			case s.isRetryOverdue(ctx, obj.ID, overdue):
+				// The scheduled retry of the event stream lives in the worker
+				// process only and is lost by its restart
+				ctxlogger.Get(ctx).Info("dispatch overdue job retry", zap.String("object_id", obj.ID))
+				s.updateObjectState(ctx, obj.ID)
+				res.redispatched++
+			}
+			res.recovered += recovered
+		}
+		if len(objects) < reaperBatchSize {
+			return res, nil
+		}
+	}
+}
+
+// isRetryOverdue returns true if the scheduled job retry of the object is
+// not started for the overdue time after its attempt time
+func (s *server) isRetryOverdue(ctx context.Context, objectID string, overdue time.Duration) bool {
+	state, err := s.store.GetProcessingState(ctx, objectID)
+	if err != nil || state == nil || state.Status.IsTerminal() {
+		return false
+	}
+	next := state.NextAttemptAt()
+	return next != nil && time.Since(*next) > overdue
+}
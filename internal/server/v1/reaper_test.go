@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/fs"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/internal/storage/database/memory"
+	kvmemory "github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	statememory "github.com/apfs-io/apfs/internal/storage/statestore/memory"
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+// eventRecorder collects the published events
+type eventRecorder struct {
+	mx     sync.Mutex
+	events []*models.Event
+}
+
+func (r *eventRecorder) Publish(_ context.Context, messages ...any) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for _, msg := range messages {
+		r.events = append(r.events, msg.(*models.Event))
+	}
+	return nil
+}
+
+func (r *eventRecorder) objectIDs(etype models.EventType) []string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var ids []string
+	for _, event := range r.events {
+		if event.Type == etype {
+			ids = append(ids, event.Object.ObjectID())
+		}
+	}
+	return ids
+}
+
+func TestReapJobsOnce(t *testing.T) {
+	var (
+		ctx    = context.TODO()
+		now    = time.Now()
+		events = &eventRecorder{}
+	)
+	db, err := memory.Connect(ctx, "memory://")
+	require.NoError(t, err)
+	driver, err := fs.NewStorage(t.TempDir())
+	require.NoError(t, err)
+	store := storage.NewStorage(
+		storage.WithDatabase(db),
+		storage.WithDriver(driver),
+		storage.WithStateStore(statememory.New()),
+		storage.WithProcessingStatus(&kvmemory.KVMemory{}),
+	)
+	srv := &server{
+		store:       store,
+		eventStream: events,
+		wfExecutor:  workflow.NewExecutor(storage.NewWorkflowStorage(store), nil),
+	}
+	require.NoError(t, store.SetWorkflow(ctx, "images", &models.Workflow{
+		Version: "2",
+		Jobs: map[string]*models.WorkflowJob{
+			"convert": {Steps: []*models.WorkflowStep{{Uses: "shell", Run: "true"}}},
+		},
+	}))
+
+	jobs := map[string]*models.JobState{
+		// The retry scheduled by the lost worker
+		"overdue": {Status: models.JobStatusPending, NextAttemptAt: ptrTime(now.Add(-time.Hour))},
+		// The retry which is still waited for
+		"scheduled": {Status: models.JobStatusPending, NextAttemptAt: ptrTime(now.Add(time.Hour))},
+		// The job left running by the lost worker
+		"stuck": {Status: models.JobStatusRunning, StartedAt: ptrTime(now.Add(-2 * time.Hour))},
+	}
+	ids := map[string]string{}
+	for name, js := range jobs {
+		obj, err := store.Upload(ctx, "images", bytes.NewReader([]byte(name)))
+		require.NoError(t, err)
+		id := obj.ID().String()
+		ids[name] = id
+		require.NoError(t, db.Set(&models.Object{ID: id, Bucket: "images",
+			Status: models.StatusProcessing, CreatedAt: now}))
+		require.NoError(t, store.SetProcessingState(ctx, id, &models.ProcessingState{
+			ObjectID: id,
+			Status:   models.ProcessingStatusRunning,
+			Jobs:     map[string]*models.JobState{"convert": js},
+		}))
+	}
+
+	res, err := srv.reapJobsOnce(ctx, time.Hour, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, reapResult{recovered: 1, redispatched: 1}, res)
+	assert.ElementsMatch(t, []string{ids["overdue"], ids["stuck"]}, events.objectIDs(models.UpdateEventType))
+
+	// The stuck job isn't running anymore
+	state, err := store.GetProcessingState(ctx, ids["stuck"])
+	require.NoError(t, err)
+	assert.NotEqual(t, models.JobStatusRunning, state.Jobs["convert"].Status)
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
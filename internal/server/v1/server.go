@@ -2,26 +2,34 @@ package v1
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"sync"
+	"time"
 
 	nc "github.com/geniusrabbit/notificationcenter/v2"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	"github.com/apfs-io/apfs/internal/bootstrap/workflows"
+	"github.com/apfs-io/apfs/internal/context/ctxcorrelation"
 	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/driver/diskcache"
+	"github.com/apfs-io/apfs/internal/driver/tiered"
+	"github.com/apfs-io/apfs/internal/jobqueue"
+	"github.com/apfs-io/apfs/internal/notify"
 	"github.com/apfs-io/apfs/internal/object"
 	protocol "github.com/apfs-io/apfs/internal/server/protocol/v1"
 	"github.com/apfs-io/apfs/internal/storage"
 	"github.com/apfs-io/apfs/internal/storage/database"
 	"github.com/apfs-io/apfs/internal/storage/processor"
 	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/internal/stream"
+	"github.com/apfs-io/apfs/internal/tracing"
 	"github.com/apfs-io/apfs/internal/workflow"
 	"github.com/apfs-io/apfs/libs/storerrors"
 	"github.com/apfs-io/apfs/models"
@@ -63,11 +71,26 @@ type server struct {
 	// Worker tags for workflow job affinity
 	workerTags []string
 
+	// Queue of the workflow jobs dispatched one by one
+	jobQueue jobqueue.Queue
+
+	// On-the-fly image transformer
+	transformer Transformer
+
 	// Event stream object chanel
 	eventStream nc.Publisher
 
 	// Update state accessor
 	updateState updateStateI
+
+	// Webhooks of the workflow notify blocks
+	notifier *notify.Notifier
+
+	// Proxies allowed to set the principal of the upload
+	trustedProxies trustedProxies
+
+	// Running events and jobs waited by the drain
+	work workTracker
 }
 
 // NewServer object which implements RPC actions
@@ -76,6 +99,14 @@ func NewServer(ctx context.Context, connect, storageConnect, stateConnect string
 	for _, opt := range opts {
 		opt(&options)
 	}
+	proxies, err := parseTrustedProxies(options.trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	metaCache, connect, err := newMetaCache(connect)
+	if err != nil {
+		return nil, err
+	}
 	database, err := database.Open(ctx, connect)
 	if err != nil {
 		return nil, err
@@ -84,24 +115,49 @@ func NewServer(ctx context.Context, connect, storageConnect, stateConnect string
 	if err != nil {
 		return nil, err
 	}
+	var tieredDriver *tiered.Storage
+	if len(options.storageTiers) > 0 {
+		if tieredDriver, err = newTieredStorage(ctx, driver, options.storageTiers); err != nil {
+			return nil, err
+		}
+		driver = tieredDriver
+	}
+	if options.cacheDir != "" {
+		cache, err := diskcache.NewCache(options.cacheDir, options.cacheSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "storage cache")
+		}
+		driver = diskcache.NewStorage(driver, cache)
+	}
 	stateKV, err := newKVAccessor(stateConnect)
 	if err != nil {
 		return nil, err
 	}
+	stateStore, err := newStateStore(stateConnect)
+	if err != nil {
+		return nil, err
+	}
+	statsCounter, err := newStatsCounter(stateConnect, stateStore)
+	if err != nil {
+		return nil, err
+	}
+	pendingStore, err := newPendingStore(stateConnect, stateStore)
+	if err != nil {
+		return nil, err
+	}
 	pool := &sync.Pool{New: func() any {
 		return &bufferItem{buff: make([]byte, 10*1024)}
 	}}
-	store := options._storage(database, driver, stateKV)
+	store := options._storage(database, driver, stateKV, stateStore, metaCache, statsCounter)
 	if options.workflowsDir != "" {
 		if err := workflows.Bootstrap(ctx, store, options.workflowsDir, options.workflowsReconfigure, ctxlogger.Get(ctx)); err != nil {
 			return nil, errors.Wrap(err, "workflows bootstrap")
 		}
 	}
-	var wfExecutor *workflow.Executor
-	if options.wfRegistry != nil {
-		wfExecutor = workflow.NewExecutor(storage.NewWorkflowStorage(store), options.wfRegistry)
+	if tieredDriver != nil && options.tiersMoveInterval > 0 {
+		runTierMover(ctx, store, tieredDriver, options.tiersMoveInterval)
 	}
-	return &server{
+	srv := &server{
 		stageProcessingLimit: options.stageProcessingLimit,
 		taskProcessingLimit:  options.taskProcessingLimit,
 		bufferpool:           pool,
@@ -109,9 +165,27 @@ func NewServer(ctx context.Context, connect, storageConnect, stateConnect string
 		updateState:          options.updateState,
 		store:                store,
 		processor:            options._processor(driver, stateKV),
-		wfExecutor:           wfExecutor,
 		workerTags:           options.workerTags,
-	}, nil
+		jobQueue:             options.jobQueue,
+		transformer:          options.transformer,
+		notifier:             options._notifier(pendingStore),
+		trustedProxies:       proxies,
+	}
+	if pendingStore != nil {
+		go srv.runWebhookResumer(ctx, notify.DefaultPendingLease)
+	}
+	if options.wfRegistry != nil {
+		execOpts := []workflow.ExecutorOption{
+			workflow.WithStepLogs(options.stepLogLimit, options.stepLogFlushInterval),
+			workflow.WithJobStarted(srv.jobStarted),
+			workflow.WithJobFinished(srv.jobFinished),
+		}
+		if options.jobLocker != nil {
+			execOpts = append(execOpts, workflow.WithLocker(options.jobLocker, options.jobLockTTL))
+		}
+		srv.wfExecutor = workflow.NewExecutor(storage.NewWorkflowStorage(store), options.wfRegistry, execOpts...)
+	}
+	return srv, nil
 }
 
 // Head returns basic meta information from object
@@ -340,6 +414,35 @@ func (s *server) GetManifest(ctx context.Context, group *protocol.ManifestGroup)
 	}, nil
 }
 
+// GetGroupStats returns the usage statistics of the group
+func (s *server) GetGroupStats(ctx context.Context, group *protocol.ManifestGroup) (*protocol.GroupStatsResponse, error) {
+	ctxlogger.Get(ctx).Info("Get Group Stats",
+		zap.String("group", group.GetGroup()))
+
+	if group.GetGroup() == "" {
+		return &protocol.GroupStatsResponse{
+			Status:  protocol.ResponseStatusCode_FAILED,
+			Message: storage.ErrStorageInvalidGroupName.Error(),
+		}, nil
+	}
+
+	groupStats, err := s.store.GroupStats(ctx, group.GetGroup())
+	if err != nil {
+		ctxlogger.Get(ctx).Error("Get Group Stats",
+			zap.String("group", group.GetGroup()), zap.Error(err))
+		return &protocol.GroupStatsResponse{
+			Status:  protocol.ResponseStatusCode_FAILED,
+			Message: fmt.Sprintf("Group [%s] stats error: %s", group.GetGroup(), err.Error()),
+		}, nil
+	}
+
+	return &protocol.GroupStatsResponse{
+		Status:  protocol.ResponseStatusCode_OK,
+		Message: "Group stats successfully loaded",
+		Stats:   protocol.GroupStatsFromModel(groupStats),
+	}, nil
+}
+
 // Upload new object from the stream
 func (s *server) Upload(stream protocol.ServiceAPI_UploadServer) (err error) {
 	var (
@@ -350,9 +453,15 @@ func (s *server) Upload(stream protocol.ServiceAPI_UploadServer) (err error) {
 		overwrite bool
 		tags      []string
 		object    *protocol.Object
-		ctx       = stream.Context()
+		ctx       = s.trustedProxies.withGRPCPrincipal(stream.Context())
 	)
 
+	ctx, span := tracing.Start(ctx, "apfs.Upload", trace.SpanKindServer)
+	defer func() {
+		span.SetAttributes(tracing.ObjectAttributes(object.GetId())...)
+		tracing.End(span, err)
+	}()
+
 	ctxlogger.Get(ctx).Info("Upload")
 
 	defer func() {
@@ -410,13 +519,17 @@ func (s *server) Upload(stream protocol.ServiceAPI_UploadServer) (err error) {
 	}
 
 	// Reset file cursor
+	size, err := tmpfile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
 	if _, err = tmpfile.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
 
 	// Upload file into store
 	file, err = s.store.Upload(
-		stream.Context(),
+		ctx,
 		group,
 		tmpfile,
 		storage.WithTags(tags),
@@ -424,7 +537,9 @@ func (s *server) Upload(stream protocol.ServiceAPI_UploadServer) (err error) {
 			storio.ObjectIDType(customID),
 		),
 		storage.WithOverwrite(overwrite),
+		storage.WithContentLength(size),
 	)
+	err = grpcQuotaError(stream, err)
 	if err == nil {
 		object, err = s.protoObject(file)
 
@@ -460,7 +575,10 @@ func (s *server) UploadObject(ctx context.Context, group, customID string, overw
 	)
 	ctxlogger.Get(ctx).Error("UploadObject", zap.String("group", group))
 
+	ctx, span := tracing.Start(ctx, "apfs.UploadObject", trace.SpanKindServer)
 	defer func() {
+		span.SetAttributes(tracing.ObjectAttributes(object.GetId())...)
+		tracing.End(span, err)
 		if err != nil {
 			ctxlogger.Get(ctx).Error("UploadObject",
 				zap.String("group", group),
@@ -509,17 +627,36 @@ func (s *server) Receive(message nc.Message) error {
 	var (
 		err     error
 		ctx     = message.Context()
-		event   models.Event
+		event   *models.Event
 		cObject storio.Object
 	)
 
-	// Unpack event from body
-	if err := json.Unmarshal(message.Body(), &event); err != nil {
+	// Unpack event from body of any stream format
+	if event, err = stream.DecodeEvent(message.Body()); err != nil {
 		ctxlogger.Get(ctx).Error("event unmarshal", zap.Error(err))
 		return message.Ack()
 	}
 
+	// The draining worker doesn't start the new events, the event is
+	// published back to the stream for the other workers
+	if !s.beginWork() {
+		return s.requeueEvent(ctx, message, event)
+	}
+	defer s.endWork()
+
+	// The events caused by this one share its correlation ID
+	ctx = ctxcorrelation.WithCorrelationID(ctx, event.CorrelationID)
+
+	// Continue the trace of the event producer
+	ctx, span := tracing.Start(tracing.Extract(ctx, event.TraceContext),
+		"event.consume "+event.Type.String(), trace.SpanKindConsumer,
+		tracing.ObjectAttributes(event.Object.ObjectID())...)
+	defer func() { tracing.End(span, err) }()
+
 	fields := []zapcore.Field{zap.String("event", event.Type.String())}
+	if event.CorrelationID != "" {
+		fields = append(fields, zap.String("correlation_id", event.CorrelationID))
+	}
 
 	if event.Object != nil {
 		fields = append(fields,
@@ -562,11 +699,18 @@ func (s *server) Receive(message nc.Message) error {
 		cObject.Meta().CleanSubItems()
 		fallthrough
 	case models.UpdateEventType:
-		s.updateEventAction(ctx, &event, cObject, fields)
+		s.updateEventAction(ctx, event, cObject, fields)
 	case models.ProcessedEventType:
 		ctxlogger.Get(ctx).Info("processed object", fields...)
+		s.endUpdate(event.Object.ObjectID())
+		s.notifyEvent(ctx, event)
 	case models.DeleteEventType:
 		ctxlogger.Get(ctx).Info("delete object", fields...)
+		s.endUpdate(event.Object.ObjectID())
+		s.notifyEvent(ctx, event)
+	case models.JobStartedEventType, models.JobCompletedEventType, models.JobFailedEventType:
+		// The job events are for the external consumers
+		ctxlogger.Get(ctx).Debug("job event", append(fields, zap.String("job_id", event.JobID))...)
 	default:
 		ctxlogger.Get(ctx).Error("undefined event type", fields...)
 	}
@@ -601,9 +745,36 @@ func (s *server) updateEventAction(ctx context.Context, event *models.Event, cOb
 	}
 	// Remove redundant extra objects
 	_ = s.removeObjectItems(ctx, cObject, items, fields...)
+	// The cancelled processing is finished once the running jobs stop
+	if wf != nil && wf.Version == "2" {
+		state, _ := s.store.GetProcessingState(ctx, cObject.ID().String())
+		switch {
+		case state == nil:
+		case state.CancelledAt != nil:
+			if state.Status.IsTerminal() {
+				ctxlogger.Get(ctx).Info("processing cancelled", fields...)
+				s.sendEvent(ctx, models.ProcessedEventType, event.Object, workflow.ErrJobCancelled)
+			}
+			return
+		case state.Status == models.ProcessingStatusFailed:
+			ctxlogger.Get(ctx).Info("processing failed", fields...)
+			s.sendEvent(ctx, models.ProcessedEventType, event.Object, workflow.ProcessingError(state))
+			return
+		}
+	}
 	// Process next task actions
 	if wf != nil && wf.Version == "2" && len(wf.Jobs) > 0 && s.wfExecutor != nil {
-		isComplete, err = s.wfExecutor.ProcessObject(ctx, wf, cObject.ID().String(), s.workerTags, s.taskProcessingLimit)
+		if s.jobQueue != nil {
+			// The jobs are executed by the queue consumers which send
+			// the update event after every finished job
+			isComplete, err = s.wfExecutor.DispatchObject(ctx, wf, cObject.ID().String(), s.jobQueue)
+			if err == nil && !isComplete {
+				ctxlogger.Get(ctx).Debug("jobs dispatched", fields...)
+				return
+			}
+		} else {
+			isComplete, err = s.wfExecutor.ProcessObject(ctx, wf, cObject.ID().String(), s.workerTags, s.taskProcessingLimit)
+		}
 		if err == nil {
 			if reloaded, reloadErr := s.store.Object(ctx, cObject.ID()); reloadErr == nil {
 				cObject = reloaded
@@ -614,6 +785,10 @@ func (s *server) updateEventAction(ctx context.Context, event *models.Event, cOb
 			s.taskProcessingLimit, s.taskProcessingLimit)
 	}
 	switch {
+	case errors.Is(err, workflow.ErrWorkerShutdown):
+		// The interrupted jobs are continued by another worker
+		ctxlogger.Get(ctx).Warn("processing interrupted by the worker shutdown", fields...)
+		s.sendEvent(ctx, models.UpdateEventType, event.Object, nil)
 	case err != nil:
 		isNotFound := storerrors.IsNotFound(err)
 		ctxlogger.Get(ctx).Error("process",
@@ -650,11 +825,33 @@ func (s *server) updateEventAction(ctx context.Context, event *models.Event, cOb
 		}
 		s.sendEvent(ctx, models.ProcessedEventType, event.Object, nil)
 	default:
+		if delay := s.retryDelay(ctx, wf, cObject); delay > 0 {
+			ctxlogger.Get(ctx).Info("next step", append(fields, zap.Duration("retry_in", delay))...)
+			s.sendEventAfter(ctx, delay, models.UpdateEventType, event.Object)
+			break
+		}
 		ctxlogger.Get(ctx).Info("next step", fields...)
 		s.sendEvent(ctx, models.UpdateEventType, event.Object, nil)
 	}
 }
 
+// retryDelay returns the time left to the scheduled job retry if the
+// object has no other jobs to run
+func (s *server) retryDelay(ctx context.Context, wf *models.Workflow, cObject storio.Object) time.Duration {
+	if wf == nil || wf.Version != "2" {
+		return 0
+	}
+	state, _ := s.store.GetProcessingState(ctx, cObject.ID().String())
+	next := state.NextAttemptAt()
+	if next == nil {
+		return 0
+	}
+	if dag, err := workflow.BuildDAG(wf); err != nil || len(dag.ReadyJobs(state, s.workerTags)) > 0 {
+		return 0
+	}
+	return time.Until(*next)
+}
+
 func (s *server) removeObjectItems(ctx context.Context, cObject storio.Object,
 	items []*models.ItemMeta, fields ...zapcore.Field) error {
 	if len(items) == 0 {
@@ -848,21 +1045,28 @@ func (s *server) updateObjectState(ctx context.Context, objectID string) {
 	s.sendEvent(ctx, models.UpdateEventType, &models.Object{ID: objectID}, nil)
 }
 
+// sendEventAfter sends the event after the delay. The event is lost if the
+// server stops before.
+func (s *server) sendEventAfter(ctx context.Context, delay time.Duration, etype models.EventType, obj *models.Object) {
+	ctx = context.WithoutCancel(ctx)
+	time.AfterFunc(delay, func() { s.sendEvent(ctx, etype, obj, nil) })
+}
+
 func (s *server) sendEvent(ctx context.Context, etype models.EventType, obj *models.Object, err error) {
-	var emsg string
+	event := &models.Event{
+		Type:            etype,
+		Object:          obj,
+		WorkflowVersion: obj.WorkflowVersion(),
+	}
 	if err != nil {
-		emsg = err.Error()
-	}
-	objectID := ""
-	if obj != nil {
-		objectID = obj.ObjectID()
-	}
-	ctxlogger.Get(ctx).Info("sendEvent",
-		zap.String("event_type", etype.String()),
-		zap.String("object_id", objectID))
-	s.errorLog(ctx, s.eventStream.Publish(ctx, &models.Event{
-		Type:   etype,
-		Error:  emsg,
-		Object: obj,
-	}))
+		event.Error = err.Error()
+	}
+	if etype == models.ProcessedEventType && obj != nil {
+		// Snapshot of the workflow processing, nil for the legacy manifests
+		event.State, _ = s.store.GetProcessingState(ctx, obj.ObjectID())
+		if event.WorkflowVersion == "" && event.State != nil {
+			event.WorkflowVersion = event.State.ManifestVersion
+		}
+	}
+	s.publishEvent(ctx, event)
 }
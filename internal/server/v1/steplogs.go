@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	protocol "github.com/apfs-io/apfs/internal/server/protocol/v1"
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/libs/storerrors"
+	"github.com/apfs-io/apfs/models"
+)
+
+// stepLogPollInterval of the followed step log
+var stepLogPollInterval = time.Second
+
+// ErrInvalidStepLogRequest is returned if the object, job or step is not defined
+var ErrInvalidStepLogRequest = errors.New("[server] object id, job and step are required")
+
+// GetStepLogs streams the stdout/stderr log of the job step.
+// The log of the running step is flushed to the storage by the worker
+// periodically, with follow=true the new parts are sent until the job finishes.
+func (s *server) GetStepLogs(req *protocol.StepLogsRequest, stream protocol.ServiceAPI_GetStepLogsServer) error {
+	err := s.streamStepLog(stream.Context(), req, func(offset int64, data []byte) error {
+		return stream.Send(&protocol.StepLogChunk{Data: data, Offset: offset})
+	})
+	if errors.Is(err, ErrInvalidStepLogRequest) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
+
+// streamStepLog sends the step log by chunks with the offset of the chunk
+// in the whole step output
+func (s *server) streamStepLog(ctx context.Context, req *protocol.StepLogsRequest, send func(offset int64, data []byte) error) error {
+	if req.GetId() == "" || req.GetJob() == "" || req.GetStep() == "" {
+		return ErrInvalidStepLogRequest
+	}
+	var next int64
+	for {
+		// The state is checked before the log read, so the log read after
+		// the job end is the final one
+		finished := !req.GetFollow()
+		if !finished {
+			state, err := s.store.GetProcessingState(ctx, req.GetId())
+			if err != nil {
+				return err
+			}
+			finished = isStepLogFinished(state, req.GetJob())
+		}
+		data, err := s.store.ReadStepLog(ctx, req.GetId(), req.GetJob(), req.GetStep())
+		switch {
+		case err == nil:
+			offset, tail := workflow.ParseStepLog(data)
+			end := offset + int64(len(tail))
+			if end < next {
+				// The log was restarted by the next attempt of the job
+				next = 0
+			}
+			if from := max(next, offset); from < end {
+				if err = send(from, tail[from-offset:]); err != nil {
+					return err
+				}
+			}
+			next = end
+		case !storerrors.IsNotFound(err) || !req.GetFollow():
+			return err
+		}
+		if finished {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stepLogPollInterval):
+		}
+	}
+}
+
+// isStepLogFinished reports whether the log of the job can't be changed anymore
+func isStepLogFinished(state *models.ProcessingState, jobID string) bool {
+	if state == nil {
+		return true
+	}
+	if js := state.Jobs[jobID]; js != nil {
+		return js.Status.IsTerminal()
+	}
+	return state.Status.IsTerminal()
+}
+
+// GetStepLogsHTTPHandler streams the step log as the plain text
+//
+//	GET /v1/logs/{id}?job=convert&step=resize&follow=true
+func (s *ServerHTTPWrapper) GetStepLogsHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		query = r.URL.Query()
+		req   = &protocol.StepLogsRequest{
+			Id:     chi.URLParam(r, "*"),
+			Job:    query.Get("job"),
+			Step:   query.Get("step"),
+			Follow: gocast.Bool(query.Get("follow")),
+		}
+		flusher, _ = w.(http.Flusher)
+		started    bool
+	)
+	if req.Id == "" {
+		req.Id = query.Get("id")
+	}
+	err := s.streamStepLog(ctx, req, func(_ int64, data []byte) error {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	switch {
+	case started:
+		if err != nil && ctx.Err() == nil {
+			ctxlogger.Get(ctx).Error("stream step log",
+				zap.String("object_id", req.Id), zap.Error(err))
+		}
+	case errors.Is(err, ErrInvalidStepLogRequest):
+		errorResponseCode(w, http.StatusBadRequest, err.Error())
+	case storerrors.IsNotFound(err):
+		errorResponseCode(w, http.StatusNotFound, "step log not found")
+	case err != nil:
+		errorResponse(w, err.Error())
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
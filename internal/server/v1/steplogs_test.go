@@ -0,0 +1,223 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	protocol "github.com/apfs-io/apfs/internal/server/protocol/v1"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/libs/storerrors"
+	"github.com/apfs-io/apfs/models"
+)
+
+// stepLogPoll is the job status and the stored log seen by one poll
+type stepLogPoll struct {
+	status models.JobStatus
+	log    []byte // nil if the log isn't flushed yet
+}
+
+// stepLogDriver replays the polls of the followed step log, every state
+// read moves to the next poll
+type stepLogDriver struct {
+	storio.StorageAccessor
+
+	mx    sync.Mutex
+	polls []stepLogPoll
+	pos   int
+	reads int
+}
+
+func (d *stepLogDriver) ReadState(_ context.Context, id storio.ObjectID) (*models.ProcessingState, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if d.reads++; d.reads > 1 && d.pos < len(d.polls)-1 {
+		d.pos++
+	}
+	return &models.ProcessingState{
+		ObjectID: id.ID().String(),
+		Status:   models.ProcessingStatusRunning,
+		Jobs:     map[string]*models.JobState{"convert": {Status: d.polls[d.pos].status}},
+	}, nil
+}
+
+func (d *stepLogDriver) ReadFile(_ context.Context, _ storio.ObjectID, path string) (io.ReadCloser, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	log := d.polls[d.pos].log
+	if log == nil || path != workflow.StepLogPath("convert", "resize") {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(log)), nil
+}
+
+type stepLogChunk struct {
+	offset int64
+	data   string
+}
+
+func newStepLogServer(t *testing.T, polls ...stepLogPoll) *ServerHTTPWrapper {
+	t.Helper()
+	interval := stepLogPollInterval
+	stepLogPollInterval = time.Millisecond
+	t.Cleanup(func() { stepLogPollInterval = interval })
+	store := storage.NewStorage(
+		storage.WithDatabase(&storage.DatabaseMock{}),
+		storage.WithDriver(&stepLogDriver{polls: polls}),
+	)
+	return &ServerHTTPWrapper{server: &server{store: store}}
+}
+
+// truncatedLog returns the stored log of the output retaining limit bytes
+func truncatedLog(limit int, output string) []byte {
+	log := workflow.NewStepLog(limit)
+	_, _ = log.Write([]byte(output))
+	return log.Bytes()
+}
+
+func stepLogRequest(follow bool) *protocol.StepLogsRequest {
+	return &protocol.StepLogsRequest{Id: "images/1", Job: "convert", Step: "resize", Follow: follow}
+}
+
+func TestStreamStepLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		follow bool
+		polls  []stepLogPoll
+		chunks []stepLogChunk
+	}{
+		{
+			name:   "read once",
+			polls:  []stepLogPoll{{status: models.JobStatusRunning, log: []byte("hello")}},
+			chunks: []stepLogChunk{{0, "hello"}},
+		},
+		{
+			name:   "follow",
+			follow: true,
+			polls: []stepLogPoll{
+				{status: models.JobStatusRunning},
+				{status: models.JobStatusRunning, log: []byte("hello ")},
+				{status: models.JobStatusRunning, log: []byte("hello ")},
+				{status: models.JobStatusRunning, log: []byte("hello world")},
+				{status: models.JobStatusCompleted, log: []byte("hello world\n")},
+			},
+			chunks: []stepLogChunk{{0, "hello "}, {6, "world"}, {11, "\n"}},
+		},
+		{
+			name:   "truncated tail",
+			follow: true,
+			polls: []stepLogPoll{
+				{status: models.JobStatusRunning, log: truncatedLog(8, "0123456789")},
+				{status: models.JobStatusRunning, log: truncatedLog(8, "0123456789abcdefgh")},
+				{status: models.JobStatusCompleted, log: truncatedLog(8, "0123456789abcdefghxy")},
+			},
+			chunks: []stepLogChunk{{2, "23456789"}, {10, "abcdefgh"}, {18, "xy"}},
+		},
+		{
+			name:   "attempt restart",
+			follow: true,
+			polls: []stepLogPoll{
+				{status: models.JobStatusRunning, log: []byte("attempt 1\n")},
+				{status: models.JobStatusPending, log: []byte("attempt 1\n")},
+				{status: models.JobStatusRunning, log: []byte("a2")},
+				{status: models.JobStatusFailed, log: []byte("a2 done")},
+			},
+			chunks: []stepLogChunk{{0, "attempt 1\n"}, {0, "a2"}, {2, " done"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				srv    = newStepLogServer(t, tt.polls...)
+				chunks []stepLogChunk
+			)
+			err := srv.streamStepLog(context.TODO(), stepLogRequest(tt.follow), func(offset int64, data []byte) error {
+				chunks = append(chunks, stepLogChunk{offset, string(data)})
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.chunks, chunks)
+		})
+	}
+}
+
+func TestStreamStepLogErrors(t *testing.T) {
+	srv := newStepLogServer(t, stepLogPoll{status: models.JobStatusRunning})
+	send := func(int64, []byte) error { return nil }
+
+	err := srv.streamStepLog(context.TODO(), &protocol.StepLogsRequest{Id: "images/1", Job: "convert"}, send)
+	assert.ErrorIs(t, err, ErrInvalidStepLogRequest)
+
+	err = srv.streamStepLog(context.TODO(), stepLogRequest(false), send)
+	assert.True(t, storerrors.IsNotFound(err), err)
+
+	// The followed log of the running job is waited for until the cancel
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	err = srv.streamStepLog(ctx, stepLogRequest(true), send)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type stepLogStream struct {
+	grpc.ServerStreamingServer[protocol.StepLogChunk]
+	chunks []*protocol.StepLogChunk
+}
+
+func (s *stepLogStream) Context() context.Context { return context.TODO() }
+
+func (s *stepLogStream) Send(chunk *protocol.StepLogChunk) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+func TestGetStepLogs(t *testing.T) {
+	srv := newStepLogServer(t, stepLogPoll{status: models.JobStatusCompleted, log: []byte("done")})
+
+	stream := &stepLogStream{}
+	require.NoError(t, srv.GetStepLogs(stepLogRequest(true), stream))
+	require.Len(t, stream.chunks, 1)
+	assert.Equal(t, []byte("done"), stream.chunks[0].Data)
+
+	err := srv.GetStepLogs(&protocol.StepLogsRequest{Id: "images/1"}, &stepLogStream{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetStepLogsHTTPHandler(t *testing.T) {
+	request := func(srv *ServerHTTPWrapper, query string) *httptest.ResponseRecorder {
+		mux := chi.NewRouter()
+		mux.Get("/v1/logs/*", srv.GetStepLogsHTTPHandler)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/logs/images/1?"+query, nil))
+		return rec
+	}
+
+	srv := newStepLogServer(t,
+		stepLogPoll{status: models.JobStatusRunning, log: []byte("hello ")},
+		stepLogPoll{status: models.JobStatusCompleted, log: []byte("hello world")},
+	)
+	rec := request(srv, "job=convert&step=resize&follow=true")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "hello world", rec.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, request(srv, "job=convert").Code)
+
+	srv = newStepLogServer(t, stepLogPoll{status: models.JobStatusCompleted})
+	assert.Equal(t, http.StatusNotFound, request(srv, "job=convert&step=resize").Code)
+	assert.Equal(t, http.StatusNoContent, request(srv, "job=convert&step=resize&follow=true").Code)
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/driver/tiered"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/internal/storio"
+)
+
+// StorageTier is the additional storage of the files matched the rule
+type StorageTier struct {
+	Name    string
+	Connect string
+	Rule    tiered.Rule
+}
+
+// newTieredStorage places the files of the default storage on the tiers
+func newTieredStorage(ctx context.Context, primary storio.StorageAccessor, tiers []StorageTier) (*tiered.Storage, error) {
+	list := make([]*tiered.Tier, 0, len(tiers))
+	for _, tier := range tiers {
+		files, err := newStorage(ctx, tier.Connect)
+		if err != nil {
+			return nil, errors.Wrap(err, "tier "+tier.Name)
+		}
+		list = append(list, &tiered.Tier{Name: tier.Name, Files: files, Rule: tier.Rule})
+	}
+	return tiered.NewStorage(primary, list...), nil
+}
+
+// runTierMover moves the aged files between the tiers in background.
+// The objects are listed by the database index.
+func runTierMover(ctx context.Context, store *storage.Storage, driver *tiered.Storage, interval time.Duration) {
+	if _, err := store.ListObjects(ctx, &storage.ObjectFilter{Limit: 1}); err != nil {
+		ctxlogger.Get(ctx).Warn("tiered storage mover is disabled", zap.Error(err))
+		return
+	}
+	mover := tiered.NewMover(driver,
+		tierObjectLister(store),
+		tiered.WithOnMoved(func(ctx context.Context, id storio.ObjectID) {
+			obj, err := driver.Open(ctx, id)
+			if err == nil {
+				err = store.UpdateObjectInfo(ctx, obj)
+			}
+			if err != nil {
+				ctxlogger.Get(ctx).Error("refresh moved object",
+					zap.String("object_id", id.ID().String()), zap.Error(err))
+			}
+		}),
+	)
+	go mover.Run(ctx, interval)
+}
+
+// tierObjectLister pages the objects of the database index by the cursor
+func tierObjectLister(store *storage.Storage) tiered.ObjectLister {
+	return func(ctx context.Context, cursor string, limit int) ([]storio.ObjectID, string, error) {
+		filter := &storage.ObjectFilter{Limit: limit}
+		if cursor != "" {
+			after, err := decodeObjectCursor(cursor)
+			if err != nil {
+				return nil, "", err
+			}
+			filter.After = after
+		}
+		objects, err := store.ListObjects(ctx, filter)
+		if err != nil || len(objects) == 0 {
+			return nil, "", err
+		}
+		ids := make([]storio.ObjectID, 0, len(objects))
+		for _, obj := range objects {
+			ids = append(ids, storio.ObjectIDType(obj.ID))
+		}
+		return ids, encodeObjectCursor(storage.CursorOf(objects[len(objects)-1])), nil
+	}
+}
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/fs"
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/internal/storage/database/memory"
+	"github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+func TestTierObjectLister(t *testing.T) {
+	var (
+		ctx = context.TODO()
+		now = time.Now()
+	)
+	db, err := memory.Connect(ctx, "memory://")
+	require.NoError(t, err)
+	driver, err := fs.NewStorage(t.TempDir())
+	require.NoError(t, err)
+	list := tierObjectLister(storage.NewStorage(storage.WithDatabase(db), storage.WithDriver(driver)))
+
+	for i, id := range []string{"images/1", "images/2", "images/3", "images/4"} {
+		require.NoError(t, db.Set(&models.Object{ID: id, Bucket: "images",
+			CreatedAt: now.Add(time.Duration(i) * time.Second)}))
+	}
+
+	ids, cursor, err := list(ctx, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []storio.ObjectID{storio.ObjectIDType("images/1"), storio.ObjectIDType("images/2")}, ids)
+
+	// The removed objects don't shift the next page
+	require.NoError(t, db.Delete("images/1"))
+	ids, cursor, err = list(ctx, cursor, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []storio.ObjectID{storio.ObjectIDType("images/3"), storio.ObjectIDType("images/4")}, ids)
+
+	ids, _, err = list(ctx, cursor, 2)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, _, err = list(ctx, "broken", 2)
+	assert.Error(t, err)
+}
@@ -4,6 +4,12 @@ type updateStateI interface {
 	TryBeginUpdate(key any) bool
 }
 
+// updateStateEnder releases the update of the key once the processing of
+// the object is finished
+type updateStateEnder interface {
+	EndUpdate(key any)
+}
+
 // UpdateStateFunc provides wrapper of function as state interface
 type UpdateStateFunc func(key any) bool
 
@@ -11,3 +17,28 @@ type UpdateStateFunc func(key any) bool
 func (f UpdateStateFunc) TryBeginUpdate(key any) bool {
 	return f(key)
 }
+
+// UpdateStateLease provides wrapper of begin and end functions of the update
+type UpdateStateLease struct {
+	Begin UpdateStateFunc
+	End   func(key any)
+}
+
+// TryBeginUpdate state update
+func (l UpdateStateLease) TryBeginUpdate(key any) bool {
+	return l.Begin(key)
+}
+
+// EndUpdate of the key
+func (l UpdateStateLease) EndUpdate(key any) {
+	if l.End != nil {
+		l.End(key)
+	}
+}
+
+// endUpdate releases the update of the object if the state supports it
+func (s *server) endUpdate(objectID string) {
+	if ender, _ := s.updateState.(updateStateEnder); ender != nil && objectID != "" {
+		ender.EndUpdate(objectID)
+	}
+}
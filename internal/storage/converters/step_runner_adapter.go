@@ -12,7 +12,7 @@ import (
 // workflow.StepRunner by the v2 workflow Executor.
 //
 // Mapping from WorkflowStep to legacy Action:
-//   - step.Uses → action.Name (the converter's ActionName)
+//   - step.Uses → action.Name ("image/resize" → "image.resize")
 //   - step.With  → action.Values (parameters forwarded verbatim)
 //
 // File output: if step.With["target"] is set, the produced io.Reader is
@@ -33,16 +33,26 @@ func NewStepRunner(actionName string, conv Converter) workflow.StepRunner {
 }
 
 // CanRun returns true when step.Uses equals the wrapped action name or starts
-// with "<actionName>/".
+// with "<actionName>/" (or the legacy "<actionName>." form).
 func (r *ConverterStepRunner) CanRun(step *models.WorkflowStep) bool {
 	return step.Uses == r.actionName ||
-		strings.HasPrefix(step.Uses, r.actionName+"/")
+		strings.HasPrefix(step.Uses, r.actionName+"/") ||
+		strings.HasPrefix(step.Uses, r.actionName+".")
+}
+
+// legacyActionName converts the workflow uses: value into the converter
+// action name, e.g. "image/resize" → "image.resize".
+func (r *ConverterStepRunner) legacyActionName(uses string) string {
+	if name, ok := strings.CutPrefix(uses, r.actionName+"/"); ok {
+		return r.actionName + "." + name
+	}
+	return uses
 }
 
 // Run executes the step by delegating to the wrapped Converter.
 func (r *ConverterStepRunner) Run(_ context.Context, step *models.WorkflowStep, in workflow.StepInput) (workflow.StepOutput, error) {
 	action := &models.Action{
-		Name:   r.actionName,
+		Name:   r.legacyActionName(step.Uses),
 		Values: step.With,
 	}
 
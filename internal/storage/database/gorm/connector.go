@@ -43,7 +43,7 @@ func New(ctx context.Context, connect string, automigrate, debug bool) (*connect
 		conn = conn.Set("gorm:table_options", "ENGINE=InnoDB")
 	}
 	if automigrate {
-		if err := conn.AutoMigrate(&models.Object{}); err != nil {
+		if err := Migrate(conn); err != nil {
 			return nil, err
 		}
 	}
@@ -59,30 +59,52 @@ func (db *connector) Get(objID string) (*models.Object, error) {
 	return &obj, res.Error
 }
 
-// Set file base object
+// Set file base object and update the object index
 func (db *connector) Set(obj *models.Object) error {
-	var (
-		count int64
-		res   *gorm.DB
-	)
-	res = db.conn.Model((*models.Object)(nil)).
-		Where("path = ?", obj.ID).
-		Count(&count)
-	if res.Error != nil {
-		return res.Error
-	}
-	if count > 0 {
-		res = db.conn.Where("path = ?", obj.Path).Save(obj)
-	} else {
-		res = db.conn.Create(obj)
-	}
-	return res.Error
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		var (
+			count int64
+			res   *gorm.DB
+		)
+		res = tx.Model((*models.Object)(nil)).
+			Where("path = ?", obj.ID).
+			Count(&count)
+		if res.Error != nil {
+			return res.Error
+		}
+		if count > 0 {
+			res = tx.Where("path = ?", obj.Path).Save(obj)
+		} else {
+			res = tx.Create(obj)
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		return updateIndex(tx.Session(&gorm.Session{NewDB: true}), obj)
+	})
 }
 
-// Delete file base object
+// Delete file base object and its index records
 func (db *connector) Delete(path string) error {
-	return db.conn.Where("path = ?", path).
-		Delete((*models.Object)(nil)).Error
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		err := tx.Model((*models.Object)(nil)).
+			Where("path = ?", path).Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("path = ?", path).
+			Delete(&models.Object{}).Error
+		if err != nil {
+			return err
+		}
+		for _, id := range append(ids, path) {
+			if err = deleteIndex(tx.Session(&gorm.Session{NewDB: true}), id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Close database connection
@@ -0,0 +1,246 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/models"
+)
+
+// indexObject is the queryable projection of the object record
+type indexObject struct {
+	ID               string                  `gorm:"primaryKey;size:255"`
+	Bucket           string                  `gorm:"size:255;index:idx_object_index_bucket_created,priority:1"`
+	Path             string                  `gorm:"size:1024"`
+	Status           models.ObjectStatus     `gorm:"size:32;index"`
+	ProcessingStatus models.ProcessingStatus `gorm:"size:32;index"` // Status of the processing state
+	ContentType      string                  `gorm:"size:255;index"`
+	Type             models.ObjectType       `gorm:"size:32"`
+	Size             uint64                  // Size of the original file
+	DerivedSize      uint64                  // Total size of the derived items
+	ItemCount        int
+	WorkflowVersion  string    `gorm:"size:64"`
+	CreatedAt        time.Time `gorm:"index:idx_object_index_bucket_created,priority:2"`
+	UpdatedAt        time.Time `gorm:"index"`
+}
+
+func (indexObject) TableName() string { return "object_index" }
+
+// indexItem is the derived item of the object
+type indexItem struct {
+	ObjectID    string            `gorm:"primaryKey;size:255"`
+	Name        string            `gorm:"primaryKey;size:255"`
+	Role        string            `gorm:"size:255;index"`
+	Type        models.ObjectType `gorm:"size:32"`
+	ContentType string            `gorm:"size:255"`
+	Size        int64
+	Width       int
+	Height      int
+	UpdatedAt   time.Time
+}
+
+func (indexItem) TableName() string { return "object_index_item" }
+
+// indexTag links the object with the tag
+type indexTag struct {
+	ObjectID string `gorm:"primaryKey;size:255"`
+	Tag      string `gorm:"primaryKey;size:255;index"`
+}
+
+func (indexTag) TableName() string { return "object_index_tag" }
+
+// updateIndex replaces the index records of the object, the processing
+// status maintained by SetProcessingStatus is kept
+func updateIndex(tx *gorm.DB, obj *models.Object) error {
+	var processing []models.ProcessingStatus
+	err := tx.Model((*indexObject)(nil)).Where("id = ?", obj.ID).
+		Limit(1).Pluck("processing_status", &processing).Error
+	if err != nil {
+		return err
+	}
+	if err = deleteIndex(tx, obj.ID); err != nil {
+		return err
+	}
+	idx := &indexObject{
+		ID:              obj.ID,
+		Bucket:          obj.Bucket,
+		Path:            obj.Path,
+		Status:          obj.Status,
+		ContentType:     obj.ContentType,
+		Type:            obj.Type,
+		Size:            obj.Size,
+		WorkflowVersion: obj.WorkflowVersion(),
+		CreatedAt:       obj.CreatedAt,
+		UpdatedAt:       obj.UpdatedAt,
+	}
+	var (
+		items []*indexItem
+		names = map[string]struct{}{}
+	)
+	if meta := obj.Meta.Data; meta != nil {
+		for _, item := range meta.Items {
+			name := item.EffectivePath()
+			if _, ok := names[name]; ok || name == "" {
+				continue
+			}
+			names[name] = struct{}{}
+			idx.DerivedSize += uint64(max(item.Size, 0))
+			items = append(items, &indexItem{
+				ObjectID:    obj.ID,
+				Name:        name,
+				Role:        item.Role,
+				Type:        item.Type,
+				ContentType: item.ContentType,
+				Size:        item.Size,
+				Width:       item.Width,
+				Height:      item.Height,
+				UpdatedAt:   item.UpdatedAt,
+			})
+		}
+	}
+	if len(processing) > 0 {
+		idx.ProcessingStatus = processing[0]
+	}
+	idx.ItemCount = len(items)
+	if err := tx.Create(idx).Error; err != nil {
+		return err
+	}
+	if len(items) > 0 {
+		if err := tx.Create(items).Error; err != nil {
+			return err
+		}
+	}
+	var tags []*indexTag
+	for _, tag := range uniqueTags(obj.Tags) {
+		tags = append(tags, &indexTag{ObjectID: obj.ID, Tag: tag})
+	}
+	if len(tags) > 0 {
+		return tx.Create(tags).Error
+	}
+	return nil
+}
+
+// deleteIndex removes the index records of the object
+func deleteIndex(tx *gorm.DB, id string) error {
+	for _, model := range []any{&indexTag{}, &indexItem{}} {
+		if err := tx.Where("object_id = ?", id).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id = ?", id).Delete(&indexObject{}).Error
+}
+
+func uniqueTags(tags []string) []string {
+	var (
+		resp = make([]string, 0, len(tags))
+		seen = make(map[string]struct{}, len(tags))
+	)
+	for _, tag := range tags {
+		if _, ok := seen[tag]; ok || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		resp = append(resp, tag)
+	}
+	return resp
+}
+
+// ListObjects implements storage.ObjectIndex
+func (db *connector) ListObjects(ctx context.Context, filter *storage.ObjectFilter) ([]*models.Object, error) {
+	var ids []string
+	query := filterQuery(db.conn.WithContext(ctx), filter).
+		Order("created_at, id")
+	if filter != nil && filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter != nil && filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var objects []*models.Object
+	if err := db.conn.WithContext(ctx).Where("id IN ?", ids).Find(&objects).Error; err != nil {
+		return nil, err
+	}
+	// Keep the order of the index
+	byID := make(map[string]*models.Object, len(objects))
+	for _, obj := range objects {
+		byID[obj.ID] = obj
+	}
+	resp := make([]*models.Object, 0, len(objects))
+	for _, id := range ids {
+		if obj := byID[id]; obj != nil {
+			resp = append(resp, obj)
+		}
+	}
+	return resp, nil
+}
+
+// SetProcessingStatus implements storage.ObjectIndex
+// The object update time stays unchanged, it reflects the object itself
+func (db *connector) SetProcessingStatus(ctx context.Context, objectID string, status models.ProcessingStatus) error {
+	return db.conn.WithContext(ctx).Model((*indexObject)(nil)).
+		Where("id = ?", objectID).UpdateColumn("processing_status", status).Error
+}
+
+// CountObjects implements storage.ObjectIndex
+func (db *connector) CountObjects(ctx context.Context, filter *storage.ObjectFilter) (int64, error) {
+	var count int64
+	err := filterQuery(db.conn.WithContext(ctx), filter).Count(&count).Error
+	return count, err
+}
+
+func filterQuery(conn *gorm.DB, filter *storage.ObjectFilter) *gorm.DB {
+	query := conn.Model((*indexObject)(nil))
+	if filter == nil {
+		return query
+	}
+	if filter.Group != "" {
+		query = query.Where("bucket = ?", filter.Group)
+	}
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+	if len(filter.Processing) > 0 {
+		query = query.Where("processing_status IN ?", filter.Processing)
+	}
+	if len(filter.Type) > 0 {
+		query = query.Where("type IN ?", filter.Type)
+	}
+	if filter.ContentType != "" {
+		query = query.Where("content_type LIKE ?", filter.ContentType+"%")
+	}
+	if filter.MinSize > 0 {
+		query = query.Where("size >= ?", filter.MinSize)
+	}
+	if filter.MaxSize > 0 {
+		query = query.Where("size <= ?", filter.MaxSize)
+	}
+	if filter.WorkflowVersion != "" {
+		query = query.Where("workflow_version = ?", filter.WorkflowVersion)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at > ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		query = query.Where("updated_at < ?", filter.UpdatedBefore)
+	}
+	if after := filter.After; after != nil {
+		query = query.Where("created_at > ? OR (created_at = ? AND id > ?)",
+			after.CreatedAt, after.CreatedAt, after.ID)
+	}
+	for _, tag := range uniqueTags(filter.Tags) {
+		query = query.Where("EXISTS (SELECT 1 FROM object_index_tag t WHERE t.object_id = object_index.id AND t.tag = ?)", tag)
+	}
+	return query
+}
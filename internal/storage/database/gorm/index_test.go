@@ -0,0 +1,207 @@
+package gorm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geniusrabbit/gosql/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/models"
+)
+
+// openTestDB opens the empty sqlite database in the temporary directory
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "apfs.db")),
+		&gorm.Config{SkipDefaultTransaction: true})
+	if err == nil {
+		err = conn.Exec("SELECT 1").Error
+	}
+	if err != nil {
+		t.Skipf("sqlite is not available: %v", err)
+	}
+	return conn
+}
+
+func newTestConnector(t *testing.T) *connector {
+	t.Helper()
+	conn := openTestDB(t)
+	require.NoError(t, Migrate(conn))
+	return &connector{conn: conn}
+}
+
+func testObject(id, contentType string, status models.ObjectStatus, size uint64, created time.Time, tags ...string) *models.Object {
+	bucket, _, _ := strings.Cut(id, "/")
+	return &models.Object{
+		ID:          id,
+		Bucket:      bucket,
+		Path:        id,
+		Status:      status,
+		ContentType: contentType,
+		Type:        models.ObjectTypeByContentType(contentType),
+		Size:        size,
+		Tags:        gosql.NullableJSONArray[string](tags),
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	}
+}
+
+func indexIDs(objects []*models.Object) []string {
+	ids := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		ids = append(ids, obj.ID)
+	}
+	return ids
+}
+
+func TestIndexSync(t *testing.T) {
+	var (
+		db      = newTestConnector(t)
+		created = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		obj     = testObject("images/1", "image/png", models.StatusOK, 100, created, "a", "b", "a")
+	)
+	obj.Meta = gosql.NullableJSON[models.Meta]{Data: &models.Meta{Items: []*models.ItemMeta{
+		{Name: "thumb", NameExt: "jpg", Role: "thumb", Type: models.TypeImage, Size: 10, Width: 32, Height: 32},
+		{Name: "small", NameExt: "jpg", Role: "small", Type: models.TypeImage, Size: 20},
+		{Name: "thumb", NameExt: "jpg", Role: "thumb", Size: 10}, // duplicate path
+	}}}
+	obj.Workflow = gosql.NullableJSON[models.Workflow]{Data: &models.Workflow{Version: "v1"}}
+	require.NoError(t, db.Set(obj))
+
+	var idx indexObject
+	require.NoError(t, db.conn.First(&idx, "id = ?", obj.ID).Error)
+	assert.Equal(t, "images", idx.Bucket)
+	assert.Equal(t, uint64(30), idx.DerivedSize)
+	assert.Equal(t, 2, idx.ItemCount)
+	assert.Equal(t, "v1", idx.WorkflowVersion)
+
+	var items []indexItem
+	require.NoError(t, db.conn.Order("name").Find(&items, "object_id = ?", obj.ID).Error)
+	require.Len(t, items, 2)
+	assert.Equal(t, "small.jpg", items[0].Name)
+	assert.Equal(t, "thumb.jpg", items[1].Name)
+	assert.Equal(t, 32, items[1].Width)
+
+	var tags []string
+	require.NoError(t, db.conn.Model((*indexTag)(nil)).Order("tag").
+		Where("object_id = ?", obj.ID).Pluck("tag", &tags).Error)
+	assert.Equal(t, []string{"a", "b"}, tags)
+
+	// The update replaces the index records
+	obj.Status = models.StatusError
+	obj.Tags = gosql.NullableJSONArray[string]{"c"}
+	obj.Meta.Data.Items = obj.Meta.Data.Items[:1]
+	require.NoError(t, db.Set(obj))
+
+	require.NoError(t, db.conn.First(&idx, "id = ?", obj.ID).Error)
+	assert.Equal(t, models.StatusError, idx.Status)
+	assert.Equal(t, 1, idx.ItemCount)
+	assert.Equal(t, uint64(10), idx.DerivedSize)
+	require.NoError(t, db.conn.Model((*indexTag)(nil)).
+		Where("object_id = ?", obj.ID).Pluck("tag", &tags).Error)
+	assert.Equal(t, []string{"c"}, tags)
+
+	// The processing status is kept by the update
+	require.NoError(t, db.SetProcessingStatus(context.TODO(), obj.ID, models.ProcessingStatusRunning))
+	require.NoError(t, db.Set(obj))
+	require.NoError(t, db.conn.First(&idx, "id = ?", obj.ID).Error)
+	assert.Equal(t, models.ProcessingStatusRunning, idx.ProcessingStatus)
+
+	// The delete removes all of them
+	require.NoError(t, db.Delete(obj.Path))
+	for _, model := range []any{(*models.Object)(nil), (*indexObject)(nil), (*indexItem)(nil), (*indexTag)(nil)} {
+		var count int64
+		require.NoError(t, db.conn.Model(model).Count(&count).Error)
+		assert.Zero(t, count, "%T", model)
+	}
+}
+
+func TestObjectFilter(t *testing.T) {
+	var (
+		ctx  = context.TODO()
+		db   = newTestConnector(t)
+		base = time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	)
+	objects := []*models.Object{
+		testObject("images/1", "image/png", models.StatusOK, 100, base, "a", "b"),
+		testObject("images/2", "image/jpeg", models.StatusError, 2000, base.Add(time.Hour), "a"),
+		testObject("images/3", "image/jpeg", models.StatusOK, 3000, base.Add(time.Hour)),
+		testObject("videos/1", "video/mp4", models.StatusOK, 5000, base.Add(2*time.Hour), "b"),
+	}
+	objects[1].Workflow = gosql.NullableJSON[models.Workflow]{Data: &models.Workflow{Version: "v2"}}
+	objects[3].UpdatedAt = base.Add(3 * time.Hour)
+	for _, obj := range objects {
+		require.NoError(t, db.Set(obj))
+	}
+	require.NoError(t, db.SetProcessingStatus(ctx, "images/2", models.ProcessingStatusFailed))
+	require.NoError(t, db.SetProcessingStatus(ctx, "videos/1", models.ProcessingStatusRunning))
+
+	tests := []struct {
+		name   string
+		filter *storage.ObjectFilter
+		ids    []string
+	}{
+		{name: "all", ids: []string{"images/1", "images/2", "images/3", "videos/1"}},
+		{name: "group", filter: &storage.ObjectFilter{Group: "videos"}, ids: []string{"videos/1"}},
+		{name: "status", filter: &storage.ObjectFilter{Status: []models.ObjectStatus{models.StatusError}},
+			ids: []string{"images/2"}},
+		{name: "processing status", filter: &storage.ObjectFilter{Processing: []models.ProcessingStatus{
+			models.ProcessingStatusFailed, models.ProcessingStatusRunning}}, ids: []string{"images/2", "videos/1"}},
+		{name: "type", filter: &storage.ObjectFilter{Type: []models.ObjectType{models.TypeVideo}},
+			ids: []string{"videos/1"}},
+		{name: "content type", filter: &storage.ObjectFilter{ContentType: "image/j"},
+			ids: []string{"images/2", "images/3"}},
+		{name: "tags", filter: &storage.ObjectFilter{Tags: []string{"a", "b"}}, ids: []string{"images/1"}},
+		{name: "min size", filter: &storage.ObjectFilter{MinSize: 3000}, ids: []string{"images/3", "videos/1"}},
+		{name: "max size", filter: &storage.ObjectFilter{MaxSize: 2000}, ids: []string{"images/1", "images/2"}},
+		{name: "workflow version", filter: &storage.ObjectFilter{WorkflowVersion: "v2"}, ids: []string{"images/2"}},
+		{name: "created after", filter: &storage.ObjectFilter{CreatedAfter: base.Add(time.Hour)},
+			ids: []string{"videos/1"}},
+		{name: "created before", filter: &storage.ObjectFilter{CreatedBefore: base.Add(time.Hour)},
+			ids: []string{"images/1"}},
+		{name: "updated before", filter: &storage.ObjectFilter{UpdatedBefore: base.Add(3 * time.Hour)},
+			ids: []string{"images/1", "images/2", "images/3"}},
+		{name: "limit offset", filter: &storage.ObjectFilter{Limit: 2, Offset: 1},
+			ids: []string{"images/2", "images/3"}},
+		{name: "after cursor", filter: &storage.ObjectFilter{After: storage.CursorOf(objects[1])},
+			ids: []string{"images/3", "videos/1"}},
+		{name: "after cursor with limit", filter: &storage.ObjectFilter{After: storage.CursorOf(objects[0]), Limit: 1},
+			ids: []string{"images/2"}},
+		{name: "no match", filter: &storage.ObjectFilter{Group: "videos", MaxSize: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := db.ListObjects(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.ids), len(list))
+			if len(tt.ids) > 0 {
+				assert.Equal(t, tt.ids, indexIDs(list))
+			}
+			if tt.filter == nil || tt.filter.Limit == 0 {
+				count, err := db.CountObjects(ctx, tt.filter)
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(tt.ids)), count)
+			}
+		})
+	}
+
+	// The cursor page doesn't shift when the listed objects stop matching
+	filter := &storage.ObjectFilter{Status: []models.ObjectStatus{models.StatusOK}, Limit: 1}
+	list, err := db.ListObjects(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	objects[0].Status = models.StatusError
+	require.NoError(t, db.Set(objects[0]))
+	filter.After = storage.CursorOf(list[0])
+	list, err = db.ListObjects(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"images/3"}, indexIDs(list))
+}
@@ -0,0 +1,114 @@
+package gorm
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// schemaMigration is the applied migration record
+type schemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+type migration struct {
+	version int
+	name    string
+	up      func(tx *gorm.DB) error
+}
+
+// migrations of the database schema. Append the new versions only,
+// the applied migrations must never be changed.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create object table",
+		up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&models.Object{}) {
+				// The table was created by AutoMigrate of the previous releases
+				return tx.AutoMigrate(&models.Object{})
+			}
+			return tx.Migrator().CreateTable(&models.Object{})
+		},
+	},
+	{
+		version: 2,
+		name:    "create object index",
+		up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&indexObject{}, &indexItem{}, &indexTag{})
+		},
+	},
+	{
+		version: 3,
+		name:    "backfill object index",
+		up: func(tx *gorm.DB) error {
+			var (
+				objects []*models.Object
+				writer  = tx.Session(&gorm.Session{NewDB: true})
+			)
+			return tx.Model((*models.Object)(nil)).FindInBatches(&objects, 500, func(_ *gorm.DB, _ int) error {
+				for _, obj := range objects {
+					if err := updateIndex(writer, obj); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		},
+	},
+	{
+		version: 4,
+		name:    "add processing status to object index",
+		up: func(tx *gorm.DB) error {
+			// The index created by the migration 2 of this release has the column already
+			if tx.Migrator().HasColumn(&indexObject{}, "ProcessingStatus") {
+				return nil
+			}
+			if err := tx.Migrator().AddColumn(&indexObject{}, "ProcessingStatus"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&indexObject{}, "ProcessingStatus")
+		},
+	},
+}
+
+// Migrate applies the pending migrations, every migration runs in its own transaction
+func Migrate(conn *gorm.DB) error {
+	if err := conn.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+	var applied []int
+	if err := conn.Model((*schemaMigration)(nil)).Pluck("version", &applied).Error; err != nil {
+		return err
+	}
+	done := make(map[int]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+	for _, m := range migrations {
+		if done[m.version] {
+			continue
+		}
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{
+				Version:   m.version,
+				Name:      m.name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return errors.Wrapf(err, "migration %d %q", m.version, m.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geniusrabbit/gosql/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+func appliedMigrations(t *testing.T, conn *gorm.DB) []int {
+	t.Helper()
+	var versions []int
+	require.NoError(t, conn.Model((*schemaMigration)(nil)).Order("version").Pluck("version", &versions).Error)
+	return versions
+}
+
+func TestMigrate(t *testing.T) {
+	conn := openTestDB(t)
+	require.NoError(t, Migrate(conn))
+	require.NoError(t, Migrate(conn))
+
+	assert.Equal(t, []int{1, 2, 3, 4}, appliedMigrations(t, conn))
+	for _, model := range []any{&models.Object{}, &indexObject{}, &indexItem{}, &indexTag{}} {
+		assert.True(t, conn.Migrator().HasTable(model), "%T", model)
+	}
+}
+
+func TestMigrateAutoMigratedDatabase(t *testing.T) {
+	conn := openTestDB(t)
+
+	// The object table created by AutoMigrate of the previous releases
+	require.NoError(t, conn.AutoMigrate(&models.Object{}))
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	obj := testObject("images/1", "image/png", models.StatusOK, 100, created, "a", "b")
+	obj.Meta = gosql.NullableJSON[models.Meta]{Data: &models.Meta{Items: []*models.ItemMeta{
+		{Name: "thumb", NameExt: "jpg", Role: "thumb", Size: 10},
+	}}}
+	require.NoError(t, conn.Create(obj).Error)
+	require.NoError(t, conn.Create(testObject("videos/1", "video/mp4", models.StatusOK, 500, created)).Error)
+
+	require.NoError(t, Migrate(conn))
+	assert.Equal(t, []int{1, 2, 3, 4}, appliedMigrations(t, conn))
+
+	// The existing objects are backfilled into the index
+	var ids []string
+	require.NoError(t, conn.Model((*indexObject)(nil)).Order("id").Pluck("id", &ids).Error)
+	assert.Equal(t, []string{"images/1", "videos/1"}, ids)
+
+	var idx indexObject
+	require.NoError(t, conn.First(&idx, "id = ?", obj.ID).Error)
+	assert.Equal(t, 1, idx.ItemCount)
+	assert.Equal(t, uint64(10), idx.DerivedSize)
+
+	var count int64
+	require.NoError(t, conn.Model((*indexTag)(nil)).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	// The applied backfill doesn't run again
+	require.NoError(t, conn.Where("id = ?", "videos/1").Delete(&indexObject{}).Error)
+	require.NoError(t, Migrate(conn))
+	require.NoError(t, conn.Model((*indexObject)(nil)).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMigrateProcessingStatus(t *testing.T) {
+	conn := openTestDB(t)
+	require.NoError(t, Migrate(conn))
+
+	// The index created by the migration 2 of the previous release
+	require.NoError(t, conn.Migrator().DropIndex(&indexObject{}, "ProcessingStatus"))
+	require.NoError(t, conn.Migrator().DropColumn(&indexObject{}, "ProcessingStatus"))
+	require.NoError(t, conn.Where("version = ?", 4).Delete(&schemaMigration{}).Error)
+
+	require.NoError(t, Migrate(conn))
+	assert.True(t, conn.Migrator().HasColumn(&indexObject{}, "ProcessingStatus"))
+	assert.True(t, conn.Migrator().HasIndex(&indexObject{}, "ProcessingStatus"))
+}
@@ -3,6 +3,8 @@ package memory
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/apfs-io/apfs/internal/storage"
@@ -16,14 +18,15 @@ var (
 
 // connector represents an in-memory database implementation of the storage.DB interface.
 type connector struct {
-	mx  sync.RWMutex              // Mutex to ensure thread-safe access to the in-memory map.
-	mem map[string]*models.Object // In-memory storage for objects, keyed by their ID.
+	mx         sync.RWMutex                       // Mutex to ensure thread-safe access to the in-memory map.
+	mem        map[string]*models.Object          // In-memory storage for objects, keyed by their ID.
+	processing map[string]models.ProcessingStatus // Processing status of the objects, keyed by their ID.
 }
 
 // Connect initializes a new in-memory database instance.
 // The connectURL parameter is ignored as this is an in-memory implementation.
 func Connect(_ context.Context, connectURL string) (storage.DB, error) {
-	return &connector{mem: map[string]*models.Object{}}, nil
+	return &connector{mem: map[string]*models.Object{}, processing: map[string]models.ProcessingStatus{}}, nil
 }
 
 // Get retrieves an object from the in-memory database by its ID.
@@ -52,14 +55,62 @@ func (db *connector) Delete(id string) error {
 	db.mx.Lock() // Acquire a write lock for thread-safe modification.
 	defer db.mx.Unlock()
 	delete(db.mem, id)
+	delete(db.processing, id)
 	return nil
 }
 
+// SetProcessingStatus of the object, the status is listed by the filter
+func (db *connector) SetProcessingStatus(_ context.Context, objectID string, status models.ProcessingStatus) error {
+	db.mx.Lock()
+	defer db.mx.Unlock()
+	db.processing[objectID] = status
+	return nil
+}
+
+// ListObjects returns the objects matched the filter ordered by the creation time
+func (db *connector) ListObjects(_ context.Context, filter *storage.ObjectFilter) ([]*models.Object, error) {
+	objects := db.match(filter)
+	slices.SortFunc(objects, func(a, b *models.Object) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if filter != nil && filter.After != nil {
+		objects = slices.DeleteFunc(objects, filter.After.Before)
+	}
+	if filter != nil && filter.Offset > 0 {
+		objects = objects[min(filter.Offset, len(objects)):]
+	}
+	if filter != nil && filter.Limit > 0 && len(objects) > filter.Limit {
+		objects = objects[:filter.Limit]
+	}
+	return objects, nil
+}
+
+// CountObjects returns the number of the objects matched the filter
+func (db *connector) CountObjects(_ context.Context, filter *storage.ObjectFilter) (int64, error) {
+	return int64(len(db.match(filter))), nil
+}
+
+func (db *connector) match(filter *storage.ObjectFilter) []*models.Object {
+	db.mx.RLock()
+	defer db.mx.RUnlock()
+	var objects []*models.Object
+	for _, obj := range db.mem {
+		if filter.Match(obj) && filter.MatchProcessing(db.processing[obj.ID]) {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
 // Close clears all objects from the in-memory database.
 // This method is typically called to release resources.
 func (db *connector) Close() error {
 	db.mx.Lock() // Acquire a write lock for thread-safe modification.
 	defer db.mx.Unlock()
 	clear(db.mem) // Clear the in-memory map.
+	clear(db.processing)
 	return nil
 }
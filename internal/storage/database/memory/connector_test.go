@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/geniusrabbit/gosql/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage"
+	"github.com/apfs-io/apfs/models"
+)
+
+func TestObjectIndex(t *testing.T) {
+	var (
+		ctx = context.TODO()
+		now = time.Now()
+	)
+	db, err := Connect(ctx, "memory://")
+	require.NoError(t, err)
+	index := db.(storage.ObjectIndex)
+
+	objects := []*models.Object{
+		{ID: "images/1", Bucket: "images", Status: models.StatusOK, ContentType: "image/png",
+			Size: 100, Tags: gosql.NullableJSONArray[string]{"a", "b"}, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "images/2", Bucket: "images", Status: models.StatusError, ContentType: "image/jpeg",
+			Size: 2000, Tags: gosql.NullableJSONArray[string]{"a"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "videos/1", Bucket: "videos", Status: models.StatusOK, ContentType: "video/mp4",
+			Size: 5000, CreatedAt: now},
+	}
+	for _, obj := range objects {
+		require.NoError(t, db.Set(obj))
+	}
+
+	list, err := index.ListObjects(ctx, &storage.ObjectFilter{Group: "images"})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "images/1", list[0].ID)
+
+	list, err = index.ListObjects(ctx, &storage.ObjectFilter{Group: "images", Offset: 1, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "images/2", list[0].ID)
+
+	// The cursor page doesn't shift when the listed objects stop matching
+	list, err = index.ListObjects(ctx, &storage.ObjectFilter{Status: []models.ObjectStatus{models.StatusOK}, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.NoError(t, db.Set(&models.Object{ID: "images/1", Bucket: "images", Status: models.StatusError,
+		CreatedAt: list[0].CreatedAt}))
+	list, err = index.ListObjects(ctx, &storage.ObjectFilter{Status: []models.ObjectStatus{models.StatusOK},
+		Limit: 1, After: storage.CursorOf(list[0])})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "videos/1", list[0].ID)
+	require.NoError(t, db.Set(objects[0]))
+
+	count, err := index.CountObjects(ctx, &storage.ObjectFilter{Tags: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = index.CountObjects(ctx, &storage.ObjectFilter{ContentType: "image/", MinSize: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = index.CountObjects(ctx, &storage.ObjectFilter{
+		Status: []models.ObjectStatus{models.StatusOK}, CreatedBefore: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, index.SetProcessingStatus(ctx, "videos/1", models.ProcessingStatusFailed))
+	list, err = index.ListObjects(ctx, &storage.ObjectFilter{
+		Processing: []models.ProcessingStatus{models.ProcessingStatusFailed}})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "videos/1", list[0].ID)
+
+	require.NoError(t, db.Delete("videos/1"))
+	count, err = index.CountObjects(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/models"
+)
+
+// ErrStorageIndexNotSupported is returned when the database can't query the objects
+var ErrStorageIndexNotSupported = errors.New("[storage] object index is not supported by the database")
+
+// ObjectIndex is implemented by the databases which maintain the queryable
+// index of the objects on every Set/Delete
+type ObjectIndex interface {
+	// ListObjects returns the objects matched the filter ordered by the creation time
+	ListObjects(ctx context.Context, filter *ObjectFilter) ([]*models.Object, error)
+
+	// CountObjects returns the number of the objects matched the filter
+	CountObjects(ctx context.Context, filter *ObjectFilter) (int64, error)
+
+	// SetProcessingStatus of the indexed object, the status is kept when the
+	// object record is updated
+	SetProcessingStatus(ctx context.Context, objectID string, status models.ProcessingStatus) error
+}
+
+// ObjectFilter of the object index queries. Empty fields are not filtered.
+type ObjectFilter struct {
+	Group       string
+	Status      []models.ObjectStatus
+	Processing  []models.ProcessingStatus // Status of the processing state
+	Type        []models.ObjectType
+	ContentType string   // Content type prefix, e.g. "image/"
+	Tags        []string // Object must have all of the tags
+
+	MinSize uint64
+	MaxSize uint64
+
+	WorkflowVersion string
+
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedBefore time.Time
+
+	Limit  int
+	Offset int
+
+	// After is the keyset cursor of the listing ordered by the creation
+	// time, only the objects after it are listed. Unlike Offset the cursor
+	// doesn't skip objects when the listed ones stop matching the filter.
+	After *ObjectCursor
+}
+
+// ObjectCursor is the position of the object in the listing
+type ObjectCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// CursorOf returns the listing cursor positioned on the object
+func CursorOf(obj *models.Object) *ObjectCursor {
+	return &ObjectCursor{CreatedAt: obj.CreatedAt, ID: obj.ID}
+}
+
+// Before returns true if the object is ordered before or at the cursor
+func (c *ObjectCursor) Before(obj *models.Object) bool {
+	if c == nil {
+		return false
+	}
+	if cmp := obj.CreatedAt.Compare(c.CreatedAt); cmp != 0 {
+		return cmp < 0
+	}
+	return obj.ID <= c.ID
+}
+
+// Match returns true if the object matches the filter (paging is ignored)
+func (f *ObjectFilter) Match(obj *models.Object) bool {
+	if f == nil {
+		return true
+	}
+	switch {
+	case f.Group != "" && obj.Bucket != f.Group,
+		len(f.Status) > 0 && !slices.Contains(f.Status, obj.Status),
+		len(f.Type) > 0 && !slices.Contains(f.Type, obj.Type),
+		f.ContentType != "" && !strings.HasPrefix(obj.ContentType, f.ContentType),
+		f.MinSize > 0 && obj.Size < f.MinSize,
+		f.MaxSize > 0 && obj.Size > f.MaxSize,
+		f.WorkflowVersion != "" && obj.WorkflowVersion() != f.WorkflowVersion,
+		!f.CreatedAfter.IsZero() && !obj.CreatedAt.After(f.CreatedAfter),
+		!f.CreatedBefore.IsZero() && !obj.CreatedAt.Before(f.CreatedBefore),
+		!f.UpdatedBefore.IsZero() && !obj.UpdatedAt.Before(f.UpdatedBefore):
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(obj.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchProcessing returns true if the processing status matches the filter
+func (f *ObjectFilter) MatchProcessing(status models.ProcessingStatus) bool {
+	return f == nil || len(f.Processing) == 0 || slices.Contains(f.Processing, status)
+}
+
+// ListObjects returns the objects matched the filter from the database index
+func (s *Storage) ListObjects(ctx context.Context, filter *ObjectFilter) ([]*models.Object, error) {
+	index, ok := s.db.(ObjectIndex)
+	if !ok {
+		return nil, ErrStorageIndexNotSupported
+	}
+	return index.ListObjects(ctx, filter)
+}
+
+// CountObjects returns the number of the objects matched the filter
+func (s *Storage) CountObjects(ctx context.Context, filter *ObjectFilter) (int64, error) {
+	index, ok := s.db.(ObjectIndex)
+	if !ok {
+		return 0, ErrStorageIndexNotSupported
+	}
+	return index.CountObjects(ctx, filter)
+}
+
+// indexProcessingStatus logs the error of the index update, the index never
+// fails the state update
+func (s *Storage) indexProcessingStatus(ctx context.Context, objectID string, status models.ProcessingStatus) {
+	index, ok := s.db.(ObjectIndex)
+	if !ok {
+		return
+	}
+	if err := index.SetProcessingStatus(ctx, objectID, status); err != nil {
+		ctxlogger.Get(ctx).Warn("update object index processing status",
+			zap.String("object_id", objectID), zap.Error(err))
+	}
+}
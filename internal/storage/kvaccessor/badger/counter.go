@@ -0,0 +1,161 @@
+package badger
+
+import (
+	"context"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+// Key prefixes of the counter records: counter/<key>\x00<field>
+// and window/<key>:<window start ms>
+const (
+	counterKeyPrefix = `counter/`
+	windowKeyPrefix  = `window/`
+)
+
+// Counter is the BadgerDB implementation of kvaccessor.Counter
+type Counter struct {
+	mx sync.Mutex
+	db *badger.DB
+}
+
+// NewCounter opens the counter database by URL
+// connection: badger:///var/lib/apfs/counters?sync=true
+func NewCounter(connection string) (*Counter, error) {
+	db, err := Open(connection)
+	if err != nil {
+		return nil, errors.Wrap(err, "open badger counter")
+	}
+	return NewCounterWithDB(db), nil
+}
+
+// NewCounterWithDB returns the counter over the opened database
+func NewCounterWithDB(db *badger.DB) *Counter {
+	return &Counter{db: db}
+}
+
+// IncrBy adds delta to the field counter of the key
+func (c *Counter) IncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	var value int64
+	err := c.db.Update(func(txn *badger.Txn) error {
+		fieldKey := counterKey(key, field)
+		item, err := txn.Get(fieldKey)
+		switch {
+		case err == nil:
+			err = item.Value(func(data []byte) error {
+				value = decodeCounter(data)
+				return nil
+			})
+		case errors.Is(err, badger.ErrKeyNotFound):
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		value += delta
+		if value == 0 {
+			return txn.Delete(fieldKey)
+		}
+		return txn.Set(fieldKey, binary.BigEndian.AppendUint64(nil, uint64(value)))
+	})
+	return value, err
+}
+
+// Counters returns the field counters of the key
+func (c *Counter) Counters(ctx context.Context, key string) (map[string]int64, error) {
+	counters := map[string]int64{}
+	err := c.iterate(key, func(txn *badger.Txn, item *badger.Item) error {
+		field := strings.TrimPrefix(string(item.Key()), string(counterKey(key, "")))
+		return item.Value(func(data []byte) error {
+			counters[field] = decodeCounter(data)
+			return nil
+		})
+	}, false)
+	return counters, err
+}
+
+// DeleteCounters removes the key
+func (c *Counter) DeleteCounters(ctx context.Context, key string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.iterate(key, func(txn *badger.Txn, item *badger.Item) error {
+		return txn.Delete(item.KeyCopy(nil))
+	}, true)
+}
+
+// IncrWindow increments the counter of the key in the current window,
+// the window record expires with the window
+func (c *Counter) IncrWindow(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	var (
+		now   = time.Now()
+		start = kvaccessor.WindowStart(now, window)
+		left  = start.Add(window).Sub(now)
+		value int64
+	)
+	windowKey := []byte(windowKeyPrefix + key + ":" + strconv.FormatInt(start.UnixMilli(), 10))
+	err := c.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(windowKey)
+		switch {
+		case err == nil:
+			err = item.Value(func(data []byte) error {
+				value = decodeCounter(data)
+				return nil
+			})
+		case errors.Is(err, badger.ErrKeyNotFound):
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		value++
+		return txn.SetEntry(badger.NewEntry(windowKey,
+			binary.BigEndian.AppendUint64(nil, uint64(value))).WithTTL(left))
+	})
+	return value, left, err
+}
+
+// Close the database
+func (c *Counter) Close() error {
+	return c.db.Close()
+}
+
+func (c *Counter) iterate(key string, fn func(txn *badger.Txn, item *badger.Item) error, update bool) error {
+	run := func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := counterKey(key, "")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			if err := fn(txn, it.Item()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if update {
+		return c.db.Update(run)
+	}
+	return c.db.View(run)
+}
+
+func counterKey(key, field string) []byte {
+	return []byte(counterKeyPrefix + key + "\x00" + field)
+}
+
+func decodeCounter(data []byte) int64 {
+	if len(data) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(data))
+}
@@ -0,0 +1,59 @@
+package badger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	ctx := context.TODO()
+	counter, err := NewCounter("badger://counter?inmemory=true")
+	require.NoError(t, err)
+	defer func() { _ = counter.Close() }()
+
+	value, err := counter.IncrBy(ctx, "group", "objects", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+	value, err = counter.IncrBy(ctx, "group", "bytes", -10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-10), value)
+	_, err = counter.IncrBy(ctx, "group2", "objects", 1)
+	require.NoError(t, err)
+
+	// The zero counter is removed
+	_, err = counter.IncrBy(ctx, "group", "objects", -2)
+	require.NoError(t, err)
+	counters, err := counter.Counters(ctx, "group")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"bytes": -10}, counters)
+
+	require.NoError(t, counter.DeleteCounters(ctx, "group"))
+	counters, err = counter.Counters(ctx, "group")
+	require.NoError(t, err)
+	assert.Empty(t, counters)
+	counters, err = counter.Counters(ctx, "group2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"objects": 1}, counters)
+}
+
+func TestCounterWindow(t *testing.T) {
+	ctx := context.TODO()
+	counter, err := NewCounter("badger://counter?inmemory=true")
+	require.NoError(t, err)
+	defer func() { _ = counter.Close() }()
+
+	for i := int64(1); i <= 3; i++ {
+		value, left, err := counter.IncrWindow(ctx, "rate", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, value)
+		assert.Greater(t, left, time.Duration(0))
+		assert.LessOrEqual(t, left, time.Minute)
+	}
+	value, _, err := counter.IncrWindow(ctx, "other", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+}
@@ -0,0 +1,77 @@
+package badger
+
+import (
+	"context"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+)
+
+// hashKeyPrefix of the hash records: hash/<key>\x00<field>
+const hashKeyPrefix = `hash/`
+
+// Hash is the BadgerDB implementation of kvaccessor.Hash
+type Hash struct {
+	db *badger.DB
+}
+
+// NewHash opens the hash database by URL
+// connection: badger:///var/lib/apfs/hash?sync=true
+func NewHash(connection string) (*Hash, error) {
+	db, err := Open(connection)
+	if err != nil {
+		return nil, errors.Wrap(err, "open badger hash")
+	}
+	return NewHashWithDB(db), nil
+}
+
+// NewHashWithDB returns the hash over the opened database
+func NewHashWithDB(db *badger.DB) *Hash {
+	return &Hash{db: db}
+}
+
+// HSet sets the value of the field of the key
+func (h *Hash) HSet(ctx context.Context, key, field, value string) error {
+	return h.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(hashKey(key, field), []byte(value))
+	})
+}
+
+// HDel removes the field of the key
+func (h *Hash) HDel(ctx context.Context, key, field string) error {
+	return h.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(hashKey(key, field))
+	})
+}
+
+// HGetAll returns all fields of the key
+func (h *Hash) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	fields := map[string]string{}
+	err := h.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := hashKey(key, "")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			field := strings.TrimPrefix(string(it.Item().Key()), string(prefix))
+			err := it.Item().Value(func(data []byte) error {
+				fields[field] = string(data)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return fields, err
+}
+
+// Close the database
+func (h *Hash) Close() error {
+	return h.db.Close()
+}
+
+func hashKey(key, field string) []byte {
+	return []byte(hashKeyPrefix + key + "\x00" + field)
+}
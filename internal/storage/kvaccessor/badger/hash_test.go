@@ -0,0 +1,35 @@
+package badger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	ctx := context.TODO()
+	hash, err := NewHash("badger://hash?inmemory=true")
+	require.NoError(t, err)
+	defer func() { _ = hash.Close() }()
+
+	require.NoError(t, hash.HSet(ctx, "pending", "a", "1"))
+	require.NoError(t, hash.HSet(ctx, "pending", "b", "2"))
+	require.NoError(t, hash.HSet(ctx, "pending", "a", "3"))
+	require.NoError(t, hash.HSet(ctx, "pending2", "c", "4"))
+
+	fields, err := hash.HGetAll(ctx, "pending")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "3", "b": "2"}, fields)
+
+	require.NoError(t, hash.HDel(ctx, "pending", "a"))
+	require.NoError(t, hash.HDel(ctx, "pending", "missing"))
+	fields, err = hash.HGetAll(ctx, "pending")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"b": "2"}, fields)
+
+	fields, err = hash.HGetAll(ctx, "empty")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
@@ -0,0 +1,198 @@
+// Package badger implements kvaccessor.Locker on top of the embedded
+// BadgerDB. The locks survive the process restarts, which keeps the fencing
+// tokens monotonic for a single-node deployment without external services.
+package badger
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demdxx/gocast/v2"
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+// Key prefixes of the lock records and fencing counters
+const (
+	lockKeyPrefix  = `lock/`
+	fenceKeyPrefix = `fence/`
+)
+
+type lockRecord struct {
+	Owner   string    `json:"owner"`
+	Token   uint64    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// Locker is the BadgerDB implementation of kvaccessor.Locker
+type Locker struct {
+	mx sync.Mutex
+	db *badger.DB
+}
+
+// New opens the locker database by URL
+// connection: badger:///var/lib/apfs/locks?sync=true or badger://locks?inmemory=true
+func New(connection string) (*Locker, error) {
+	db, err := Open(connection)
+	if err != nil {
+		return nil, errors.Wrap(err, "open badger locker")
+	}
+	return NewWithDB(db), nil
+}
+
+// Open the badger database by URL
+// connection: badger:///var/lib/apfs/data?sync=true or badger://data?inmemory=true
+func Open(connection string) (*badger.DB, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimPrefix(connection, `badger://`)
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	opts := badger.DefaultOptions(path).
+		WithValueLogFileSize(1 * 1024 * 1024).
+		WithLogger(nil)
+	if gocast.Bool(u.Query().Get(`sync`)) {
+		opts = opts.WithSyncWrites(true)
+	}
+	if gocast.Bool(u.Query().Get(`inmemory`)) {
+		opts = opts.WithInMemory(true).WithDir("").WithValueDir("")
+	}
+	return badger.Open(opts)
+}
+
+// NewWithDB returns the locker over the opened database
+func NewWithDB(db *badger.DB) *Locker {
+	return &Locker{db: db}
+}
+
+// Acquire the lock of the key for ttl
+func (l *Locker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (*kvaccessor.Lease, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	var lease *kvaccessor.Lease
+	err := l.db.Update(func(txn *badger.Txn) error {
+		rec, err := getLock(txn, key)
+		if err != nil {
+			return err
+		}
+		if rec != nil && time.Now().Before(rec.Expires) {
+			return kvaccessor.ErrLockHeld
+		}
+		token, err := nextFence(txn, key)
+		if err != nil {
+			return err
+		}
+		rec = &lockRecord{Owner: owner, Token: token, Expires: time.Now().Add(ttl)}
+		if err = setLock(txn, key, rec, ttl); err != nil {
+			return err
+		}
+		lease = &kvaccessor.Lease{Key: key, Owner: owner, Token: token, Expires: rec.Expires}
+		return nil
+	})
+	return lease, err
+}
+
+// Renew extends the lease for ttl
+func (l *Locker) Renew(ctx context.Context, lease *kvaccessor.Lease, ttl time.Duration) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.db.Update(func(txn *badger.Txn) error {
+		rec, err := heldLock(txn, lease)
+		if err != nil {
+			return err
+		}
+		rec.Expires = time.Now().Add(ttl)
+		if err = setLock(txn, lease.Key, rec, ttl); err != nil {
+			return err
+		}
+		lease.Expires = rec.Expires
+		return nil
+	})
+}
+
+// Release the lock
+func (l *Locker) Release(ctx context.Context, lease *kvaccessor.Lease) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.db.Update(func(txn *badger.Txn) error {
+		if _, err := heldLock(txn, lease); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(lockKeyPrefix + lease.Key))
+	})
+}
+
+// Close the database
+func (l *Locker) Close() error {
+	return l.db.Close()
+}
+
+func getLock(txn *badger.Txn, key string) (*lockRecord, error) {
+	item, err := txn.Get([]byte(lockKeyPrefix + key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec lockRecord
+	err = item.Value(func(data []byte) error {
+		return json.Unmarshal(data, &rec)
+	})
+	return &rec, err
+}
+
+// heldLock returns the lock record if it's still held by the lease
+func heldLock(txn *badger.Txn, lease *kvaccessor.Lease) (*lockRecord, error) {
+	rec, err := getLock(txn, lease.Key)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil || rec.Token != lease.Token || rec.Owner != lease.Owner || !time.Now().Before(rec.Expires) {
+		return nil, kvaccessor.ErrLockLost
+	}
+	return rec, nil
+}
+
+func setLock(txn *badger.Txn, key string, rec *lockRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return txn.SetEntry(badger.NewEntry([]byte(lockKeyPrefix+key), data).WithTTL(ttl))
+}
+
+// nextFence increments the fencing counter of the key
+func nextFence(txn *badger.Txn, key string) (uint64, error) {
+	var (
+		fenceKey = []byte(fenceKeyPrefix + key)
+		token    uint64
+	)
+	item, err := txn.Get(fenceKey)
+	switch {
+	case err == nil:
+		err = item.Value(func(data []byte) error {
+			if len(data) == 8 {
+				token = binary.BigEndian.Uint64(data)
+			}
+			return nil
+		})
+	case errors.Is(err, badger.ErrKeyNotFound):
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	token++
+	return token, txn.Set(fenceKey, binary.BigEndian.AppendUint64(nil, token))
+}
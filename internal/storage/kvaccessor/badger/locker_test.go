@@ -0,0 +1,67 @@
+package badger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+func TestLocker(t *testing.T) {
+	ctx := context.TODO()
+	locker, err := New("badger://" + t.TempDir())
+	require.NoError(t, err)
+	defer func() { _ = locker.Close() }()
+
+	lease, err := locker.Acquire(ctx, "job", "worker-1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), lease.Token)
+
+	_, err = locker.Acquire(ctx, "job", "worker-2", time.Minute)
+	assert.ErrorIs(t, err, kvaccessor.ErrLockHeld)
+
+	require.NoError(t, locker.Renew(ctx, lease, time.Minute))
+	require.NoError(t, locker.Release(ctx, lease))
+	assert.ErrorIs(t, locker.Renew(ctx, lease, time.Minute), kvaccessor.ErrLockLost)
+
+	lease2, err := locker.Acquire(ctx, "job", "worker-2", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), lease2.Token)
+}
+
+func TestLockerFencePersistsAcrossRestart(t *testing.T) {
+	var (
+		ctx = context.TODO()
+		dir = t.TempDir()
+	)
+	locker, err := New("badger://" + dir)
+	require.NoError(t, err)
+	lease, err := locker.Acquire(ctx, "job", "crashed", time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, locker.Close())
+
+	time.Sleep(5 * time.Millisecond)
+
+	locker, err = New("badger://" + dir)
+	require.NoError(t, err)
+	defer func() { _ = locker.Close() }()
+
+	// The orphaned lock is expired and reclaimed with a greater token
+	lease2, err := locker.Acquire(ctx, "job", "worker-2", time.Minute)
+	require.NoError(t, err)
+	assert.Greater(t, lease2.Token, lease.Token)
+}
+
+func TestLockerInMemory(t *testing.T) {
+	locker, err := New("badger://locks?inmemory=true")
+	require.NoError(t, err)
+	defer func() { _ = locker.Close() }()
+
+	lease, err := locker.Acquire(context.TODO(), "job", "worker", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, locker.Release(context.TODO(), lease))
+}
@@ -2,8 +2,26 @@ package kvaccessor
 
 import (
 	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Error list...
+var (
+	// ErrLockHeld is returned by Acquire when the lock is held by another owner
+	ErrLockHeld = errors.New(`lock is held by another owner`)
+
+	// ErrLockLost is returned by Renew and Release when the lease has expired
+	// or was taken over by another owner
+	ErrLockLost = errors.New(`lock lease is lost`)
 )
 
+// FenceRetention is the time the fencing counter of the key is kept after
+// its last lease. The lost owner can't outlive it, so the counter restarted
+// after the retention is still greater than the token of any active owner.
+const FenceRetention = 24 * time.Hour
+
 // KVAccessor interface describes the way of manipulation
 // of string values by key
 type KVAccessor interface {
@@ -11,3 +29,76 @@ type KVAccessor interface {
 	Set(ctx context.Context, key, value string) error
 	TrySet(ctx context.Context, key, value string) error
 }
+
+// Lease is the lock acquired by the owner until Expires
+type Lease struct {
+	Key   string
+	Owner string
+
+	// Token is the fencing token of the lease. It is strictly increasing
+	// for every successful Acquire of the key, so the storage could reject
+	// the writes of the owner which lost the lease to a newer one.
+	Token uint64
+
+	// Expires is the time when the lease is released if not renewed
+	Expires time.Time
+}
+
+// Expired returns true if the lease TTL is over
+func (l *Lease) Expired() bool {
+	return l == nil || !time.Now().Before(l.Expires)
+}
+
+// Locker provides lease-based distributed locks.
+// An orphaned lock of a crashed owner is reclaimed as soon as its TTL expires.
+type Locker interface {
+	// Acquire the lock of the key for ttl, returns ErrLockHeld if the key
+	// is locked by another owner
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (*Lease, error)
+
+	// Renew extends the lease for ttl (heartbeat), returns ErrLockLost if
+	// the lease is not held anymore
+	Renew(ctx context.Context, lease *Lease, ttl time.Duration) error
+
+	// Release the lock, returns ErrLockLost if the lease is not held anymore
+	Release(ctx context.Context, lease *Lease) error
+}
+
+// Counter keeps the named integer counters (fields) of the keys.
+// Every increment is atomic, so the counters could be shared by the nodes.
+type Counter interface {
+	// IncrBy adds delta to the field counter of the key and returns the new value
+	IncrBy(ctx context.Context, key, field string, delta int64) (int64, error)
+
+	// Counters returns all non-zero field counters of the key
+	Counters(ctx context.Context, key string) (map[string]int64, error)
+
+	// DeleteCounters removes the key with all of its counters
+	DeleteCounters(ctx context.Context, key string) error
+}
+
+// Hash keeps the string fields of the keys, e.g. the records which have to
+// survive the restart of the process
+type Hash interface {
+	// HSet sets the value of the field of the key
+	HSet(ctx context.Context, key, field, value string) error
+
+	// HDel removes the field of the key
+	HDel(ctx context.Context, key, field string) error
+
+	// HGetAll returns all fields of the key
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+}
+
+// WindowCounter counts the events in the fixed time windows, e.g. the
+// requests per minute of the rate limits
+type WindowCounter interface {
+	// IncrWindow increments the counter of the key in the current window and
+	// returns the new value with the time left until the window is over
+	IncrWindow(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+}
+
+// WindowStart returns the start of the fixed window of the time
+func WindowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+// maxWindows is the number of the window counters kept before the expired ones are dropped
+const maxWindows = 1024
+
+type window struct {
+	end   time.Time
+	count int64
+}
+
+// Counter is the in-process implementation of kvaccessor.Counter
+// and kvaccessor.WindowCounter
+type Counter struct {
+	mx       sync.Mutex
+	counters map[string]map[string]int64
+	windows  map[string]*window
+}
+
+// NewCounter object
+func NewCounter() *Counter {
+	return &Counter{
+		counters: map[string]map[string]int64{},
+		windows:  map[string]*window{},
+	}
+}
+
+// IncrBy adds delta to the field counter of the key
+func (c *Counter) IncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	fields := c.counters[key]
+	if fields == nil {
+		fields = map[string]int64{}
+		c.counters[key] = fields
+	}
+	fields[field] += delta
+	value := fields[field]
+	if value == 0 {
+		delete(fields, field)
+	}
+	return value, nil
+}
+
+// Counters returns the field counters of the key
+func (c *Counter) Counters(ctx context.Context, key string) (map[string]int64, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return maps.Clone(c.counters[key]), nil
+}
+
+// DeleteCounters removes the key
+func (c *Counter) DeleteCounters(ctx context.Context, key string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	delete(c.counters, key)
+	return nil
+}
+
+// IncrWindow increments the counter of the key in the current window
+func (c *Counter) IncrWindow(ctx context.Context, key string, size time.Duration) (int64, time.Duration, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	now := time.Now()
+	if len(c.windows) >= maxWindows {
+		for k, w := range c.windows {
+			if !now.Before(w.end) {
+				delete(c.windows, k)
+			}
+		}
+	}
+	w := c.windows[key]
+	if w == nil || !now.Before(w.end) {
+		w = &window{end: kvaccessor.WindowStart(now, size).Add(size)}
+		c.windows[key] = w
+	}
+	w.count++
+	return w.count, w.end.Sub(now), nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Hash is the in-process implementation of kvaccessor.Hash
+type Hash struct {
+	mx     sync.Mutex
+	hashes map[string]map[string]string
+}
+
+// NewHash object
+func NewHash() *Hash {
+	return &Hash{hashes: map[string]map[string]string{}}
+}
+
+// HSet sets the value of the field of the key
+func (h *Hash) HSet(ctx context.Context, key, field, value string) error {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	fields := h.hashes[key]
+	if fields == nil {
+		fields = map[string]string{}
+		h.hashes[key] = fields
+	}
+	fields[field] = value
+	return nil
+}
+
+// HDel removes the field of the key
+func (h *Hash) HDel(ctx context.Context, key, field string) error {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if fields := h.hashes[key]; fields != nil {
+		delete(fields, field)
+		if len(fields) == 0 {
+			delete(h.hashes, key)
+		}
+	}
+	return nil
+}
+
+// HGetAll returns all fields of the key
+func (h *Hash) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return maps.Clone(h.hashes[key]), nil
+}
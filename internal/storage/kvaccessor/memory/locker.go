@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+type lock struct {
+	owner     string
+	token     uint64
+	expiredAt time.Time
+}
+
+type fence struct {
+	token     uint64
+	touchedAt time.Time
+}
+
+// Locker is the in-process implementation of kvaccessor.Locker
+type Locker struct {
+	mx     sync.Mutex
+	locks  map[string]*lock
+	fences map[string]*fence
+
+	// retention of the fencing counters of the released keys
+	retention time.Duration
+	sweptAt   time.Time
+}
+
+// NewLocker object
+func NewLocker() *Locker {
+	return &Locker{
+		locks:     map[string]*lock{},
+		fences:    map[string]*fence{},
+		retention: kvaccessor.FenceRetention,
+		sweptAt:   time.Now(),
+	}
+}
+
+// Acquire the lock of the key for ttl
+func (l *Locker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (*kvaccessor.Lease, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	now := time.Now()
+	l.sweep(now)
+	if lk := l.locks[key]; lk != nil && now.Before(lk.expiredAt) {
+		return nil, kvaccessor.ErrLockHeld
+	}
+	fn := l.fences[key]
+	if fn == nil {
+		fn = &fence{}
+		l.fences[key] = fn
+	}
+	fn.token++
+	fn.touchedAt = now
+	lk := &lock{owner: owner, token: fn.token, expiredAt: now.Add(ttl)}
+	l.locks[key] = lk
+	return &kvaccessor.Lease{Key: key, Owner: owner, Token: lk.token, Expires: lk.expiredAt}, nil
+}
+
+// Renew extends the lease for ttl
+func (l *Locker) Renew(ctx context.Context, lease *kvaccessor.Lease, ttl time.Duration) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	lk := l.held(lease)
+	if lk == nil {
+		return kvaccessor.ErrLockLost
+	}
+	lk.expiredAt = time.Now().Add(ttl)
+	lease.Expires = lk.expiredAt
+	if fn := l.fences[lease.Key]; fn != nil {
+		fn.touchedAt = time.Now()
+	}
+	return nil
+}
+
+// Release the lock
+func (l *Locker) Release(ctx context.Context, lease *kvaccessor.Lease) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	if l.held(lease) == nil {
+		return kvaccessor.ErrLockLost
+	}
+	delete(l.locks, lease.Key)
+	return nil
+}
+
+// sweep removes the expired locks and the fencing counters of the keys
+// unused for the retention period
+func (l *Locker) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < min(l.retention, time.Minute) {
+		return
+	}
+	l.sweptAt = now
+	for key, lk := range l.locks {
+		if !now.Before(lk.expiredAt) {
+			delete(l.locks, key)
+		}
+	}
+	for key, fn := range l.fences {
+		if l.locks[key] == nil && now.Sub(fn.touchedAt) >= l.retention {
+			delete(l.fences, key)
+		}
+	}
+}
+
+// held returns the active lock of the lease
+func (l *Locker) held(lease *kvaccessor.Lease) *lock {
+	lk := l.locks[lease.Key]
+	if lk == nil || lk.token != lease.Token || lk.owner != lease.Owner || !time.Now().Before(lk.expiredAt) {
+		return nil
+	}
+	return lk
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+func TestLocker(t *testing.T) {
+	var (
+		ctx    = context.TODO()
+		locker = NewLocker()
+	)
+
+	lease, err := locker.Acquire(ctx, "job", "worker-1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), lease.Token)
+
+	_, err = locker.Acquire(ctx, "job", "worker-2", time.Minute)
+	assert.ErrorIs(t, err, kvaccessor.ErrLockHeld)
+
+	require.NoError(t, locker.Renew(ctx, lease, time.Minute))
+	require.NoError(t, locker.Release(ctx, lease))
+	assert.ErrorIs(t, locker.Release(ctx, lease), kvaccessor.ErrLockLost)
+
+	lease2, err := locker.Acquire(ctx, "job", "worker-2", time.Minute)
+	require.NoError(t, err)
+	assert.Greater(t, lease2.Token, lease.Token, "fencing token must grow")
+}
+
+func TestLockerExpiredLeaseIsReclaimed(t *testing.T) {
+	var (
+		ctx    = context.TODO()
+		locker = NewLocker()
+	)
+
+	lease, err := locker.Acquire(ctx, "job", "crashed", time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, lease.Expired())
+
+	lease2, err := locker.Acquire(ctx, "job", "worker-2", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), lease2.Token)
+
+	// The stale owner can't renew or release the lock of the new one
+	assert.ErrorIs(t, locker.Renew(ctx, lease, time.Minute), kvaccessor.ErrLockLost)
+	assert.ErrorIs(t, locker.Release(ctx, lease), kvaccessor.ErrLockLost)
+	require.NoError(t, locker.Release(ctx, lease2))
+}
+
+func TestLockerSweepsUnusedFences(t *testing.T) {
+	var (
+		ctx    = context.TODO()
+		locker = NewLocker()
+	)
+	locker.retention = time.Millisecond
+
+	lease, err := locker.Acquire(ctx, "released", "worker-1", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, locker.Release(ctx, lease))
+	_, err = locker.Acquire(ctx, "expired", "crashed", time.Millisecond)
+	require.NoError(t, err)
+	held, err := locker.Acquire(ctx, "held", "worker-1", time.Minute)
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = locker.Acquire(ctx, "other", "worker-1", time.Minute)
+	require.NoError(t, err)
+
+	assert.NotContains(t, locker.fences, "released")
+	assert.NotContains(t, locker.fences, "expired")
+	assert.NotContains(t, locker.locks, "expired")
+	assert.Contains(t, locker.fences, "held", "the fence of the held lock is kept")
+	require.NoError(t, locker.Renew(ctx, held, time.Minute))
+}
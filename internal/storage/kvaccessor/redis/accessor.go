@@ -29,13 +29,36 @@ func New(connection string) (*Accessor, error) {
 		return nil, err
 	}
 
+	// Create a new Redis client with options derived from the connection string
+	client, err := NewClient(connection)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the lifetime parameter for key expiration
+	lifetime, _ := time.ParseDuration(parsedURL.Query().Get(`lifetime`))
+	if lifetime == 0 {
+		lifetime = time.Second // Default to 1 second if not specified
+	}
+
+	// Return the initialized Accessor
+	return &Accessor{client: client, lifetime: lifetime}, nil
+}
+
+// NewClient creates the Redis client of the connection string
+// connection: redis://:password@localhost:6379/0?pool=10&max_retries=2
+func NewClient(connection string) (*goredis.Client, error) {
+	parsedURL, err := url.Parse(connection) // Parse the connection string
+	if err != nil {
+		return nil, err
+	}
+
 	// Adjust scheme if necessary (e.g., redis -> tcp)
 	if parsedURL.Scheme == "redis" {
 		parsedURL.Scheme = "tcp"
 	}
 
-	// Create a new Redis client with options derived from the connection string
-	client := goredis.NewClient(&goredis.Options{
+	return goredis.NewClient(&goredis.Options{
 		Network:               parsedURL.Scheme,                                 // Network type (e.g., tcp)
 		Addr:                  parsedURL.Host,                                   // Redis server address
 		Password:              parsedURL.User.Username(),                        // Password for authentication
@@ -44,16 +67,7 @@ func New(connection string) (*Accessor, error) {
 		MaxRetries:            gocast.Int(parsedURL.Query().Get(`max_retries`)), // Max retry attempts
 		MinIdleConns:          gocast.Int(parsedURL.Query().Get(`idle_cons`)),   // Minimum idle connections
 		ContextTimeoutEnabled: true,                                             // Enable context timeout
-	})
-
-	// Parse the lifetime parameter for key expiration
-	lifetime, _ := time.ParseDuration(parsedURL.Query().Get(`lifetime`))
-	if lifetime == 0 {
-		lifetime = time.Second // Default to 1 second if not specified
-	}
-
-	// Return the initialized Accessor
-	return &Accessor{client: client, lifetime: lifetime}, nil
+	}), nil
 }
 
 // Get retrieves the value associated with the given key from Redis
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+// Counter is the redis implementation of kvaccessor.Counter,
+// the counters of the key are the fields of the redis hash
+type Counter struct {
+	client *goredis.Client
+}
+
+// NewCounter connects to redis
+// connection: redis://:password@localhost:6379/0
+func NewCounter(connection string) (*Counter, error) {
+	client, err := NewClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	return NewCounterWithClient(client), nil
+}
+
+// NewCounterWithClient returns the counter over the redis client
+func NewCounterWithClient(client *goredis.Client) *Counter {
+	return &Counter{client: client}
+}
+
+// IncrBy adds delta to the field counter of the key
+func (c *Counter) IncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	return c.client.HIncrBy(ctx, key, field, delta).Result()
+}
+
+// Counters returns the non-zero field counters of the key
+func (c *Counter) Counters(ctx context.Context, key string) (map[string]int64, error) {
+	values, err := c.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	counters := make(map[string]int64, len(values))
+	for field, value := range values {
+		if n, _ := strconv.ParseInt(value, 10, 64); n != 0 {
+			counters[field] = n
+		}
+	}
+	return counters, nil
+}
+
+// DeleteCounters removes the key
+func (c *Counter) DeleteCounters(ctx context.Context, key string) error {
+	return c.client.Del(ctx, key).Err()
+}
+
+// IncrWindow increments the counter of the key in the current window,
+// every window is the separate redis key expired with the window
+func (c *Counter) IncrWindow(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	var (
+		now   = time.Now()
+		start = kvaccessor.WindowStart(now, window)
+		incr  *goredis.IntCmd
+	)
+	windowKey := key + ":" + strconv.FormatInt(start.UnixMilli(), 10)
+	_, err := c.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		incr = pipe.Incr(ctx, windowKey)
+		pipe.PExpire(ctx, windowKey, window)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return incr.Val(), start.Add(window).Sub(now), nil
+}
+
+// Close the redis client
+func (c *Counter) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Hash is the redis implementation of kvaccessor.Hash
+type Hash struct {
+	client *goredis.Client
+}
+
+// NewHash connects to redis
+// connection: redis://:password@localhost:6379/0
+func NewHash(connection string) (*Hash, error) {
+	client, err := NewClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	return NewHashWithClient(client), nil
+}
+
+// NewHashWithClient returns the hash over the redis client
+func NewHashWithClient(client *goredis.Client) *Hash {
+	return &Hash{client: client}
+}
+
+// HSet sets the value of the field of the key
+func (h *Hash) HSet(ctx context.Context, key, field, value string) error {
+	return h.client.HSet(ctx, key, field, value).Err()
+}
+
+// HDel removes the field of the key
+func (h *Hash) HDel(ctx context.Context, key, field string) error {
+	return h.client.HDel(ctx, key, field).Err()
+}
+
+// HGetAll returns all fields of the key
+func (h *Hash) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return h.client.HGetAll(ctx, key).Result()
+}
+
+// Close the redis client
+func (h *Hash) Close() error {
+	return h.client.Close()
+}
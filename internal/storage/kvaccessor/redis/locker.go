@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+)
+
+// fenceKeySuffix is appended to the lock key to keep the fencing counter
+const fenceKeySuffix = `:fence`
+
+// acquireScript sets the lock if it does not exist and returns the new
+// fencing token, or 0 if the lock is held. The fence key expires after the
+// retention period past the lock.
+// KEYS[1] - lock key, KEYS[2] - fence key, ARGV[1] - owner, ARGV[2] - TTL ms,
+// ARGV[3] - fence TTL ms
+var acquireScript = goredis.NewScript(`
+if redis.call('exists', KEYS[1]) == 1 then
+  return 0
+end
+local token = redis.call('incr', KEYS[2])
+redis.call('pexpire', KEYS[2], ARGV[3])
+redis.call('set', KEYS[1], ARGV[1] .. '|' .. token, 'PX', ARGV[2])
+return token
+`)
+
+// renewScript prolongs the lock and its fence key only if it is still held
+// by the lease.
+// KEYS[1] - lock key, KEYS[2] - fence key, ARGV[1] - lease value,
+// ARGV[2] - TTL ms, ARGV[3] - fence TTL ms
+var renewScript = goredis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+  redis.call('pexpire', KEYS[2], ARGV[3])
+  return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript removes the lock only if it is still held by the lease.
+// KEYS[1] - lock key, ARGV[1] - lease value
+var releaseScript = goredis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+  return redis.call('del', KEYS[1])
+end
+return 0
+`)
+
+// Acquire the lock of the key for ttl
+func (ac *Accessor) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (*kvaccessor.Lease, error) {
+	expires := time.Now().Add(ttl)
+	token, err := acquireScript.Run(ctx, ac.client,
+		[]string{key, key + fenceKeySuffix}, owner, ttl.Milliseconds(), fenceTTL(ttl)).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if token == 0 {
+		return nil, kvaccessor.ErrLockHeld
+	}
+	return &kvaccessor.Lease{Key: key, Owner: owner, Token: uint64(token), Expires: expires}, nil
+}
+
+// Renew extends the lease for ttl
+func (ac *Accessor) Renew(ctx context.Context, lease *kvaccessor.Lease, ttl time.Duration) error {
+	expires := time.Now().Add(ttl)
+	res, err := renewScript.Run(ctx, ac.client,
+		[]string{lease.Key, lease.Key + fenceKeySuffix}, leaseValue(lease), ttl.Milliseconds(), fenceTTL(ttl)).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return kvaccessor.ErrLockLost
+	}
+	lease.Expires = expires
+	return nil
+}
+
+// Release the lock
+func (ac *Accessor) Release(ctx context.Context, lease *kvaccessor.Lease) error {
+	res, err := releaseScript.Run(ctx, ac.client, []string{lease.Key}, leaseValue(lease)).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return kvaccessor.ErrLockLost
+	}
+	return nil
+}
+
+// fenceTTL returns the expiration of the fence key in ms
+func fenceTTL(ttl time.Duration) int64 {
+	return (ttl + kvaccessor.FenceRetention).Milliseconds()
+}
+
+func leaseValue(lease *kvaccessor.Lease) string {
+	return strings.Join([]string{lease.Owner, strconv.FormatUint(lease.Token, 10)}, "|")
+}
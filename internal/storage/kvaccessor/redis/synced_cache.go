@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// SyncedCache is the local cache of the values shared by the nodes through
+// redis. Every write is broadcast over the pub/sub channel and the other
+// nodes drop their local copy of the key.
+type SyncedCache struct {
+	client  *goredis.Client
+	channel string
+	node    string
+	local   *expirable.LRU[string, []byte]
+	cancel  context.CancelFunc
+
+	// invalidations counts the received invalidations, the value loaded
+	// while another node changed any key is not cached
+	invalidations atomic.Uint64
+}
+
+// NewSyncedCache subscribes to the invalidation channel.
+// The local cache is disabled if ttl is zero, the writes are broadcast anyway.
+func NewSyncedCache(client *goredis.Client, channel string, size int, ttl time.Duration) *SyncedCache {
+	node := make([]byte, 8)
+	_, _ = rand.Read(node)
+	cache := &SyncedCache{
+		client:  client,
+		channel: channel,
+		node:    hex.EncodeToString(node),
+	}
+	if ttl > 0 {
+		cache.local = expirable.NewLRU[string, []byte](size, nil, ttl)
+		ctx, cancel := context.WithCancel(context.Background())
+		cache.cancel = cancel
+		sub := client.Subscribe(ctx, channel)
+		go cache.listen(ctx, sub)
+	}
+	return cache
+}
+
+// Load returns the cached value of the key or loads it by fetch.
+// Fetch returns nil for the missing value which is not cached.
+func (c *SyncedCache) Load(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	if c.local == nil {
+		return fetch()
+	}
+	if data, ok := c.local.Get(key); ok {
+		return data, nil
+	}
+	version := c.invalidations.Load()
+	data, err := fetch()
+	if err == nil && data != nil && version == c.invalidations.Load() {
+		c.local.Add(key, data)
+	}
+	return data, err
+}
+
+// Set the local value and notify the other nodes
+func (c *SyncedCache) Set(ctx context.Context, key string, data []byte) error {
+	if c.local != nil {
+		c.local.Add(key, data)
+	}
+	return c.publish(ctx, key)
+}
+
+// Invalidate the key on every node
+func (c *SyncedCache) Invalidate(ctx context.Context, key string) error {
+	if c.local != nil {
+		c.local.Remove(key)
+	}
+	return c.publish(ctx, key)
+}
+
+// Close stops the invalidation listener
+func (c *SyncedCache) Close() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+func (c *SyncedCache) publish(ctx context.Context, key string) error {
+	return c.client.Publish(ctx, c.channel, c.node+"|"+key).Err()
+}
+
+// listen drops the keys changed by the other nodes
+func (c *SyncedCache) listen(ctx context.Context, sub *goredis.PubSub) {
+	defer func() { _ = sub.Close() }()
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			node, key, ok := strings.Cut(msg.Payload, "|")
+			if !ok || node == c.node {
+				continue
+			}
+			c.invalidations.Add(1)
+			c.local.Remove(key)
+		}
+	}
+}
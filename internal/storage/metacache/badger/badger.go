@@ -0,0 +1,89 @@
+// Package badger provides a MetaCache persisted in the embedded BadgerDB,
+// so the cache of a single-node deployment survives the restarts.
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+
+	kvbadger "github.com/apfs-io/apfs/internal/storage/kvaccessor/badger"
+	"github.com/apfs-io/apfs/internal/storage/metacache"
+	"github.com/apfs-io/apfs/models"
+)
+
+const keyPrefix = `meta/`
+
+// Cache is the BadgerDB MetaCache
+type Cache struct {
+	db         *badger.DB
+	defaultTTL time.Duration
+}
+
+// New opens the database
+// connection: badger:///var/lib/apfs/metacache?sync=true
+// If ttl is zero DefaultTTL is used.
+func New(connection string, defaultTTL time.Duration) (*Cache, error) {
+	db, err := kvbadger.Open(connection)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithDB(db, defaultTTL), nil
+}
+
+// NewWithDB returns the cache over the opened database
+func NewWithDB(db *badger.DB, defaultTTL time.Duration) *Cache {
+	if defaultTTL <= 0 {
+		defaultTTL = metacache.DefaultTTL
+	}
+	return &Cache{db: db, defaultTTL: defaultTTL}
+}
+
+// Get implements MetaCache.
+func (c *Cache) Get(_ context.Context, id string) (*models.Meta, error) {
+	var meta *models.Meta
+	err := c.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(keyPrefix + id))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(data []byte) error {
+			return json.Unmarshal(data, &meta)
+		})
+	})
+	return meta, err
+}
+
+// Set implements MetaCache.
+func (c *Cache) Set(_ context.Context, id string, meta *models.Meta, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry([]byte(keyPrefix+id), data).WithTTL(ttl))
+	})
+}
+
+// Invalidate implements MetaCache.
+func (c *Cache) Invalidate(_ context.Context, id string) error {
+	return c.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(keyPrefix + id))
+	})
+}
+
+// Close the database
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+var _ metacache.MetaCache = (*Cache)(nil)
@@ -0,0 +1,41 @@
+package badger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.TODO()
+	cache, err := New("badger://metacache?inmemory=true", time.Minute)
+	require.NoError(t, err)
+	defer func() { _ = cache.Close() }()
+
+	meta, err := cache.Get(ctx, "obj")
+	require.NoError(t, err)
+	assert.Nil(t, meta)
+
+	require.NoError(t, cache.Set(ctx, "obj", &models.Meta{Main: models.ItemMeta{Name: "prim.jpg"}}, 0))
+	meta, err = cache.Get(ctx, "obj")
+	require.NoError(t, err)
+	require.NotNil(t, meta)
+	assert.Equal(t, "prim.jpg", meta.Main.Name)
+
+	require.NoError(t, cache.Invalidate(ctx, "obj"))
+	meta, err = cache.Get(ctx, "obj")
+	require.NoError(t, err)
+	assert.Nil(t, meta)
+
+	// Badger expires the entries with one second precision
+	require.NoError(t, cache.Set(ctx, "short", &models.Meta{}, time.Second))
+	time.Sleep(2 * time.Second)
+	meta, err = cache.Get(ctx, "short")
+	require.NoError(t, err)
+	assert.Nil(t, meta)
+}
@@ -0,0 +1,116 @@
+// Package redis provides a MetaCache shared by the server replicas through
+// redis. Every replica keeps a local copy of the recently read entries which
+// is dropped when another replica updates or invalidates the entry.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	kvredis "github.com/apfs-io/apfs/internal/storage/kvaccessor/redis"
+	"github.com/apfs-io/apfs/internal/storage/metacache"
+	"github.com/apfs-io/apfs/models"
+)
+
+const (
+	keyPrefix           = "apfs:meta:"
+	invalidationChannel = "apfs:meta:invalidate"
+	localCacheSize      = 4096
+)
+
+// DefaultLocalTTL is the lifetime of the local copy of the entry
+const DefaultLocalTTL = 30 * time.Second
+
+// Cache is the redis MetaCache
+type Cache struct {
+	client     *goredis.Client
+	cache      *kvredis.SyncedCache
+	defaultTTL time.Duration
+}
+
+// New connects to the redis server
+// connection: redis://localhost:6379/1?local_ttl=30s
+// If ttl is zero DefaultTTL is used.
+func New(connection string, defaultTTL time.Duration) (*Cache, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kvredis.NewClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	localTTL := DefaultLocalTTL
+	if v := u.Query().Get(`local_ttl`); v != "" {
+		localTTL, _ = time.ParseDuration(v)
+	}
+	return NewWithClient(client, defaultTTL, localTTL), nil
+}
+
+// NewWithClient returns the cache over the redis client.
+// If ttl is zero DefaultTTL is used.
+func NewWithClient(client *goredis.Client, defaultTTL, localTTL time.Duration) *Cache {
+	if defaultTTL <= 0 {
+		defaultTTL = metacache.DefaultTTL
+	}
+	return &Cache{
+		client:     client,
+		cache:      kvredis.NewSyncedCache(client, invalidationChannel, localCacheSize, min(localTTL, defaultTTL)),
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Get implements MetaCache.
+func (c *Cache) Get(ctx context.Context, id string) (*models.Meta, error) {
+	data, err := c.cache.Load(ctx, id, func() ([]byte, error) {
+		data, err := c.client.Get(ctx, keyPrefix+id).Bytes()
+		if errors.Is(err, goredis.Nil) {
+			return nil, nil
+		}
+		return data, err
+	})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var meta models.Meta
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// Set implements MetaCache.
+func (c *Cache) Set(ctx context.Context, id string, meta *models.Meta, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err = c.client.Set(ctx, keyPrefix+id, data, ttl).Err(); err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, id, data)
+}
+
+// Invalidate implements MetaCache.
+func (c *Cache) Invalidate(ctx context.Context, id string) error {
+	if err := c.client.Del(ctx, keyPrefix+id).Err(); err != nil {
+		return err
+	}
+	return c.cache.Invalidate(ctx, id)
+}
+
+// Close the redis connection
+func (c *Cache) Close() error {
+	c.cache.Close()
+	return c.client.Close()
+}
+
+var _ metacache.MetaCache = (*Cache)(nil)
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+)
+
+// checkpoint keeps the IDs of the completed objects one per line
+type checkpoint struct {
+	mx   sync.Mutex
+	file *os.File
+	done map[string]struct{}
+}
+
+// openCheckpoint loads the completed objects, the file is not written in read only mode
+func openCheckpoint(filename string, readOnly bool) (*checkpoint, error) {
+	c := &checkpoint{done: map[string]struct{}{}}
+	if filename == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	// The last line not terminated by the interrupted run is incomplete
+	complete := data
+	if idx := bytes.LastIndexByte(data, '\n'); idx+1 < len(data) {
+		complete = data[:idx+1]
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(complete))
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			c.done[id] = struct{}{}
+		}
+	}
+	if readOnly {
+		return c, nil
+	}
+	if c.file, err = os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+		return nil, err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		_, err = c.file.WriteString("\n")
+	}
+	return c, err
+}
+
+// IsDone reports whether the object was already migrated
+func (c *checkpoint) IsDone(id string) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	_, ok := c.done[id]
+	return ok
+}
+
+// Done marks the object as migrated
+func (c *checkpoint) Done(id string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.done[id] = struct{}{}
+	if c.file == nil {
+		return nil
+	}
+	_, err := c.file.WriteString(id + "\n")
+	return err
+}
+
+// Close the checkpoint file
+func (c *checkpoint) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	return c.file.Close()
+}
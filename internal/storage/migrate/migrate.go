@@ -0,0 +1,228 @@
+// Package migrate copies the objects of one storage driver to another one.
+//
+// The source is walked by the ObjectScanner: every "<group>/manifest.*" is
+// copied as the workflow of the group and every directory with "meta.json"
+// as the object with all its files and the processing state. The copied files
+// are verified by MD5 and the completed objects are appended to the
+// checkpoint file, so the interrupted migration continues where it stopped.
+package migrate
+
+import (
+	"context"
+	"crypto/md5"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/storio"
+)
+
+const (
+	metaFileName  = "meta.json"
+	stateFileName = "state.json"
+)
+
+// ErrChecksumMismatch is returned if the copied file differs from the source
+var ErrChecksumMismatch = errors.New("[migrate] checksum mismatch")
+
+// Report of the migration
+type Report struct {
+	Workflows int64
+	Objects   int64
+	Skipped   int64
+	Files     int64
+	Bytes     int64
+	Failed    []string
+}
+
+// Migrator copies the objects from the source storage to the destination
+type Migrator struct {
+	src storio.StorageAccessor
+	dst storio.StorageAccessor
+
+	group       string
+	concurrency int
+	checkpoint  string
+	dryRun      bool
+}
+
+// New migrator from the source storage to the destination one
+func New(src, dst storio.StorageAccessor, opts ...Option) *Migrator {
+	m := &Migrator{src: src, dst: dst, concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.concurrency < 1 {
+		m.concurrency = 1
+	}
+	return m
+}
+
+// Run the migration. The failed objects are listed in the report and are not
+// added to the checkpoint, so they are retried by the next run.
+func (m *Migrator) Run(ctx context.Context) (*Report, error) {
+	var (
+		report         = &Report{}
+		groups, ids, e = m.scan(ctx)
+	)
+	if e != nil {
+		return nil, errors.Wrap(e, "scan")
+	}
+	checkpoint, err := openCheckpoint(m.checkpoint, m.dryRun)
+	if err != nil {
+		return nil, errors.Wrap(err, "checkpoint")
+	}
+	defer func() { _ = checkpoint.Close() }()
+
+	for _, group := range groups {
+		if err = m.copyWorkflow(ctx, group); err != nil {
+			return nil, errors.Wrap(err, "workflow "+group)
+		}
+		report.Workflows++
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mx    sync.Mutex
+		queue = make(chan storio.ObjectIDType)
+	)
+	for i := 0; i < m.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				files, size, err := m.copyObject(ctx, id)
+				if err == nil && !m.dryRun {
+					err = checkpoint.Done(id.String())
+				}
+				if err != nil {
+					ctxlogger.Get(ctx).Error("migrate object",
+						zap.String("object", id.String()), zap.Error(err))
+					mx.Lock()
+					report.Failed = append(report.Failed, id.String())
+					mx.Unlock()
+					continue
+				}
+				atomic.AddInt64(&report.Objects, 1)
+				atomic.AddInt64(&report.Files, files)
+				atomic.AddInt64(&report.Bytes, size)
+			}
+		}()
+	}
+loop:
+	for _, id := range ids {
+		if checkpoint.IsDone(id.String()) {
+			report.Skipped++
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			break loop
+		case queue <- id:
+		}
+	}
+	close(queue)
+	wg.Wait()
+	sort.Strings(report.Failed)
+	return report, ctx.Err()
+}
+
+// scan the source for the groups with the workflow and the objects
+func (m *Migrator) scan(ctx context.Context) (groups []string, ids []storio.ObjectIDType, err error) {
+	pattern := "**"
+	if m.group != "" {
+		pattern = m.group + "/**"
+	}
+	err = m.src.Scan(ctx, pattern, func(name string, err error) error {
+		if err != nil {
+			return err
+		}
+		dir, base := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case base == metaFileName && strings.Contains(dir, "/"):
+			ids = append(ids, storio.ObjectIDType(dir))
+		case strings.HasPrefix(base, "manifest.") && dir != "" && !strings.Contains(dir, "/"):
+			groups = append(groups, dir)
+		}
+		return ctx.Err()
+	})
+	return groups, ids, err
+}
+
+func (m *Migrator) copyWorkflow(ctx context.Context, group string) error {
+	workflow, err := m.src.ReadWorkflow(ctx, group)
+	if err != nil || workflow == nil || m.dryRun {
+		return err
+	}
+	return m.dst.UpdateWorkflow(ctx, group, workflow)
+}
+
+// copyObject copies the files of the object, the meta is written the last
+// so the object is not visible on the destination until all files copied
+func (m *Migrator) copyObject(ctx context.Context, id storio.ObjectIDType) (count, size int64, err error) {
+	files, err := m.src.ListFiles(ctx, id, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Path != metaFileName && files[j].Path == metaFileName
+	})
+	for _, file := range files {
+		if file.Path == stateFileName {
+			continue
+		}
+		n := file.Size
+		if !m.dryRun {
+			if n, err = m.copyFile(ctx, id, file.Path); err != nil {
+				return 0, 0, errors.Wrap(err, file.Path)
+			}
+		}
+		count++
+		size += n
+	}
+	if m.dryRun {
+		return count, size, nil
+	}
+	state, err := m.src.ReadState(ctx, id)
+	if err != nil || state == nil {
+		return count, size, err
+	}
+	return count, size, errors.Wrap(m.dst.WriteState(ctx, id, state), stateFileName)
+}
+
+// copyFile copies the file and compares the MD5 of the source and the written data
+func (m *Migrator) copyFile(ctx context.Context, id storio.ObjectID, name string) (int64, error) {
+	reader, err := m.src.ReadFile(ctx, id, name)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	sum := md5.New()
+	if err = m.dst.WriteFile(ctx, id, name, io.TeeReader(reader, sum), nil); err != nil {
+		return 0, err
+	}
+	written, err := m.dst.ReadFile(ctx, id, name)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = written.Close() }()
+
+	check := md5.New()
+	size, err := io.Copy(check, written)
+	if err != nil {
+		return 0, err
+	}
+	if string(sum.Sum(nil)) != string(check.Sum(nil)) {
+		return 0, ErrChecksumMismatch
+	}
+	return size, nil
+}
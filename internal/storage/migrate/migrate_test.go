@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/driver/fs"
+	"github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+func newFSStorage(t *testing.T) *fs.Storage {
+	root := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.MkdirAll(root, 0o755))
+	store, err := fs.NewStorage(root)
+	require.NoError(t, err)
+	return store
+}
+
+func TestMigrate(t *testing.T) {
+	var (
+		ctx        = context.TODO()
+		src        = newFSStorage(t)
+		dst        = newFSStorage(t)
+		checkpoint = filepath.Join(t.TempDir(), "migrate.checkpoint")
+	)
+	workflow := &models.Workflow{Name: "media"}
+	require.NoError(t, src.UpdateWorkflow(ctx, "media", workflow))
+
+	var ids []storio.ObjectID
+	for _, data := range []string{"first", "second"} {
+		obj, err := src.Create(ctx, "media", nil, false, nil)
+		require.NoError(t, err)
+		require.NoError(t, src.Update(ctx, obj, models.OriginalFilename, strings.NewReader(data), nil))
+		require.NoError(t, src.WriteFile(ctx, obj, "thumbs/1.txt", strings.NewReader("thumb "+data), nil))
+		require.NoError(t, src.WriteState(ctx, obj, models.NewProcessingState(obj.ID().String(), "1", nil)))
+		ids = append(ids, obj.ID())
+	}
+
+	// Dry run counts the files without writing
+	report, err := New(src, dst, WithDryRun(true), WithCheckpoint(checkpoint)).Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Workflows)
+	assert.Equal(t, int64(2), report.Objects)
+	assert.Equal(t, int64(6), report.Files)
+	_, err = dst.Open(ctx, ids[0])
+	assert.Error(t, err)
+	_, err = os.Stat(checkpoint)
+	assert.True(t, os.IsNotExist(err))
+
+	report, err = New(src, dst, WithGroup("media"), WithConcurrency(2), WithCheckpoint(checkpoint)).Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Objects)
+	assert.Equal(t, int64(6), report.Files)
+	assert.Empty(t, report.Failed)
+
+	for i, data := range []string{"first", "second"} {
+		obj, err := dst.Open(ctx, ids[i])
+		require.NoError(t, err)
+		assert.Equal(t, "media", obj.Workflow().Name)
+		reader, err := dst.Read(ctx, obj, models.OriginalFilename)
+		require.NoError(t, err)
+		content, _ := io.ReadAll(reader)
+		_ = reader.Close()
+		assert.Equal(t, data, string(content))
+
+		state, err := dst.ReadState(ctx, obj)
+		require.NoError(t, err)
+		require.NotNil(t, state)
+		assert.Equal(t, obj.ID().String(), state.ObjectID)
+	}
+
+	// The completed objects are skipped by the next run
+	report, err = New(src, dst, WithCheckpoint(checkpoint)).Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), report.Objects)
+	assert.Equal(t, int64(2), report.Skipped)
+}
+
+func TestCheckpointIncompleteLine(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checkpoint")
+	require.NoError(t, os.WriteFile(filename, []byte("media/a\nmedia/b"), 0o644))
+
+	c, err := openCheckpoint(filename, false)
+	require.NoError(t, err)
+	assert.True(t, c.IsDone("media/a"))
+	assert.False(t, c.IsDone("media/b"))
+	require.NoError(t, c.Done("media/c"))
+	require.NoError(t, c.Close())
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "media/a\nmedia/b\nmedia/c\n", string(data))
+}
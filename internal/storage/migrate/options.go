@@ -0,0 +1,35 @@
+package migrate
+
+// DefaultConcurrency of the object copying
+const DefaultConcurrency = 4
+
+// Option of the migrator
+type Option func(m *Migrator)
+
+// WithGroup limits the migration by the single group
+func WithGroup(group string) Option {
+	return func(m *Migrator) {
+		m.group = group
+	}
+}
+
+// WithConcurrency sets the number of the objects copied in parallel
+func WithConcurrency(concurrency int) Option {
+	return func(m *Migrator) {
+		m.concurrency = concurrency
+	}
+}
+
+// WithCheckpoint keeps the completed objects in the file to resume the migration
+func WithCheckpoint(filename string) Option {
+	return func(m *Migrator) {
+		m.checkpoint = filename
+	}
+}
+
+// WithDryRun only counts the objects and files to copy
+func WithDryRun(dryRun bool) Option {
+	return func(m *Migrator) {
+		m.dryRun = dryRun
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"net/url"
 
 	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/internal/storage/metacache"
+	"github.com/apfs-io/apfs/internal/storage/quota"
+	"github.com/apfs-io/apfs/internal/storage/statestore"
+	"github.com/apfs-io/apfs/internal/storage/stats"
 	storio "github.com/apfs-io/apfs/internal/storio"
 )
 
@@ -88,6 +92,19 @@ type Options struct {
 	// Processing status KeyValue accessor.
 	// contains statuses of the object processing stages
 	processingStatus kvaccessor.KVAccessor
+
+	// Store of the workflow processing states, the driver keeps
+	// state.json beside the object if not set
+	stateStore statestore.StateStore
+
+	// Cache of the object metadata (optional)
+	metaCache metacache.MetaCache
+
+	// Recorder of the group statistics (optional)
+	stats *stats.Recorder
+
+	// Limiter of the group quotas (optional)
+	quota *quota.Limiter
 }
 
 func (opts *Options) validate() error {
@@ -120,3 +137,31 @@ func WithProcessingStatus(processingStatus kvaccessor.KVAccessor) Option {
 		opts.processingStatus = processingStatus
 	}
 }
+
+// WithStateStore of the workflow processing states
+func WithStateStore(store statestore.StateStore) Option {
+	return func(opts *Options) {
+		opts.stateStore = store
+	}
+}
+
+// WithMetaCache of the object metadata
+func WithMetaCache(cache metacache.MetaCache) Option {
+	return func(opts *Options) {
+		opts.metaCache = cache
+	}
+}
+
+// WithStats recorder of the group statistics
+func WithStats(recorder *stats.Recorder) Option {
+	return func(opts *Options) {
+		opts.stats = recorder
+	}
+}
+
+// WithQuota limiter of the group quotas defined by the workflow
+func WithQuota(limiter *quota.Limiter) Option {
+	return func(opts *Options) {
+		opts.quota = limiter
+	}
+}
@@ -0,0 +1,111 @@
+// Package quota checks the group limits of the workflow quota before the upload.
+// The usage is read from the group statistics and the upload rate is counted
+// in the shared window counters, so the limits work across the replicas.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/internal/storage/stats"
+	"github.com/apfs-io/apfs/models"
+)
+
+// RateWindow of the upload rate limit
+const RateWindow = time.Minute
+
+// rateKeyPrefix of the upload rate counters: quota:rate:<group>:<principal>
+const rateKeyPrefix = `quota:rate:`
+
+// Limit names of the exceeded quota
+const (
+	LimitBytes   = "max_bytes"
+	LimitObjects = "max_objects"
+	LimitUploads = "uploads_per_minute"
+)
+
+// ExceededError is returned when the upload exceeds the group quota
+type ExceededError struct {
+	Group string
+	Limit string
+
+	// RetryAfter is the time when the upload could be retried,
+	// zero if the quota is not released by itself
+	RetryAfter time.Duration
+}
+
+// Error implements error
+func (e *ExceededError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("[quota] group %q exceeded %s, retry after %s", e.Group, e.Limit, e.RetryAfter)
+	}
+	return fmt.Sprintf("[quota] group %q exceeded %s", e.Group, e.Limit)
+}
+
+// IsExceeded returns the quota error if the err is caused by the exceeded quota
+func IsExceeded(err error) (*ExceededError, bool) {
+	var qerr *ExceededError
+	if errors.As(err, &qerr) {
+		return qerr, true
+	}
+	return nil, false
+}
+
+// Limiter checks the quota of the group
+type Limiter struct {
+	stats *stats.Recorder
+	rate  kvaccessor.WindowCounter
+}
+
+// NewLimiter of the quotas, the usage limits are skipped without stats
+// and the rate limit is skipped without the rate counter
+func NewLimiter(stats *stats.Recorder, rate kvaccessor.WindowCounter) *Limiter {
+	return &Limiter{stats: stats, rate: rate}
+}
+
+// Check the upload of the size bytes (0 if unknown) by the principal to the group
+func (l *Limiter) Check(ctx context.Context, group, principal string, size int64, quota *models.WorkflowQuota) error {
+	if quota.IsEmpty() {
+		return nil
+	}
+	if err := l.checkUsage(ctx, group, size, quota); err != nil {
+		return err
+	}
+	return l.checkRate(ctx, group, principal, quota)
+}
+
+func (l *Limiter) checkUsage(ctx context.Context, group string, size int64, quota *models.WorkflowQuota) error {
+	maxBytes := quota.MaxBytesValue()
+	if l.stats == nil || (maxBytes <= 0 && quota.MaxObjects <= 0) {
+		return nil
+	}
+	usage, err := l.stats.Group(ctx, group)
+	if err != nil {
+		return err
+	}
+	if maxBytes > 0 && (usage.TotalBytes() >= maxBytes || usage.TotalBytes()+max(size, 0) > maxBytes) {
+		return &ExceededError{Group: group, Limit: LimitBytes}
+	}
+	if quota.MaxObjects > 0 && usage.Objects >= quota.MaxObjects {
+		return &ExceededError{Group: group, Limit: LimitObjects}
+	}
+	return nil
+}
+
+func (l *Limiter) checkRate(ctx context.Context, group, principal string, quota *models.WorkflowQuota) error {
+	if l.rate == nil || quota.UploadsPerMinute <= 0 {
+		return nil
+	}
+	count, left, err := l.rate.IncrWindow(ctx, rateKeyPrefix+group+":"+principal, RateWindow)
+	if err != nil {
+		return err
+	}
+	if count > quota.UploadsPerMinute {
+		return &ExceededError{Group: group, Limit: LimitUploads, RetryAfter: left}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	"github.com/apfs-io/apfs/internal/storage/stats"
+	"github.com/apfs-io/apfs/models"
+)
+
+func TestLimiter(t *testing.T) {
+	var (
+		ctx      = context.TODO()
+		counter  = memory.NewCounter()
+		recorder = stats.NewRecorder(counter)
+		limiter  = NewLimiter(recorder, counter)
+	)
+	require.NoError(t, recorder.ObjectUpdated(ctx, &models.Object{ID: "images/1", Bucket: "images", Size: 600}))
+
+	t.Run("empty", func(t *testing.T) {
+		assert.NoError(t, limiter.Check(ctx, "images", "user", 1<<30, nil))
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		quota := &models.WorkflowQuota{MaxBytes: "1KB"}
+		assert.NoError(t, limiter.Check(ctx, "images", "user", 100, quota))
+		err := limiter.Check(ctx, "images", "user", 500, quota)
+		qerr, ok := IsExceeded(err)
+		require.True(t, ok, err)
+		assert.Equal(t, LimitBytes, qerr.Limit)
+		assert.Zero(t, qerr.RetryAfter)
+	})
+
+	t.Run("objects", func(t *testing.T) {
+		err := limiter.Check(ctx, "images", "user", 0, &models.WorkflowQuota{MaxObjects: 1})
+		qerr, ok := IsExceeded(err)
+		require.True(t, ok, err)
+		assert.Equal(t, LimitObjects, qerr.Limit)
+		assert.NoError(t, limiter.Check(ctx, "videos", "user", 0, &models.WorkflowQuota{MaxObjects: 1}))
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		quota := &models.WorkflowQuota{UploadsPerMinute: 2}
+		assert.NoError(t, limiter.Check(ctx, "images", "user", 0, quota))
+		assert.NoError(t, limiter.Check(ctx, "images", "user", 0, quota))
+		err := limiter.Check(ctx, "images", "user", 0, quota)
+		qerr, ok := IsExceeded(err)
+		require.True(t, ok, err)
+		assert.Equal(t, LimitUploads, qerr.Limit)
+		assert.Greater(t, qerr.RetryAfter, time.Duration(0))
+		assert.LessOrEqual(t, qerr.RetryAfter, RateWindow)
+
+		// Other principals have their own limit
+		assert.NoError(t, limiter.Check(ctx, "images", "other", 0, quota))
+	})
+}
@@ -0,0 +1,109 @@
+// Package badger provides a StateStore persisted in the embedded BadgerDB.
+// The database is owned by a single process, so it serves single-node
+// deployments which have nothing to invalidate on the other nodes.
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+
+	kvbadger "github.com/apfs-io/apfs/internal/storage/kvaccessor/badger"
+	"github.com/apfs-io/apfs/internal/storage/statestore"
+	"github.com/apfs-io/apfs/models"
+)
+
+const keyPrefix = `state/`
+
+// Store is the BadgerDB StateStore
+type Store struct {
+	db *badger.DB
+
+	// ttl of the state records, zero keeps them forever
+	ttl time.Duration
+}
+
+// New opens the database
+// connection: badger:///var/lib/apfs/state?sync=true&ttl=720h
+func New(connection string) (*Store, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	db, err := kvbadger.Open(connection)
+	if err != nil {
+		return nil, err
+	}
+	ttl, _ := time.ParseDuration(u.Query().Get(`ttl`))
+	return NewWithDB(db, ttl), nil
+}
+
+// NewWithDB returns the store over the opened database
+func NewWithDB(db *badger.DB, ttl time.Duration) *Store {
+	return &Store{db: db, ttl: ttl}
+}
+
+// Get implements StateStore.
+func (s *Store) Get(_ context.Context, id string) (*models.ProcessingState, error) {
+	var state *models.ProcessingState
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(keyPrefix + id))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(data []byte) error {
+			return json.Unmarshal(data, &state)
+		})
+	})
+	return state, err
+}
+
+// Set implements StateStore.
+func (s *Store) Set(ctx context.Context, id string, state *models.ProcessingState) error {
+	if state == nil {
+		return s.Delete(ctx, id)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		entry := badger.NewEntry([]byte(keyPrefix+id), data)
+		if s.ttl > 0 {
+			entry = entry.WithTTL(s.ttl)
+		}
+		return txn.SetEntry(entry)
+	})
+}
+
+// Delete implements StateStore.
+func (s *Store) Delete(_ context.Context, id string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(keyPrefix + id))
+	})
+}
+
+// Counter returns the counters kept in the same database,
+// badger allows only one process per database directory
+func (s *Store) Counter() *kvbadger.Counter {
+	return kvbadger.NewCounterWithDB(s.db)
+}
+
+// Hash returns the hashes kept in the same database
+func (s *Store) Hash() *kvbadger.Hash {
+	return kvbadger.NewHashWithDB(s.db)
+}
+
+// Close the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+var _ statestore.StateStore = (*Store)(nil)
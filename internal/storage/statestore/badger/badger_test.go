@@ -0,0 +1,42 @@
+package badger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+func TestStore(t *testing.T) {
+	var (
+		ctx = context.TODO()
+		dir = t.TempDir()
+	)
+	store, err := New("badger://" + dir + "?sync=true")
+	require.NoError(t, err)
+
+	state, err := store.Get(ctx, "obj")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	require.NoError(t, store.Set(ctx, "obj", models.NewProcessingState("obj", "2", []string{"thumb"})))
+	require.NoError(t, store.Close())
+
+	// The state survives the restart
+	store, err = New("badger://" + dir)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	state, err = store.Get(ctx, "obj")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, models.JobStatusPending, state.Jobs["thumb"].Status)
+
+	require.NoError(t, store.Set(ctx, "obj", nil))
+	state, err = store.Get(ctx, "obj")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
@@ -0,0 +1,114 @@
+// Package redis provides a StateStore shared by the server replicas through
+// redis. Recently read states are kept in a short-lived local cache which is
+// invalidated when another replica writes the state.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	kvredis "github.com/apfs-io/apfs/internal/storage/kvaccessor/redis"
+	"github.com/apfs-io/apfs/internal/storage/statestore"
+	"github.com/apfs-io/apfs/models"
+)
+
+const (
+	keyPrefix           = "apfs:state:"
+	invalidationChannel = "apfs:state:invalidate"
+	localCacheSize      = 4096
+)
+
+// DefaultLocalTTL is the lifetime of the local copy of the state
+const DefaultLocalTTL = time.Second
+
+// Store is the redis StateStore
+type Store struct {
+	client *goredis.Client
+	cache  *kvredis.SyncedCache
+
+	// ttl of the state records, zero keeps them forever
+	ttl time.Duration
+}
+
+// New connects to the redis server
+// connection: redis://localhost:6379/0?ttl=720h&local_ttl=1s
+func New(connection string) (*Store, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kvredis.NewClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	ttl, _ := time.ParseDuration(u.Query().Get(`ttl`))
+	localTTL := DefaultLocalTTL
+	if v := u.Query().Get(`local_ttl`); v != "" {
+		localTTL, _ = time.ParseDuration(v)
+	}
+	return NewWithClient(client, ttl, localTTL), nil
+}
+
+// NewWithClient returns the store over the redis client
+func NewWithClient(client *goredis.Client, ttl, localTTL time.Duration) *Store {
+	return &Store{
+		client: client,
+		cache:  kvredis.NewSyncedCache(client, invalidationChannel, localCacheSize, localTTL),
+		ttl:    ttl,
+	}
+}
+
+// Get implements StateStore.
+func (s *Store) Get(ctx context.Context, id string) (*models.ProcessingState, error) {
+	data, err := s.cache.Load(ctx, id, func() ([]byte, error) {
+		data, err := s.client.Get(ctx, keyPrefix+id).Bytes()
+		if errors.Is(err, goredis.Nil) {
+			return nil, nil
+		}
+		return data, err
+	})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var state models.ProcessingState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Set implements StateStore.
+func (s *Store) Set(ctx context.Context, id string, state *models.ProcessingState) error {
+	if state == nil {
+		return s.Delete(ctx, id)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = s.client.Set(ctx, keyPrefix+id, data, s.ttl).Err(); err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, id, data)
+}
+
+// Delete implements StateStore.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, keyPrefix+id).Err(); err != nil {
+		return err
+	}
+	return s.cache.Invalidate(ctx, id)
+}
+
+// Close the redis connection
+func (s *Store) Close() error {
+	s.cache.Close()
+	return s.client.Close()
+}
+
+var _ statestore.StateStore = (*Store)(nil)
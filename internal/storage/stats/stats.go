@@ -0,0 +1,297 @@
+// Package stats keeps the usage statistics of the groups up to date on every
+// object upload, update, processing state change and delete, so the stats
+// are read from the counters without scanning the objects.
+package stats
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/models"
+)
+
+// Key prefixes of the group and object counters
+const (
+	groupKeyPrefix  = `stats:group:`
+	objectKeyPrefix = `stats:object:`
+)
+
+// Counter fields
+const (
+	fieldObjects          = `objects`
+	fieldOriginalBytes    = `bytes:original`
+	fieldDerivedBytes     = `bytes:derived`
+	fieldProcessingPrefix = `processing:`
+	fieldJobPrefix        = `job:`
+
+	// Object record fields: the contribution of the object to the group counters
+	fieldObjectUpdates = `updates`
+	fieldObjectStatus  = `status:`
+)
+
+// Job counter suffixes
+const (
+	jobCompleted  = `:completed`
+	jobFailed     = `:failed`
+	jobDurationMs = `:duration_ms`
+)
+
+// JobStats of the workflow job in the group
+type JobStats struct {
+	Completed int64
+	Failed    int64
+
+	// TotalDuration of the completed runs
+	TotalDuration time.Duration
+}
+
+// AverageDuration of the completed job run
+func (s *JobStats) AverageDuration() time.Duration {
+	if s == nil || s.Completed == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Completed)
+}
+
+// Stats of the group
+type Stats struct {
+	Group         string
+	Objects       int64
+	OriginalBytes int64
+	DerivedBytes  int64
+
+	// Processing is the number of the objects in every processing status
+	Processing map[models.ProcessingStatus]int64
+
+	// Jobs statistics by job ID
+	Jobs map[string]*JobStats
+}
+
+// TotalBytes used by the group
+func (s *Stats) TotalBytes() int64 {
+	return s.OriginalBytes + s.DerivedBytes
+}
+
+// Recorder updates the group counters incrementally
+type Recorder struct {
+	counter kvaccessor.Counter
+}
+
+// NewRecorder of the statistics kept by the counter
+func NewRecorder(counter kvaccessor.Counter) *Recorder {
+	return &Recorder{counter: counter}
+}
+
+// GroupOf returns the group of the object ID (group/path)
+func GroupOf(objectID string) string {
+	group, _, _ := strings.Cut(objectID, "/")
+	return group
+}
+
+// ObjectUpdated records the sizes of the created or updated object
+func (r *Recorder) ObjectUpdated(ctx context.Context, obj *models.Object) error {
+	var (
+		objectKey = objectKeyPrefix + obj.ID
+		groupKey  = groupKeyPrefix + obj.Bucket
+	)
+	current, err := r.counter.Counters(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+	updates, err := r.counter.IncrBy(ctx, objectKey, fieldObjectUpdates, 1)
+	if err != nil {
+		return err
+	}
+	if updates == 1 {
+		if _, err = r.counter.IncrBy(ctx, groupKey, fieldObjects, 1); err != nil {
+			return err
+		}
+	}
+	sizes := map[string]int64{
+		fieldOriginalBytes: int64(obj.Size),
+		fieldDerivedBytes:  derivedSize(obj.Meta.Data),
+	}
+	for field, size := range sizes {
+		if err = r.add(ctx, objectKey, groupKey, field, size-current[field]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObjectDeleted subtracts the object from the group counters
+func (r *Recorder) ObjectDeleted(ctx context.Context, objectID string) error {
+	var (
+		objectKey = objectKeyPrefix + objectID
+		groupKey  = groupKeyPrefix + GroupOf(objectID)
+	)
+	current, err := r.counter.Counters(ctx, objectKey)
+	if err != nil || len(current) == 0 {
+		return err
+	}
+	if err = r.counter.DeleteCounters(ctx, objectKey); err != nil {
+		return err
+	}
+	for field, value := range current {
+		switch {
+		case field == fieldObjectUpdates:
+			field, value = fieldObjects, 1
+		case strings.HasPrefix(field, fieldObjectStatus):
+			field = fieldProcessingPrefix + strings.TrimPrefix(field, fieldObjectStatus)
+		}
+		if _, err = r.counter.IncrBy(ctx, groupKey, field, -value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StateChanged records the processing status of the object and the jobs
+// finished since the previous state
+func (r *Recorder) StateChanged(ctx context.Context, objectID string, prev, next *models.ProcessingState) error {
+	if next == nil {
+		return nil
+	}
+	var (
+		objectKey = objectKeyPrefix + objectID
+		groupKey  = groupKeyPrefix + GroupOf(objectID)
+	)
+	current, err := r.counter.Counters(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+	// Move the object to the new processing status
+	status := fieldObjectStatus + next.Status.String()
+	for field, value := range current {
+		if field == status || !strings.HasPrefix(field, fieldObjectStatus) || value == 0 {
+			continue
+		}
+		if err = r.setStatus(ctx, objectKey, groupKey, field, -value); err != nil {
+			return err
+		}
+	}
+	if next.Status != "" && current[status] == 0 {
+		if err = r.setStatus(ctx, objectKey, groupKey, status, 1); err != nil {
+			return err
+		}
+	}
+
+	// Count the jobs which reached the terminal status
+	for jobID, job := range next.Jobs {
+		if job == nil || !jobFinished(prev, jobID, job) {
+			continue
+		}
+		prefix := fieldJobPrefix + jobID
+		switch job.Status {
+		case models.JobStatusFailed:
+			_, err = r.counter.IncrBy(ctx, groupKey, prefix+jobFailed, 1)
+		case models.JobStatusCompleted:
+			if _, err = r.counter.IncrBy(ctx, groupKey, prefix+jobCompleted, 1); err == nil {
+				_, err = r.counter.IncrBy(ctx, groupKey, prefix+jobDurationMs, jobDuration(job).Milliseconds())
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Group returns the statistics of the group
+func (r *Recorder) Group(ctx context.Context, group string) (*Stats, error) {
+	counters, err := r.counter.Counters(ctx, groupKeyPrefix+group)
+	if err != nil {
+		return nil, err
+	}
+	stats := &Stats{
+		Group:         group,
+		Objects:       counters[fieldObjects],
+		OriginalBytes: counters[fieldOriginalBytes],
+		DerivedBytes:  counters[fieldDerivedBytes],
+		Processing:    map[models.ProcessingStatus]int64{},
+		Jobs:          map[string]*JobStats{},
+	}
+	for field, value := range counters {
+		switch {
+		case strings.HasPrefix(field, fieldProcessingPrefix):
+			if value != 0 {
+				stats.Processing[models.ProcessingStatus(strings.TrimPrefix(field, fieldProcessingPrefix))] = value
+			}
+		case strings.HasPrefix(field, fieldJobPrefix):
+			name := strings.TrimPrefix(field, fieldJobPrefix)
+			i := strings.LastIndex(name, ":")
+			if i < 0 {
+				continue
+			}
+			jobID := name[:i]
+			job := stats.Jobs[jobID]
+			if job == nil {
+				job = &JobStats{}
+				stats.Jobs[jobID] = job
+			}
+			switch name[i:] {
+			case jobCompleted:
+				job.Completed = value
+			case jobFailed:
+				job.Failed = value
+			case jobDurationMs:
+				job.TotalDuration = time.Duration(value) * time.Millisecond
+			}
+		}
+	}
+	return stats, nil
+}
+
+// add the delta to the object record and the group counter
+func (r *Recorder) add(ctx context.Context, objectKey, groupKey, field string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	if _, err := r.counter.IncrBy(ctx, objectKey, field, delta); err != nil {
+		return err
+	}
+	_, err := r.counter.IncrBy(ctx, groupKey, field, delta)
+	return err
+}
+
+func (r *Recorder) setStatus(ctx context.Context, objectKey, groupKey, field string, delta int64) error {
+	if _, err := r.counter.IncrBy(ctx, objectKey, field, delta); err != nil {
+		return err
+	}
+	groupField := fieldProcessingPrefix + strings.TrimPrefix(field, fieldObjectStatus)
+	_, err := r.counter.IncrBy(ctx, groupKey, groupField, delta)
+	return err
+}
+
+// jobFinished returns true if the job reached the terminal status after the previous state
+func jobFinished(prev *models.ProcessingState, jobID string, job *models.JobState) bool {
+	if job.Status != models.JobStatusCompleted && job.Status != models.JobStatusFailed {
+		return false
+	}
+	if prev == nil || prev.Jobs[jobID] == nil {
+		return true
+	}
+	prevJob := prev.Jobs[jobID]
+	return prevJob.Status != job.Status || prevJob.Attempts != job.Attempts
+}
+
+func jobDuration(job *models.JobState) time.Duration {
+	if job.StartedAt == nil || job.FinishedAt == nil || job.FinishedAt.Before(*job.StartedAt) {
+		return 0
+	}
+	return job.FinishedAt.Sub(*job.StartedAt)
+}
+
+func derivedSize(meta *models.Meta) (size int64) {
+	if meta == nil {
+		return 0
+	}
+	for _, item := range meta.Items {
+		if item != nil {
+			size += item.Size
+		}
+	}
+	return size
+}
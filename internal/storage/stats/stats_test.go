@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/geniusrabbit/gosql/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	"github.com/apfs-io/apfs/models"
+)
+
+func newObject(id string, size uint64, items ...int64) *models.Object {
+	meta := &models.Meta{}
+	for i, itemSize := range items {
+		meta.Items = append(meta.Items, &models.ItemMeta{Name: string(rune('a' + i)), Size: itemSize})
+	}
+	return &models.Object{
+		ID:     id,
+		Bucket: GroupOf(id),
+		Size:   size,
+		Meta:   gosql.NullableJSON[models.Meta]{Data: meta},
+	}
+}
+
+func finishedJob(status models.JobStatus, duration time.Duration) *models.JobState {
+	started := time.Now().Add(-duration)
+	finished := started.Add(duration)
+	return &models.JobState{Status: status, Attempts: 1, StartedAt: &started, FinishedAt: &finished}
+}
+
+func TestRecorder(t *testing.T) {
+	var (
+		ctx      = context.TODO()
+		recorder = NewRecorder(memory.NewCounter())
+	)
+
+	// Upload and process two objects
+	require.NoError(t, recorder.ObjectUpdated(ctx, newObject("avatars/1", 100)))
+	require.NoError(t, recorder.ObjectUpdated(ctx, newObject("avatars/1", 100, 10, 20)))
+	require.NoError(t, recorder.ObjectUpdated(ctx, newObject("avatars/2", 300, 5)))
+	require.NoError(t, recorder.ObjectUpdated(ctx, newObject("videos/1", 1000)))
+
+	pending := models.NewProcessingState("avatars/1", "2", []string{"thumb", "blur"})
+	require.NoError(t, recorder.StateChanged(ctx, "avatars/1", nil, pending))
+	done := models.NewProcessingState("avatars/1", "2", nil)
+	done.Jobs["thumb"] = finishedJob(models.JobStatusCompleted, 2*time.Second)
+	done.Jobs["blur"] = finishedJob(models.JobStatusFailed, time.Second)
+	done.ComputeStatus()
+	require.NoError(t, recorder.StateChanged(ctx, "avatars/1", pending, done))
+	// The same state written again is not counted twice
+	require.NoError(t, recorder.StateChanged(ctx, "avatars/1", done, done))
+
+	other := models.NewProcessingState("avatars/2", "2", nil)
+	other.Jobs["thumb"] = finishedJob(models.JobStatusCompleted, 4*time.Second)
+	other.ComputeStatus()
+	require.NoError(t, recorder.StateChanged(ctx, "avatars/2", nil, other))
+
+	stats, err := recorder.Group(ctx, "avatars")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Objects)
+	assert.Equal(t, int64(400), stats.OriginalBytes)
+	assert.Equal(t, int64(35), stats.DerivedBytes)
+	assert.Equal(t, int64(435), stats.TotalBytes())
+	assert.Equal(t, map[models.ProcessingStatus]int64{
+		models.ProcessingStatusPartial:   1,
+		models.ProcessingStatusCompleted: 1,
+	}, stats.Processing)
+	require.Contains(t, stats.Jobs, "thumb")
+	assert.Equal(t, int64(2), stats.Jobs["thumb"].Completed)
+	assert.Equal(t, 3*time.Second, stats.Jobs["thumb"].AverageDuration())
+	assert.Equal(t, int64(1), stats.Jobs["blur"].Failed)
+
+	// Delete subtracts the contribution of the object
+	require.NoError(t, recorder.ObjectDeleted(ctx, "avatars/1"))
+	require.NoError(t, recorder.ObjectDeleted(ctx, "avatars/1"))
+	stats, err = recorder.Group(ctx, "avatars")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Objects)
+	assert.Equal(t, int64(300), stats.OriginalBytes)
+	assert.Equal(t, int64(5), stats.DerivedBytes)
+	assert.Equal(t, map[models.ProcessingStatus]int64{
+		models.ProcessingStatusCompleted: 1,
+	}, stats.Processing)
+	assert.Equal(t, int64(1), stats.Jobs["blur"].Failed, "the job history is kept")
+
+	stats, err = recorder.Group(ctx, "videos")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Objects)
+	assert.Equal(t, int64(1000), stats.TotalBytes())
+}
@@ -7,6 +7,7 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -18,11 +19,18 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/context/ctxprincipal"
 	"github.com/apfs-io/apfs/internal/object"
 	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/internal/storage/metacache"
 	"github.com/apfs-io/apfs/internal/storage/processor"
+	"github.com/apfs-io/apfs/internal/storage/quota"
+	"github.com/apfs-io/apfs/internal/storage/statestore"
+	"github.com/apfs-io/apfs/internal/storage/stats"
 	storio "github.com/apfs-io/apfs/internal/storio"
 	"github.com/apfs-io/apfs/internal/validation"
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/libs/storerrors"
 	"github.com/apfs-io/apfs/models"
 )
 
@@ -34,6 +42,7 @@ var (
 	ErrStorageObjectInProcessing   = errors.New("[storage] object in processing")
 	ErrStorageInvalidGroupName     = errors.New("[storage] invalid group name")
 	ErrStorageInvalidAction        = errors.New("[storage] invalid action")
+	ErrStorageStatsNotSupported    = errors.New("[storage] group statistics are not configured")
 )
 
 // AllTasks defines task processing count
@@ -55,6 +64,18 @@ type Storage struct {
 	// Key-value accessor for processing statuses
 	processingStatus kvaccessor.KVAccessor
 
+	// Store of the workflow processing states (optional)
+	stateStore statestore.StateStore
+
+	// Cache of the object metadata (optional)
+	metaCache metacache.MetaCache
+
+	// Recorder of the group statistics (optional)
+	stats *stats.Recorder
+
+	// Limiter of the group quotas (optional)
+	quota *quota.Limiter
+
 	// Validator runs synchronous checks during Upload.
 	// When nil, validation is skipped.
 	Validator validation.Validator
@@ -73,6 +94,10 @@ func NewStorage(options ...Option) *Storage {
 		db:               opts.Database,
 		driver:           opts.Driver,
 		processingStatus: opts.processingStatus,
+		stateStore:       opts.stateStore,
+		metaCache:        opts.metaCache,
+		stats:            opts.stats,
+		quota:            opts.quota,
 	}
 }
 
@@ -81,6 +106,9 @@ func (s *Storage) SetWorkflow(ctx context.Context, group string, w *models.Workf
 	if w == nil {
 		return nil
 	}
+	if err := w.Transform.Validate(); err != nil {
+		return err
+	}
 	return s.driver.UpdateWorkflow(ctx, group, w)
 }
 
@@ -91,20 +119,101 @@ func (s *Storage) GetWorkflow(ctx context.Context, group string) (*models.Workfl
 
 // GetProcessingState returns the current ProcessingState for an object.
 func (s *Storage) GetProcessingState(ctx context.Context, objectID string) (*models.ProcessingState, error) {
+	if s.stateStore != nil {
+		return s.stateStore.Get(ctx, objectID)
+	}
 	return s.driver.ReadState(ctx, storio.ObjectIDType(objectID))
 }
 
+// ReadStepLog returns the log of the job step stored in the object scope
+func (s *Storage) ReadStepLog(ctx context.Context, objectID, jobID, step string) ([]byte, error) {
+	reader, err := s.driver.ReadFile(ctx, storio.ObjectIDType(objectID), workflow.StepLogPath(jobID, step))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = storerrors.WrapNotFound(objectID, err)
+		}
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
 // SetProcessingState persists a ProcessingState for an object.
-func (s *Storage) SetProcessingState(ctx context.Context, objectID string, state *models.ProcessingState) error {
-	return s.driver.WriteState(ctx, storio.ObjectIDType(objectID), state)
+func (s *Storage) SetProcessingState(ctx context.Context, objectID string, state *models.ProcessingState) (err error) {
+	var prev *models.ProcessingState
+	if s.stats != nil {
+		// The missing previous state is not an error, all the jobs are new
+		prev, _ = s.GetProcessingState(ctx, objectID)
+	}
+	if s.stateStore != nil {
+		err = s.stateStore.Set(ctx, objectID, state)
+	} else {
+		err = s.driver.WriteState(ctx, storio.ObjectIDType(objectID), state)
+	}
+	if err == nil && s.stats != nil {
+		s.recordStats(ctx, objectID, s.stats.StateChanged(ctx, objectID, prev, state))
+	}
+	if err == nil && state != nil {
+		s.indexProcessingStatus(ctx, objectID, state.Status)
+	}
+	return err
+}
+
+// GroupStats returns the usage statistics of the group
+func (s *Storage) GroupStats(ctx context.Context, group string) (*stats.Stats, error) {
+	if s.stats == nil {
+		return nil, ErrStorageStatsNotSupported
+	}
+	return s.stats.Group(ctx, group)
+}
+
+// recordStats logs the error of the statistics update, the stats never
+// fail the object operation
+func (s *Storage) recordStats(ctx context.Context, objectID string, err error) {
+	if err != nil {
+		ctxlogger.Get(ctx).Warn("update group stats",
+			zap.String("object_id", objectID), zap.Error(err))
+	}
+}
+
+// checkQuota of the group workflow for the upload of the size bytes (0 if unknown)
+func (s *Storage) checkQuota(ctx context.Context, group string, size int64) error {
+	if s.quota == nil {
+		return nil
+	}
+	wf, err := s.GetWorkflow(ctx, group)
+	if err != nil {
+		return err
+	}
+	if wf == nil || wf.Quota.IsEmpty() {
+		return nil
+	}
+	return s.quota.Check(ctx, group, ctxprincipal.Get(ctx), size, wf.Quota)
 }
 
 // ReadMeta reads the Meta for an object (used by the workflow executor).
 func (s *Storage) ReadMeta(ctx context.Context, id storio.ObjectID) (*models.Meta, error) {
+	if s.metaCache != nil {
+		meta, err := s.metaCache.Get(ctx, id.ID().String())
+		if err != nil {
+			ctxlogger.Get(ctx).Warn("read meta cache", zap.Error(err))
+		} else if meta != nil {
+			return cloneMeta(meta)
+		}
+	}
 	obj, err := s.driver.Open(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if s.metaCache != nil && obj.Meta() != nil {
+		cached, err := cloneMeta(obj.Meta())
+		if err == nil {
+			err = s.metaCache.Set(ctx, id.ID().String(), cached, 0)
+		}
+		if err != nil {
+			ctxlogger.Get(ctx).Warn("write meta cache", zap.Error(err))
+		}
+	}
 	return obj.Meta(), nil
 }
 
@@ -120,6 +229,31 @@ func (s *Storage) WriteMeta(ctx context.Context, id storio.ObjectID, meta *model
 	return s.UpdateObjectInfo(ctx, obj)
 }
 
+// invalidateMeta drops the cached meta of the object on every node
+func (s *Storage) invalidateMeta(ctx context.Context, objectID string) {
+	if s.metaCache == nil {
+		return
+	}
+	if err := s.metaCache.Invalidate(ctx, objectID); err != nil {
+		ctxlogger.Get(ctx).Warn("invalidate meta cache",
+			zap.String("object_id", objectID), zap.Error(err))
+	}
+}
+
+// cloneMeta returns the deep copy of the meta, so the cached value is
+// never shared with the caller
+func cloneMeta(meta *models.Meta) (*models.Meta, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	var res models.Meta
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // UploadFile into storage
 func (s *Storage) UploadFile(ctx context.Context, group string, sourceFilePath string, options ...UploadOption) (storio.Object, error) {
 	file, err := os.Open(sourceFilePath)
@@ -155,6 +289,11 @@ func (s *Storage) Upload(ctx context.Context, group string, data io.Reader, opti
 		data = req.Reader
 	}
 
+	// Check the group quota before anything is persisted
+	if err := s.checkQuota(ctx, group, option.contentLength); err != nil {
+		return nil, err
+	}
+
 	// Create new object container
 	obj, err = s.driver.Create(ctx, group, option.customID, option.overwrite, option.Params())
 	if err != nil {
@@ -240,11 +379,30 @@ func (s *Storage) OpenObject(ctx context.Context, obj any, name string) (storio.
 	return nObject, fr, nil
 }
 
+// UpdateObjectFile writes a derived file into the object scope and refreshes
+// the cached object information
+func (s *Storage) UpdateObjectFile(ctx context.Context, obj storio.Object, name string, data io.Reader, meta *models.ItemMeta) (storio.Object, error) {
+	if err := s.driver.Update(ctx, obj, name, data, meta); err != nil {
+		return nil, err
+	}
+	nObject, err := s.driver.Open(ctx, obj.ID())
+	if err != nil {
+		return nil, err
+	}
+	if err = s.UpdateObjectInfo(ctx, nObject); err != nil {
+		return nil, err
+	}
+	return nObject, nil
+}
+
 // Delete object completeley
 func (s *Storage) Delete(ctx context.Context, obj any, names ...string) (err error) {
 	var nObject storio.Object
 	if nObject, err = s.Object(ctx, obj); err != nil {
 		if os.IsNotExist(err) {
+			if s.stats != nil && len(names) == 0 {
+				s.recordStats(ctx, objcID(obj), s.stats.ObjectDeleted(ctx, objcID(obj)))
+			}
 			return s.db.Delete(objcID(obj))
 		}
 		return err
@@ -252,16 +410,46 @@ func (s *Storage) Delete(ctx context.Context, obj any, names ...string) (err err
 	if err = s.driver.Remove(ctx, nObject, names...); err != nil {
 		return err
 	}
+	s.invalidateMeta(ctx, nObject.ID().String())
+	if s.stats != nil {
+		s.recordDeleteStats(ctx, nObject, len(names) > 0)
+	}
 	return s.db.Delete(nObject.ID().String())
 }
 
+// recordDeleteStats subtracts the removed object or its removed items
+func (s *Storage) recordDeleteStats(ctx context.Context, obj storio.Object, partial bool) {
+	id := obj.ID().String()
+	if !partial {
+		s.recordStats(ctx, id, s.stats.ObjectDeleted(ctx, id))
+		return
+	}
+	nObject, err := s.driver.Open(ctx, obj.ID())
+	if err != nil {
+		s.recordStats(ctx, id, err)
+		return
+	}
+	mObj, err := object.ToModel(nObject)
+	if err == nil {
+		err = s.stats.ObjectUpdated(ctx, mObj)
+	}
+	s.recordStats(ctx, id, err)
+}
+
 // Update information about object in database
 func (s *Storage) UpdateObjectInfo(ctx context.Context, obj storio.Object) error {
 	mObj, err := object.ToModel(obj)
 	if err != nil {
 		return err
 	}
-	return s.db.Set(mObj)
+	defer s.invalidateMeta(ctx, obj.ID().String())
+	if err = s.db.Set(mObj); err != nil {
+		return err
+	}
+	if s.stats != nil {
+		s.recordStats(ctx, mObj.ID, s.stats.ObjectUpdated(ctx, mObj))
+	}
+	return nil
 }
 
 // ClearObject metainformation and all subobjects
@@ -273,6 +461,7 @@ func (s *Storage) ClearObject(ctx context.Context, obj any) error {
 	ctxlogger.Get(ctx).Info("clean object",
 		zap.String("object_bucket", collectionObject.Bucket()),
 		zap.String("object_path", collectionObject.Path()))
+	defer s.invalidateMeta(ctx, collectionObject.ID().String())
 	return s.driver.Clean(ctx, collectionObject)
 }
 
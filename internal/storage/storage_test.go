@@ -12,7 +12,11 @@ import (
 
 	"github.com/apfs-io/apfs/internal/driver/fs"
 	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	metamemory "github.com/apfs-io/apfs/internal/storage/metacache/memory"
 	"github.com/apfs-io/apfs/internal/storage/processor"
+	"github.com/apfs-io/apfs/internal/storage/quota"
+	statememory "github.com/apfs-io/apfs/internal/storage/statestore/memory"
+	"github.com/apfs-io/apfs/internal/storage/stats"
 	storio "github.com/apfs-io/apfs/internal/storio"
 	"github.com/apfs-io/apfs/libs/converters/image"
 	"github.com/apfs-io/apfs/models"
@@ -226,3 +230,128 @@ func TestStorageProcess(t *testing.T) {
 	// Finaly remove all files
 	_ = os.RemoveAll(filepath.Join(testStorePath, imagesBucket))
 }
+
+func TestStorageStateStoreAndMetaCache(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Second*10)
+		cache       = metamemory.New(time.Minute)
+		states      = statememory.New()
+	)
+	defer cancel()
+	defer func() { _ = os.RemoveAll(filepath.Join(testStorePath, "cached")) }()
+
+	store := NewStorage(
+		WithDatabase(&DatabaseMock{}),
+		WithDriver(fsdriver),
+		WithProcessingStatus(&memory.KVMemory{}),
+		WithStateStore(states),
+		WithMetaCache(cache),
+	)
+	obj, err := store.UploadFile(ctx, "cached",
+		filepath.Join(testStorePath, "bucket/file/prim.jpg"))
+	if !assert.NoError(t, err, "upload file") {
+		return
+	}
+	id := obj.ID().String()
+
+	// The state is kept by the state store instead of the driver
+	state := models.NewProcessingState(id, "2", []string{"thumb"})
+	assert.NoError(t, store.SetProcessingState(ctx, id, state))
+	driverState, _ := fsdriver.ReadState(ctx, obj.ID())
+	assert.Nil(t, driverState)
+	stored, err := store.GetProcessingState(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, state, stored)
+
+	// The meta is cached on read and the cached copy is not shared
+	meta, err := store.ReadMeta(ctx, obj.ID())
+	assert.NoError(t, err)
+	cached, _ := cache.Get(ctx, id)
+	if assert.NotNil(t, cached) {
+		assert.Equal(t, meta.Main.Name, cached.Main.Name)
+	}
+	meta.SetAttribute("changed", true)
+	meta, err = store.ReadMeta(ctx, obj.ID())
+	assert.NoError(t, err)
+	assert.Nil(t, meta.GetAttribute("changed"))
+
+	// Any update drops the cached meta
+	assert.NoError(t, store.WriteMeta(ctx, obj.ID(), meta))
+	cached, _ = cache.Get(ctx, id)
+	assert.Nil(t, cached)
+
+	assert.NoError(t, store.Delete(ctx, obj))
+}
+
+func TestStorageGroupStats(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Second*10)
+		states      = statememory.New()
+	)
+	defer cancel()
+	defer func() { _ = os.RemoveAll(filepath.Join(testStorePath, "counted")) }()
+
+	_, err := storage.GroupStats(ctx, "counted")
+	assert.ErrorIs(t, err, ErrStorageStatsNotSupported)
+
+	store := NewStorage(
+		WithDatabase(&DatabaseMock{}),
+		WithDriver(fsdriver),
+		WithProcessingStatus(&memory.KVMemory{}),
+		WithStateStore(states),
+		WithStats(stats.NewRecorder(memory.NewCounter())),
+	)
+	obj, err := store.UploadFile(ctx, "counted",
+		filepath.Join(testStorePath, "bucket/file/prim.jpg"))
+	if !assert.NoError(t, err, "upload file") {
+		return
+	}
+	id := obj.ID().String()
+	assert.NoError(t, store.SetProcessingState(ctx, id,
+		models.NewProcessingState(id, "2", []string{"thumb"})))
+
+	groupStats, err := store.GroupStats(ctx, "counted")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), groupStats.Objects)
+	assert.Equal(t, int64(obj.Meta().Main.Size), groupStats.OriginalBytes)
+	assert.Equal(t, int64(1), groupStats.Processing[models.ProcessingStatusPending])
+
+	assert.NoError(t, store.Delete(ctx, obj))
+	groupStats, err = store.GroupStats(ctx, "counted")
+	assert.NoError(t, err)
+	assert.Zero(t, groupStats.Objects)
+	assert.Zero(t, groupStats.TotalBytes())
+	assert.Empty(t, groupStats.Processing)
+}
+
+func TestStorageQuota(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Second*10)
+		counter     = memory.NewCounter()
+		recorder    = stats.NewRecorder(counter)
+		source      = filepath.Join(testStorePath, "bucket/file/prim.jpg")
+	)
+	defer cancel()
+	defer func() { _ = os.RemoveAll(filepath.Join(testStorePath, "limited")) }()
+
+	store := NewStorage(
+		WithDatabase(&DatabaseMock{}),
+		WithDriver(fsdriver),
+		WithProcessingStatus(&memory.KVMemory{}),
+		WithStats(recorder),
+		WithQuota(quota.NewLimiter(recorder, counter)),
+	)
+	assert.NoError(t, store.SetWorkflow(ctx, "limited", &models.Workflow{
+		Quota: &models.WorkflowQuota{MaxObjects: 1},
+	}))
+
+	_, err := store.UploadFile(ctx, "limited", source)
+	if !assert.NoError(t, err, "upload file") {
+		return
+	}
+	_, err = store.UploadFile(ctx, "limited", source)
+	qerr, ok := quota.IsExceeded(err)
+	if assert.True(t, ok, err) {
+		assert.Equal(t, quota.LimitObjects, qerr.Limit)
+	}
+}
@@ -33,9 +33,16 @@ func (s *WorkflowStorage) WriteFile(
 	data interface{ Read([]byte) (int, error) },
 	meta *models.ItemMeta,
 ) error {
+	defer s.invalidateMeta(ctx, id.ID().String())
 	return s.driver.Update(ctx, id, path, data, meta)
 }
 
 func (s *WorkflowStorage) ReadFile(ctx context.Context, id storio.ObjectID, name string) (io.ReadCloser, error) {
 	return s.driver.Read(ctx, id, name)
 }
+
+// WriteLog implements workflow.StepLogStorage, the log is stored as the raw
+// file of the object scope without the meta registration
+func (s *WorkflowStorage) WriteLog(ctx context.Context, id storio.ObjectID, path string, data io.Reader) error {
+	return s.driver.WriteFile(ctx, id, path, data, nil)
+}
@@ -2,6 +2,8 @@ package storio
 
 import (
 	"context"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/apfs-io/apfs/models"
@@ -19,12 +21,52 @@ type ScanObjInfo interface {
 	UpdatedAt() time.Time
 }
 
-// WalkStorageFunc defines the function which will be call by storage scanning process
+// WalkStorageFunc defines the function which will be call by storage scanning process.
+// The path is relative to the storage root: "group/object/path/file".
 type WalkStorageFunc func(path string, err error) error
 
 // ObjectScanner of the structure accessor
 type ObjectScanner interface {
 	// Scan storage by pattern
 	// 	pattern: search type equals to glob https://golang.org/pkg/path/filepath/#Glob
+	// 	the trailing "**" matches any path below, e.g. "group/**"
 	Scan(ctx context.Context, pattern string, walkf WalkStorageFunc) error
 }
+
+// MatchScanPattern reports whether the relative path matches the scan pattern
+func MatchScanPattern(pattern, name string) bool {
+	pattern = strings.TrimLeft(pattern, "/")
+	name = strings.TrimLeft(name, "/")
+	if pattern == "**" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		// Any parent directory of the file matches the prefix
+		for i := strings.Index(name, "/"); i > 0; {
+			if ok, _ := filepath.Match(prefix, name[:i]); ok {
+				return true
+			}
+			next := strings.Index(name[i+1:], "/")
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+		return false
+	}
+	ok, _ := filepath.Match(pattern, name)
+	return ok
+}
+
+// ScanPrefix returns the static directory prefix of the pattern
+// which can be used to limit the listing of the storage
+func ScanPrefix(pattern string) string {
+	pattern = strings.TrimLeft(pattern, "/")
+	if idx := strings.IndexAny(pattern, "*?[\\"); idx >= 0 {
+		pattern = pattern[:idx]
+	}
+	if idx := strings.LastIndex(pattern, "/"); idx >= 0 {
+		return pattern[:idx+1]
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package storio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchScanPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{pattern: "**", name: "media/a/meta.json", match: true},
+		{pattern: "media/**", name: "media/a/b/meta.json", match: true},
+		{pattern: "/media/**", name: "media/manifest.yaml", match: true},
+		{pattern: "media/**", name: "docs/a/meta.json", match: false},
+		{pattern: "media/a/**", name: "media/ab/meta.json", match: false},
+		{pattern: "media/*/meta.json", name: "media/a/meta.json", match: true},
+		{pattern: "media/*/meta.json", name: "media/a/b/meta.json", match: false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.match, MatchScanPattern(test.pattern, test.name), test.pattern+" "+test.name)
+	}
+	assert.Equal(t, "media/", ScanPrefix("media/**"))
+	assert.Equal(t, "media/a/", ScanPrefix("/media/a/*.json"))
+	assert.Equal(t, "", ScanPrefix("**"))
+}
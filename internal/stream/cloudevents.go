@@ -0,0 +1,331 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/geniusrabbit/notificationcenter/v2/encoder"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	protocol "github.com/apfs-io/apfs/internal/server/protocol/v1"
+	"github.com/apfs-io/apfs/models"
+)
+
+// Event formats of the stream messages
+const (
+	// FormatLegacy is the plain JSON of the models.Event
+	FormatLegacy = "legacy"
+
+	// FormatCloudEvents is the CloudEvents 1.0 JSON (structured content mode)
+	FormatCloudEvents = "cloudevents"
+
+	// FormatCloudEventsProto is the CloudEvents 1.0 protobuf format
+	FormatCloudEventsProto = "cloudevents+proto"
+
+	// DefaultFormat of the published events, CloudEvents are opt-in
+	DefaultFormat = FormatLegacy
+)
+
+// CloudEvents attributes of the storage events
+const (
+	CloudEventsSpecVersion = "1.0"
+	CloudEventsSource      = "/apfs"
+	CloudEventsDataType    = "application/json"
+
+	// CloudEventsTypePrefix of the event types: io.apfs.object.processed,
+	// io.apfs.job.completed, ...
+	CloudEventsTypePrefix = "io.apfs."
+)
+
+// Extension attributes of the storage events
+const (
+	attrSubject         = "subject"
+	attrTime            = "time"
+	attrDataContentType = "datacontenttype"
+	attrCorrelationID   = "correlationid"
+	attrWorkflowVersion = "workflowversion"
+	attrJobID           = "jobid"
+)
+
+// traceAttributes are the trace context keys passed as the CloudEvents
+// distributed tracing extension
+var traceAttributes = []string{"traceparent", "tracestate", "baggage"}
+
+// Error list...
+var (
+	ErrUnsupportedFormat = errors.New(`[stream] unsupported event format`)
+	ErrInvalidEvent      = errors.New(`[stream] invalid event message`)
+)
+
+// cloudEvent is the CloudEvents 1.0 JSON envelope of the event
+type cloudEvent struct {
+	SpecVersion     string     `json:"specversion"`
+	ID              string     `json:"id"`
+	Source          string     `json:"source"`
+	Type            string     `json:"type"`
+	Subject         string     `json:"subject,omitempty"`
+	Time            *time.Time `json:"time,omitempty"`
+	DataContentType string     `json:"datacontenttype,omitempty"`
+
+	CorrelationID   string `json:"correlationid,omitempty"`
+	WorkflowVersion string `json:"workflowversion,omitempty"`
+	JobID           string `json:"jobid,omitempty"`
+	TraceParent     string `json:"traceparent,omitempty"`
+	TraceState      string `json:"tracestate,omitempty"`
+	Baggage         string `json:"baggage,omitempty"`
+
+	Data *eventData `json:"data,omitempty"`
+}
+
+// eventData is the payload of the CloudEvents envelope
+type eventData struct {
+	Error  string                  `json:"error,omitempty"`
+	Object *models.Object          `json:"object,omitempty"`
+	State  *models.ProcessingState `json:"state,omitempty"`
+}
+
+// EventEncoder returns the encoder of the stream messages in the format.
+// The messages which are not events are encoded as JSON.
+func EventEncoder(format string) (encoder.Encoder, error) {
+	if format == "" {
+		format = DefaultFormat
+	}
+	switch format {
+	case FormatCloudEvents:
+		return encodeWith(func(event *models.Event) ([]byte, error) {
+			return json.Marshal(toCloudEvent(event))
+		}), nil
+	case FormatCloudEventsProto:
+		return encodeWith(func(event *models.Event) ([]byte, error) {
+			msg, err := toCloudEventProto(event)
+			if err != nil {
+				return nil, err
+			}
+			return proto.Marshal(msg)
+		}), nil
+	case FormatLegacy:
+		return encoder.JSON, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func encodeWith(fn func(event *models.Event) ([]byte, error)) encoder.Encoder {
+	return func(msg any, wr io.Writer) error {
+		var event *models.Event
+		switch v := msg.(type) {
+		case *models.Event:
+			event = v
+		case models.Event:
+			event = &v
+		default:
+			return encoder.JSON(msg, wr)
+		}
+		data, err := fn(event)
+		if err != nil {
+			return err
+		}
+		_, err = wr.Write(data)
+		return err
+	}
+}
+
+// DecodeEvent decodes the stream message of any supported format
+func DecodeEvent(body []byte) (*models.Event, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var probe struct {
+			SpecVersion string `json:"specversion"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return nil, err
+		}
+		if probe.SpecVersion == "" {
+			var event models.Event
+			if err := json.Unmarshal(trimmed, &event); err != nil {
+				return nil, err
+			}
+			return &event, nil
+		}
+		var ce cloudEvent
+		if err := json.Unmarshal(trimmed, &ce); err != nil {
+			return nil, err
+		}
+		return fromCloudEvent(&ce)
+	}
+	var msg protocol.CloudEvent
+	if err := proto.Unmarshal(body, &msg); err != nil || msg.GetSpecVersion() == "" {
+		return nil, ErrInvalidEvent
+	}
+	return fromCloudEventProto(&msg)
+}
+
+// EventType returns the CloudEvents type of the event type
+func EventType(tp models.EventType) string {
+	if tp.IsJobEvent() {
+		return CloudEventsTypePrefix + tp.String()
+	}
+	return CloudEventsTypePrefix + "object." + tp.String()
+}
+
+// eventTypeOf returns the event type of the CloudEvents type
+func eventTypeOf(tp string) models.EventType {
+	tp = strings.TrimPrefix(tp, CloudEventsTypePrefix)
+	return models.EventType(strings.TrimPrefix(tp, "object."))
+}
+
+// eventSource returns the source of the event: /apfs/{group}
+func eventSource(event *models.Event) string {
+	objectID := event.Object.ObjectID()
+	if event.Object != nil && event.Object.Bucket != "" {
+		return CloudEventsSource + "/" + event.Object.Bucket
+	}
+	if group, _, ok := strings.Cut(objectID, "/"); ok && group != "" {
+		return CloudEventsSource + "/" + group
+	}
+	return CloudEventsSource
+}
+
+func toCloudEvent(event *models.Event) *cloudEvent {
+	ce := &cloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              event.ID,
+		Source:          eventSource(event),
+		Type:            EventType(event.Type),
+		Subject:         event.Object.ObjectID(),
+		DataContentType: CloudEventsDataType,
+		CorrelationID:   event.CorrelationID,
+		WorkflowVersion: event.WorkflowVersion,
+		JobID:           event.JobID,
+		TraceParent:     event.TraceContext["traceparent"],
+		TraceState:      event.TraceContext["tracestate"],
+		Baggage:         event.TraceContext["baggage"],
+		Data:            &eventData{Error: event.Error, Object: event.Object, State: event.State},
+	}
+	if !event.Time.IsZero() {
+		tm := event.Time.UTC()
+		ce.Time = &tm
+	}
+	return ce
+}
+
+func fromCloudEvent(ce *cloudEvent) (*models.Event, error) {
+	if ce.Type == "" {
+		return nil, ErrInvalidEvent
+	}
+	event := &models.Event{
+		ID:              ce.ID,
+		Type:            eventTypeOf(ce.Type),
+		JobID:           ce.JobID,
+		WorkflowVersion: ce.WorkflowVersion,
+		CorrelationID:   ce.CorrelationID,
+	}
+	if ce.Time != nil {
+		event.Time = *ce.Time
+	}
+	if ce.Data != nil {
+		event.Error = ce.Data.Error
+		event.Object = ce.Data.Object
+		event.State = ce.Data.State
+	}
+	for key, val := range map[string]string{
+		"traceparent": ce.TraceParent,
+		"tracestate":  ce.TraceState,
+		"baggage":     ce.Baggage,
+	} {
+		if val != "" {
+			if event.TraceContext == nil {
+				event.TraceContext = map[string]string{}
+			}
+			event.TraceContext[key] = val
+		}
+	}
+	return event, nil
+}
+
+func toCloudEventProto(event *models.Event) (*protocol.CloudEvent, error) {
+	data, err := json.Marshal(&eventData{Error: event.Error, Object: event.Object, State: event.State})
+	if err != nil {
+		return nil, err
+	}
+	attrs := map[string]*protocol.CloudEventAttributeValue{
+		attrDataContentType: stringAttr(CloudEventsDataType),
+	}
+	setStringAttr(attrs, attrSubject, event.Object.ObjectID())
+	setStringAttr(attrs, attrCorrelationID, event.CorrelationID)
+	setStringAttr(attrs, attrWorkflowVersion, event.WorkflowVersion)
+	setStringAttr(attrs, attrJobID, event.JobID)
+	for _, key := range traceAttributes {
+		setStringAttr(attrs, key, event.TraceContext[key])
+	}
+	if !event.Time.IsZero() {
+		attrs[attrTime] = &protocol.CloudEventAttributeValue{
+			Attr: &protocol.CloudEventAttributeValue_CeTimestamp{CeTimestamp: timestamppb.New(event.Time)},
+		}
+	}
+	return &protocol.CloudEvent{
+		Id:          event.ID,
+		Source:      eventSource(event),
+		SpecVersion: CloudEventsSpecVersion,
+		Type:        EventType(event.Type),
+		Attributes:  attrs,
+		Data:        &protocol.CloudEvent_BinaryData{BinaryData: data},
+	}, nil
+}
+
+func fromCloudEventProto(msg *protocol.CloudEvent) (*models.Event, error) {
+	if msg.GetType() == "" {
+		return nil, ErrInvalidEvent
+	}
+	attrs := msg.GetAttributes()
+	event := &models.Event{
+		ID:              msg.GetId(),
+		Type:            eventTypeOf(msg.GetType()),
+		JobID:           attrs[attrJobID].GetCeString(),
+		WorkflowVersion: attrs[attrWorkflowVersion].GetCeString(),
+		CorrelationID:   attrs[attrCorrelationID].GetCeString(),
+	}
+	if ts := attrs[attrTime].GetCeTimestamp(); ts != nil {
+		event.Time = ts.AsTime()
+	}
+	for _, key := range traceAttributes {
+		if val := attrs[key].GetCeString(); val != "" {
+			if event.TraceContext == nil {
+				event.TraceContext = map[string]string{}
+			}
+			event.TraceContext[key] = val
+		}
+	}
+	var data []byte
+	switch v := msg.GetData().(type) {
+	case *protocol.CloudEvent_BinaryData:
+		data = v.BinaryData
+	case *protocol.CloudEvent_TextData:
+		data = []byte(v.TextData)
+	}
+	if len(data) > 0 {
+		var payload eventData
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, err
+		}
+		event.Error = payload.Error
+		event.Object = payload.Object
+		event.State = payload.State
+	}
+	return event, nil
+}
+
+func stringAttr(val string) *protocol.CloudEventAttributeValue {
+	return &protocol.CloudEventAttributeValue{
+		Attr: &protocol.CloudEventAttributeValue_CeString{CeString: val},
+	}
+}
+
+func setStringAttr(attrs map[string]*protocol.CloudEventAttributeValue, key, val string) {
+	if val != "" {
+		attrs[key] = stringAttr(val)
+	}
+}
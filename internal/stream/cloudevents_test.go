@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+func testEvent() *models.Event {
+	return &models.Event{
+		ID:              "e1",
+		Type:            models.JobFailedEventType,
+		Time:            time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Error:           "resize failed",
+		Object:          &models.Object{ID: "images/a.jpg", Bucket: "images"},
+		JobID:           "thumb",
+		WorkflowVersion: "2",
+		State:           models.NewProcessingState("images/a.jpg", "2", []string{"thumb"}),
+		CorrelationID:   "c1",
+		TraceContext:    map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}
+}
+
+func encode(t *testing.T, format string, msg any) []byte {
+	enc, err := EventEncoder(format)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, enc(msg, &buf))
+	return buf.Bytes()
+}
+
+func TestEventFormats_RoundTrip(t *testing.T) {
+	for _, format := range []string{FormatLegacy, FormatCloudEvents, FormatCloudEventsProto} {
+		t.Run(format, func(t *testing.T) {
+			event := testEvent()
+			got, err := DecodeEvent(encode(t, format, event))
+			require.NoError(t, err)
+			assert.Equal(t, event.ID, got.ID)
+			assert.Equal(t, event.Type, got.Type)
+			assert.True(t, event.Time.Equal(got.Time))
+			assert.Equal(t, event.Error, got.Error)
+			assert.Equal(t, event.Object.ID, got.Object.ObjectID())
+			assert.Equal(t, event.JobID, got.JobID)
+			assert.Equal(t, event.WorkflowVersion, got.WorkflowVersion)
+			assert.Equal(t, event.CorrelationID, got.CorrelationID)
+			assert.Equal(t, event.TraceContext, got.TraceContext)
+			require.NotNil(t, got.State)
+			assert.Contains(t, got.State.Jobs, "thumb")
+		})
+	}
+}
+
+func TestEventFormats_CloudEventsJSON(t *testing.T) {
+	var ce map[string]any
+	require.NoError(t, json.Unmarshal(encode(t, FormatCloudEvents, testEvent()), &ce))
+	assert.Equal(t, "1.0", ce["specversion"])
+	assert.Equal(t, "io.apfs.job.failed", ce["type"])
+	assert.Equal(t, "/apfs/images", ce["source"])
+	assert.Equal(t, "images/a.jpg", ce["subject"])
+	assert.Equal(t, "application/json", ce["datacontenttype"])
+	assert.Equal(t, "c1", ce["correlationid"])
+	assert.Equal(t, "thumb", ce["jobid"])
+	assert.Contains(t, ce["data"], "state")
+
+	event := &models.Event{Type: models.ProcessedEventType, Object: &models.Object{ID: "docs/b.pdf"}}
+	require.NoError(t, json.Unmarshal(encode(t, FormatCloudEvents, event), &ce))
+	assert.Equal(t, "io.apfs.object.processed", ce["type"])
+	assert.Equal(t, "/apfs/docs", ce["source"])
+}
+
+func TestEventFormats_DefaultLegacy(t *testing.T) {
+	event := testEvent()
+	assert.Equal(t, encode(t, FormatLegacy, event), encode(t, "", event))
+}
+
+func TestEventFormats_NotEventMessage(t *testing.T) {
+	assert.JSONEq(t, `{"a":1}`, string(encode(t, FormatCloudEventsProto, map[string]int{"a": 1})))
+}
+
+func TestEventFormats_Invalid(t *testing.T) {
+	_, err := EventEncoder("xml")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = DecodeEvent([]byte("not an event"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package local
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/url"
+	"strings"
+
+	"github.com/demdxx/gocast/v2"
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+)
+
+// Key prefixes of the stream records
+//
+//	msg/<seq>                          the not acknowledged message
+const (
+	msgKeyPrefix = `msg/`
+	seqKey       = `seq`
+)
+
+// badgerStorage keeps the messages in the BadgerDB
+type badgerStorage struct {
+	db  *badger.DB
+	seq *badger.Sequence
+}
+
+// openBadger opens the stream database by URL
+// connection: badger:///var/lib/apfs/events?sync=true or badger://events?inmemory=true
+func openBadger(connection string) (*badgerStorage, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimPrefix(connection, `badger://`)
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	dbOpts := badger.DefaultOptions(path).
+		WithValueLogFileSize(16 * 1024 * 1024).
+		WithLogger(nil)
+	if gocast.Bool(u.Query().Get(`sync`)) {
+		dbOpts = dbOpts.WithSyncWrites(true)
+	}
+	if gocast.Bool(u.Query().Get(`inmemory`)) {
+		dbOpts = dbOpts.WithInMemory(true).WithDir("").WithValueDir("")
+	}
+	db, err := badger.Open(dbOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "open badger event stream")
+	}
+	seq, err := db.GetSequence([]byte(seqKey), 1000)
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "event stream sequence")
+	}
+	return &badgerStorage{db: db, seq: seq}, nil
+}
+
+func (s *badgerStorage) Append(data []byte) (uint64, error) {
+	seq, err := s.seq.Next()
+	if err != nil {
+		return 0, err
+	}
+	return seq, s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(msgKey(seq), data)
+	})
+}
+
+func (s *badgerStorage) Delete(seq uint64) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(msgKey(seq))
+	})
+}
+
+func (s *badgerStorage) Pending(fn func(seq uint64, data []byte)) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		prefix := []byte(msgKeyPrefix)
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, Prefix: prefix})
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().Key()
+			data, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			fn(binary.BigEndian.Uint64(bytes.TrimPrefix(key, prefix)), data)
+		}
+		return nil
+	})
+}
+
+func (s *badgerStorage) Close() error {
+	_ = s.seq.Release()
+	return s.db.Close()
+}
+
+func msgKey(seq uint64) []byte {
+	key := make([]byte, len(msgKeyPrefix)+8)
+	copy(key, msgKeyPrefix)
+	binary.BigEndian.PutUint64(key[len(msgKeyPrefix):], seq)
+	return key
+}
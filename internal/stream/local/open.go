@@ -0,0 +1,102 @@
+package local
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/geniusrabbit/notificationcenter/v2/encoder"
+	"github.com/pkg/errors"
+)
+
+// URL schemes of the local streams
+const (
+	SchemeMemory = "mem"
+	SchemeBadger = "badger"
+)
+
+// ErrUnsupportedScheme of the local stream URL
+var ErrUnsupportedScheme = errors.New(`[stream] unsupported local stream scheme`)
+
+var registry = struct {
+	mx      sync.Mutex
+	streams map[string]*Stream
+}{streams: map[string]*Stream{}}
+
+// IsLocal reports whether the URL is the local stream
+func IsLocal(urlStr string) bool {
+	return strings.HasPrefix(urlStr, SchemeMemory+"://") ||
+		strings.HasPrefix(urlStr, SchemeBadger+"://")
+}
+
+// Open returns the stream of the URL, the stream is shared by all opened
+// references of the same URL and is closed with the last one.
+//
+//	mem://events
+//	badger:///var/lib/apfs/events?sync=true
+func Open(urlStr string) (*Stream, error) {
+	key, err := streamKey(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	registry.mx.Lock()
+	defer registry.mx.Unlock()
+	if s := registry.streams[key]; s != nil {
+		s.refs++
+		return s, nil
+	}
+	var store storage
+	if strings.HasPrefix(key, SchemeBadger+"://") {
+		if store, err = openBadger(urlStr); err != nil {
+			return nil, err
+		}
+	}
+	s, err := newStream(key, store)
+	if err != nil {
+		if store != nil {
+			_ = store.Close()
+		}
+		return nil, err
+	}
+	s.refs = 1
+	registry.streams[key] = s
+	return s, nil
+}
+
+// NewPublisher opens the stream of the URL and returns its publisher
+func NewPublisher(urlStr string, enc encoder.Encoder) (*Publisher, error) {
+	s, err := Open(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	return s.Publisher(enc), nil
+}
+
+// NewSubscriber opens the stream of the URL and returns its subscriber
+func NewSubscriber(urlStr string) (*Subscriber, error) {
+	s, err := Open(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	return s.Subscriber(), nil
+}
+
+// streamKey is the URL without the query, the same stream is opened by the
+// publisher and the subscriber URLs with different options
+func streamKey(urlStr string) (string, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case SchemeMemory, SchemeBadger:
+	default:
+		return "", ErrUnsupportedScheme
+	}
+	key := u.Scheme + "://" + u.Host + u.Path
+	if u.Scheme == SchemeBadger && gocast.Bool(u.Query().Get(`inmemory`)) {
+		key += "?inmemory"
+	}
+	return key, nil
+}
@@ -0,0 +1,301 @@
+// Package local implements the event stream inside of the process for the
+// single-node deployments without NATS or Kafka. The mem:// stream keeps the
+// messages in memory, the badger:// stream keeps them in the embedded
+// BadgerDB until they are acknowledged, so the events survive the restarts.
+//
+// The publishers and the subscribers of the same URL in the process share
+// one stream, every message is delivered to all subscribed receivers.
+package local
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	nc "github.com/geniusrabbit/notificationcenter/v2"
+	"github.com/geniusrabbit/notificationcenter/v2/encoder"
+)
+
+// ErrClosed is returned by the closed stream
+var ErrClosed = errors.New(`[stream] local stream is closed`)
+
+// storage keeps the messages of the durable stream until acknowledged
+type storage interface {
+	// Append the message data, returns the sequence number of the message
+	Append(data []byte) (uint64, error)
+
+	// Delete the acknowledged message
+	Delete(seq uint64) error
+
+	// Pending returns the not acknowledged messages in order
+	Pending(fn func(seq uint64, data []byte)) error
+
+	io.Closer
+}
+
+// Stream is the queue of the messages shared by the publishers and the
+// subscribers of the same URL
+type Stream struct {
+	nc.ModelSubscriber
+
+	mx      sync.Mutex
+	key     string
+	refs    int
+	seq     uint64
+	queue   []*message
+	storage storage
+
+	// wake is closed and replaced when a message is published
+	wake   chan struct{}
+	closed chan struct{}
+}
+
+func newStream(key string, store storage) (*Stream, error) {
+	s := &Stream{
+		key:     key,
+		storage: store,
+		wake:    make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	if store != nil {
+		// Redeliver the messages not acknowledged before the restart
+		err := store.Pending(func(seq uint64, data []byte) {
+			s.queue = append(s.queue, &message{stream: s, seq: seq, data: data})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Publisher returns the publisher of the stream encoding the messages with
+// the encoder (JSON if nil)
+func (s *Stream) Publisher(enc encoder.Encoder) *Publisher {
+	if enc == nil {
+		enc = encoder.JSON
+	}
+	return &Publisher{stream: s, encoder: enc}
+}
+
+// Subscriber returns the subscriber of the stream
+func (s *Stream) Subscriber() *Subscriber {
+	return &Subscriber{stream: s, closed: make(chan struct{})}
+}
+
+// Len returns the number of the messages waiting for the delivery
+func (s *Stream) Len() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return len(s.queue)
+}
+
+func (s *Stream) push(data []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.isClosed() {
+		return ErrClosed
+	}
+	msg := &message{stream: s, data: data}
+	if s.storage != nil {
+		seq, err := s.storage.Append(data)
+		if err != nil {
+			return err
+		}
+		msg.seq = seq
+	} else {
+		s.seq++
+		msg.seq = s.seq
+	}
+	s.queue = append(s.queue, msg)
+	close(s.wake)
+	s.wake = make(chan struct{})
+	return nil
+}
+
+// next blocks until the message is available
+func (s *Stream) next(ctx context.Context) (*message, error) {
+	for {
+		s.mx.Lock()
+		if s.isClosed() {
+			s.mx.Unlock()
+			return nil, ErrClosed
+		}
+		if len(s.queue) > 0 {
+			msg := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.mx.Unlock()
+			return msg, nil
+		}
+		wake := s.wake
+		s.mx.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.closed:
+			return nil, ErrClosed
+		case <-wake:
+		}
+	}
+}
+
+func (s *Stream) ack(seq uint64) error {
+	if s.storage == nil {
+		return nil
+	}
+	return s.storage.Delete(seq)
+}
+
+// listen delivers the messages until the stop context is done, the
+// messages are processed with the work context
+func (s *Stream) listen(stop, work context.Context) error {
+	for {
+		msg, err := s.next(stop)
+		if err != nil {
+			// The stopped listener is not an error, like the NATS subscriber
+			return nil
+		}
+		msg.ctx = work
+		// The failed message is not acknowledged and is redelivered by the
+		// durable stream after the restart
+		_ = s.ProcessMessage(msg)
+	}
+}
+
+func (s *Stream) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// release the reference of the stream, the last one closes it
+func (s *Stream) release() error {
+	registry.mx.Lock()
+	defer registry.mx.Unlock()
+	if s.refs--; s.refs > 0 {
+		return nil
+	}
+	delete(registry.streams, s.key)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.isClosed() {
+		return nil
+	}
+	close(s.closed)
+	if s.storage != nil {
+		return s.storage.Close()
+	}
+	return nil
+}
+
+// Publisher of the local stream
+type Publisher struct {
+	once    sync.Once
+	stream  *Stream
+	encoder encoder.Encoder
+}
+
+// Publish the messages to the stream, never blocks on the slow subscribers
+func (p *Publisher) Publish(ctx context.Context, messages ...any) error {
+	for _, msg := range messages {
+		var buf bytes.Buffer
+		if err := p.encoder(msg, &buf); err != nil {
+			return err
+		}
+		if err := p.stream.push(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close the publisher
+func (p *Publisher) Close() (err error) {
+	p.once.Do(func() { err = p.stream.release() })
+	return err
+}
+
+// Subscriber of the local stream
+type Subscriber struct {
+	once   sync.Once
+	stream *Stream
+
+	// closed stops the listeners of the subscriber
+	closed chan struct{}
+}
+
+// Subscribe new receiver to the messages of the stream
+func (s *Subscriber) Subscribe(ctx context.Context, receiver nc.Receiver) error {
+	s.stream.mx.Lock()
+	defer s.stream.mx.Unlock()
+	return s.stream.ModelSubscriber.Subscribe(ctx, receiver)
+}
+
+// Listen delivers the messages to the receivers until the context is done
+// or the subscriber is closed. The messages in process are not canceled by
+// Close.
+func (s *Subscriber) Listen(ctx context.Context) error {
+	stop, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closed:
+			cancel()
+		case <-stop.Done():
+		}
+	}()
+	return s.stream.listen(stop, ctx)
+}
+
+// Close the subscriber and stop its listeners
+func (s *Subscriber) Close() (err error) {
+	s.once.Do(func() {
+		close(s.closed)
+		err = s.stream.release()
+	})
+	return err
+}
+
+// message of the local stream
+type message struct {
+	ctx    context.Context
+	stream *Stream
+	seq    uint64
+	data   []byte
+}
+
+// Context of the message
+func (m *message) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// ID of the message, the sequence number in the stream
+func (m *message) ID() string {
+	return strconv.FormatUint(m.seq, 10)
+}
+
+// Body returns message data as bytes
+func (m *message) Body() []byte {
+	return m.data
+}
+
+// Ack removes the message from the durable stream
+func (m *message) Ack() error {
+	return m.stream.ack(m.seq)
+}
+
+var (
+	_ nc.Publisher  = (*Publisher)(nil)
+	_ nc.Subscriber = (*Subscriber)(nil)
+)
@@ -0,0 +1,156 @@
+package local
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	nc "github.com/geniusrabbit/notificationcenter/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector receives the messages and acknowledges the ones accepted by ack
+type collector struct {
+	mx     sync.Mutex
+	bodies []string
+	ack    func(body string) bool
+	got    chan struct{}
+}
+
+func newCollector(ack func(string) bool) *collector {
+	return &collector{ack: ack, got: make(chan struct{}, 100)}
+}
+
+func (c *collector) Receive(msg nc.Message) error {
+	c.mx.Lock()
+	c.bodies = append(c.bodies, string(msg.Body()))
+	c.mx.Unlock()
+	defer func() { c.got <- struct{}{} }()
+	if c.ack == nil || c.ack(string(msg.Body())) {
+		return msg.Ack()
+	}
+	return nil
+}
+
+func (c *collector) wait(t *testing.T, n int) []string {
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.got:
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d messages", i, n)
+		}
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return append([]string(nil), c.bodies...)
+}
+
+func listen(t *testing.T, sub *Subscriber, rcv nc.Receiver) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, sub.Subscribe(ctx, rcv))
+	go func() { _ = sub.Listen(ctx) }()
+	return cancel
+}
+
+func TestMemoryStream_SharedByURL(t *testing.T) {
+	pub, err := NewPublisher("mem://events?pool=1", nil)
+	require.NoError(t, err)
+	defer pub.Close()
+	sub, err := NewSubscriber("mem://events")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	rcv := newCollector(nil)
+	defer listen(t, sub, rcv)()
+
+	require.NoError(t, pub.Publish(context.Background(), "a", "b"))
+	assert.Equal(t, []string{"\"a\"\n", "\"b\"\n"}, rcv.wait(t, 2))
+
+	other, err := NewSubscriber("mem://other")
+	require.NoError(t, err)
+	defer other.Close()
+	assert.Zero(t, other.stream.Len())
+}
+
+func TestMemoryStream_PublishFromReceiver(t *testing.T) {
+	s, err := Open("mem://loop")
+	require.NoError(t, err)
+	pub, sub := s.Publisher(nil), s.Subscriber()
+	defer pub.Close()
+	defer sub.Close()
+
+	// The receiver publishes to its own stream, the publisher never blocks
+	rcv := newCollector(nil)
+	defer listen(t, sub, nc.FuncReceiver(func(msg nc.Message) error {
+		if string(msg.Body()) != "\"done\"\n" {
+			for i := 0; i < 10; i++ {
+				_ = pub.Publish(msg.Context(), "done")
+			}
+		}
+		return rcv.Receive(msg)
+	}))()
+	require.NoError(t, pub.Publish(context.Background(), "start"))
+	assert.Len(t, rcv.wait(t, 11), 11)
+}
+
+func TestBadgerStream_RedeliverUnacked(t *testing.T) {
+	url := "badger://" + t.TempDir()
+
+	pub, err := NewPublisher(url, nil)
+	require.NoError(t, err)
+	sub, err := NewSubscriber(url)
+	require.NoError(t, err)
+	rcv := newCollector(func(body string) bool { return body != "\"b\"\n" })
+	cancel := listen(t, sub, rcv)
+	require.NoError(t, pub.Publish(context.Background(), "a", "b", "c"))
+	assert.Len(t, rcv.wait(t, 3), 3)
+	cancel()
+	require.NoError(t, pub.Close())
+	require.NoError(t, sub.Close())
+
+	// Only the not acknowledged message is redelivered after the restart
+	sub, err = NewSubscriber(url)
+	require.NoError(t, err)
+	defer sub.Close()
+	rcv = newCollector(nil)
+	defer listen(t, sub, rcv)()
+	assert.Equal(t, []string{"\"b\"\n"}, rcv.wait(t, 1))
+}
+
+func TestOpen_UnsupportedScheme(t *testing.T) {
+	_, err := Open("nats://localhost:4222")
+	assert.ErrorIs(t, err, ErrUnsupportedScheme)
+	assert.True(t, IsLocal("mem://events"))
+	assert.False(t, IsLocal("kafka://localhost"))
+}
+
+func TestSubscriber_CloseKeepsMessageContext(t *testing.T) {
+	s, err := Open("mem://close")
+	require.NoError(t, err)
+	pub, sub := s.Publisher(nil), s.Subscriber()
+	defer pub.Close()
+
+	started, done := make(chan struct{}), make(chan error, 1)
+	require.NoError(t, sub.Subscribe(context.Background(), nc.FuncReceiver(func(msg nc.Message) error {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		done <- msg.Context().Err()
+		return msg.Ack()
+	})))
+	listenDone := make(chan error, 1)
+	go func() { listenDone <- sub.Listen(context.Background()) }()
+	require.NoError(t, pub.Publish(context.Background(), "a"))
+	<-started
+
+	// Close stops the listener, the message in process is not canceled
+	require.NoError(t, sub.Close())
+	assert.NoError(t, <-done)
+	select {
+	case err := <-listenDone:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("listener is not stopped by Close")
+	}
+}
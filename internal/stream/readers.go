@@ -9,6 +9,8 @@ import (
 	"github.com/geniusrabbit/notificationcenter/v2/kafka"
 	"github.com/geniusrabbit/notificationcenter/v2/nats"
 	natsio "github.com/nats-io/nats.go"
+
+	"github.com/apfs-io/apfs/internal/stream/local"
 )
 
 // NewReader stream
@@ -20,6 +22,9 @@ func NewReader(ctx context.Context, urlStr string) (nc.Subscriber, error) {
 	case strings.HasPrefix(urlStr, "kafka://"):
 		// kafka://broker1:9092,broker2:9092/group?client_id={service_name}&topics={topic_name1},{topic_name2}
 		return kafka.NewSubscriber(kafka.WithKafkaURL(urlStr))
+	case local.IsLocal(urlStr):
+		// mem://events or badger:///var/lib/apfs/events?sync=true
+		return local.NewSubscriber(urlStr)
 	}
 	return nil, ErrUndefinedStreamScheme
 }
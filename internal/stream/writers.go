@@ -10,6 +10,8 @@ import (
 	"github.com/geniusrabbit/notificationcenter/v2/kafka"
 	"github.com/geniusrabbit/notificationcenter/v2/nats"
 	natsio "github.com/nats-io/nats.go"
+
+	"github.com/apfs-io/apfs/internal/stream/local"
 )
 
 // Error list...
@@ -17,15 +19,44 @@ var (
 	ErrUndefinedStreamScheme = errors.New(`[stream] invalid scheme`)
 )
 
+// WriterOptions of the stream publisher
+type WriterOptions struct {
+	// Format of the published events (DefaultFormat if empty)
+	Format string
+}
+
+// WriterOption of the stream publisher
+type WriterOption func(opts *WriterOptions)
+
+// WithFormat of the published events: legacy, cloudevents or cloudevents+proto
+func WithFormat(format string) WriterOption {
+	return func(opts *WriterOptions) {
+		opts.Format = format
+	}
+}
+
 // NewWriter publisher interface
-func NewWriter(ctx context.Context, urlStr string) (nc.Publisher, error) {
+func NewWriter(ctx context.Context, urlStr string, options ...WriterOption) (nc.Publisher, error) {
+	var opts WriterOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	enc, err := EventEncoder(opts.Format)
+	if err != nil {
+		return nil, err
+	}
 	switch {
 	case strings.HasPrefix(urlStr, "nats://"):
 		// nats://broker1:9092,broker2:9092/group?client_id={service_name}&topics={topic_name1},{topic_name2}
-		return nats.NewPublisher(nats.WithNatsURL(urlStr), nats.WithNatsOptions(natsio.ReconnectWait(time.Second*5)))
+		return nats.NewPublisher(nats.WithNatsURL(urlStr), nats.WithEncoder(enc),
+			nats.WithNatsOptions(natsio.ReconnectWait(time.Second*5)))
 	case strings.HasPrefix(urlStr, "kafka://"):
 		// kafka://broker1:9092,broker2:9092/group?client_id={service_name}&topics={topic_name1},{topic_name2}
-		return kafka.NewPublisher(ctx, kafka.WithKafkaURL(urlStr))
+		return kafka.NewPublisher(ctx, kafka.WithKafkaURL(urlStr),
+			func(kopts *kafka.Options) { kopts.Encoder = enc })
+	case local.IsLocal(urlStr):
+		// mem://events or badger:///var/lib/apfs/events?sync=true
+		return local.NewPublisher(urlStr, enc)
 	}
 	return nil, ErrUndefinedStreamScheme
 }
@@ -0,0 +1,181 @@
+// Package tracing configures the OpenTelemetry tracer of the service and
+// propagates the trace context through the events and the job queue messages,
+// so the upload, the event processing and the job execution of the object
+// are the parts of the same trace.
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/apfs-io/apfs"
+
+// ErrUnsupportedExporter is returned by Init for the unknown exporter scheme
+var ErrUnsupportedExporter = errors.New("[tracing] unsupported exporter")
+
+// propagator of the trace context inside the messages
+var propagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// ShutdownFunc flushes the buffered spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Init sets the global tracer provider exporting the spans by the connection:
+//
+//	"" or none                        - tracing is disabled
+//	stdout                            - pretty printed spans in stdout
+//	file:///var/log/apfs/traces.json  - spans as JSON lines in the file
+//	otlp://collector:4317             - OTLP over gRPC without TLS
+//	otlps://collector:4317            - OTLP over gRPC with TLS
+//
+// sampleRatio is the fraction of the new traces sampled, the traces
+// continued from the incoming context follow the parent decision.
+func Init(ctx context.Context, serviceName, serviceVersion, connect string, sampleRatio float64) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagator)
+	if connect == "" || connect == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := newExporter(ctx, connect)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(serviceVersion),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, connect string) (sdktrace.SpanExporter, error) {
+	if connect == "stdout" {
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	}
+	u, err := url.Parse(connect)
+	if err != nil {
+		return nil, errors.Wrap(err, "[tracing] exporter")
+	}
+	switch u.Scheme {
+	case "file":
+		file, err := os.OpenFile(u.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		return NewWriterExporter(file)
+	case "otlp", "otlps":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host)}
+		if u.Scheme == "otlp" {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	}
+	return nil, errors.Wrap(ErrUnsupportedExporter, u.Scheme)
+}
+
+// NewWriterExporter writes the spans as JSON lines to the writer,
+// the writer is closed with the exporter if it is io.Closer
+func NewWriterExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := w.(io.Closer); ok {
+		return &closingExporter{SpanExporter: exporter, closer: closer}, nil
+	}
+	return exporter, nil
+}
+
+type closingExporter struct {
+	sdktrace.SpanExporter
+	closer io.Closer
+}
+
+func (e *closingExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if cerr := e.closer.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Tracer of the service
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start the span of the operation
+func Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End the span recording the error of the operation
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the trace context of the span in the context as the message
+// carrier, nil if the context has no span
+func Inject(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract the trace context of the message carrier into the context
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// TraceID returns the hex trace ID of the span in the context, empty if none
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// ObjectAttributes of the object ID "group/path"
+func ObjectAttributes(objectID string) []attribute.KeyValue {
+	group, _, _ := strings.Cut(objectID, "/")
+	return []attribute.KeyValue{
+		attribute.String("apfs.object_id", objectID),
+		attribute.String("apfs.group", group),
+	}
+}
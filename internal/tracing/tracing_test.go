@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	assert.Nil(t, Inject(context.Background()))
+	assert.Empty(t, TraceID(context.Background()))
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "upload")
+	defer span.End()
+
+	carrier := Inject(ctx)
+	require.NotEmpty(t, carrier["traceparent"])
+
+	remote := Extract(context.Background(), carrier)
+	assert.Equal(t, span.SpanContext().TraceID().String(), TraceID(remote))
+	assert.True(t, trace.SpanContextFromContext(remote).IsRemote())
+}
+
+func TestInitFileExporter(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+
+	filename := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Init(context.Background(), "apfs", "test", "file://"+filename, 1)
+	require.NoError(t, err)
+
+	ctx, span := Start(context.Background(), "apfs.Upload", trace.SpanKindServer, ObjectAttributes("media/a.jpg")...)
+	assert.NotEmpty(t, TraceID(ctx))
+	End(span, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"apfs.Upload"`)
+	assert.Contains(t, string(data), `"media/a.jpg"`)
+}
+
+func TestInitExporters(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+
+	shutdown, err := Init(context.Background(), "apfs", "test", "none", 1)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), "apfs", "test", "jaeger://localhost:6831", 1)
+	assert.ErrorIs(t, err, ErrUnsupportedExporter)
+}
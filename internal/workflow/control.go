@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// DefaultCancelCheckInterval of the processing state check by the running job
+const DefaultCancelCheckInterval = 5 * time.Second
+
+// Manual job control errors
+var (
+	// ErrJobNotFound is returned if the job is not defined in the processing state
+	ErrJobNotFound = errors.New("workflow: job not found")
+
+	// ErrJobIsRunning is returned on the retry or skip of the running job
+	ErrJobIsRunning = errors.New("workflow: job is running")
+
+	// ErrProcessingFinished is returned on the cancellation of the finished processing
+	ErrProcessingFinished = errors.New("workflow: processing is finished")
+
+	// ErrJobCancelled is the cause of the cancellation of the running job
+	ErrJobCancelled = errors.New("workflow: processing is cancelled")
+)
+
+// Reasons of the manually skipped jobs
+const (
+	skipReasonManual    = "skipped manually"
+	skipReasonCancelled = "processing cancelled"
+)
+
+// RetryJob resets the finished job and all its downstream jobs to pending,
+// so they run again with the full retry budget. The cancellation of the
+// processing is revoked.
+func RetryJob(w *models.Workflow, state *models.ProcessingState, jobID string) error {
+	js, err := controlJob(state, jobID)
+	if err != nil {
+		return err
+	}
+	dag, err := BuildDAG(w)
+	if err != nil {
+		return err
+	}
+	resetJob(js)
+	for _, downID := range dag.Downstream(jobID) {
+		if djs := state.Jobs[downID]; djs != nil && djs.Status != models.JobStatusRunning {
+			resetJob(djs)
+		}
+	}
+	state.CancelledAt = nil
+	state.FinishedAt = nil
+	updateControlledState(state)
+	return nil
+}
+
+// SkipJob marks the job as skipped, the downstream jobs skipped because of
+// its failure are reset to pending so the processing proceeds
+func SkipJob(w *models.Workflow, state *models.ProcessingState, jobID, reason string) error {
+	js, err := controlJob(state, jobID)
+	if err != nil {
+		return err
+	}
+	dag, err := BuildDAG(w)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		reason = skipReasonManual
+	}
+	js.MarkSkipped(reason)
+	if state.CancelledAt == nil {
+		for _, downID := range dag.Downstream(jobID) {
+			if djs := state.Jobs[downID]; djs != nil && djs.Status == models.JobStatusSkipped {
+				resetJob(djs)
+			}
+		}
+	}
+	state.FinishedAt = nil
+	updateControlledState(state)
+	return nil
+}
+
+// CancelProcessing marks the processing as cancelled and skips the pending
+// jobs. The running jobs are stopped by their workers (see WithCancelCheckInterval).
+func CancelProcessing(state *models.ProcessingState) error {
+	if state.Status.IsTerminal() {
+		return ErrProcessingFinished
+	}
+	now := time.Now()
+	state.CancelledAt = &now
+	for _, js := range state.Jobs {
+		if js != nil && js.Status == models.JobStatusPending {
+			js.MarkSkipped(skipReasonCancelled)
+		}
+	}
+	updateControlledState(state)
+	return nil
+}
+
+// controlJob returns the job of the state which is not running
+func controlJob(state *models.ProcessingState, jobID string) (*models.JobState, error) {
+	js := state.Jobs[jobID]
+	switch {
+	case js == nil:
+		return nil, fmt.Errorf("%w: %q", ErrJobNotFound, jobID)
+	case js.Status == models.JobStatusRunning:
+		return nil, fmt.Errorf("%w: %q", ErrJobIsRunning, jobID)
+	}
+	return js, nil
+}
+
+// resetJob prepares the job for the manual run
+func resetJob(js *models.JobState) {
+	js.ResetForRetry()
+	js.Attempts = 0
+}
+
+func updateControlledState(state *models.ProcessingState) {
+	state.UpdatedAt = time.Now()
+	state.ComputeProgress()
+	state.ComputeStatus()
+	if state.Status.IsTerminal() && state.FinishedAt == nil {
+		finishedAt := state.UpdatedAt
+		state.FinishedAt = &finishedAt
+	}
+}
+
+// watchCancel cancels the job context with ErrJobCancelled cause once the
+// processing of the object is cancelled. The returned func stops the watch.
+func (e *Executor) watchCancel(ctx context.Context, id storio.ObjectID) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(e.cancelCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if state, err := e.storage.ReadState(ctx, id); err == nil && state != nil && state.CancelledAt != nil {
+				cancel(ErrJobCancelled)
+				return
+			}
+		}
+	}()
+	return ctx, func() {
+		close(done)
+		cancel(nil)
+	}
+}
+
+// isJobCancelled reports whether the job context was canceled by CancelProcessing
+func isJobCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrJobCancelled)
+}
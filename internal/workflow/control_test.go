@@ -0,0 +1,326 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// chainWorkflow returns the workflow with jobs "source" → "thumb" → "upload"
+func chainWorkflow() *models.Workflow {
+	step := func() []*models.WorkflowStep {
+		return []*models.WorkflowStep{{Name: "s", Uses: "image/resize", With: map[string]any{}}}
+	}
+	return &models.Workflow{
+		Version: "2",
+		Jobs: map[string]*models.WorkflowJob{
+			"source": {Steps: step()},
+			"thumb":  {Needs: []string{"source"}, Steps: step()},
+			"upload": {Needs: []string{"thumb"}, Steps: step()},
+		},
+	}
+}
+
+func failedChainState() *models.ProcessingState {
+	state := models.NewProcessingState("obj-1", "2", []string{"source", "thumb", "upload"})
+	state.Jobs["source"].MarkStarted("")
+	state.Jobs["source"].MarkFailed(errors.New("resize failed"))
+	state.Jobs["source"].Attempts = 3
+	state.Jobs["thumb"].MarkSkipped(`upstream job "source" failed`)
+	state.Jobs["upload"].MarkSkipped(`upstream job "thumb" failed`)
+	state.ComputeStatus()
+	return state
+}
+
+func TestRetryJob(t *testing.T) {
+	state := failedChainState()
+	require.True(t, state.Status.IsTerminal())
+
+	require.NoError(t, RetryJob(chainWorkflow(), state, "source"))
+	for _, jobID := range []string{"source", "thumb", "upload"} {
+		js := state.Jobs[jobID]
+		assert.Equal(t, models.JobStatusPending, js.Status, jobID)
+		assert.Empty(t, js.Error, jobID)
+		assert.Zero(t, js.Attempts, jobID)
+	}
+	assert.Equal(t, models.ProcessingStatusPending, state.Status)
+	assert.Nil(t, state.FinishedAt)
+	assert.Equal(t, []string{"source"}, mustBuildDAG(t, chainWorkflow()).ReadyJobs(state, nil))
+}
+
+func TestRetryJob_Errors(t *testing.T) {
+	state := failedChainState()
+	assert.ErrorIs(t, RetryJob(chainWorkflow(), state, "unknown"), ErrJobNotFound)
+
+	state.Jobs["source"].MarkStarted("worker")
+	assert.ErrorIs(t, RetryJob(chainWorkflow(), state, "source"), ErrJobIsRunning)
+	assert.ErrorIs(t, SkipJob(chainWorkflow(), state, "source", ""), ErrJobIsRunning)
+}
+
+func TestSkipJob_DownstreamProceeds(t *testing.T) {
+	state := failedChainState()
+
+	require.NoError(t, SkipJob(chainWorkflow(), state, "source", ""))
+	assert.Equal(t, models.JobStatusSkipped, state.Jobs["source"].Status)
+	assert.Equal(t, "skipped manually", state.Jobs["source"].Error)
+	assert.Equal(t, models.JobStatusPending, state.Jobs["thumb"].Status)
+	assert.Equal(t, models.JobStatusPending, state.Jobs["upload"].Status)
+	assert.Equal(t, []string{"thumb"}, mustBuildDAG(t, chainWorkflow()).ReadyJobs(state, nil))
+}
+
+func TestCancelProcessing(t *testing.T) {
+	state := models.NewProcessingState("obj-1", "2", []string{"source", "thumb", "upload"})
+	state.Jobs["source"].MarkStarted("worker")
+	state.ComputeStatus()
+
+	require.NoError(t, CancelProcessing(state))
+	require.NotNil(t, state.CancelledAt)
+	assert.Equal(t, models.JobStatusSkipped, state.Jobs["thumb"].Status)
+	assert.Equal(t, models.JobStatusSkipped, state.Jobs["upload"].Status)
+	assert.Equal(t, models.ProcessingStatusRunning, state.Status, "the running job is not stopped yet")
+
+	state.Jobs["source"].MarkFailed(ErrJobCancelled)
+	state.ComputeStatus()
+	assert.Equal(t, models.ProcessingStatusCancelled, state.Status)
+	assert.ErrorIs(t, CancelProcessing(state), ErrProcessingFinished)
+
+	// The retry revokes the cancellation
+	require.NoError(t, RetryJob(chainWorkflow(), state, "source"))
+	assert.Nil(t, state.CancelledAt)
+	assert.Equal(t, models.ProcessingStatusPending, state.Status)
+}
+
+// cancelStorage is the fake storage safe for the concurrent state access
+type cancelStorage struct {
+	*fakeStorage
+	mx sync.Mutex
+}
+
+func (s *cancelStorage) ReadState(ctx context.Context, id storio.ObjectID) (*models.ProcessingState, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.fakeStorage.ReadState(ctx, id)
+}
+
+func (s *cancelStorage) WriteState(ctx context.Context, id storio.ObjectID, state *models.ProcessingState) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.fakeStorage.WriteState(ctx, id, state)
+}
+
+// cancelRunning cancels the processing once the job is running
+func (s *cancelStorage) cancelRunning(jobID string) {
+	for !s.cancel(jobID) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *cancelStorage) cancel(jobID string) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.state.Jobs[jobID].Status != models.JobStatusRunning {
+		return false
+	}
+	latest := *s.state
+	latest.Jobs = map[string]*models.JobState{}
+	for jobID, js := range s.state.Jobs {
+		latest.Jobs[jobID] = js
+	}
+	_ = CancelProcessing(&latest)
+	s.state = &latest
+	return true
+}
+
+func TestExecuteJob_CancelledProcessing(t *testing.T) {
+	var (
+		store = &cancelStorage{fakeStorage: newFakeStorage()}
+		reg   = NewRunnerRegistry()
+		wf    = chainWorkflow()
+	)
+	wf.Jobs["source"].OnFailure = "retry:3"
+	reg.Register(blockingRunner{})
+	store.state = models.NewProcessingState("obj-1", "2", []string{"source", "thumb", "upload"})
+	exec := NewExecutor(store, reg, WithCancelCheckInterval(10*time.Millisecond))
+
+	go store.cancelRunning("source")
+
+	require.NoError(t, exec.ExecuteJob(context.Background(), wf, "obj-1", "source", nil))
+
+	state, _ := store.ReadState(context.Background(), storio.ObjectIDType("obj-1"))
+	assert.Equal(t, models.JobStatusFailed, state.Jobs["source"].Status, "the cancelled job is not retried")
+	assert.Equal(t, ErrJobCancelled.Error(), state.Jobs["source"].Error)
+	assert.Equal(t, models.JobStatusSkipped, state.Jobs["thumb"].Status)
+	assert.Equal(t, models.ProcessingStatusCancelled, state.Status)
+	assert.NotNil(t, state.FinishedAt)
+}
+
+// copyStorage keeps the copy of the written state like the real storages,
+// so the concurrent writers overwrite each other
+type copyStorage struct {
+	*fakeStorage
+	mx sync.Mutex
+
+	// onRead is called after the state is read
+	onRead func()
+}
+
+func (s *copyStorage) ReadState(_ context.Context, _ storio.ObjectID) (*models.ProcessingState, error) {
+	s.mx.Lock()
+	state, onRead := cloneState(s.state), s.onRead
+	s.mx.Unlock()
+	if onRead != nil {
+		onRead()
+	}
+	return state, nil
+}
+
+func (s *copyStorage) setOnRead(fn func()) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.onRead = fn
+}
+
+func (s *copyStorage) WriteState(_ context.Context, _ storio.ObjectID, state *models.ProcessingState) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.state = cloneState(state)
+	return nil
+}
+
+func (s *copyStorage) ReadMeta(ctx context.Context, id storio.ObjectID) (*models.Meta, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.fakeStorage.ReadMeta(ctx, id)
+}
+
+func (s *copyStorage) WriteMeta(ctx context.Context, id storio.ObjectID, meta *models.Meta) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.fakeStorage.WriteMeta(ctx, id, meta)
+}
+
+func (s *copyStorage) jobStatus(jobID string) models.JobStatus {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.state.Jobs[jobID].Status
+}
+
+func cloneState(state *models.ProcessingState) *models.ProcessingState {
+	if state == nil {
+		return nil
+	}
+	data, _ := json.Marshal(state)
+	var clone models.ProcessingState
+	_ = json.Unmarshal(data, &clone)
+	return &clone
+}
+
+// gateRunner runs the steps of the action until the gate is closed
+type gateRunner struct {
+	uses string
+	gate chan struct{}
+}
+
+func (r gateRunner) CanRun(step *models.WorkflowStep) bool { return step.Uses == r.uses }
+
+func (r gateRunner) Run(ctx context.Context, _ *models.WorkflowStep, _ StepInput) (StepOutput, error) {
+	<-r.gate
+	return StepOutput{Outputs: map[string]any{"width": 100}}, nil
+}
+
+func TestUpdateState_ConcurrentJobCompletion(t *testing.T) {
+	var (
+		store  = &copyStorage{fakeStorage: newFakeStorage()}
+		reg    = NewRunnerRegistry()
+		runner = gateRunner{uses: "image/resize", gate: make(chan struct{})}
+		step   = []*models.WorkflowStep{{Name: "s", Uses: "image/resize", With: map[string]any{}}}
+		wf     = &models.Workflow{Version: "2", Jobs: map[string]*models.WorkflowJob{
+			"thumb":  {Steps: step},
+			"upload": {Steps: step},
+		}}
+	)
+	reg.Register(runner)
+	store.state = models.NewProcessingState("obj-1", "2", []string{"thumb", "upload"})
+	exec := NewExecutor(store, reg)
+
+	done := make(chan error, 1)
+	go func() { done <- exec.ExecuteJob(context.Background(), wf, "obj-1", "thumb", nil) }()
+	for store.jobStatus("thumb") != models.JobStatusRunning {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The job finishes between the read and the write of the manual control
+	err := exec.UpdateState(context.Background(), "obj-1", func(state *models.ProcessingState) error {
+		require.Equal(t, models.JobStatusRunning, state.Jobs["thumb"].Status)
+		close(runner.gate)
+		time.Sleep(50 * time.Millisecond)
+		return SkipJob(wf, state, "upload", "")
+	})
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	state, _ := store.ReadState(context.Background(), storio.ObjectIDType("obj-1"))
+	assert.Equal(t, models.JobStatusCompleted, state.Jobs["thumb"].Status, "the job result is not overwritten")
+	assert.EqualValues(t, 100, state.Jobs["thumb"].Outputs["width"])
+	assert.Equal(t, models.JobStatusSkipped, state.Jobs["upload"].Status, "the manual control is kept")
+	assert.True(t, state.Status.IsTerminal())
+}
+
+func TestExecuteJob_ConcurrentJobsOfObject(t *testing.T) {
+	var (
+		store  = &copyStorage{fakeStorage: newFakeStorage()}
+		reg    = NewRunnerRegistry()
+		resize = gateRunner{uses: "image/resize", gate: make(chan struct{})}
+		upload = gateRunner{uses: "s3/upload", gate: make(chan struct{})}
+		wf     = &models.Workflow{Version: "2", Jobs: map[string]*models.WorkflowJob{
+			"thumb":  {Steps: []*models.WorkflowStep{{Name: "s", Uses: resize.uses}}},
+			"upload": {Steps: []*models.WorkflowStep{{Name: "s", Uses: upload.uses}}},
+		}}
+	)
+	reg.Register(resize)
+	reg.Register(upload)
+	store.state = models.NewProcessingState("obj-1", "2", []string{"thumb", "upload"})
+	exec := NewExecutor(store, reg)
+
+	thumbDone := make(chan error, 1)
+	go func() { thumbDone <- exec.ExecuteJob(context.Background(), wf, "obj-1", "thumb", nil) }()
+	for store.jobStatus("thumb") != models.JobStatusRunning {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The thumb job finishes between the read and the write of the upload start
+	var fired atomic.Bool
+	store.setOnRead(func() {
+		if fired.CompareAndSwap(false, true) {
+			close(resize.gate)
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+	uploadDone := make(chan error, 1)
+	go func() { uploadDone <- exec.ExecuteJob(context.Background(), wf, "obj-1", "upload", nil) }()
+	require.NoError(t, <-thumbDone)
+	close(upload.gate)
+	require.NoError(t, <-uploadDone)
+
+	state, _ := store.ReadState(context.Background(), storio.ObjectIDType("obj-1"))
+	assert.Equal(t, models.JobStatusCompleted, state.Jobs["thumb"].Status)
+	assert.Equal(t, models.JobStatusCompleted, state.Jobs["upload"].Status)
+	assert.Equal(t, models.ProcessingStatusCompleted, state.Status)
+}
+
+func mustBuildDAG(t *testing.T, w *models.Workflow) *DAG {
+	t.Helper()
+	dag, err := BuildDAG(w)
+	require.NoError(t, err)
+	return dag
+}
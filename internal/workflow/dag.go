@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/apfs-io/apfs/models"
 )
@@ -98,17 +99,20 @@ func (d *DAG) TopologicalOrder() []string {
 // ProcessingState and the worker's tag set.
 //
 // A job is ready when:
-//  1. Its status is JobStatusPending, AND
+//  1. Its status is JobStatusPending and its scheduled retry is due, AND
 //  2. All jobs in its Needs list are in a terminal state, AND
 //  3. At least one worker tag satisfies the job's runs-on field.
 func (d *DAG) ReadyJobs(state *models.ProcessingState, workerTags []string) []string {
 	if d == nil || state == nil || d.workflow == nil {
 		return nil
 	}
-	var ready []string
+	var (
+		ready []string
+		now   = time.Now()
+	)
 	for _, id := range d.order {
 		js, ok := state.Jobs[id]
-		if !ok || js == nil || js.Status != models.JobStatusPending {
+		if !ok || js == nil || js.Status != models.JobStatusPending || !js.IsDue(now) {
 			continue
 		}
 		job := d.workflow.Jobs[id]
@@ -2,18 +2,29 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
 	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/internal/tracing"
 	"github.com/apfs-io/apfs/models"
 )
 
+// ErrJobRetry is returned by ExecuteJob when the failed job is reset to
+// pending to be retried according to the retry policy (see RetryError)
+var ErrJobRetry = errors.New("executor: job is scheduled for retry")
+
 // ExecutorStorage is the minimal storage interface required by the Executor.
 type ExecutorStorage interface {
 	// ReadState reads the current ProcessingState for an object.
@@ -36,11 +47,115 @@ type ExecutorStorage interface {
 type Executor struct {
 	storage  ExecutorStorage
 	registry *RunnerRegistry
+
+	// Job lease locker (optional)
+	locker  kvaccessor.Locker
+	lockTTL time.Duration
+	owner   string
+
+	// localLocker of the processing states if the job locker is not set
+	localLocker kvaccessor.Locker
+
+	// Step output logs
+	logLimit         int
+	logFlushInterval time.Duration
+
+	// Interval of the processing cancellation check
+	cancelCheckInterval time.Duration
+
+	// interrupted is closed by InterruptJobs on the worker shutdown
+	interrupted   chan struct{}
+	interruptOnce sync.Once
+
+	// Callbacks of the started and the finished jobs (optional)
+	onJobStarted  JobEventFunc
+	onJobFinished JobEventFunc
+}
+
+// JobEventFunc is called after the state of the started or the finished job
+// (completed, failed or skipped) is written. The state is the written
+// processing state.
+type JobEventFunc func(ctx context.Context, w *models.Workflow, objectID, jobID string, state *models.ProcessingState)
+
+// ExecutorOption configures the Executor
+type ExecutorOption func(*Executor)
+
+// WithLocker makes the executor hold a lease of every job it runs, so a job
+// is executed by exactly one worker. The lease is renewed every ttl/3 while
+// the job runs; the lock of a crashed worker is reclaimed after ttl.
+func WithLocker(locker kvaccessor.Locker, ttl time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.locker = locker
+		e.lockTTL = ttl
+	}
+}
+
+// WithLockOwner sets the owner name of the job leases (host:pid by default)
+func WithLockOwner(owner string) ExecutorOption {
+	return func(e *Executor) {
+		e.owner = owner
+	}
+}
+
+// WithStepLogs sets the size of the step output tail kept in the step log
+// and the interval of the log flush while the step runs
+func WithStepLogs(limit int, flushInterval time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.logLimit = limit
+		e.logFlushInterval = flushInterval
+	}
+}
+
+// WithCancelCheckInterval sets the interval of the processing state check
+// by the running job. The steps of the job are stopped once the processing
+// of the object is cancelled.
+func WithCancelCheckInterval(interval time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.cancelCheckInterval = interval
+	}
+}
+
+// WithJobStarted sets the callback of the started jobs
+func WithJobStarted(fn JobEventFunc) ExecutorOption {
+	return func(e *Executor) {
+		e.onJobStarted = fn
+	}
+}
+
+// WithJobFinished sets the callback of the finished jobs
+func WithJobFinished(fn JobEventFunc) ExecutorOption {
+	return func(e *Executor) {
+		e.onJobFinished = fn
+	}
 }
 
 // NewExecutor creates an Executor with the given storage and runner registry.
-func NewExecutor(storage ExecutorStorage, registry *RunnerRegistry) *Executor {
-	return &Executor{storage: storage, registry: registry}
+func NewExecutor(storage ExecutorStorage, registry *RunnerRegistry, opts ...ExecutorOption) *Executor {
+	e := &Executor{
+		storage:     storage,
+		registry:    registry,
+		localLocker: memory.NewLocker(),
+		interrupted: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.lockTTL <= 0 {
+		e.lockTTL = DefaultLockTTL
+	}
+	if e.owner == "" {
+		e.owner = defaultLockOwner()
+	}
+	if e.logLimit <= 0 {
+		e.logLimit = DefaultStepLogLimit
+	}
+	if e.logFlushInterval <= 0 {
+		e.logFlushInterval = DefaultStepLogFlushInterval
+	}
+	if e.cancelCheckInterval <= 0 {
+		e.cancelCheckInterval = DefaultCancelCheckInterval
+	}
+	return e
 }
 
 // ExecuteJob runs the specified job for the object identified by objectID.
@@ -48,14 +163,34 @@ func NewExecutor(storage ExecutorStorage, registry *RunnerRegistry) *Executor {
 // stored in the job state for observability. Pass nil or empty to indicate
 // an untagged worker.
 //
+// When the executor has a locker the job is leased first, ErrJobLocked is
+// returned if another worker holds it.
+//
 // It:
 //  1. Loads the current ProcessingState and Meta.
 //  2. Evaluates the job's if: condition (skips if false).
 //  3. Evaluates the on-failure policy.
 //  4. Runs each step in order.
 //  5. Persists the updated state and meta.
-func (e *Executor) ExecuteJob(ctx context.Context, w *models.Workflow, objectID string, jobID string, workerTags []string) error {
+func (e *Executor) ExecuteJob(ctx context.Context, w *models.Workflow, objectID string, jobID string, workerTags []string) (err error) {
+	if e.isInterrupted() {
+		return ErrWorkerShutdown
+	}
 	workerLabel := strings.Join(workerTags, ",")
+	ctx, span := tracing.Start(ctx, "workflow.job "+jobID, trace.SpanKindInternal,
+		append(tracing.ObjectAttributes(objectID),
+			attribute.String("apfs.job_id", jobID),
+			attribute.String("apfs.worker", workerLabel))...)
+	defer func() {
+		// The job held by another worker is not the failure
+		if errors.Is(err, ErrJobLocked) {
+			span.SetAttributes(attribute.Bool("apfs.job_locked", true))
+			span.End()
+			return
+		}
+		tracing.End(span, err)
+	}()
+
 	log := ctxlogger.Get(ctx).With(
 		zap.String("object_id", objectID),
 		zap.String("job_id", jobID),
@@ -64,6 +199,21 @@ func (e *Executor) ExecuteJob(ctx context.Context, w *models.Workflow, objectID
 
 	id := storio.ObjectIDType(objectID)
 
+	// Hold the job lease until the state is written
+	lease, err := e.acquireJob(ctx, objectID, jobID, log)
+	if err != nil {
+		return err
+	}
+	defer lease.release(ctx)
+
+	// The state is loaded and the job is marked started under the state
+	// lock, so the concurrent jobs of the object don't overwrite each other
+	unlockState, err := e.lockState(ctx, objectID)
+	if err != nil {
+		return err
+	}
+	defer unlockState()
+
 	// Load state
 	state, err := e.storage.ReadState(ctx, id)
 	if err != nil {
@@ -88,6 +238,7 @@ func (e *Executor) ExecuteJob(ctx context.Context, w *models.Workflow, objectID
 	if !ok || job == nil {
 		return fmt.Errorf("executor: job %q not found in workflow", jobID)
 	}
+	group := objectGroup(objectID)
 
 	// Collect upstream outputs for evaluator and template resolution
 	jobOutputs := collectOutputs(state)
@@ -100,18 +251,30 @@ func (e *Executor) ExecuteJob(ctx context.Context, w *models.Workflow, objectID
 	}
 	if skip {
 		js.MarkSkipped("if condition evaluated to false")
+		observeJob(group, jobID, js, jobResultSkipped, 0)
 		state.UpdatedAt = time.Now()
 		state.ComputeProgress()
 		state.ComputeStatus()
-		return e.storage.WriteState(ctx, id, state)
+		return e.writeFinishedState(ctx, w, objectID, jobID, state)
 	}
 
 	// Mark started
+	firstJob := isFirstJobStart(state)
 	js.MarkStarted(workerLabel)
+	if state.TraceID == "" {
+		state.TraceID = tracing.TraceID(ctx)
+	}
+	if lease != nil {
+		js.Fence = lease.token()
+	}
 	state.Status = models.ProcessingStatusRunning
 	state.UpdatedAt = time.Now()
-	if err := e.storage.WriteState(ctx, id, state); err != nil {
+	err = e.storage.WriteState(ctx, id, state)
+	unlockState()
+	if err != nil {
 		log.Warn("write state before job start", zap.Error(err))
+	} else if e.onJobStarted != nil {
+		e.onJobStarted(ctx, w, objectID, jobID, state)
 	}
 
 	// Load meta
@@ -122,52 +285,103 @@ func (e *Executor) ExecuteJob(ctx context.Context, w *models.Workflow, objectID
 	if meta == nil {
 		meta = &models.Meta{}
 	}
+	if firstJob {
+		uploadedAt := meta.CreatedAt
+		if uploadedAt.IsZero() {
+			uploadedAt = state.StartedAt
+		}
+		observeQueueLag(group, uploadedAt)
+	}
 
 	// Apply timeout
 	jobCtx := ctx
+	if lease != nil {
+		jobCtx = lease.ctx
+	}
 	if timeout := job.Timeout(); timeout > 0 {
 		var cancel context.CancelFunc
-		jobCtx, cancel = context.WithTimeout(ctx, timeout)
+		jobCtx, cancel = context.WithTimeout(jobCtx, timeout)
 		defer cancel()
 	}
+	jobCtx, stopWatch := e.watchCancel(jobCtx, id)
+	jobCtx, stopInterrupt := e.watchInterrupt(jobCtx)
 
 	// Execute steps
+	activeJobs := metricActiveJobs.WithLabelValues(workerLabel)
+	activeJobs.Inc()
+	jobStart := time.Now()
 	jobErr := e.runSteps(jobCtx, job, jobID, id, meta, jobOutputs, js, log)
+	jobDuration := time.Since(jobStart)
+	activeJobs.Dec()
+	cancelled := isJobCancelled(jobCtx)
+	interrupted := isJobInterrupted(jobCtx)
+	timedOut := errors.Is(jobCtx.Err(), context.DeadlineExceeded)
+	stopInterrupt()
+	stopWatch()
+
+	// The result is merged into the latest state under the state lock, the
+	// state could be changed meanwhile by the other jobs and the manual control
+	unlockResult, err := e.lockState(context.WithoutCancel(ctx), objectID)
+	if err != nil {
+		return err
+	}
+	defer unlockResult()
+
+	// Another worker could take over the job while it was running
+	if err := e.checkFence(ctx, id, jobID, lease); err != nil {
+		log.Error("job result is discarded", zap.Error(err))
+		return err
+	}
+
+	state = e.mergeLatestState(ctx, id, state, jobID, js, log)
 
 	// Handle failure policy
 	fp := job.FailurePolicy()
-	if jobErr != nil {
+	if jobErr != nil && (cancelled || state.CancelledAt != nil) {
+		log.Warn("job stopped, processing is cancelled", zap.Error(jobErr))
+		js.MarkFailed(ErrJobCancelled)
+		observeJob(group, jobID, js, jobResultFailed, jobDuration)
+	} else if jobErr != nil && interrupted {
+		// The job is not failed, another worker runs it again
+		now := time.Now()
+		log.Warn("job interrupted by the worker shutdown, will retry", zap.Error(jobErr))
+		js.ScheduleRetry(now, ErrWorkerShutdown)
+		observeJob(group, jobID, js, jobResultRetried, jobDuration)
+		state.UpdatedAt = now
+		state.ComputeProgress()
+		state.ComputeStatus()
+		if err := e.storage.WriteState(ctx, id, state); err != nil {
+			log.Error("write state of the interrupted job", zap.Error(err))
+		}
+		return &RetryError{JobID: jobID, At: now, Err: ErrWorkerShutdown}
+	} else if jobErr != nil {
+		if at, ok := scheduleRetry(job, js, jobErr, timedOut); ok {
+			log.Warn("job failed, will retry", zap.Error(jobErr),
+				zap.Int("attempts", js.Attempts), zap.Int("max", job.RetryPolicy().MaxRetries()),
+				zap.Time("next_attempt_at", at))
+			observeJob(group, jobID, js, jobResultRetried, jobDuration)
+			state.UpdatedAt = time.Now()
+			_ = e.storage.WriteState(ctx, id, state)
+			return &RetryError{JobID: jobID, At: at, Err: jobErr}
+		}
 		switch fp & 0x0F {
 		case models.FailurePolicyContinue:
 			log.Warn("job failed (on-failure:continue)", zap.Error(jobErr))
 			js.MarkFailed(jobErr)
+			observeJob(group, jobID, js, jobResultFailed, jobDuration)
 		case models.FailurePolicyRetry:
-			maxRetries := fp.MaxRetries()
-			if js.Attempts < maxRetries {
-				log.Warn("job failed, will retry",
-					zap.Error(jobErr), zap.Int("attempts", js.Attempts), zap.Int("max", maxRetries))
-				js.ResetForRetry()
-				state.UpdatedAt = time.Now()
-				_ = e.storage.WriteState(ctx, id, state)
-				return fmt.Errorf("executor: retry job %q: %w", jobID, jobErr)
-			}
 			log.Error("job failed, max retries reached", zap.Error(jobErr))
 			js.MarkFailed(jobErr)
+			observeJob(group, jobID, js, jobResultFailed, jobDuration)
 		default: // FailurePolicyFail
 			log.Error("job failed (on-failure:fail)", zap.Error(jobErr))
 			js.MarkFailed(jobErr)
-			// Mark downstream jobs as skipped
-			dag, dagErr := BuildDAG(w)
-			if dagErr == nil {
-				for _, downID := range dag.Downstream(jobID) {
-					if djs, ok := state.Jobs[downID]; ok && djs.Status == models.JobStatusPending {
-						djs.MarkSkipped(fmt.Sprintf("upstream job %q failed", jobID))
-					}
-				}
-			}
+			observeJob(group, jobID, js, jobResultFailed, jobDuration)
+			skipDownstream(w, state, jobID)
 		}
 	} else {
 		js.MarkCompleted(js.Outputs)
+		observeJob(group, jobID, js, jobResultCompleted, jobDuration)
 		meta.ManifestVersion = w.Version
 		if err := e.storage.WriteMeta(ctx, id, meta); err != nil {
 			log.Warn("write meta after job complete", zap.Error(err))
@@ -181,10 +395,57 @@ func (e *Executor) ExecuteJob(ctx context.Context, w *models.Workflow, objectID
 		now := time.Now()
 		state.FinishedAt = &now
 	}
-	return e.storage.WriteState(ctx, id, state)
+	return e.writeFinishedState(ctx, w, objectID, jobID, state)
+}
+
+// writeFinishedState writes the state of the finished job and calls the
+// job finished callback
+func (e *Executor) writeFinishedState(ctx context.Context, w *models.Workflow, objectID, jobID string, state *models.ProcessingState) error {
+	if err := e.storage.WriteState(ctx, storio.ObjectIDType(objectID), state); err != nil {
+		return err
+	}
+	if e.onJobFinished != nil {
+		e.onJobFinished(ctx, w, objectID, jobID, state)
+	}
+	return nil
+}
+
+// skipDownstream marks the pending downstream jobs of the failed job as skipped
+func skipDownstream(w *models.Workflow, state *models.ProcessingState, jobID string) {
+	dag, err := BuildDAG(w)
+	if err != nil {
+		return
+	}
+	for _, downID := range dag.Downstream(jobID) {
+		if djs, ok := state.Jobs[downID]; ok && djs.Status == models.JobStatusPending {
+			djs.MarkSkipped(fmt.Sprintf("upstream job %q failed", jobID))
+		}
+	}
+}
+
+// mergeLatestState puts the job state into the latest processing state
+// of the object. The loaded state is returned if the latest can't be read.
+func (e *Executor) mergeLatestState(ctx context.Context, id storio.ObjectID, state *models.ProcessingState, jobID string, js *models.JobState, log *zap.Logger) *models.ProcessingState {
+	latest, err := e.storage.ReadState(ctx, id)
+	if err != nil || latest == nil {
+		if err != nil {
+			log.Warn("read state after job end", zap.Error(err))
+		}
+		return state
+	}
+	if latest.Jobs == nil {
+		latest.Jobs = map[string]*models.JobState{}
+	}
+	latest.Jobs[jobID] = js
+	return latest
 }
 
 // runSteps executes all steps in the job in order.
+//
+// When a step reads the artifact produced by the previous step, the artifact
+// is streamed into it while being written to the storage. The runner which
+// returns StepOutput.Wait keeps producing the artifact while the next step
+// consumes it, so the steps of the job are pipelined (see artifactStream).
 func (e *Executor) runSteps(
 	ctx context.Context,
 	job *models.WorkflowJob,
@@ -194,13 +455,43 @@ func (e *Executor) runSteps(
 	jobOutputs map[string]map[string]any,
 	js *models.JobState,
 	log *zap.Logger,
-) error {
+) (err error) {
 	if js.Outputs == nil {
 		js.Outputs = map[string]any{}
 	}
 	js.Steps = make([]*models.StepState, 0, len(job.Steps))
+	group := objectGroup(id.ID().String())
+	logStore, _ := e.storage.(StepLogStorage)
+
+	// Span of the current step
+	var span trace.Span
+	defer func() {
+		if span != nil {
+			tracing.End(span, err)
+		}
+	}()
+
+	// Artifact of the previous step streamed into the current one
+	var stream *artifactStream
+	defer func() {
+		if stream != nil {
+			if werr := stream.wait(); werr != nil && err == nil {
+				err = werr
+			}
+		}
+	}()
+
+	for i, step := range job.Steps {
+		// The span of the previous step is ended by the next one, the failed
+		// step ends the job
+		if span != nil {
+			span.End()
+		}
+		var stepCtx context.Context
+		stepCtx, span = tracing.Start(ctx, "workflow.step "+step.Name, trace.SpanKindInternal,
+			attribute.String("apfs.step", step.Name),
+			attribute.String("apfs.runner", stepRunnerName(step)))
 
-	for _, step := range job.Steps {
 		ss := &models.StepState{Name: step.Name, Status: models.StepStatusRunning}
 		js.Steps = append(js.Steps, ss)
 
@@ -213,55 +504,145 @@ func (e *Executor) runSteps(
 
 		start := time.Now()
 		sourceName := stepSourceName(step, meta)
-		reader, err := e.storage.ReadFile(ctx, id, sourceName)
+		reader, release, err := e.stepInput(stepCtx, id, sourceName, stream, needsSeekableInput(runner, step))
 		if err != nil {
 			ss.Status = models.StepStatusFailed
 			ss.Error = err.Error()
 			return fmt.Errorf("step %q read source %q: %w", step.Name, sourceName, err)
 		}
+		runnerName := stepRunnerName(step)
 		in := StepInput{
 			ObjectID:   id.ID().String(),
 			JobID:      jobID,
 			Meta:       meta,
 			JobOutputs: jobOutputs,
-			Reader:     reader,
+			Reader:     countReader(reader, metricStepReadBytes.WithLabelValues(group, jobID, runnerName)),
+		}
+		var (
+			stepLog *StepLog
+			logPath = StepLogPath(jobID, step.Name)
+			stopLog func() error
+		)
+		if logStore != nil {
+			stepLog = NewStepLog(e.logLimit)
+			in.Log = stepLog
+			stopLog = e.flushStepLog(ctx, logStore, id, logPath, stepLog)
 		}
-		out, err := runner.Run(ctx, step, in)
-		_ = reader.Close()
-		ss.DurationMs = time.Since(start).Milliseconds()
+		out, err := runner.Run(stepCtx, step, in)
 
+		// complete the step once its output is produced
+		complete := func(err error) error {
+			release()
+			ss.DurationMs = time.Since(start).Milliseconds()
+			if stopLog != nil {
+				if lerr := stopLog(); lerr != nil {
+					log.Warn("write step log", zap.String("step", step.Name), zap.Error(lerr))
+				} else if stepLog.Size() > 0 {
+					ss.LogPath = logPath
+				}
+			}
+			if err != nil {
+				failStep(ss, err)
+				return fmt.Errorf("step %q: %w", step.Name, err)
+			}
+			ss.Status = models.StepStatusCompleted
+			return nil
+		}
+
+		// The runner could keep producing the artifact after Run returned,
+		// then the step is completed when the producer is finished
+		producer := out.Wait
+		if err != nil || producer == nil {
+			producer = nil
+			err = complete(err)
+		}
+
+		// The previous artifact must be stored before moving on. The running
+		// producer could wait until its output is consumed, so the output is
+		// handled first.
+		prev := stream
+		stream = nil
+		if producer == nil && prev != nil {
+			if werr := prev.wait(); werr != nil {
+				return werr
+			}
+			prev = nil
+		}
 		if err != nil {
-			ss.Status = models.StepStatusFailed
-			ss.Error = err.Error()
-			return fmt.Errorf("step %q: %w", step.Name, err)
+			return err
 		}
-		ss.Status = models.StepStatusCompleted
 
 		// Merge step outputs into job outputs
 		for k, v := range out.Outputs {
 			js.Outputs[k] = v
 		}
 
+		// finish closes the step output and waits for its producer
+		finish := func() error {
+			closeReader(out.Writer)
+			if producer == nil {
+				return nil
+			}
+			return complete(producer())
+		}
+
 		// Write artifact if the step produced one
+		var next *artifactStream
 		if out.Writer != nil && out.TargetPath != "" {
+			writer := countReader(out.Writer, metricStepWriteBytes.WithLabelValues(group, jobID, runnerName))
 			im := out.ItemMeta
 			if im == nil {
 				im = &models.ItemMeta{}
 			}
 			im.Role = jobID
 			im.UpdateName(out.TargetPath)
-			if err := e.storage.WriteFile(ctx, id, out.TargetPath, out.Writer, im); err != nil {
-				return fmt.Errorf("step %q write artifact: %w", step.Name, err)
-			}
 			meta.SetItem(im)
-			log.Debug("step artifact written",
-				zap.String("path", out.TargetPath),
-				zap.String("role", jobID))
+			if i+1 < len(job.Steps) && stepSourceName(job.Steps[i+1], meta) == out.TargetPath {
+				next = e.streamArtifact(ctx, id, step.Name, out.TargetPath, writer, im, finish)
+				log.Debug("step artifact streamed to the next step",
+					zap.String("path", out.TargetPath),
+					zap.String("role", jobID))
+			} else {
+				err = e.writeArtifact(ctx, id, out.TargetPath, writer, im)
+				if ferr := finish(); ferr != nil {
+					err = ferr
+				} else if err != nil {
+					err = fmt.Errorf("step %q write artifact: %w", step.Name, err)
+				} else {
+					log.Debug("step artifact written",
+						zap.String("path", out.TargetPath),
+						zap.String("role", jobID))
+				}
+			}
+		} else if err = finish(); err == nil && out.ItemMeta != nil {
+			// Meta-only step: annotate the source item (e.g. placeholders, probe)
+			if item := meta.ItemByName(sourceName); item != nil {
+				mergeItemMeta(item, out.ItemMeta)
+			}
+		}
+		if prev != nil {
+			if werr := prev.wait(); werr != nil && err == nil {
+				err = werr
+			}
+		}
+		stream = next
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// failStep marks the step failed by err
+func failStep(ss *models.StepState, err error) {
+	ss.Status = models.StepStatusFailed
+	ss.Error = err.Error()
+	if lerr := (*LimitError)(nil); errors.As(err, &lerr) {
+		ss.Status = models.StepStatusLimitExceeded
+		ss.Limit = lerr.Limit
+	}
+}
+
 // collectOutputs builds a map[jobID]outputs from completed jobs in state.
 func collectOutputs(state *models.ProcessingState) map[string]map[string]any {
 	out := make(map[string]map[string]any, len(state.Jobs))
@@ -273,6 +654,29 @@ func collectOutputs(state *models.ProcessingState) map[string]map[string]any {
 	return out
 }
 
+// mergeItemMeta copies the properties produced by a meta-only step into item.
+// Empty values never overwrite the existing ones.
+func mergeItemMeta(item, src *models.ItemMeta) {
+	if src.Type != "" {
+		item.Type = src.Type
+	}
+	if src.Width > 0 && src.Height > 0 {
+		item.Width, item.Height = src.Width, src.Height
+	}
+	if src.Duration > 0 {
+		item.Duration = src.Duration
+	}
+	if src.Bitrate != "" {
+		item.Bitrate = src.Bitrate
+	}
+	if src.Codec != "" {
+		item.Codec = src.Codec
+	}
+	for k, v := range src.Attributes {
+		item.SetAttribute(k, v)
+	}
+}
+
 func stepSourceName(step *models.WorkflowStep, meta *models.Meta) string {
 	if step != nil {
 		if src, ok := step.With["source"].(string); ok && src != "" && !models.IsOriginal(src) {
@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/apfs-io/apfs/internal/jobqueue"
+	jobqueuebadger "github.com/apfs-io/apfs/internal/jobqueue/badger"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
 	storio "github.com/apfs-io/apfs/internal/storio"
 	"github.com/apfs-io/apfs/models"
 )
@@ -151,6 +157,60 @@ func TestExecuteJob_HappyPath(t *testing.T) {
 	assert.Equal(t, []byte("fake-image-data"), store.written["out.jpg"])
 }
 
+func TestExecuteJob_JobFinishedCallback(t *testing.T) {
+	var (
+		store    = newFakeStorage()
+		reg      = NewRunnerRegistry()
+		started  []models.JobStatus
+		finished []models.JobStatus
+	)
+	reg.Register(&fakeRunner{usesPrefix: "image/", err: errors.New("resize failed")})
+	exec := NewExecutor(store, reg, WithJobStarted(
+		func(_ context.Context, _ *models.Workflow, objectID, jobID string, state *models.ProcessingState) {
+			started = append(started, state.Jobs[jobID].Status)
+		}), WithJobFinished(
+		func(_ context.Context, _ *models.Workflow, objectID, jobID string, state *models.ProcessingState) {
+			assert.Equal(t, "obj-1", objectID)
+			finished = append(finished, state.Jobs[jobID].Status)
+		}))
+
+	// The scheduled retry is not finished
+	wf := singleJobWorkflow("thumbnail", "image/resize", withOnFailure("retry:1"))
+	require.ErrorIs(t, exec.ExecuteJob(context.Background(), wf, "obj-1", "thumbnail", nil), ErrJobRetry)
+	assert.Empty(t, finished)
+
+	require.NoError(t, exec.ExecuteJob(context.Background(), wf, "obj-1", "thumbnail", nil))
+	assert.Equal(t, []models.JobStatus{models.JobStatusFailed}, finished)
+	assert.Equal(t, []models.JobStatus{models.JobStatusRunning, models.JobStatusRunning}, started)
+}
+
+func TestExecuteJob_MetaOnlyStepAnnotatesSource(t *testing.T) {
+	store := newFakeStorage()
+	store.meta = &models.Meta{Main: models.ItemMeta{Name: models.OriginalFilename, NameExt: "jpg"}}
+	runner := &fakeRunner{
+		usesPrefix: "image/",
+		output: StepOutput{
+			ItemMeta: &models.ItemMeta{Attributes: map[string]any{models.AttributeBlurHash: "LKO2?U%2Tw=w]~RBVZRi};RPxuwH"}},
+			Outputs:  map[string]any{models.AttributeBlurHash: "LKO2?U%2Tw=w]~RBVZRi};RPxuwH"},
+		},
+	}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+
+	wf := &models.Workflow{
+		Version: "2",
+		Jobs: map[string]*models.WorkflowJob{
+			"placeholder": {Steps: []*models.WorkflowStep{{Uses: "image/placeholder"}}},
+		},
+	}
+	exec := NewExecutor(store, reg)
+	err := exec.ExecuteJob(context.Background(), wf, "obj-1", "placeholder", nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, store.written, "meta-only step must not write artifacts")
+	assert.Equal(t, "LKO2?U%2Tw=w]~RBVZRi};RPxuwH", store.meta.Main.BlurHash())
+}
+
 func TestExecuteJob_SkippedByIfCondition(t *testing.T) {
 	store := newFakeStorage()
 	runner := &fakeRunner{usesPrefix: "image/"}
@@ -233,6 +293,27 @@ func TestExecuteJob_OnFailureContinue(t *testing.T) {
 	assert.Equal(t, models.ProcessingStatusPartial, store.state.Status)
 }
 
+func TestExecuteJob_StepLimitExceeded(t *testing.T) {
+	store := newFakeStorage()
+	runner := &fakeRunner{
+		usesPrefix: "shell",
+		err:        fmt.Errorf("exec step: %w", NewLimitError(models.StepLimitCPU, errors.New("signal: CPU time limit exceeded"))),
+	}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+
+	wf := singleJobWorkflow("script", "shell")
+	exec := NewExecutor(store, reg)
+	err := exec.ExecuteJob(context.Background(), wf, "obj-1", "script", []string{"worker-a"})
+	require.NoError(t, err)
+
+	js := store.state.Jobs["script"]
+	require.Len(t, js.Steps, 1)
+	assert.Equal(t, models.JobStatusFailed, js.Status)
+	assert.Equal(t, models.StepStatusLimitExceeded, js.Steps[0].Status)
+	assert.Equal(t, models.StepLimitCPU, js.Steps[0].Limit)
+}
+
 func TestExecuteJob_OnFailureFail_DownstreamSkipped(t *testing.T) {
 	store := newFakeStorage()
 	runner := &fakeRunner{
@@ -310,7 +391,7 @@ func TestExecuteJob_OnFailureRetry_SucceedsEventually(t *testing.T) {
 	// First attempt: runner returns error → executor returns retry sentinel
 	err := exec.ExecuteJob(context.Background(), wf, "obj-1", "encode", []string{"worker-a"})
 	require.Error(t, err, "first attempt should return retry error")
-	assert.Contains(t, err.Error(), "retry")
+	assert.ErrorIs(t, err, ErrJobRetry)
 	// After ResetForRetry the job is pending again, ready for reschedule
 	assert.Equal(t, models.JobStatusPending, store.state.Jobs["encode"].Status)
 
@@ -322,6 +403,298 @@ func TestExecuteJob_OnFailureRetry_SucceedsEventually(t *testing.T) {
 
 // ── RunnerRegistry ────────────────────────────────────────────────────────────
 
+// streamRunner uppercases its input, recording how the input was provided
+type streamRunner struct {
+	uses     string
+	seekable bool
+	inputs   []string
+	seekers  []bool
+}
+
+func (r *streamRunner) CanRun(step *models.WorkflowStep) bool { return step.Uses == r.uses }
+
+func (r *streamRunner) NeedsSeekableInput(_ *models.WorkflowStep) bool { return r.seekable }
+
+func (r *streamRunner) Run(_ context.Context, step *models.WorkflowStep, in StepInput) (StepOutput, error) {
+	_, isSeeker := in.Reader.(io.Seeker)
+	data, err := io.ReadAll(in.Reader)
+	if err != nil {
+		return StepOutput{}, err
+	}
+	r.inputs = append(r.inputs, string(data))
+	r.seekers = append(r.seekers, isSeeker)
+	return StepOutput{
+		Writer:     bytes.NewReader(bytes.ToUpper(append(data, '!'))),
+		TargetPath: step.With["target"].(string),
+	}, nil
+}
+
+func pipelineWorkflow(uses string) *models.Workflow {
+	return &models.Workflow{
+		Version: "2",
+		Jobs: map[string]*models.WorkflowJob{"encode": {Steps: []*models.WorkflowStep{
+			{Name: "first", Uses: uses, With: map[string]any{"target": "first.bin"}},
+			{Name: "second", Uses: uses, With: map[string]any{"source": "first.bin", "target": "second.bin"}},
+		}}},
+	}
+}
+
+func TestExecuteJob_StreamsArtifactToNextStep(t *testing.T) {
+	store := newFakeStorage()
+	store.source = []byte("data")
+	runner := &streamRunner{uses: "stream"}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+
+	exec := NewExecutor(store, reg)
+	err := exec.ExecuteJob(context.Background(), pipelineWorkflow("stream"), "obj-1", "encode", nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusCompleted, store.state.Jobs["encode"].Status)
+
+	// The second step got the first artifact from the pipe, not from the storage
+	assert.Equal(t, []string{"data", "DATA!"}, runner.inputs)
+	assert.False(t, runner.seekers[1], "streamed input is not seekable")
+	assert.Equal(t, "DATA!", string(store.written["first.bin"]))
+	assert.Equal(t, "DATA!!", string(store.written["second.bin"]))
+}
+
+func TestExecuteJob_SeekableRunnerGetsTempFile(t *testing.T) {
+	store := newFakeStorage()
+	store.source = []byte("data")
+	runner := &streamRunner{uses: "stream", seekable: true}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+
+	exec := NewExecutor(store, reg)
+	err := exec.ExecuteJob(context.Background(), pipelineWorkflow("stream"), "obj-1", "encode", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"data", "DATA!"}, runner.inputs)
+	assert.Equal(t, []bool{true, true}, runner.seekers)
+	assert.Equal(t, "DATA!", string(store.written["first.bin"]))
+}
+
+func TestExecuteJob_StreamWriteError(t *testing.T) {
+	store := newFakeStorage()
+	store.writeErr = errors.New("disk full")
+	runner := &streamRunner{uses: "stream"}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+
+	exec := NewExecutor(store, reg)
+	_ = exec.ExecuteJob(context.Background(), pipelineWorkflow("stream"), "obj-1", "encode", nil)
+	require.NotNil(t, store.state)
+	js := store.state.Jobs["encode"]
+	assert.Equal(t, models.JobStatusFailed, js.Status)
+	assert.Contains(t, js.Error, `step "first" write artifact`)
+}
+
+// pipeRunner produces the artifact of the first step after Run returned,
+// the rest of the artifact is produced once the next step got its start
+type pipeRunner struct {
+	uses     string
+	failWith error
+	got      chan struct{}
+	inputs   []string
+}
+
+func (r *pipeRunner) CanRun(step *models.WorkflowStep) bool { return step.Uses == r.uses }
+
+func (r *pipeRunner) Run(_ context.Context, step *models.WorkflowStep, in StepInput) (StepOutput, error) {
+	if step.Name != "first" {
+		head := make([]byte, len("head;"))
+		if _, err := io.ReadFull(in.Reader, head); err != nil {
+			return StepOutput{}, err
+		}
+		close(r.got)
+		tail, err := io.ReadAll(in.Reader)
+		if err != nil {
+			return StepOutput{}, err
+		}
+		r.inputs = append(r.inputs, string(head)+string(tail))
+		return StepOutput{Writer: bytes.NewReader(tail), TargetPath: step.With["target"].(string)}, nil
+	}
+	pr, pw := io.Pipe()
+	result := make(chan error, 1)
+	go func() {
+		_, err := pw.Write([]byte("head;"))
+		select {
+		case <-r.got:
+		case <-time.After(5 * time.Second):
+			err = errors.New("the next step is not started")
+		}
+		if err == nil {
+			_, err = pw.Write([]byte("tail"))
+		}
+		if err == nil {
+			err = r.failWith
+		}
+		_ = pw.CloseWithError(err)
+		result <- err
+	}()
+	return StepOutput{
+		Writer:     pr,
+		TargetPath: step.With["target"].(string),
+		Wait:       func() error { return <-result },
+	}, nil
+}
+
+func TestExecuteJob_PipelinesRunningProducer(t *testing.T) {
+	store := newFakeStorage()
+	store.source = []byte("data")
+	runner := &pipeRunner{uses: "pipe", got: make(chan struct{})}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+
+	exec := NewExecutor(store, reg)
+	err := exec.ExecuteJob(context.Background(), pipelineWorkflow("pipe"), "obj-1", "encode", nil)
+	require.NoError(t, err)
+
+	js := store.state.Jobs["encode"]
+	assert.Equal(t, models.JobStatusCompleted, js.Status)
+	require.Len(t, js.Steps, 2)
+	assert.Equal(t, models.StepStatusCompleted, js.Steps[0].Status)
+	assert.Equal(t, []string{"head;tail"}, runner.inputs)
+	assert.Equal(t, "head;tail", string(store.written["first.bin"]))
+	assert.Equal(t, "tail", string(store.written["second.bin"]))
+}
+
+func TestExecuteJob_RunningProducerFails(t *testing.T) {
+	store := newFakeStorage()
+	store.source = []byte("data")
+	runner := &pipeRunner{
+		uses:     "pipe",
+		got:      make(chan struct{}),
+		failWith: NewLimitError(models.StepLimitOutput, errors.New("output is too large")),
+	}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+
+	exec := NewExecutor(store, reg)
+	_ = exec.ExecuteJob(context.Background(), pipelineWorkflow("pipe"), "obj-1", "encode", nil)
+	require.NotNil(t, store.state)
+
+	js := store.state.Jobs["encode"]
+	assert.Equal(t, models.JobStatusFailed, js.Status)
+	require.NotEmpty(t, js.Steps)
+	assert.Equal(t, models.StepStatusLimitExceeded, js.Steps[0].Status)
+	assert.Equal(t, models.StepLimitOutput, js.Steps[0].Limit)
+	assert.Contains(t, js.Error, `step "first"`)
+}
+
+func TestExecuteJob_LockedByAnotherWorker(t *testing.T) {
+	store := newFakeStorage()
+	runner := &fakeRunner{usesPrefix: "image/"}
+	reg := NewRunnerRegistry()
+	reg.Register(runner)
+	locker := memory.NewLocker()
+
+	_, err := locker.Acquire(context.Background(), jobLockKey("obj-1", "thumbnail"), "worker-b", time.Minute)
+	require.NoError(t, err)
+
+	exec := NewExecutor(store, reg, WithLocker(locker, time.Minute), WithLockOwner("worker-a"))
+	err = exec.ExecuteJob(context.Background(), singleJobWorkflow("thumbnail", "image/resize"), "obj-1", "thumbnail", nil)
+	assert.ErrorIs(t, err, ErrJobLocked)
+	assert.Zero(t, runner.callCount)
+}
+
+func TestExecuteJob_LeaseReleasedWithFence(t *testing.T) {
+	store := newFakeStorage()
+	reg := NewRunnerRegistry()
+	reg.Register(&fakeRunner{usesPrefix: "image/"})
+	locker := memory.NewLocker()
+
+	exec := NewExecutor(store, reg, WithLocker(locker, time.Minute))
+	err := exec.ExecuteJob(context.Background(), singleJobWorkflow("thumbnail", "image/resize"), "obj-1", "thumbnail", nil)
+	require.NoError(t, err)
+
+	js := store.state.Jobs["thumbnail"]
+	assert.Equal(t, models.JobStatusCompleted, js.Status)
+	assert.Equal(t, uint64(1), js.Fence)
+
+	// The lock is released after the job
+	lease, err := locker.Acquire(context.Background(), jobLockKey("obj-1", "thumbnail"), "worker-b", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), lease.Token)
+}
+
+// takeoverRunner simulates another worker taking over the job while it runs
+type takeoverRunner struct {
+	store *fakeStorage
+	job   string
+}
+
+func (r *takeoverRunner) CanRun(*models.WorkflowStep) bool { return true }
+
+func (r *takeoverRunner) Run(context.Context, *models.WorkflowStep, StepInput) (StepOutput, error) {
+	r.store.state = &models.ProcessingState{Jobs: map[string]*models.JobState{
+		r.job: {Status: models.JobStatusRunning, Worker: "worker-b", Fence: 100},
+	}}
+	return StepOutput{}, nil
+}
+
+func TestExecuteJob_FencedOutAfterTakeover(t *testing.T) {
+	store := newFakeStorage()
+	reg := NewRunnerRegistry()
+	reg.Register(&takeoverRunner{store: store, job: "thumbnail"})
+
+	exec := NewExecutor(store, reg, WithLocker(memory.NewLocker(), time.Minute))
+	err := exec.ExecuteJob(context.Background(), singleJobWorkflow("thumbnail", "image/resize"), "obj-1", "thumbnail", nil)
+	assert.ErrorIs(t, err, ErrLeaseLost)
+
+	// The state of the new owner is kept
+	js := store.state.Jobs["thumbnail"]
+	assert.Equal(t, "worker-b", js.Worker)
+	assert.Equal(t, models.JobStatusRunning, js.Status)
+}
+
+// lostLeaseLocker loses every lease on the first renew
+type lostLeaseLocker struct {
+	*memory.Locker
+}
+
+func (l lostLeaseLocker) Renew(context.Context, *kvaccessor.Lease, time.Duration) error {
+	return kvaccessor.ErrLockLost
+}
+
+// blockingRunner runs until the context is canceled
+type blockingRunner struct{}
+
+func (blockingRunner) CanRun(*models.WorkflowStep) bool { return true }
+
+func (blockingRunner) Run(ctx context.Context, _ *models.WorkflowStep, _ StepInput) (StepOutput, error) {
+	<-ctx.Done()
+	return StepOutput{}, context.Cause(ctx)
+}
+
+func TestExecuteJob_LostLeaseCancelsJob(t *testing.T) {
+	store := newFakeStorage()
+	reg := NewRunnerRegistry()
+	reg.Register(blockingRunner{})
+
+	exec := NewExecutor(store, reg, WithLocker(lostLeaseLocker{memory.NewLocker()}, 30*time.Millisecond))
+	err := exec.ExecuteJob(context.Background(), singleJobWorkflow("thumbnail", "image/resize"), "obj-1", "thumbnail", nil)
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	assert.Equal(t, models.JobStatusRunning, store.state.Jobs["thumbnail"].Status, "the result must not be written")
+}
+
+func TestExecuteJob_LostLeaseCancelsJobWithTimeout(t *testing.T) {
+	store := newFakeStorage()
+	reg := NewRunnerRegistry()
+	reg.Register(blockingRunner{})
+
+	wf := singleJobWorkflow("thumbnail", "image/resize", func(j *models.WorkflowJob) { j.TimeoutMinutes = 10 })
+	exec := NewExecutor(store, reg, WithLocker(lostLeaseLocker{memory.NewLocker()}, 30*time.Millisecond))
+	done := make(chan error, 1)
+	go func() { done <- exec.ExecuteJob(context.Background(), wf, "obj-1", "thumbnail", nil) }()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrLeaseLost)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job with timeout is not canceled by the lost lease")
+	}
+}
+
 func TestRunnerRegistry_FindAndRegister(t *testing.T) {
 	reg := NewRunnerRegistry()
 	r1 := &fakeRunner{usesPrefix: "image/"}
@@ -378,3 +751,63 @@ func TestProcessObject_HappyPath(t *testing.T) {
 	assert.NotNil(t, store.meta.ItemByName("small"))
 	assert.Equal(t, 2, runner.callCount)
 }
+
+func TestDispatchObject_EnqueuesReadyJobsByLabel(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStorage()
+	store.meta = &models.Meta{Main: models.ItemMeta{Name: "prim.mp4", Type: models.TypeVideo}}
+	reg := NewRunnerRegistry()
+	reg.Register(&fakeRunner{usesPrefix: "video/"})
+
+	queue, err := jobqueuebadger.NewInMemory(jobqueue.WithPollInterval(10 * time.Millisecond))
+	require.NoError(t, err)
+	defer func() { _ = queue.Close() }()
+
+	wf := &models.Workflow{
+		Version: "2",
+		Jobs: map[string]*models.WorkflowJob{
+			"probe": {
+				Steps: []*models.WorkflowStep{{Uses: "video/probe"}},
+			},
+			"encode": {
+				RunsOn: "label:gpu",
+				Needs:  []string{"probe"},
+				Steps:  []*models.WorkflowStep{{Uses: "video/encode"}},
+			},
+		},
+	}
+	exec := NewExecutor(store, reg)
+
+	next := func(labels ...string) *jobqueue.Job {
+		dctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		delivery, err := queue.Dequeue(dctx, labels)
+		require.NoError(t, err)
+		require.NoError(t, exec.ExecuteJob(ctx, wf, delivery.Job().ObjectID, delivery.Job().JobID, labels))
+		require.NoError(t, delivery.Ack(ctx))
+		return delivery.Job()
+	}
+
+	complete, err := exec.DispatchObject(ctx, wf, "obj-1", queue)
+	require.NoError(t, err)
+	assert.False(t, complete)
+	// The second dispatch doesn't duplicate the queued job
+	_, err = exec.DispatchObject(ctx, wf, "obj-1", queue)
+	require.NoError(t, err)
+
+	job := next(jobqueue.AnyLabel)
+	assert.Equal(t, "probe", job.JobID)
+
+	complete, err = exec.DispatchObject(ctx, wf, "obj-1", queue)
+	require.NoError(t, err)
+	assert.False(t, complete)
+
+	job = next("gpu")
+	assert.Equal(t, "encode", job.JobID)
+	assert.Equal(t, "gpu", job.Label)
+
+	complete, err = exec.DispatchObject(ctx, wf, "obj-1", queue)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, models.ProcessingStatusCompleted, store.state.Status)
+}
@@ -0,0 +1,148 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	storio "github.com/apfs-io/apfs/internal/storio"
+)
+
+// Job lease errors
+var (
+	// ErrJobLocked is returned by ExecuteJob when the job is held by another worker
+	ErrJobLocked = errors.New("executor: job is locked by another worker")
+
+	// ErrLeaseLost is returned by ExecuteJob when the job lease expired or was
+	// taken over while the job was running. The job state is not written.
+	ErrLeaseLost = errors.New("executor: job lease is lost")
+)
+
+// DefaultLockTTL is the job lease TTL used when WithLocker gets zero TTL
+const DefaultLockTTL = 30 * time.Second
+
+// jobLockKeyPrefix prefixes the job lock keys in the Locker
+const jobLockKeyPrefix = "apfs:job:"
+
+// jobLease is the lease of the running job renewed in background
+type jobLease struct {
+	locker kvaccessor.Locker
+	lease  *kvaccessor.Lease
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	stop   chan struct{}
+	done   chan struct{}
+	lost   atomic.Bool
+}
+
+// acquireJob acquires the lease of the job and starts the heartbeat.
+// Returns nil lease if the executor has no locker.
+func (e *Executor) acquireJob(ctx context.Context, objectID, jobID string, log *zap.Logger) (*jobLease, error) {
+	if e.locker == nil {
+		return nil, nil
+	}
+	lease, err := e.locker.Acquire(ctx, jobLockKey(objectID, jobID), e.owner, e.lockTTL)
+	if errors.Is(err, kvaccessor.ErrLockHeld) {
+		return nil, ErrJobLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("executor: acquire job lock: %w", err)
+	}
+	jl := &jobLease{
+		locker: e.locker,
+		lease:  lease,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	jl.ctx, jl.cancel = context.WithCancelCause(ctx)
+	go jl.heartbeat(e.lockTTL, log)
+	return jl, nil
+}
+
+// heartbeat renews the lease every ttl/3. The job context is canceled as
+// soon as the lease is lost.
+func (jl *jobLease) heartbeat(ttl time.Duration, log *zap.Logger) {
+	defer close(jl.done)
+	ticker := time.NewTicker(max(ttl/3, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-jl.stop:
+			return
+		case <-jl.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := jl.locker.Renew(jl.ctx, jl.lease, ttl)
+		if err == nil {
+			continue
+		}
+		// Transient errors are retried until the lease expires
+		if errors.Is(err, kvaccessor.ErrLockLost) || jl.lease.Expired() {
+			log.Error("job lease is lost", zap.Error(err), zap.Uint64("fence", jl.lease.Token))
+			jl.lost.Store(true)
+			jl.cancel(ErrLeaseLost)
+			return
+		}
+		log.Warn("renew job lease", zap.Error(err))
+	}
+}
+
+// token returns the fencing token of the lease
+func (jl *jobLease) token() uint64 {
+	if jl == nil {
+		return 0
+	}
+	return jl.lease.Token
+}
+
+// release stops the heartbeat and releases the lock
+func (jl *jobLease) release(ctx context.Context) {
+	if jl == nil {
+		return
+	}
+	close(jl.stop)
+	<-jl.done
+	jl.cancel(nil)
+	if !jl.lost.Load() {
+		_ = jl.locker.Release(context.WithoutCancel(ctx), jl.lease)
+	}
+}
+
+// checkFence verifies that the job was not taken over by another worker
+// with a newer lease before the job state is written.
+func (e *Executor) checkFence(ctx context.Context, id storio.ObjectID, jobID string, jl *jobLease) error {
+	if jl == nil {
+		return nil
+	}
+	if jl.lost.Load() {
+		return ErrLeaseLost
+	}
+	state, err := e.storage.ReadState(ctx, id)
+	if err != nil {
+		return fmt.Errorf("executor: check fence: %w", err)
+	}
+	if state != nil {
+		if js := state.Jobs[jobID]; js != nil && js.Fence > jl.lease.Token {
+			return ErrLeaseLost
+		}
+	}
+	return nil
+}
+
+func jobLockKey(objectID, jobID string) string {
+	return jobLockKeyPrefix + objectID + ":" + jobID
+}
+
+// defaultLockOwner identifies the executor instance as the lock owner
+func defaultLockOwner() string {
+	host, _ := os.Hostname()
+	return host + ":" + strconv.Itoa(os.Getpid()) + ":" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
@@ -0,0 +1,184 @@
+package workflow
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// Job results of the job counter
+const (
+	jobResultCompleted = "completed"
+	jobResultFailed    = "failed"
+	jobResultSkipped   = "skipped"
+	jobResultRetried   = "retried"
+)
+
+var (
+	metricJobDuration    *prometheus.HistogramVec
+	metricStepDuration   *prometheus.HistogramVec
+	metricJobs           *prometheus.CounterVec
+	metricQueueLag       *prometheus.HistogramVec
+	metricStepReadBytes  *prometheus.CounterVec
+	metricStepWriteBytes *prometheus.CounterVec
+	metricActiveJobs     *prometheus.GaugeVec
+	metricRecoveredJobs  *prometheus.CounterVec
+)
+
+func init() {
+	buckets := []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+	metricJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "job_duration_seconds",
+		Help:      "Histogram of the workflow job execution time in seconds",
+		Buckets:   buckets,
+	}, []string{"group", "job", "status"})
+	metricStepDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "step_duration_seconds",
+		Help:      "Histogram of the workflow step execution time in seconds",
+		Buckets:   buckets,
+	}, []string{"group", "job", "step", "status"})
+	metricJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "jobs_total",
+		Help:      "Count of the finished workflow jobs by result: completed, failed, skipped, retried",
+	}, []string{"group", "job", "result"})
+	metricQueueLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "queue_lag_seconds",
+		Help:      "Histogram of the time between the object upload and the start of its first job",
+		Buckets:   buckets,
+	}, []string{"group"})
+	metricStepReadBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "step_read_bytes_total",
+		Help:      "Bytes read by the step runners",
+	}, []string{"group", "job", "runner"})
+	metricStepWriteBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "step_written_bytes_total",
+		Help:      "Bytes of the artifacts written by the step runners",
+	}, []string{"group", "job", "runner"})
+	metricActiveJobs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "active_jobs",
+		Help:      "Count of the jobs running on the worker by the worker tags",
+	}, []string{"worker"})
+	metricRecoveredJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "apfs",
+		Subsystem: "workflow",
+		Name:      "recovered_jobs_total",
+		Help:      "Count of the stuck jobs of the lost workers recovered by result: retried, failed",
+	}, []string{"group", "job", "result"})
+}
+
+// objectGroup returns the group of the object ID "group/path"
+func objectGroup(objectID string) string {
+	group, _, _ := strings.Cut(objectID, "/")
+	return group
+}
+
+// observeJob records the result and the duration of the finished job
+func observeJob(group, jobID string, js *models.JobState, result string, duration time.Duration) {
+	metricJobs.WithLabelValues(group, jobID, result).Inc()
+	if result == jobResultSkipped {
+		return
+	}
+	metricJobDuration.WithLabelValues(group, jobID, result).Observe(duration.Seconds())
+	for _, ss := range js.Steps {
+		metricStepDuration.WithLabelValues(group, jobID, ss.Name, ss.Status.String()).
+			Observe((time.Duration(ss.DurationMs) * time.Millisecond).Seconds())
+	}
+}
+
+// observeQueueLag records the lag of the first job start after the upload
+func observeQueueLag(group string, uploadedAt time.Time) {
+	if !uploadedAt.IsZero() {
+		metricQueueLag.WithLabelValues(group).Observe(time.Since(uploadedAt).Seconds())
+	}
+}
+
+// isFirstJobStart reports whether no job of the state was started yet
+func isFirstJobStart(state *models.ProcessingState) bool {
+	for _, js := range state.Jobs {
+		if js != nil && (js.StartedAt != nil || js.Attempts > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// stepRunnerName is the runner label of the step
+func stepRunnerName(step *models.WorkflowStep) string {
+	if step.Uses != "" {
+		return step.Uses
+	}
+	return "run"
+}
+
+// countReader counts the bytes read from the reader by the counter.
+// Seeker and ReaderAt of the reader are kept, the runners use them
+// to avoid the temporary copy of the input.
+func countReader(reader io.Reader, counter prometheus.Counter) io.Reader {
+	if reader == nil {
+		return nil
+	}
+	cr := &countingReader{reader: reader, counter: counter}
+	seeker, isSeeker := reader.(io.Seeker)
+	readerAt, isReaderAt := reader.(io.ReaderAt)
+	switch {
+	case isSeeker && isReaderAt:
+		return &countingReadSeekerAt{countingReadSeeker: countingReadSeeker{countingReader: cr, seeker: seeker}, readerAt: readerAt}
+	case isSeeker:
+		return &countingReadSeeker{countingReader: cr, seeker: seeker}
+	}
+	return cr
+}
+
+type countingReader struct {
+	reader  io.Reader
+	counter prometheus.Counter
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.counter.Add(float64(n))
+	}
+	return n, err
+}
+
+type countingReadSeeker struct {
+	*countingReader
+	seeker io.Seeker
+}
+
+func (r *countingReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return r.seeker.Seek(offset, whence)
+}
+
+type countingReadSeekerAt struct {
+	countingReadSeeker
+	readerAt io.ReaderAt
+}
+
+func (r *countingReadSeekerAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.readerAt.ReadAt(p, off)
+	if n > 0 {
+		r.counter.Add(float64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// readingRunner consumes the input and produces the fixed artifact
+type readingRunner struct {
+	fakeRunner
+}
+
+func (r *readingRunner) Run(ctx context.Context, step *models.WorkflowStep, in StepInput) (StepOutput, error) {
+	if in.Reader != nil {
+		_, _ = io.Copy(io.Discard, in.Reader)
+	}
+	out, err := r.fakeRunner.Run(ctx, step, in)
+	if err == nil {
+		out.Writer = strings.NewReader("artifact")
+	}
+	return out, err
+}
+
+func TestExecuteJob_Metrics(t *testing.T) {
+	var (
+		ctx      = context.TODO()
+		store    = newFakeStorage()
+		registry = NewRunnerRegistry()
+		runner   = &readingRunner{fakeRunner: fakeRunner{usesPrefix: "image/"}}
+		wf       = singleJobWorkflow("thumb", "image/resize", withOnFailure("retry:3"))
+	)
+	registry.Register(runner)
+	exec := NewExecutor(store, registry)
+
+	var (
+		completed = counterDelta(metricJobs.WithLabelValues("metrics", "thumb", jobResultCompleted))
+		retried   = counterDelta(metricJobs.WithLabelValues("metrics", "thumb", jobResultRetried))
+		skipped   = counterDelta(metricJobs.WithLabelValues("metrics", "thumb", jobResultSkipped))
+		read      = counterDelta(metricStepReadBytes.WithLabelValues("metrics", "thumb", "image/resize"))
+		written   = counterDelta(metricStepWriteBytes.WithLabelValues("metrics", "thumb", "image/resize"))
+	)
+	require.NoError(t, exec.ExecuteJob(ctx, wf, "metrics/obj1", "thumb", []string{"cpu"}))
+	assert.Equal(t, 1., completed())
+	assert.Equal(t, float64(len(store.source)), read())
+	assert.Equal(t, float64(len("artifact")), written())
+	assert.Zero(t, testutil.ToFloat64(metricActiveJobs.WithLabelValues("cpu")))
+
+	// The failed attempt is counted as retried
+	store.state = nil
+	runner.err, runner.errUntil, runner.callCount = errors.New("resize failed"), 1, 0
+	require.ErrorIs(t, exec.ExecuteJob(ctx, wf, "metrics/obj2", "thumb", []string{"cpu"}), ErrJobRetry)
+	assert.Equal(t, 1., retried())
+
+	// Skipped job
+	store.state = nil
+	skippedWf := singleJobWorkflow("thumb", "image/resize", withIf("false"))
+	require.NoError(t, exec.ExecuteJob(ctx, skippedWf, "metrics/obj3", "thumb", nil))
+	assert.Equal(t, 1., skipped())
+}
+
+func TestCountReaderKeepsInterfaces(t *testing.T) {
+	counter := metricStepReadBytes.WithLabelValues("metrics-reader", "job", "runner")
+	delta := counterDelta(counter)
+	reader := countReader(strings.NewReader("0123456789"), counter)
+	ra, ok := reader.(interface {
+		io.ReaderAt
+		io.Seeker
+	})
+	require.True(t, ok)
+	buf := make([]byte, 4)
+	_, err := ra.ReadAt(buf, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "2345", string(buf))
+	_, _ = io.ReadAll(reader)
+	assert.Equal(t, 14., delta())
+
+	_, ok = countReader(io.MultiReader(strings.NewReader("x")), counter).(io.Seeker)
+	assert.False(t, ok)
+}
+
+// counterDelta returns the increase of the global counter since the call,
+// the counters are kept between the test runs
+func counterDelta(counter prometheus.Counter) func() float64 {
+	start := testutil.ToFloat64(counter)
+	return func() float64 { return testutil.ToFloat64(counter) - start }
+}
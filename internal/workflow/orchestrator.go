@@ -2,12 +2,36 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/apfs-io/apfs/internal/context/ctxcorrelation"
+	"github.com/apfs-io/apfs/internal/jobqueue"
 	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/internal/tracing"
 	"github.com/apfs-io/apfs/models"
 )
 
+// ErrProcessingFailed is the error of the processed event of the failed processing
+var ErrProcessingFailed = errors.New("workflow: processing failed")
+
+// ProcessingError returns ErrProcessingFailed with the error of the first
+// failed job of the state
+func ProcessingError(state *models.ProcessingState) error {
+	ids := make([]string, 0, len(state.Jobs))
+	for jobID, js := range state.Jobs {
+		if js != nil && js.Status == models.JobStatusFailed {
+			ids = append(ids, jobID)
+		}
+	}
+	if len(ids) == 0 {
+		return ErrProcessingFailed
+	}
+	sort.Strings(ids)
+	return fmt.Errorf("%w: job %q: %s", ErrProcessingFailed, ids[0], state.Jobs[ids[0]].Error)
+}
+
 // HasPendingArtifacts reports whether the workflow still has job targets
 // that are not present in meta.Items.
 func HasPendingArtifacts(w *models.Workflow, meta *models.Meta) bool {
@@ -43,6 +67,10 @@ func (e *Executor) ProcessObject(
 	jobsRun := 0
 
 	for {
+		// The interrupted jobs are continued by another worker
+		if e.isInterrupted() {
+			return false, ErrWorkerShutdown
+		}
 		state, err := e.storage.ReadState(ctx, id)
 		if err != nil {
 			return false, fmt.Errorf("process object: load state: %w", err)
@@ -67,8 +95,11 @@ func (e *Executor) ProcessObject(
 				return allJobsTerminal(dag, state) && !HasPendingArtifacts(w, loadMetaForCheck(ctx, e, id)), nil
 			}
 			if execErr := e.ExecuteJob(ctx, w, objectID, jobID, workerTags); execErr != nil {
+				// The job is running on another worker
+				if errors.Is(execErr, ErrJobLocked) {
+					continue
+				}
 				// Retryable failures are reported by ExecuteJob; continue with other ready jobs.
-				_ = execErr
 			}
 			jobsRun++
 			ran = true
@@ -89,6 +120,59 @@ func (e *Executor) ProcessObject(
 	return state.Status.IsTerminal() && state.Status.IsSuccess() && !HasPendingArtifacts(w, meta), nil
 }
 
+// DispatchObject enqueues every ready job of the workflow for objectID to
+// the queue, each one routed by its runs-on label. The job already in the
+// queue is not enqueued twice. complete is true when nothing is left to run
+// and processing succeeded.
+func (e *Executor) DispatchObject(
+	ctx context.Context,
+	w *models.Workflow,
+	objectID string,
+	queue jobqueue.Queue,
+) (complete bool, err error) {
+	if w == nil || len(w.Jobs) == 0 {
+		return true, nil
+	}
+	if e == nil || queue == nil {
+		return false, fmt.Errorf("workflow dispatcher not configured")
+	}
+
+	dag, err := BuildDAG(w)
+	if err != nil {
+		return false, err
+	}
+
+	id := storio.ObjectIDType(objectID)
+	state, err := e.storage.ReadState(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("dispatch object: load state: %w", err)
+	}
+	if state == nil {
+		state = models.NewProcessingState(objectID, w.Version, w.JobIDs())
+		if err := e.storage.WriteState(ctx, id, state); err != nil {
+			return false, fmt.Errorf("dispatch object: init state: %w", err)
+		}
+	}
+
+	// Every ready job regardless of the worker tags
+	ready := dag.ReadyJobs(state, nil)
+	if len(ready) == 0 {
+		state.ComputeStatus()
+		return state.Status.IsTerminal() && state.Status.IsSuccess() && !HasPendingArtifacts(w, loadMetaForCheck(ctx, e, id)), nil
+	}
+	jobs := make([]*jobqueue.Job, 0, len(ready))
+	for _, jobID := range ready {
+		job := jobqueue.NewJob(objectID, jobID, w.Jobs[jobID].RunsOn)
+		job.TraceContext = tracing.Inject(ctx)
+		job.CorrelationID = ctxcorrelation.Get(ctx)
+		jobs = append(jobs, job)
+	}
+	if err := queue.Enqueue(ctx, jobs...); err != nil {
+		return false, fmt.Errorf("dispatch object: enqueue jobs: %w", err)
+	}
+	return false, nil
+}
+
 func loadMetaForCheck(ctx context.Context, e *Executor, id storio.ObjectID) *models.Meta {
 	meta, err := e.storage.ReadMeta(ctx, id)
 	if err != nil {
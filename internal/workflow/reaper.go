@@ -0,0 +1,155 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apfs-io/apfs/internal/context/ctxlogger"
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// DefaultStaleJobTimeout is the running time after which the job without
+// timeout is treated as stuck when the executor has no locker
+const DefaultStaleJobTimeout = time.Hour
+
+// ErrJobLost is the error of the running job recovered after its worker
+// stopped without writing the job result
+var ErrJobLost = errors.New("workflow: job worker is lost")
+
+// RecoverStuckJobs recovers the running jobs of the object left by the lost
+// workers. With the locker the job is stuck once its lease is expired,
+// without the locker once it runs longer than the job timeout (staleTimeout
+// if the job has no timeout).
+//
+// The stuck job is retried according to its retry policy, otherwise it
+// fails and follows on-failure. Returns the number of the recovered jobs,
+// the object has to be dispatched again if it's not zero.
+func (e *Executor) RecoverStuckJobs(ctx context.Context, w *models.Workflow, objectID string, staleTimeout time.Duration) (int, error) {
+	if w == nil || len(w.Jobs) == 0 {
+		return 0, nil
+	}
+	state, err := e.storage.ReadState(ctx, storio.ObjectIDType(objectID))
+	if err != nil {
+		return 0, fmt.Errorf("recover jobs: load state: %w", err)
+	}
+	if state == nil {
+		return 0, nil
+	}
+	recovered := 0
+	for _, jobID := range w.JobIDs() {
+		if js := state.Jobs[jobID]; js == nil || js.Status != models.JobStatusRunning {
+			continue
+		}
+		ok, err := e.recoverJob(ctx, w, objectID, jobID, staleTimeout)
+		if err != nil {
+			return recovered, err
+		}
+		if ok {
+			recovered++
+		}
+	}
+	return recovered, nil
+}
+
+// recoverJob takes over the stuck job and writes its recovered state.
+// The lease of the job is held until the state is written, the fencing
+// token of the lease discards the result of the worker if it's alive.
+func (e *Executor) recoverJob(ctx context.Context, w *models.Workflow, objectID, jobID string, staleTimeout time.Duration) (bool, error) {
+	var (
+		log   = ctxlogger.Get(ctx).With(zap.String("object_id", objectID), zap.String("job_id", jobID))
+		id    = storio.ObjectIDType(objectID)
+		job   = w.Jobs[jobID]
+		lease *kvaccessor.Lease
+	)
+	if e.locker != nil {
+		var err error
+		lease, err = e.locker.Acquire(ctx, jobLockKey(objectID, jobID), e.owner, e.lockTTL)
+		if errors.Is(err, kvaccessor.ErrLockHeld) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("recover jobs: acquire job lock: %w", err)
+		}
+		defer func() { _ = e.locker.Release(context.WithoutCancel(ctx), lease) }()
+	}
+
+	unlockState, err := e.lockState(ctx, objectID)
+	if err != nil {
+		return false, err
+	}
+	defer unlockState()
+
+	// The job could be finished since the state was read
+	state, err := e.storage.ReadState(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("recover jobs: load state: %w", err)
+	}
+	if state == nil {
+		return false, nil
+	}
+	js := state.Jobs[jobID]
+	if js == nil || js.Status != models.JobStatusRunning {
+		return false, nil
+	}
+
+	jobErr, timedOut := ErrJobLost, false
+	if lease != nil {
+		js.Fence = lease.Token
+	} else {
+		timeout := job.Timeout()
+		if timeout <= 0 {
+			timeout = staleTimeout
+		}
+		if timeout <= 0 || js.StartedAt == nil || time.Since(*js.StartedAt) < timeout {
+			return false, nil
+		}
+		jobErr = fmt.Errorf("%w: running longer than %s", ErrJobLost, timeout)
+		timedOut = true
+	}
+
+	group := objectGroup(objectID)
+	switch {
+	case state.CancelledAt != nil:
+		log.Warn("stuck job recovered, processing is cancelled")
+		js.MarkFailed(ErrJobCancelled)
+		metricRecoveredJobs.WithLabelValues(group, jobID, jobResultFailed).Inc()
+	default:
+		if at, ok := scheduleRetry(job, js, jobErr, timedOut); ok {
+			log.Warn("stuck job recovered, will retry", zap.Error(jobErr),
+				zap.Int("attempts", js.Attempts), zap.Time("next_attempt_at", at))
+			metricRecoveredJobs.WithLabelValues(group, jobID, jobResultRetried).Inc()
+			break
+		}
+		log.Error("stuck job recovered as failed", zap.Error(jobErr))
+		js.MarkFailed(jobErr)
+		switch job.FailurePolicy() & 0x0F {
+		case models.FailurePolicyContinue, models.FailurePolicyRetry:
+		default:
+			skipDownstream(w, state, jobID)
+		}
+		metricRecoveredJobs.WithLabelValues(group, jobID, jobResultFailed).Inc()
+	}
+
+	state.UpdatedAt = time.Now()
+	state.ComputeProgress()
+	state.ComputeStatus()
+	if state.Status.IsTerminal() {
+		now := time.Now()
+		state.FinishedAt = &now
+	}
+	if js.Status.IsTerminal() {
+		err = e.writeFinishedState(ctx, w, objectID, jobID, state)
+	} else {
+		err = e.storage.WriteState(ctx, id, state)
+	}
+	if err != nil {
+		return false, fmt.Errorf("recover jobs: write state: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,110 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	"github.com/apfs-io/apfs/models"
+)
+
+// stuckChainState returns the state of the chain with the running source job
+// started the age ago
+func stuckChainState(age time.Duration) *models.ProcessingState {
+	state := models.NewProcessingState("obj-1", "2", []string{"source", "thumb", "upload"})
+	state.Jobs["source"].MarkStarted("w1")
+	startedAt := time.Now().Add(-age)
+	state.Jobs["source"].StartedAt = &startedAt
+	state.Status = models.ProcessingStatusRunning
+	return state
+}
+
+func TestRecoverStuckJobs_Timeout(t *testing.T) {
+	var (
+		store = newFakeStorage()
+		exec  = NewExecutor(store, NewRunnerRegistry())
+		wf    = chainWorkflow()
+	)
+
+	// The job runs shorter than the stale timeout
+	store.state = stuckChainState(time.Minute)
+	n, err := exec.RecoverStuckJobs(context.Background(), wf, "obj-1", time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, models.JobStatusRunning, store.state.Jobs["source"].Status)
+
+	// The job timeout overrides the stale timeout
+	wf.Jobs["source"].TimeoutMinutes = 1
+	store.state = stuckChainState(2 * time.Minute)
+	n, err = exec.RecoverStuckJobs(context.Background(), wf, "obj-1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	source := store.state.Jobs["source"]
+	assert.Equal(t, models.JobStatusFailed, source.Status)
+	assert.Contains(t, source.Error, ErrJobLost.Error())
+	assert.Equal(t, models.JobStatusSkipped, store.state.Jobs["thumb"].Status)
+	assert.Equal(t, models.JobStatusSkipped, store.state.Jobs["upload"].Status)
+	assert.True(t, store.state.Status.IsTerminal())
+	assert.NotNil(t, store.state.FinishedAt)
+}
+
+func TestRecoverStuckJobs_Retry(t *testing.T) {
+	var (
+		store = newFakeStorage()
+		exec  = NewExecutor(store, NewRunnerRegistry())
+		wf    = chainWorkflow()
+	)
+	wf.Jobs["source"].Retry = &models.WorkflowRetry{Max: 1, Initial: "1m", On: []string{"timeout"}}
+	store.state = stuckChainState(2 * time.Hour)
+
+	n, err := exec.RecoverStuckJobs(context.Background(), wf, "obj-1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	source := store.state.Jobs["source"]
+	assert.Equal(t, models.JobStatusPending, source.Status)
+	assert.Equal(t, 1, source.Attempts)
+	require.NotNil(t, source.NextAttemptAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *source.NextAttemptAt, time.Second)
+	assert.Equal(t, models.JobStatusPending, store.state.Jobs["thumb"].Status)
+}
+
+func TestRecoverStuckJobs_ExpiredLease(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		store  = newFakeStorage()
+		locker = memory.NewLocker()
+		exec   = NewExecutor(store, NewRunnerRegistry(), WithLocker(locker, time.Minute))
+		wf     = chainWorkflow()
+	)
+	wf.Jobs["source"].OnFailure = "continue"
+	store.state = stuckChainState(time.Second)
+
+	// The job of the live worker is not touched regardless of the time
+	lease, err := locker.Acquire(ctx, jobLockKey("obj-1", "source"), "w1", time.Minute)
+	require.NoError(t, err)
+	n, err := exec.RecoverStuckJobs(ctx, wf, "obj-1", time.Nanosecond)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, models.JobStatusRunning, store.state.Jobs["source"].Status)
+
+	// The lease of the lost worker is released once it's expired
+	require.NoError(t, locker.Release(ctx, lease))
+	n, err = exec.RecoverStuckJobs(ctx, wf, "obj-1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	source := store.state.Jobs["source"]
+	assert.Equal(t, models.JobStatusFailed, source.Status)
+	assert.Greater(t, source.Fence, lease.Token, "the result of the lost worker is discarded")
+	assert.Equal(t, models.JobStatusPending, store.state.Jobs["thumb"].Status, "on-failure: continue")
+
+	// The lock is released after the recovery
+	_, err = locker.Acquire(ctx, jobLockKey("obj-1", "source"), "w2", time.Minute)
+	assert.NoError(t, err)
+}
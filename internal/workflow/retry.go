@@ -0,0 +1,73 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// RetryError is returned by ExecuteJob when the failed job is scheduled for
+// the retry. It matches ErrJobRetry and the error of the failed attempt.
+type RetryError struct {
+	JobID string
+
+	// At is the time the next attempt of the job can start
+	At time.Time
+
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s %q: %v", ErrJobRetry.Error(), e.JobID, e.Err)
+}
+
+// Unwrap returns ErrJobRetry and the error of the failed attempt
+func (e *RetryError) Unwrap() []error { return []error{ErrJobRetry, e.Err} }
+
+// RetryAt returns the time of the next attempt of the job scheduled for
+// the retry by ExecuteJob
+func RetryAt(err error) (time.Time, bool) {
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		return retryErr.At, true
+	}
+	return time.Time{}, false
+}
+
+// scheduleRetry reschedules the failed job if the retry policy of the job
+// matches the failure. timedOut is true if the job context deadline exceeded.
+func scheduleRetry(job *models.WorkflowJob, js *models.JobState, jobErr error, timedOut bool) (time.Time, bool) {
+	retry := job.RetryPolicy()
+	if retry == nil || js.Attempts >= retry.MaxRetries() || !retry.Matches(isTimeoutError(jobErr, timedOut), exitCode(jobErr)) {
+		return time.Time{}, false
+	}
+	js.Attempts++
+	delay := retry.Delay(js.Attempts)
+	at := time.Now().Add(delay)
+	if delay > 0 {
+		js.ScheduleRetry(at, jobErr)
+	} else {
+		js.ResetForRetry()
+	}
+	return at, true
+}
+
+// isTimeoutError reports whether the job or the step was stopped by timeout
+func isTimeoutError(err error, timedOut bool) bool {
+	var limitErr *LimitError
+	return timedOut || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &limitErr) && limitErr.Limit == models.StepLimitWallClock)
+}
+
+// exitCode returns the exit code of the failed step process or -1
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// exitError returns the error of the process exited with the code
+func exitError(t *testing.T, code int) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Skip("sh is not available")
+	}
+	return fmt.Errorf("exec step %q: %w", "step1", err)
+}
+
+func withRetry(retry *models.WorkflowRetry) func(*models.WorkflowJob) {
+	return func(j *models.WorkflowJob) { j.Retry = retry }
+}
+
+func TestExecuteJob_RetryScheduledWithBackoff(t *testing.T) {
+	var (
+		store  = newFakeStorage()
+		reg    = NewRunnerRegistry()
+		runner = &fakeRunner{usesPrefix: "image/", err: exitError(t, 75)}
+		wf     = singleJobWorkflow("encode", "image/resize", withRetry(&models.WorkflowRetry{
+			Max: 2, Backoff: "exponential", Initial: "10s", MaxDelay: "15s", On: []string{"timeout", "exit-code:75"},
+		}))
+	)
+	reg.Register(runner)
+	exec := NewExecutor(store, reg)
+
+	for attempt, delay := range []time.Duration{10 * time.Second, 15 * time.Second} {
+		start := time.Now()
+		err := exec.ExecuteJob(context.Background(), wf, "obj-1", "encode", nil)
+		require.ErrorIs(t, err, ErrJobRetry)
+
+		at, ok := RetryAt(err)
+		require.True(t, ok)
+		assert.WithinDuration(t, start.Add(delay), at, time.Second)
+
+		js := store.state.Jobs["encode"]
+		assert.Equal(t, models.JobStatusPending, js.Status)
+		assert.Equal(t, attempt+1, js.Attempts)
+		require.NotNil(t, js.NextAttemptAt)
+		assert.Equal(t, at, *js.NextAttemptAt)
+		assert.Contains(t, js.Error, "exit status 75")
+		assert.Empty(t, mustBuildDAG(t, wf).ReadyJobs(store.state, nil), "the retry is not due yet")
+
+		// The retry is due
+		past := time.Now().Add(-time.Second)
+		js.NextAttemptAt = &past
+	}
+
+	// The retries are exhausted
+	require.NoError(t, exec.ExecuteJob(context.Background(), wf, "obj-1", "encode", nil))
+	assert.Equal(t, models.JobStatusFailed, store.state.Jobs["encode"].Status)
+	assert.Equal(t, 3, runner.callCount)
+}
+
+func TestExecuteJob_RetryConditionNotMatched(t *testing.T) {
+	var (
+		store = newFakeStorage()
+		reg   = NewRunnerRegistry()
+		wf    = singleJobWorkflow("encode", "image/resize", withRetry(&models.WorkflowRetry{
+			On: []string{"exit-code:75"},
+		}))
+	)
+	reg.Register(&fakeRunner{usesPrefix: "image/", err: exitError(t, 1)})
+
+	require.NoError(t, NewExecutor(store, reg).ExecuteJob(context.Background(), wf, "obj-1", "encode", nil))
+	assert.Equal(t, models.JobStatusFailed, store.state.Jobs["encode"].Status)
+	assert.Nil(t, store.state.Jobs["encode"].NextAttemptAt)
+}
+
+func TestExecuteJob_RetryOnTimeout(t *testing.T) {
+	var (
+		store = newFakeStorage()
+		reg   = NewRunnerRegistry()
+		wf    = singleJobWorkflow("encode", "image/resize", withRetry(&models.WorkflowRetry{
+			Backoff: "constant", Initial: "1m", On: []string{"timeout"},
+		}))
+	)
+	reg.Register(&fakeRunner{usesPrefix: "image/",
+		err: NewLimitError(models.StepLimitWallClock, errors.New("signal: killed"))})
+
+	err := NewExecutor(store, reg).ExecuteJob(context.Background(), wf, "obj-1", "encode", nil)
+	require.ErrorIs(t, err, ErrJobRetry)
+	assert.Equal(t, 1, store.state.Jobs["encode"].Attempts)
+	assert.NotNil(t, store.state.Jobs["encode"].NextAttemptAt)
+}
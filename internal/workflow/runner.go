@@ -20,6 +20,15 @@ type StepRunner interface {
 	Run(ctx context.Context, step *models.WorkflowStep, in StepInput) (StepOutput, error)
 }
 
+// SeekableInputRunner is implemented by the runners which need random access
+// to the step input. The executor streams the artifacts between the steps of
+// a job, such runners get a temporary file copy of the input instead.
+type SeekableInputRunner interface {
+	// NeedsSeekableInput reports whether StepInput.Reader of the step must
+	// implement io.ReadSeeker.
+	NeedsSeekableInput(step *models.WorkflowStep) bool
+}
+
 // StepInput is the read context available to a step runner.
 type StepInput struct {
 	// Reader provides the current file data (source artifact).
@@ -34,6 +43,10 @@ type StepInput struct {
 	// JobOutputs contains the accumulated outputs from all jobs that completed
 	// before this one (for ${{ jobID.outputs.key }} resolution).
 	JobOutputs map[string]map[string]any
+	// Log receives the stdout/stderr of the step process. The tail of the
+	// output is stored as the step log in the object scope.
+	// May be nil when the storage does not keep the step logs.
+	Log io.Writer
 }
 
 // StepOutput is produced by a step runner after execution.
@@ -49,6 +62,11 @@ type StepOutput struct {
 	// Outputs are key/value pairs published by this step into the job's
 	// outputs map (accessible to downstream jobs via ${{ jobID.outputs.key }}).
 	Outputs map[string]any
+	// Wait is set when the runner keeps producing Writer after Run returned.
+	// It is called once the Writer is consumed and closed, and returns the
+	// result of the step. The next step of the job reads the artifact while
+	// it is produced.
+	Wait func() error
 }
 
 // RunnerRegistry is a registry of StepRunners. The executor uses it to
@@ -76,3 +94,24 @@ func (r *RunnerRegistry) Find(step *models.WorkflowStep) StepRunner {
 	}
 	return nil
 }
+
+// LimitError is returned by a StepRunner when the step was stopped because
+// it violated one of its sandbox limits (see models.WorkflowStepLimits).
+type LimitError struct {
+	Limit models.StepLimit
+	Err   error
+}
+
+// NewLimitError wraps err as a violation of the given limit
+func NewLimitError(limit models.StepLimit, err error) *LimitError {
+	return &LimitError{Limit: limit, Err: err}
+}
+
+func (e *LimitError) Error() string {
+	if e.Err == nil {
+		return e.Limit.String() + " limit exceeded"
+	}
+	return e.Limit.String() + " limit exceeded: " + e.Err.Error()
+}
+
+func (e *LimitError) Unwrap() error { return e.Err }
@@ -0,0 +1,53 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrWorkerShutdown is returned by ExecuteJob and ProcessObject once the
+// running jobs are interrupted by the worker shutdown. The interrupted job
+// is rescheduled, so another worker runs it again.
+var ErrWorkerShutdown = errors.New("workflow: worker is shutting down")
+
+// InterruptJobs stops the steps of the running jobs and refuses to start
+// the new ones. The interrupted job is rescheduled for the immediate retry
+// without counting the attempt, its lease is released once the state is
+// written. It's called by the worker drain after the grace period.
+func (e *Executor) InterruptJobs() {
+	e.interruptOnce.Do(func() { close(e.interrupted) })
+}
+
+// isInterrupted reports whether the running jobs are interrupted
+func (e *Executor) isInterrupted() bool {
+	select {
+	case <-e.interrupted:
+		return true
+	default:
+		return false
+	}
+}
+
+// watchInterrupt cancels the job context with ErrWorkerShutdown cause once
+// the running jobs are interrupted. The returned func stops the watch.
+func (e *Executor) watchInterrupt(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		case <-e.interrupted:
+			cancel(ErrWorkerShutdown)
+		}
+	}()
+	return ctx, func() {
+		close(done)
+		cancel(nil)
+	}
+}
+
+// isJobInterrupted reports whether the job context was canceled by InterruptJobs
+func isJobInterrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrWorkerShutdown)
+}
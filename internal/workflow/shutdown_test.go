@@ -0,0 +1,45 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor/memory"
+	"github.com/apfs-io/apfs/models"
+)
+
+func TestExecuteJob_InterruptedJobIsRescheduled(t *testing.T) {
+	var (
+		store  = newFakeStorage()
+		reg    = NewRunnerRegistry()
+		locker = memory.NewLocker()
+		wf     = singleJobWorkflow("thumbnail", "image/resize", withOnFailure("retry:1"))
+	)
+	reg.Register(blockingRunner{})
+	exec := NewExecutor(store, reg, WithLocker(locker, time.Second))
+
+	time.AfterFunc(20*time.Millisecond, exec.InterruptJobs)
+	err := exec.ExecuteJob(context.Background(), wf, "obj-1", "thumbnail", nil)
+	require.ErrorIs(t, err, ErrWorkerShutdown)
+	require.ErrorIs(t, err, ErrJobRetry)
+
+	js := store.state.Jobs["thumbnail"]
+	assert.Equal(t, models.JobStatusPending, js.Status)
+	assert.Zero(t, js.Attempts, "the interrupted attempt is not counted")
+	assert.True(t, js.IsDue(time.Now()))
+	assert.Equal(t, ErrWorkerShutdown.Error(), js.Error)
+
+	// The lease is released for another worker
+	lease, err := locker.Acquire(context.Background(), jobLockKey("obj-1", "thumbnail"), "other", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, locker.Release(context.Background(), lease))
+
+	// The interrupted executor doesn't start the new jobs
+	assert.ErrorIs(t, exec.ExecuteJob(context.Background(), wf, "obj-1", "thumbnail", nil), ErrWorkerShutdown)
+	_, err = exec.ProcessObject(context.Background(), wf, "obj-1", nil, 0)
+	assert.ErrorIs(t, err, ErrWorkerShutdown)
+}
@@ -0,0 +1,84 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apfs-io/apfs/internal/storage/kvaccessor"
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// DefaultStateLockTTL is the TTL of the processing state lock of the object.
+// The lock is held only for the read-modify-write of the state.
+const DefaultStateLockTTL = 10 * time.Second
+
+// stateLockKeyPrefix prefixes the state lock keys in the Locker
+const stateLockKeyPrefix = "apfs:state:"
+
+// Backoff of the state lock acquire attempts
+const (
+	stateLockRetryMin = 5 * time.Millisecond
+	stateLockRetryMax = 200 * time.Millisecond
+)
+
+// UpdateState applies fn to the processing state of the object and writes
+// it under the state lock of the object, so the concurrent updates of the
+// manual control and the finished jobs don't overwrite each other. The state
+// passed to fn is nil if the object has no processing state, the state is
+// not written if fn returns an error.
+func (e *Executor) UpdateState(ctx context.Context, objectID string, fn func(state *models.ProcessingState) error) error {
+	unlock, err := e.lockState(ctx, objectID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	id := storio.ObjectIDType(objectID)
+	state, err := e.storage.ReadState(ctx, id)
+	if err != nil {
+		return fmt.Errorf("executor: load state: %w", err)
+	}
+	if err = fn(state); err != nil {
+		return err
+	}
+	return e.storage.WriteState(ctx, id, state)
+}
+
+// lockState waits for the state lock of the object. The lock is taken from
+// the job locker or from the process local one if the executor has none.
+// The returned unlock func could be called more than once.
+func (e *Executor) lockState(ctx context.Context, objectID string) (unlock func(), err error) {
+	var (
+		locker = e.stateLocker()
+		key    = stateLockKeyPrefix + objectID
+		wait   = stateLockRetryMin
+	)
+	for {
+		lease, err := locker.Acquire(ctx, key, e.owner, DefaultStateLockTTL)
+		if err == nil {
+			var once sync.Once
+			return func() {
+				once.Do(func() { _ = locker.Release(context.WithoutCancel(ctx), lease) })
+			}, nil
+		}
+		if !errors.Is(err, kvaccessor.ErrLockHeld) {
+			return nil, fmt.Errorf("executor: acquire state lock: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("executor: acquire state lock: %w", ctx.Err())
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, stateLockRetryMax)
+	}
+}
+
+func (e *Executor) stateLocker() kvaccessor.Locker {
+	if e.locker != nil {
+		return e.locker
+	}
+	return e.localLocker
+}
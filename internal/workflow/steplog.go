@@ -0,0 +1,175 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+)
+
+const (
+	// DefaultStepLogLimit is the size of the step output tail kept in the log
+	DefaultStepLogLimit = 64 * 1024
+
+	// DefaultStepLogFlushInterval of the log of the running step to the storage
+	DefaultStepLogFlushInterval = 2 * time.Second
+)
+
+// stepLogDir is the directory of the step logs in the object scope
+const stepLogDir = "logs"
+
+// Header of the log which lost the head of the output: "[... 1024 bytes truncated ...]\n"
+const (
+	stepLogTruncatedPrefix = "[... "
+	stepLogTruncatedSuffix = " bytes truncated ...]\n"
+)
+
+// StepLogStorage is implemented by the ExecutorStorage which keeps the step
+// logs in the object scope. The logs are not the artifacts of the object and
+// are not registered in the meta.
+type StepLogStorage interface {
+	// WriteLog replaces the file at the relative path inside the object scope
+	WriteLog(ctx context.Context, id storio.ObjectID, path string, data io.Reader) error
+}
+
+// StepLogPath returns the relative path of the step log inside the object scope
+func StepLogPath(jobID, step string) string {
+	return stepLogDir + "/" + logPathName(jobID) + "/" + logPathName(step) + ".log"
+}
+
+// logPathName replaces the characters which are not safe in the file name
+func logPathName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if strings.Trim(name, ".") == "" {
+		return "_"
+	}
+	return name
+}
+
+// StepLog collects the stdout/stderr of the step keeping the last limit bytes
+// of the output. It is safe for the concurrent writes.
+type StepLog struct {
+	mx    sync.Mutex
+	limit int
+	buf   []byte
+	total int64
+}
+
+// NewStepLog returns the log retaining the tail of limit bytes
+func NewStepLog(limit int) *StepLog {
+	if limit <= 0 {
+		limit = DefaultStepLogLimit
+	}
+	return &StepLog{limit: limit}
+}
+
+// Write implements io.Writer
+func (l *StepLog) Write(p []byte) (int, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.total += int64(len(p))
+	if len(p) >= l.limit {
+		l.buf = append(l.buf[:0], p[len(p)-l.limit:]...)
+		return len(p), nil
+	}
+	l.buf = append(l.buf, p...)
+	// Drop the head once the buffer doubled the limit to amortize the copying
+	if len(l.buf) >= 2*l.limit {
+		l.buf = append(l.buf[:0], l.buf[len(l.buf)-l.limit:]...)
+	}
+	return len(p), nil
+}
+
+// Size returns the total number of bytes written to the log
+func (l *StepLog) Size() int64 {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.total
+}
+
+// Bytes returns the retained tail of the output. When the head of the output
+// was dropped the data starts with the header of the truncated size
+// (see ParseStepLog).
+func (l *StepLog) Bytes() []byte {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	tail := l.buf
+	if len(tail) > l.limit {
+		tail = tail[len(tail)-l.limit:]
+	}
+	var buf bytes.Buffer
+	if truncated := l.total - int64(len(tail)); truncated > 0 {
+		fmt.Fprintf(&buf, "%s%d%s", stepLogTruncatedPrefix, truncated, stepLogTruncatedSuffix)
+	}
+	buf.Write(tail)
+	return buf.Bytes()
+}
+
+// ParseStepLog splits the stored step log to the offset of the retained tail
+// in the whole step output and the tail itself
+func ParseStepLog(data []byte) (offset int64, tail []byte) {
+	if !bytes.HasPrefix(data, []byte(stepLogTruncatedPrefix)) {
+		return 0, data
+	}
+	end := bytes.Index(data, []byte(stepLogTruncatedSuffix))
+	if end < 0 {
+		return 0, data
+	}
+	offset, err := strconv.ParseInt(string(data[len(stepLogTruncatedPrefix):end]), 10, 64)
+	if err != nil {
+		return 0, data
+	}
+	return offset, data[end+len(stepLogTruncatedSuffix):]
+}
+
+// flushStepLog writes the log of the running step to the storage every
+// flush interval, so it can be followed while the step runs. The returned
+// func stops the flushing and writes the final log.
+func (e *Executor) flushStepLog(ctx context.Context, store StepLogStorage, id storio.ObjectID, path string, log *StepLog) func() error {
+	var (
+		written int64
+		done    = make(chan struct{})
+		stopped = make(chan struct{})
+	)
+	write := func(ctx context.Context) error {
+		size := log.Size()
+		if size == written {
+			return nil
+		}
+		written = size
+		return store.WriteLog(ctx, id, path, bytes.NewReader(log.Bytes()))
+	}
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(e.logFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = write(ctx)
+			}
+		}
+	}()
+	return func() error {
+		close(done)
+		<-stopped
+		// The log of the step stopped by the timeout is the most valuable one
+		return write(context.WithoutCancel(ctx))
+	}
+}
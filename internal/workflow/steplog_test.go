@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// logStorage is the fake storage keeping the step logs
+type logStorage struct {
+	*fakeStorage
+	mx   sync.Mutex
+	logs map[string][]string // path → every written version
+}
+
+func (s *logStorage) WriteLog(_ context.Context, _ storio.ObjectID, path string, data io.Reader) error {
+	b, err := io.ReadAll(data)
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.logs[path] = append(s.logs[path], string(b))
+	return err
+}
+
+func (s *logStorage) log(path string) []string {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return append([]string(nil), s.logs[path]...)
+}
+
+// loggingRunner writes the lines into the step log pausing between them
+type loggingRunner struct {
+	fakeRunner
+	lines []string
+	pause time.Duration
+}
+
+func (r *loggingRunner) Run(ctx context.Context, step *models.WorkflowStep, in StepInput) (StepOutput, error) {
+	for _, line := range r.lines {
+		if in.Log != nil {
+			_, _ = io.WriteString(in.Log, line+"\n")
+		}
+		time.Sleep(r.pause)
+	}
+	return r.fakeRunner.Run(ctx, step, in)
+}
+
+func TestStepLog_TailRetention(t *testing.T) {
+	log := NewStepLog(10)
+	_, _ = io.WriteString(log, "0123")
+	assert.Equal(t, "0123", string(log.Bytes()))
+
+	for i := 0; i < 10; i++ {
+		_, _ = fmt.Fprintf(log, "%d", i)
+	}
+	_, _ = io.WriteString(log, "abcdef")
+	assert.Equal(t, int64(20), log.Size())
+
+	offset, tail := ParseStepLog(log.Bytes())
+	assert.Equal(t, int64(10), offset)
+	assert.Equal(t, "6789abcdef", string(tail))
+	assert.True(t, strings.HasPrefix(string(log.Bytes()), "[... 10 bytes truncated ...]\n"))
+
+	_, _ = io.WriteString(log, strings.Repeat("x", 25))
+	offset, tail = ParseStepLog(log.Bytes())
+	assert.Equal(t, int64(35), offset)
+	assert.Equal(t, strings.Repeat("x", 10), string(tail))
+}
+
+func TestParseStepLog_NotTruncated(t *testing.T) {
+	offset, tail := ParseStepLog([]byte("[... not a header\n"))
+	assert.Equal(t, int64(0), offset)
+	assert.Equal(t, "[... not a header\n", string(tail))
+}
+
+func TestStepLogPath(t *testing.T) {
+	assert.Equal(t, "logs/convert/resize.log", StepLogPath("convert", "resize"))
+	assert.Equal(t, "logs/convert/make_thumb_1_2.log", StepLogPath("convert", "make thumb/1:2"))
+	assert.Equal(t, "logs/_/_.log", StepLogPath("..", ""))
+}
+
+func TestExecuteJob_StepLogFlushedWhileRunning(t *testing.T) {
+	var (
+		ctx   = context.TODO()
+		store = &logStorage{fakeStorage: newFakeStorage(), logs: map[string][]string{}}
+		path  = StepLogPath("thumb", "step1")
+	)
+	registry := NewRunnerRegistry()
+	registry.Register(&loggingRunner{
+		fakeRunner: fakeRunner{usesPrefix: "image/", err: errors.New("resize failed")},
+		lines:      []string{"start", "processing", "boom"},
+		pause:      30 * time.Millisecond,
+	})
+	exec := NewExecutor(store, registry, WithStepLogs(1024, 10*time.Millisecond))
+
+	require.NoError(t, exec.ExecuteJob(ctx, singleJobWorkflow("thumb", "image/resize"), "grp/obj1", "thumb", nil))
+
+	versions := store.log(path)
+	require.Greater(t, len(versions), 1, "log must be flushed while the step runs")
+	assert.Equal(t, "start\nprocessing\nboom\n", versions[len(versions)-1])
+
+	js := store.state.Jobs["thumb"]
+	require.Len(t, js.Steps, 1)
+	assert.Equal(t, models.StepStatusFailed, js.Steps[0].Status)
+	assert.Equal(t, path, js.Steps[0].LogPath)
+}
+
+func TestExecuteJob_EmptyStepLogNotStored(t *testing.T) {
+	var (
+		ctx      = context.TODO()
+		store    = &logStorage{fakeStorage: newFakeStorage(), logs: map[string][]string{}}
+		registry = NewRunnerRegistry()
+	)
+	registry.Register(&fakeRunner{usesPrefix: "image/", output: StepOutput{Writer: strings.NewReader("data")}})
+	exec := NewExecutor(store, registry)
+
+	require.NoError(t, exec.ExecuteJob(ctx, singleJobWorkflow("thumb", "image/resize"), "grp/obj1", "thumb", nil))
+	assert.Empty(t, store.log(StepLogPath("thumb", "step1")))
+	assert.Empty(t, store.state.Jobs["thumb"].Steps[0].LogPath)
+}
@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	storio "github.com/apfs-io/apfs/internal/storio"
+	"github.com/apfs-io/apfs/models"
+)
+
+// artifactStream is a step artifact which is written to the storage in
+// background while it is streamed into the next step of the job.
+//
+// The storage write and the next step are connected with io.Pipe, so the
+// producer never gets ahead of the slowest consumer (backpressure) and the
+// intermediate file is never materialized on the worker. When the runner
+// of the producing step is still running (StepOutput.Wait), the producer,
+// the storage write and the next step run concurrently.
+type artifactStream struct {
+	step   string // name of the step produced the artifact
+	path   string
+	reader *io.PipeReader
+	done   chan error
+	finish func() error // closes the artifact and waits for its producer
+}
+
+// streamArtifact starts writing the artifact to the storage and returns the
+// stream to be consumed by the next step.
+func (e *Executor) streamArtifact(ctx context.Context, id storio.ObjectID, step, path string, data io.Reader, im *models.ItemMeta, finish func() error) *artifactStream {
+	pr, pw := io.Pipe()
+	stream := &artifactStream{step: step, path: path, reader: pr, done: make(chan error, 1), finish: finish}
+	go func() {
+		err := e.writeArtifact(ctx, id, path, io.TeeReader(data, pw), im)
+		_ = pw.CloseWithError(err)
+		stream.done <- err
+	}()
+	return stream
+}
+
+// wait drains the part of the artifact not consumed by the next step and
+// waits until the storage write and the producer are finished. The failure
+// of the producer is returned first, it is the cause of the write failure.
+func (s *artifactStream) wait() error {
+	_, _ = io.Copy(io.Discard, s.reader)
+	err := <-s.done
+	_ = s.reader.Close()
+	if s.finish != nil {
+		if ferr := s.finish(); ferr != nil {
+			return ferr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("step %q write artifact: %w", s.step, err)
+	}
+	return nil
+}
+
+// writeArtifact writes the step output into the storage
+func (e *Executor) writeArtifact(ctx context.Context, id storio.ObjectID, path string, data io.Reader, im *models.ItemMeta) error {
+	return e.storage.WriteFile(ctx, id, path, data, im)
+}
+
+// closeReader closes the reader if it is an io.Closer
+func closeReader(r io.Reader) {
+	if closer, ok := r.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// stepInput opens the input of the step: the stream of the previous step
+// artifact if any, or the source file from the storage. Runners which need
+// random access get a temporary file copy of non-seekable inputs.
+func (e *Executor) stepInput(ctx context.Context, id storio.ObjectID, sourceName string, stream *artifactStream, seekable bool) (io.Reader, func(), error) {
+	var (
+		reader  io.Reader
+		release = func() {}
+	)
+	if stream != nil {
+		// The pipe is closed by the stream itself once the write is done
+		reader = stream.reader
+	} else {
+		rc, err := e.storage.ReadFile(ctx, id, sourceName)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader, release = rc, func() { _ = rc.Close() }
+	}
+	if !seekable {
+		return reader, release, nil
+	}
+	if _, ok := reader.(io.ReadSeeker); ok {
+		return reader, release, nil
+	}
+	file, err := spoolTempFile(reader)
+	release()
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}, nil
+}
+
+// spoolTempFile copies the reader into a temporary file rewound to the start
+func spoolTempFile(r io.Reader) (*os.File, error) {
+	file, err := os.CreateTemp("", "apfs-step-input-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(file, r); err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// needsSeekableInput returns true if the runner declares it needs random
+// access to the step input
+func needsSeekableInput(runner StepRunner, step *models.WorkflowStep) bool {
+	sr, ok := runner.(SeekableInputRunner)
+	return ok && sr.NeedsSeekableInput(step)
+}
@@ -0,0 +1,42 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestExecuteJob_Tracing(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	var (
+		store    = newFakeStorage()
+		registry = NewRunnerRegistry()
+		wf       = singleJobWorkflow("thumb", "image/resize")
+	)
+	registry.Register(&readingRunner{fakeRunner: fakeRunner{usesPrefix: "image/"}})
+
+	// The job continues the trace of the upload
+	ctx, upload := otel.Tracer("test").Start(context.Background(), "apfs.Upload")
+	require.NoError(t, NewExecutor(store, registry).ExecuteJob(ctx, wf, "media/obj", "thumb", nil))
+	upload.End()
+
+	traceID := upload.SpanContext().TraceID()
+	assert.Equal(t, traceID.String(), store.state.TraceID)
+
+	names := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, traceID, span.SpanContext().TraceID())
+		names[span.Name()] = true
+	}
+	assert.True(t, names["workflow.job thumb"])
+	assert.True(t, names["workflow.step step1"])
+}
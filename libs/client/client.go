@@ -292,6 +292,109 @@ func (c *client) GetWorkflow(ctx context.Context, opts ...RequestOption) (*model
 	return models.FromLegacyManifest(response.GetManifest().ToModel()), nil
 }
 
+// StepLogs writes the log of the job step into w
+func (c *client) StepLogs(ctx context.Context, id *ObjectID, jobID, step string, follow bool, w io.Writer, opts ...RequestOption) error {
+	var ro RequestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	ro.prepareGroup(c.defaultGroup)
+	stream, err := c.sclient.GetStepLogs(prepareContext(ctx), &protocol.StepLogsRequest{
+		Id:     toProtoObjectID(id, ro.group).GetId(),
+		Job:    jobID,
+		Step:   step,
+		Follow: follow,
+	}, ro.grpcOpts...)
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(chunk.GetData()); err != nil {
+			return err
+		}
+	}
+}
+
+// RetryJob runs the failed or skipped job and its downstream jobs again
+func (c *client) RetryJob(ctx context.Context, id *ObjectID, jobID string, opts ...RequestOption) error {
+	var ro RequestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	ro.prepareGroup(c.defaultGroup)
+	resp, err := c.sclient.RetryJob(prepareContext(ctx), &protocol.JobRequest{
+		Id:  toProtoObjectID(id, ro.group).GetId(),
+		Job: jobID,
+	}, ro.grpcOpts...)
+	return prepareControlResponse(resp, err)
+}
+
+// SkipJob marks the job as skipped so its downstream jobs proceed
+func (c *client) SkipJob(ctx context.Context, id *ObjectID, jobID, reason string, opts ...RequestOption) error {
+	var ro RequestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	ro.prepareGroup(c.defaultGroup)
+	resp, err := c.sclient.SkipJob(prepareContext(ctx), &protocol.JobRequest{
+		Id:     toProtoObjectID(id, ro.group).GetId(),
+		Job:    jobID,
+		Reason: reason,
+	}, ro.grpcOpts...)
+	return prepareControlResponse(resp, err)
+}
+
+// CancelProcessing stops the running jobs of the object and skips the pending ones
+func (c *client) CancelProcessing(ctx context.Context, id *ObjectID, opts ...RequestOption) error {
+	var ro RequestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	ro.prepareGroup(c.defaultGroup)
+	resp, err := c.sclient.CancelProcessing(prepareContext(ctx),
+		toProtoObjectID(id, ro.group), ro.grpcOpts...)
+	return prepareControlResponse(resp, err)
+}
+
+func prepareControlResponse(resp *protocol.SimpleResponse, err error) error {
+	if err != nil {
+		return err
+	}
+	switch status := resp.GetStatus(); {
+	case status.IsNotFound():
+		return storerrors.WrapNotFound(``, toError(nil, resp.GetMessage()))
+	case status.IsFailed():
+		return errors.New(resp.GetMessage())
+	}
+	return nil
+}
+
+// GetGroupStats returns the usage statistics of the group.
+func (c *client) GetGroupStats(ctx context.Context, opts ...RequestOption) (*GroupStats, error) {
+	var ro RequestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	ro.prepareGroup(c.defaultGroup)
+	response, err := c.sclient.GetGroupStats(prepareContext(ctx), &protocol.ManifestGroup{
+		Group: ro.group,
+	}, ro.grpcOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if !response.GetStatus().IsOK() {
+		return nil, errors.New(response.GetMessage())
+	}
+	return groupStatsFromProto(response.GetStats()), nil
+}
+
 // WithGroup returns client with group name by default
 func (c *client) WithGroup(name string) Client {
 	return &client{
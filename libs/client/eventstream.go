@@ -2,7 +2,6 @@ package client
 
 import (
 	"context"
-	"encoding/json"
 	"io"
 
 	nc "github.com/geniusrabbit/notificationcenter/v2"
@@ -14,6 +13,10 @@ import (
 // EventHandler function callback
 type EventHandler func(models.EventType, *models.Object)
 
+// FullEventHandler function callback of the whole event with the job ID,
+// the processing state and the correlation ID
+type FullEventHandler func(*models.Event) error
+
 // Eventstream client to process events of of the service like delete, update
 type Eventstream struct {
 	sub nc.Subscriber
@@ -30,12 +33,23 @@ func NewEventStream(ctx context.Context, connect string) (*Eventstream, error) {
 
 // Subscribe new event handler
 func (es *Eventstream) Subscribe(ctx context.Context, h EventHandler) error {
+	return es.SubscribeEvents(ctx, func(event *models.Event) error {
+		h(event.Type, event.Object)
+		return nil
+	})
+}
+
+// SubscribeEvents subscribes the handler of the whole events.
+// The events of every stream format (CloudEvents or legacy) are accepted.
+func (es *Eventstream) SubscribeEvents(ctx context.Context, h FullEventHandler) error {
 	return es.sub.Subscribe(ctx, nc.FuncReceiver(func(msg nc.Message) error {
-		var event models.Event
-		if err := json.Unmarshal(msg.Body(), &event); err != nil {
+		event, err := stream.DecodeEvent(msg.Body())
+		if err != nil {
+			return err
+		}
+		if err = h(event); err != nil {
 			return err
 		}
-		h(event.Type, event.Object)
 		return msg.Ack()
 	}))
 }
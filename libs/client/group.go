@@ -58,6 +58,28 @@ func (g *Group) Delete(ctx context.Context, id string, names ...string) error {
 	return g.client.Delete(ctx, &ObjectIDNames{Id: id, Names: names}, WithGroupOpt(g.name))
 }
 
+// StepLogs writes the stdout/stderr log of the job step of the object into w.
+// With follow the log of the running step is streamed until the job finishes.
+func (g *Group) StepLogs(ctx context.Context, id, jobID, step string, follow bool, w io.Writer) error {
+	return g.client.StepLogs(ctx, &ObjectID{Id: id}, jobID, step, follow, w, WithGroupOpt(g.name))
+}
+
+// RetryJob runs the failed or skipped job of the object and its downstream jobs again.
+func (g *Group) RetryJob(ctx context.Context, id, jobID string) error {
+	return g.client.RetryJob(ctx, &ObjectID{Id: id}, jobID, WithGroupOpt(g.name))
+}
+
+// SkipJob marks the job of the object as skipped so its downstream jobs proceed.
+// The reason is stored as the job error ("skipped manually" if empty).
+func (g *Group) SkipJob(ctx context.Context, id, jobID, reason string) error {
+	return g.client.SkipJob(ctx, &ObjectID{Id: id}, jobID, reason, WithGroupOpt(g.name))
+}
+
+// CancelProcessing stops the running jobs of the object and skips the pending ones.
+func (g *Group) CancelProcessing(ctx context.Context, id string) error {
+	return g.client.CancelProcessing(ctx, &ObjectID{Id: id}, WithGroupOpt(g.name))
+}
+
 // SetWorkflow stores the workflow manifest for this group.
 func (g *Group) SetWorkflow(ctx context.Context, w *models.Workflow, opts ...RequestOption) error {
 	all := append(opts, WithGroupOpt(g.name))
@@ -70,6 +92,12 @@ func (g *Group) GetWorkflow(ctx context.Context, opts ...RequestOption) (*models
 	return g.client.GetWorkflow(ctx, all...)
 }
 
+// Stats returns the usage statistics of this group.
+func (g *Group) Stats(ctx context.Context, opts ...RequestOption) (*GroupStats, error) {
+	all := append(opts, WithGroupOpt(g.name))
+	return g.client.GetGroupStats(ctx, all...)
+}
+
 // ProcessingState returns the current processing state for the given object ID.
 // Pass WithState() for a compact view (counters only) or WithFullState() for
 // the complete job detail. Without either option the returned State field will
@@ -34,6 +34,19 @@ type ObjectManagerClient interface {
 
 	// Delete removes an object (or named sub-items) from storage.
 	Delete(ctx context.Context, id any, opts ...RequestOption) error
+
+	// StepLogs writes the stdout/stderr log of the job step into w.
+	// With follow the log of the running step is streamed until the job finishes.
+	StepLogs(ctx context.Context, id *ObjectID, jobID, step string, follow bool, w io.Writer, opts ...RequestOption) error
+
+	// RetryJob runs the failed or skipped job and its downstream jobs again.
+	RetryJob(ctx context.Context, id *ObjectID, jobID string, opts ...RequestOption) error
+
+	// SkipJob marks the job as skipped so its downstream jobs proceed.
+	SkipJob(ctx context.Context, id *ObjectID, jobID, reason string, opts ...RequestOption) error
+
+	// CancelProcessing stops the running jobs of the object and skips the pending ones.
+	CancelProcessing(ctx context.Context, id *ObjectID, opts ...RequestOption) error
 }
 
 // MetadataManagerClient interface represents interaction with metadata storage
@@ -43,6 +56,9 @@ type MetadataManagerClient interface {
 
 	// GetWorkflow reads the workflow manifest for the group.
 	GetWorkflow(ctx context.Context, opts ...RequestOption) (*models.Workflow, error)
+
+	// GetGroupStats returns the usage statistics of the group.
+	GetGroupStats(ctx context.Context, opts ...RequestOption) (*GroupStats, error)
 }
 
 // Client interface accessor to the Disk API
@@ -22,6 +22,8 @@ type ItemMeta struct {
 	Duration    int64
 	Bitrate     string
 	Codec       string
+	BlurHash    string // image placeholder computed by the image/placeholder step
+	ThumbHash   string // base64-encoded image placeholder
 	Attributes  map[string]any
 	UpdatedAt   time.Time
 }
@@ -72,6 +74,8 @@ func itemMetaFromProto(p *protocol.ItemMeta) ItemMeta {
 		Duration:    p.GetDuration(),
 		Bitrate:     p.GetBitrate(),
 		Codec:       p.GetCodec(),
+		BlurHash:    p.GetBlurhash(),
+		ThumbHash:   p.GetThumbhash(),
 		UpdatedAt:   time.Unix(0, p.GetUpdatedAt()),
 	}
 	src := p.GetAttributesJson()
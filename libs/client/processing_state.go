@@ -29,6 +29,8 @@ type ProcessingState struct {
 	StartedAt       time.Time
 	UpdatedAt       time.Time
 	FinishedAt      *time.Time
+	CancelledAt     *time.Time
+	TraceID         string
 }
 
 // JobState is the client-facing state of one job in the processing DAG.
@@ -41,6 +43,9 @@ type JobState struct {
 	Steps      []*StepState
 	StartedAt  *time.Time
 	FinishedAt *time.Time
+
+	// NextAttemptAt is the start time of the scheduled retry of the pending job
+	NextAttemptAt *time.Time
 }
 
 // StepState is the client-facing state of one step within a job.
@@ -49,6 +54,8 @@ type StepState struct {
 	Status     models.StepStatus
 	DurationMs int64
 	Error      string
+	Limit      models.StepLimit
+	LogPath    string
 }
 
 // stateFromProto converts the generated proto ProcessingState to the client type.
@@ -62,6 +69,7 @@ func stateFromProto(p *protocol.ProcessingState, full bool) *ProcessingState {
 		Status:          protoProcessingStatusToModel(p.GetStatus()),
 		Progress:        float64(p.GetProgress()),
 		ManifestVersion: p.GetManifestVersion(),
+		TraceID:         p.GetTraceId(),
 		StartedAt:       time.UnixMilli(p.GetStartedAt()),
 		UpdatedAt:       time.UnixMilli(p.GetUpdatedAt()),
 	}
@@ -69,6 +77,10 @@ func stateFromProto(p *protocol.ProcessingState, full bool) *ProcessingState {
 		t := time.UnixMilli(p.GetFinishedAt())
 		s.FinishedAt = &t
 	}
+	if p.GetCancelledAt() > 0 {
+		t := time.UnixMilli(p.GetCancelledAt())
+		s.CancelledAt = &t
+	}
 	if c := p.GetCounters(); c != nil {
 		s.Counters = ProcessingCounters{
 			Total:     int(c.GetTotal()),
@@ -100,12 +112,18 @@ func stateFromProto(p *protocol.ProcessingState, full bool) *ProcessingState {
 				t := time.UnixMilli(pj.GetFinishedAt())
 				js.FinishedAt = &t
 			}
+			if pj.GetNextAttemptAt() > 0 {
+				t := time.UnixMilli(pj.GetNextAttemptAt())
+				js.NextAttemptAt = &t
+			}
 			for _, sp := range pj.GetSteps() {
 				js.Steps = append(js.Steps, &StepState{
 					Name:       sp.GetName(),
 					Status:     protoStepStatusToModel(sp.GetStatus()),
 					DurationMs: sp.GetDurationMs(),
 					Error:      sp.GetError(),
+					Limit:      models.StepLimit(sp.GetLimit()),
+					LogPath:    sp.GetLogPath(),
 				})
 			}
 			s.Jobs[pj.GetId()] = js
@@ -124,6 +142,8 @@ func protoProcessingStatusToModel(s protocol.ProcessingStatus) models.Processing
 		return models.ProcessingStatusPartial
 	case protocol.ProcessingStatus_PROCESSING_FAILED:
 		return models.ProcessingStatusFailed
+	case protocol.ProcessingStatus_PROCESSING_CANCELLED:
+		return models.ProcessingStatusCancelled
 	default:
 		return models.ProcessingStatusPending
 	}
@@ -154,6 +174,8 @@ func protoStepStatusToModel(s protocol.StepStatus) models.StepStatus {
 		return models.StepStatusFailed
 	case protocol.StepStatus_STEP_SKIPPED:
 		return models.StepStatusSkipped
+	case protocol.StepStatus_STEP_LIMIT_EXCEEDED:
+		return models.StepStatusLimitExceeded
 	default:
 		return models.StepStatusPending
 	}
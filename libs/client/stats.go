@@ -0,0 +1,54 @@
+package client
+
+import (
+	"time"
+
+	protocol "github.com/apfs-io/apfs/internal/server/protocol/v1"
+	"github.com/apfs-io/apfs/models"
+)
+
+// JobStats aggregates the runs of one workflow job in the group.
+type JobStats struct {
+	ID              string
+	Completed       int64
+	Failed          int64
+	AverageDuration time.Duration
+}
+
+// GroupStats is the client-facing usage statistics of the group.
+type GroupStats struct {
+	Group         string
+	Objects       int64
+	OriginalBytes int64
+	DerivedBytes  int64
+	TotalBytes    int64
+	Processing    map[models.ProcessingStatus]int64
+	Jobs          []*JobStats // sorted by ID
+}
+
+// groupStatsFromProto converts the generated proto GroupStats to the client type.
+func groupStatsFromProto(p *protocol.GroupStats) *GroupStats {
+	if p == nil {
+		return nil
+	}
+	s := &GroupStats{
+		Group:         p.GetGroup(),
+		Objects:       p.GetObjects(),
+		OriginalBytes: p.GetOriginalBytes(),
+		DerivedBytes:  p.GetDerivedBytes(),
+		TotalBytes:    p.GetTotalBytes(),
+		Processing:    make(map[models.ProcessingStatus]int64, len(p.GetProcessing())),
+	}
+	for _, c := range p.GetProcessing() {
+		s.Processing[protoProcessingStatusToModel(c.GetStatus())] = c.GetCount()
+	}
+	for _, j := range p.GetJobs() {
+		s.Jobs = append(s.Jobs, &JobStats{
+			ID:              j.GetId(),
+			Completed:       j.GetCompleted(),
+			Failed:          j.GetFailed(),
+			AverageDuration: time.Duration(j.GetAvgDurationMs()) * time.Millisecond,
+		})
+	}
+	return s
+}
@@ -13,6 +13,7 @@ const (
 	ActionBrightness    = "image.brightness"
 	ActionExtractColors = "image.extract-colors"
 	ActionBase64        = "image.base64"
+	ActionPlaceholder   = "image.placeholder"
 	ActionSave          = "image.save"
 )
 
@@ -29,4 +30,6 @@ const (
 	ActionParamMetaField   = "target-meta"
 	ActionParamSave        = "save"
 	ActionParamJPEGQuality = "jpeg.quality"
+	ActionParamComponentsX = "components-x"
+	ActionParamComponentsY = "components-y"
 )
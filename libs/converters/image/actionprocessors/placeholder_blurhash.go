@@ -0,0 +1,132 @@
+package actionprocessors
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// encodeBlurHash computes the BlurHash (https://blurha.sh) of the image
+// with componentsX*componentsY DCT components (each in range 1..9).
+// The image is expected to be already downscaled, the cost is O(w*h*cx*cy).
+func encodeBlurHash(img image.Image, componentsX, componentsY int) string {
+	componentsX = max(1, min(9, componentsX))
+	componentsY = max(1, min(9, componentsY))
+
+	var (
+		bounds  = img.Bounds()
+		width   = bounds.Dx()
+		height  = bounds.Dy()
+		pixels  = linearPixels(img)
+		factors = make([][3]float64, 0, componentsX*componentsY)
+	)
+	if width == 0 || height == 0 {
+		return ""
+	}
+
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			var (
+				r, g, b       float64
+				normalisation = 2.0
+			)
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			for y := 0; y < height; y++ {
+				fy := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+				for x := 0; x < width; x++ {
+					basis := fy * math.Cos(math.Pi*float64(i)*float64(x)/float64(width))
+					px := pixels[y*width+x]
+					r += basis * px[0]
+					g += basis * px[1]
+					b += basis * px[2]
+				}
+			}
+			scale := normalisation / float64(width*height)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var (
+		hash     strings.Builder
+		dc       = factors[0]
+		ac       = factors[1:]
+		maxValue = 1.0
+	)
+	hash.WriteString(encodeBase83((componentsX-1)+(componentsY-1)*9, 1))
+
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = max(actualMax, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+		quantisedMax := int(max(0, min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		hash.WriteString(encodeBase83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	hash.WriteString(encodeBase83(
+		(linearToSRGB(dc[0])<<16)+(linearToSRGB(dc[1])<<8)+linearToSRGB(dc[2]), 4))
+
+	for _, f := range ac {
+		quant := func(v float64) int {
+			return int(max(0, min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+	return hash.String()
+}
+
+// linearPixels converts the image into a row-major slice of linear RGB values.
+func linearPixels(img image.Image) [][3]float64 {
+	var (
+		bounds = img.Bounds()
+		pixels = make([][3]float64, 0, bounds.Dx()*bounds.Dy())
+	)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pixels = append(pixels, [3]float64{
+				sRGBToLinear(int(c.R)),
+				sRGBToLinear(int(c.G)),
+				sRGBToLinear(int(c.B)),
+			})
+		}
+	}
+	return pixels
+}
+
+func encodeBase83(value, length int) string {
+	buf := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		buf[i-1] = base83Chars[digit]
+	}
+	return string(buf)
+}
+
+func sRGBToLinear(value int) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := max(0, min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
@@ -0,0 +1,44 @@
+package actionprocessors
+
+import (
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"os"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBlurHash(t *testing.T) {
+	img := imaging.New(32, 32, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
+
+	hash := encodeBlurHash(img, 4, 3)
+	assert.Len(t, hash, 6+2*(4*3-1))
+	// Size flag for 4x3 components and the DC term encodes the solid red color
+	assert.Equal(t, encodeBase83(3+2*9, 1), hash[:1])
+	assert.Equal(t, encodeBase83(0xff0000, 4), hash[2:6])
+
+	assert.Len(t, encodeBlurHash(img, 1, 1), 6)
+	assert.Empty(t, encodeBlurHash(image.NewNRGBA(image.Rect(0, 0, 0, 0)), 4, 3))
+}
+
+func TestEncodeThumbHash(t *testing.T) {
+	file, err := os.Open("../../../../testdata/cat.jpg")
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	src, _, err := image.Decode(file)
+	require.NoError(t, err)
+
+	img := imaging.Fit(src, thumbHashMaxSize, thumbHashMaxSize, imaging.Box)
+	hash := encodeThumbHash(img)
+	require.NotEmpty(t, hash)
+	// Opaque image: no alpha flag, 5 bytes header + packed AC nibbles
+	assert.Zero(t, hash[2]&0x80)
+	assert.Greater(t, len(hash), 5)
+
+	assert.Nil(t, encodeThumbHash(src), "images larger than 100x100 are rejected")
+}
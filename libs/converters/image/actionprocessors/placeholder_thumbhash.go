@@ -0,0 +1,179 @@
+package actionprocessors
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// thumbHashMaxSize is the maximal image side accepted by the ThumbHash encoder.
+const thumbHashMaxSize = 100
+
+// encodeThumbHash computes the binary ThumbHash (https://evanw.github.io/thumbhash/)
+// of the image. The image must fit into 100x100 pixels.
+func encodeThumbHash(img image.Image) []byte {
+	var (
+		bounds = img.Bounds()
+		w, h   = bounds.Dx(), bounds.Dy()
+		n      = w * h
+	)
+	if w == 0 || h == 0 || w > thumbHashMaxSize || h > thumbHashMaxSize {
+		return nil
+	}
+
+	// Non-premultiplied RGBA in range 0..1
+	rgba := make([][4]float64, 0, n)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgba = append(rgba, [4]float64{
+				float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255, float64(c.A) / 255,
+			})
+		}
+	}
+
+	// Determine the average color
+	var avgR, avgG, avgB, avgA float64
+	for _, px := range rgba {
+		avgR += px[3] * px[0]
+		avgG += px[3] * px[1]
+		avgB += px[3] * px[2]
+		avgA += px[3]
+	}
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	var (
+		hasAlpha = avgA < float64(n)
+		lLimit   = 7 // Use fewer luminance bits if there's alpha
+	)
+	if hasAlpha {
+		lLimit = 5
+	}
+	var (
+		maxSide = float64(max(w, h))
+		lx      = max(1, int(jsRound(float64(lLimit*w)/maxSide)))
+		ly      = max(1, int(jsRound(float64(lLimit*h)/maxSide)))
+		l       = make([]float64, n) // luminance
+		p       = make([]float64, n) // yellow - blue
+		q       = make([]float64, n) // red - green
+		a       = make([]float64, n) // alpha
+	)
+
+	// Convert the image from RGBA to LPQA (composite atop the average color)
+	for i, px := range rgba {
+		alpha := px[3]
+		r := avgR*(1-alpha) + alpha*px[0]
+		g := avgG*(1-alpha) + alpha*px[1]
+		b := avgB*(1-alpha) + alpha*px[2]
+		l[i] = (r + g + b) / 3
+		p[i] = (r+g)/2 - b
+		q[i] = r - g
+		a[i] = alpha
+	}
+
+	encodeChannel := func(channel []float64, nx, ny int) (dc float64, ac []float64, scale float64) {
+		fx := make([]float64, w)
+		for cy := 0; cy < ny; cy++ {
+			for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+				f := 0.0
+				for x := 0; x < w; x++ {
+					fx[x] = math.Cos(math.Pi / float64(w) * float64(cx) * (float64(x) + 0.5))
+				}
+				for y := 0; y < h; y++ {
+					fy := math.Cos(math.Pi / float64(h) * float64(cy) * (float64(y) + 0.5))
+					for x := 0; x < w; x++ {
+						f += channel[x+y*w] * fx[x] * fy
+					}
+				}
+				f /= float64(n)
+				if cx > 0 || cy > 0 {
+					ac = append(ac, f)
+					scale = max(scale, math.Abs(f))
+				} else {
+					dc = f
+				}
+			}
+		}
+		if scale > 0 {
+			for i := range ac {
+				ac[i] = 0.5 + 0.5/scale*ac[i]
+			}
+		}
+		return dc, ac, scale
+	}
+
+	var (
+		lDC, lAC, lScale = encodeChannel(l, max(3, lx), max(3, ly))
+		pDC, pAC, pScale = encodeChannel(p, 3, 3)
+		qDC, qAC, qScale = encodeChannel(q, 3, 3)
+		aDC, aScale      float64
+		aAC              []float64
+	)
+	if hasAlpha {
+		aDC, aAC, aScale = encodeChannel(a, 5, 5)
+	}
+
+	// Write the constants
+	isLandscape := w > h
+	header24 := int(jsRound(63*lDC)) |
+		int(jsRound(31.5+31.5*pDC))<<6 |
+		int(jsRound(31.5+31.5*qDC))<<12 |
+		int(jsRound(31*lScale))<<18 |
+		boolInt(hasAlpha)<<23
+	header16 := int(jsRound(63*pScale))<<3 |
+		int(jsRound(63*qScale))<<9 |
+		boolInt(isLandscape)<<15
+	if isLandscape {
+		header16 |= ly
+	} else {
+		header16 |= lx
+	}
+
+	channels := [][]float64{lAC, pAC, qAC}
+	acStart := 5
+	if hasAlpha {
+		channels = append(channels, aAC)
+		acStart = 6
+	}
+	acCount := 0
+	for _, ac := range channels {
+		acCount += len(ac)
+	}
+
+	hash := make([]byte, acStart+(acCount+1)/2)
+	hash[0] = byte(header24 & 255)
+	hash[1] = byte((header24 >> 8) & 255)
+	hash[2] = byte(header24 >> 16)
+	hash[3] = byte(header16 & 255)
+	hash[4] = byte(header16 >> 8)
+	if hasAlpha {
+		hash[5] = byte(int(jsRound(15*aDC)) | int(jsRound(15*aScale))<<4)
+	}
+
+	// Write the varying factors
+	acIndex := 0
+	for _, ac := range channels {
+		for _, f := range ac {
+			hash[acStart+(acIndex>>1)] |= byte(int(jsRound(15*f)) << ((acIndex & 1) << 2))
+			acIndex++
+		}
+	}
+	return hash
+}
+
+// jsRound rounds half up like JavaScript Math.round to keep the output
+// byte-compatible with the reference implementation.
+func jsRound(v float64) float64 {
+	return math.Floor(v + 0.5)
+}
+
+func boolInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
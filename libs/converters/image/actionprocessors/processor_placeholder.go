@@ -0,0 +1,34 @@
+package actionprocessors
+
+import (
+	"encoding/base64"
+
+	"github.com/disintegration/imaging"
+
+	"github.com/apfs-io/apfs/internal/storage/converters"
+	"github.com/apfs-io/apfs/models"
+)
+
+// ActionProcessorPlaceholder computes BlurHash and ThumbHash placeholders
+// of the image and stores them into the item attributes, so clients can
+// render a preview without downloading any artifact.
+type ActionProcessorPlaceholder struct{}
+
+func (ActionProcessorPlaceholder) Name() string { return ActionPlaceholder }
+
+func (ActionProcessorPlaceholder) Process(in converters.Input, out converters.Output, action *models.Action, imgReader ImageReader) error {
+	var (
+		componentsX = int(action.ValueInt32(ActionParamComponentsX, 4))
+		componentsY = int(action.ValueInt32(ActionParamComponentsY, 3))
+		img         = imgReader.Image()
+	)
+	// Both hashes are built from a tiny preview, the source size doesn't matter
+	if rect := img.Bounds(); rect.Dx() > thumbHashMaxSize || rect.Dy() > thumbHashMaxSize {
+		img = imaging.Fit(img, thumbHashMaxSize, thumbHashMaxSize, imaging.Box)
+	}
+	out.Meta().SetAttribute(models.AttributeBlurHash, encodeBlurHash(img, componentsX, componentsY))
+	if hash := encodeThumbHash(img); len(hash) > 0 {
+		out.Meta().SetAttribute(models.AttributeThumbHash, base64.RawStdEncoding.EncodeToString(hash))
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ const (
 	ActionBrightness    = actionprocessors.ActionBrightness
 	ActionExtractColors = actionprocessors.ActionExtractColors
 	ActionBase64        = actionprocessors.ActionBase64
+	ActionPlaceholder   = actionprocessors.ActionPlaceholder
 	ActionSave          = actionprocessors.ActionSave
 )
 
@@ -36,6 +37,8 @@ const (
 	ActionParamMetaField   = actionprocessors.ActionParamMetaField
 	ActionParamSave        = actionprocessors.ActionParamSave
 	ActionParamJPEGQuality = actionprocessors.ActionParamJPEGQuality
+	ActionParamComponentsX = actionprocessors.ActionParamComponentsX
+	ActionParamComponentsY = actionprocessors.ActionParamComponentsY
 )
 
 // Error list...
@@ -117,6 +120,14 @@ func NewActionB64Extract(contentType, targetMeta string) *models.Action {
 	)
 }
 
+// NewActionPlaceholder with BlurHash components count
+func NewActionPlaceholder(componentsX, componentsY int) *models.Action {
+	return models.NewAction(ActionPlaceholder,
+		ActionParamComponentsX, componentsX,
+		ActionParamComponentsY, componentsY,
+	)
+}
+
 // NewActionSave object into the pipeline
 // This action must be the last in the sequance, otherwhise it could be a problem
 func NewActionSave(saves ...bool) *models.Action {
@@ -49,6 +49,7 @@ func NewDefaultConverter() *Converter {
 		&actionprocessors.ActionProcessorBlur{},
 		&actionprocessors.ActionProcessorExractColors{},
 		&actionprocessors.ActionProcessorBase64{},
+		&actionprocessors.ActionProcessorPlaceholder{},
 		&actionprocessors.ActionProcessorSave{},
 	)
 }
@@ -1,14 +1,13 @@
-//go:build !gc && !webp
-// +build !gc,!webp
-
 package imagereader
 
 import (
-	"fmt"
 	"image"
 	"io"
+
+	"github.com/HugoSmits86/nativewebp"
 )
 
+// encodeWebp encodes the image into the lossless webp
 func encodeWebp(out io.Writer, img image.Image) error {
-	return fmt.Errorf("[image] webp saving is not supported")
+	return nativewebp.Encode(out, img, nil)
 }
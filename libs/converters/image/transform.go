@@ -0,0 +1,65 @@
+package image
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/storage/converters"
+	"github.com/apfs-io/apfs/libs/converters/image/actionprocessors"
+	"github.com/apfs-io/apfs/libs/converters/image/imagereader"
+	"github.com/apfs-io/apfs/models"
+)
+
+// TransformActions converts the transformation preset into the sequence of
+// image actions executed by the converter processors.
+func TransformActions(preset *models.TransformPreset) []*models.Action {
+	var resize *models.Action
+	switch {
+	case preset.Width == 0 || preset.Height == 0:
+		// Single side is defined, keep the aspect ratio
+		resize = NewActionResize(preset.Width, preset.Height, "")
+	case preset.FitMode() == models.TransformFitContain:
+		resize = NewActionFit(preset.Width, preset.Height, "")
+	case preset.FitMode() == models.TransformFitFill:
+		resize = NewActionResize(preset.Width, preset.Height, "")
+	default:
+		resize = NewActionFill(preset.Width, preset.Height, "", "")
+	}
+	return []*models.Action{resize, NewActionSave()}
+}
+
+// Transform decodes the source image, applies the preset and returns the
+// reader of the encoded result together with the produced item meta.
+// ext is the target file extension which defines the output format.
+func (ic *Converter) Transform(src io.Reader, preset *models.TransformPreset, ext string) (io.ReadCloser, *models.ItemMeta, error) {
+	if err := preset.Validate(); err != nil {
+		return nil, nil, err
+	}
+	var (
+		contentType    = actionprocessors.ContentTypeFromExt(ext)
+		imgReader, err = imagereader.Decode(src, contentType, preset.Quality)
+		meta           = &models.ItemMeta{ContentType: contentType, Type: models.TypeImage}
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(errImageDecode, err.Error())
+	}
+	for _, action := range TransformActions(preset) {
+		processor := ic.processors[action.Name]
+		if processor == nil {
+			_ = imgReader.Close()
+			return nil, nil, errors.Wrap(ErrUnsupportedAction, action.Name)
+		}
+		var (
+			in  = converters.NewInput(nil, nil, action, meta)
+			out = converters.NewOutput(meta)
+		)
+		if err = processor.Process(in, out, action, imgReader); err != nil {
+			_ = imgReader.Close()
+			return nil, nil, err
+		}
+	}
+	bounds := imgReader.Image().Bounds()
+	meta.Width, meta.Height = bounds.Dx(), bounds.Dy()
+	return imgReader, meta, nil
+}
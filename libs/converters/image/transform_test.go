@@ -0,0 +1,129 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// testPNG returns the encoded PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestTransformActions(t *testing.T) {
+	tests := []struct {
+		preset models.TransformPreset
+		action string
+	}{
+		{preset: models.TransformPreset{Width: 10}, action: ActionResize},
+		{preset: models.TransformPreset{Height: 10, Fit: models.TransformFitContain}, action: ActionResize},
+		{preset: models.TransformPreset{Width: 10, Height: 10}, action: ActionFill},
+		{preset: models.TransformPreset{Width: 10, Height: 10, Fit: models.TransformFitCover}, action: ActionFill},
+		{preset: models.TransformPreset{Width: 10, Height: 10, Fit: models.TransformFitContain}, action: ActionFit},
+		{preset: models.TransformPreset{Width: 10, Height: 10, Fit: models.TransformFitFill}, action: ActionResize},
+	}
+	for _, tt := range tests {
+		actions := TransformActions(&tt.preset)
+		require.Len(t, actions, 2)
+		assert.Equal(t, tt.action, actions[0].Name, "%+v", tt.preset)
+		assert.Equal(t, ActionSave, actions[1].Name)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	var (
+		converter = NewDefaultConverter()
+		src       = testPNG(t, 80, 40)
+	)
+	tests := []struct {
+		name          string
+		preset        models.TransformPreset
+		ext           string
+		width, height int
+		contentType   string
+		decode        func(io.Reader) (image.Image, error)
+	}{
+		{
+			name:   "cover",
+			preset: models.TransformPreset{Width: 20, Height: 20},
+			ext:    ".png", width: 20, height: 20,
+			contentType: "image/png", decode: png.Decode,
+		},
+		{
+			name:   "contain",
+			preset: models.TransformPreset{Width: 20, Height: 20, Fit: models.TransformFitContain},
+			ext:    ".png", width: 20, height: 10,
+			contentType: "image/png", decode: png.Decode,
+		},
+		{
+			name:   "fill",
+			preset: models.TransformPreset{Width: 20, Height: 20, Fit: models.TransformFitFill},
+			ext:    ".png", width: 20, height: 20,
+			contentType: "image/png", decode: png.Decode,
+		},
+		{
+			name:   "keep aspect ratio",
+			preset: models.TransformPreset{Width: 40},
+			ext:    ".png", width: 40, height: 20,
+			contentType: "image/png", decode: png.Decode,
+		},
+		{
+			name:   "jpeg",
+			preset: models.TransformPreset{Width: 20, Height: 10, Format: "jpeg", Quality: 80},
+			ext:    ".jpg", width: 20, height: 10,
+			contentType: "image/jpeg", decode: jpeg.Decode,
+		},
+		{
+			name:   "webp",
+			preset: models.TransformPreset{Width: 30, Height: 20, Format: "webp"},
+			ext:    ".webp", width: 30, height: 20,
+			contentType: "image/webp", decode: webp.Decode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, meta, err := converter.Transform(bytes.NewReader(src), &tt.preset, tt.ext)
+			require.NoError(t, err)
+			defer func() { _ = data.Close() }()
+			assert.Equal(t, tt.contentType, meta.ContentType)
+			assert.Equal(t, models.TypeImage, meta.Type)
+			assert.Equal(t, tt.width, meta.Width)
+			assert.Equal(t, tt.height, meta.Height)
+
+			img, err := tt.decode(data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.width, img.Bounds().Dx())
+			assert.Equal(t, tt.height, img.Bounds().Dy())
+		})
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	converter := NewDefaultConverter()
+
+	_, _, err := converter.Transform(bytes.NewReader(testPNG(t, 8, 8)), &models.TransformPreset{}, ".png")
+	assert.Error(t, err, "invalid preset")
+
+	_, _, err = converter.Transform(bytes.NewReader([]byte("not an image")), &models.TransformPreset{Width: 4}, ".png")
+	assert.Error(t, err, "invalid source")
+}
@@ -0,0 +1,32 @@
+// Package probe implements a [workflow.StepRunner] which extracts media
+// information from audio and video files natively, without ffprobe.
+//
+// Only the container headers are parsed, the media payload is never decoded.
+// Supported containers: MP4/MOV (ISO BMFF), WebM/Matroska, MP3, WAV, FLAC.
+//
+// # Step Syntax
+//
+//	jobs:
+//	  probe:
+//	    steps:
+//	      - uses: probe
+//	  transcode:
+//	    needs: [probe]
+//	    if: ${{ probe.outputs.duration < 3600 }}
+//
+// # Outputs
+//
+// The step publishes the following job outputs:
+//
+//   - format      – container format (mp4, mov, webm, matroska, mp3, wav, flac)
+//   - duration    – duration in seconds (float)
+//   - bitrate     – overall bitrate in bits per second
+//   - rotation    – clockwise rotation of the video in degrees
+//   - has_video, has_audio
+//   - video_codec, width, height           – of the first video stream
+//   - audio_codec, sample_rate, channels   – of the first audio stream
+//   - streams     – list of all media streams
+//
+// Duration, bitrate, codec and dimensions are also written into the source
+// item meta (ItemMeta.Duration, Bitrate, Codec, Width, Height).
+package probe
@@ -0,0 +1,47 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// flacStreamInfoSize is the size of the mandatory STREAMINFO metadata block
+const flacStreamInfoSize = 34
+
+func probeFLAC(r io.ReaderAt, offset, size int64) (*Result, error) {
+	// "fLaC" marker is followed by the STREAMINFO block header
+	head, err := readAt(r, offset+4, 4)
+	if err != nil {
+		return nil, err
+	}
+	if head[0]&0x7f != 0 || int(head[1])<<16|int(head[2])<<8|int(head[3]) < flacStreamInfoSize {
+		return nil, ErrCorruptedData
+	}
+	info, err := readAt(r, offset+8, flacStreamInfoSize)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		sampleRate    = int(info[10])<<12 | int(info[11])<<4 | int(info[12])>>4
+		channels      = int(info[12]>>1&0x07) + 1
+		bitsPerSample = int(info[12]&0x01)<<4 | int(info[13])>>4 + 1
+		totalSamples  = int64(info[13]&0x0f)<<32 | int64(binary.BigEndian.Uint32(info[14:18]))
+		stream        = &Stream{
+			Type:          StreamTypeAudio,
+			Codec:         "flac",
+			SampleRate:    sampleRate,
+			Channels:      channels,
+			BitsPerSample: bitsPerSample,
+		}
+	)
+	if sampleRate > 0 {
+		stream.Duration = float64(totalSamples) / float64(sampleRate)
+	}
+	stream.Bitrate = bitrate(size-offset, stream.Duration)
+	return &Result{
+		Format:   FormatFLAC,
+		Duration: stream.Duration,
+		Bitrate:  stream.Bitrate,
+		Streams:  []*Stream{stream},
+	}, nil
+}
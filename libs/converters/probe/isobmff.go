@@ -0,0 +1,288 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+)
+
+// box is an ISO BMFF (MP4/MOV) atom
+type box struct {
+	typ       string
+	dataStart int64
+	end       int64
+}
+
+func isISOBMFF(head []byte) bool {
+	switch string(head[4:8]) {
+	case "ftyp", "moov", "mdat", "wide", "free", "skip":
+		return true
+	}
+	return false
+}
+
+func probeISOBMFF(r io.ReaderAt, size int64) (*Result, error) {
+	var (
+		res      = &Result{Format: FormatMP4}
+		haveMoov bool
+	)
+	err := readBoxes(r, 0, size, func(b box) error {
+		switch b.typ {
+		case "ftyp":
+			if data, err := readAt(r, b.dataStart, 4); err == nil && string(data) == "qt  " {
+				res.Format = FormatMOV
+			}
+		case "moov":
+			haveMoov = true
+			return parseMoov(r, b, res)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !haveMoov {
+		return nil, ErrCorruptedData
+	}
+	res.Bitrate = bitrate(size, res.Duration)
+	return res, nil
+}
+
+// readBoxes iterates over the sibling boxes in the range [start, end)
+func readBoxes(r io.ReaderAt, start, end int64, fn func(b box) error) error {
+	for offset := start; offset+8 <= end; {
+		head, err := readAt(r, offset, 8)
+		if err != nil {
+			return err
+		}
+		var (
+			size      = int64(binary.BigEndian.Uint32(head))
+			headerLen = int64(8)
+		)
+		switch size {
+		case 0: // box extends to the end of the file
+			size = end - offset
+		case 1: // 64-bit box size
+			ext, err := readAt(r, offset+8, 8)
+			if err != nil {
+				return err
+			}
+			size, headerLen = int64(binary.BigEndian.Uint64(ext)), 16
+		}
+		if size < headerLen || offset+size > end {
+			return ErrCorruptedData
+		}
+		if err = fn(box{typ: string(head[4:8]), dataStart: offset + headerLen, end: offset + size}); err != nil {
+			return err
+		}
+		offset += size
+	}
+	return nil
+}
+
+func parseMoov(r io.ReaderAt, moov box, res *Result) error {
+	return readBoxes(r, moov.dataStart, moov.end, func(b box) error {
+		switch b.typ {
+		case "mvhd":
+			data, err := readAt(r, b.dataStart, int(min(32, b.end-b.dataStart)))
+			if err != nil {
+				return err
+			}
+			timescale, duration := parseTimeHeader(data)
+			if timescale > 0 {
+				res.Duration = float64(duration) / float64(timescale)
+			}
+		case "trak":
+			stream, rotation, err := parseTrak(r, b)
+			if err != nil {
+				return err
+			}
+			if stream != nil {
+				stream.Index = len(res.Streams)
+				res.Streams = append(res.Streams, stream)
+				if stream.Type == StreamTypeVideo && res.Rotation == 0 {
+					res.Rotation = rotation
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// parseTrak returns the stream description of the track or nil for
+// non-media tracks (hints, subtitles, metadata)
+func parseTrak(r io.ReaderAt, trak box) (*Stream, int, error) {
+	var (
+		stream    = &Stream{}
+		rotation  int
+		dataSize  int64
+		timescale uint32
+		duration  uint64
+	)
+	var walk func(b box) error
+	walk = func(b box) error {
+		switch b.typ {
+		case "mdia", "minf", "stbl":
+			return readBoxes(r, b.dataStart, b.end, walk)
+		case "tkhd":
+			data, err := readAt(r, b.dataStart, int(min(96, b.end-b.dataStart)))
+			if err != nil {
+				return err
+			}
+			rotation = parseTrackRotation(data)
+		case "mdhd":
+			data, err := readAt(r, b.dataStart, int(min(32, b.end-b.dataStart)))
+			if err != nil {
+				return err
+			}
+			timescale, duration = parseTimeHeader(data)
+		case "hdlr":
+			data, err := readAt(r, b.dataStart, 12)
+			if err != nil {
+				return err
+			}
+			switch string(data[8:12]) {
+			case "vide":
+				stream.Type = StreamTypeVideo
+			case "soun":
+				stream.Type = StreamTypeAudio
+			}
+		case "stsd":
+			data, err := readAt(r, b.dataStart, int(min(44, b.end-b.dataStart)))
+			if err != nil {
+				return err
+			}
+			parseSampleDescription(data, stream)
+		case "stsz":
+			size, err := sampleDataSize(r, b)
+			if err != nil {
+				return err
+			}
+			dataSize = size
+		}
+		return nil
+	}
+	if err := readBoxes(r, trak.dataStart, trak.end, walk); err != nil {
+		return nil, 0, err
+	}
+	if stream.Type == "" {
+		return nil, 0, nil
+	}
+	if timescale > 0 {
+		stream.Duration = float64(duration) / float64(timescale)
+	}
+	stream.Bitrate = bitrate(dataSize, stream.Duration)
+	return stream, rotation, nil
+}
+
+// parseTimeHeader extracts timescale and duration from mvhd/mdhd boxes
+func parseTimeHeader(data []byte) (timescale uint32, duration uint64) {
+	if len(data) >= 32 && data[0] == 1 {
+		return binary.BigEndian.Uint32(data[20:24]), binary.BigEndian.Uint64(data[24:32])
+	}
+	if len(data) >= 20 {
+		return binary.BigEndian.Uint32(data[12:16]), uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+	return 0, 0
+}
+
+// parseTrackRotation computes the clockwise rotation from the tkhd matrix
+func parseTrackRotation(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+	offset := 4 + 20 // version/flags + v0 times
+	if data[0] == 1 {
+		offset = 4 + 32
+	}
+	offset += 16 // reserved, layer, alternate group, volume
+	if len(data) < offset+20 {
+		return 0
+	}
+	var (
+		a = int32(binary.BigEndian.Uint32(data[offset:]))
+		b = int32(binary.BigEndian.Uint32(data[offset+4:]))
+	)
+	degrees := int(math.Round(math.Atan2(float64(b), float64(a)) * 180 / math.Pi))
+	return (degrees + 360) % 360
+}
+
+// parseSampleDescription reads the first sample entry of the stsd box
+func parseSampleDescription(data []byte, stream *Stream) {
+	if len(data) < 16 {
+		return
+	}
+	stream.Codec = codecByFourCC(string(data[12:16]))
+	entry := data[16:]
+	switch stream.Type {
+	case StreamTypeVideo:
+		if len(entry) >= 28 {
+			stream.Width = int(binary.BigEndian.Uint16(entry[24:26]))
+			stream.Height = int(binary.BigEndian.Uint16(entry[26:28]))
+		}
+	case StreamTypeAudio:
+		if len(entry) >= 28 {
+			stream.Channels = int(binary.BigEndian.Uint16(entry[16:18]))
+			stream.BitsPerSample = int(binary.BigEndian.Uint16(entry[18:20]))
+			stream.SampleRate = int(binary.BigEndian.Uint32(entry[24:28]) >> 16)
+		}
+	}
+}
+
+// sampleDataSize sums the sample sizes of the track
+func sampleDataSize(r io.ReaderAt, b box) (int64, error) {
+	head, err := readAt(r, b.dataStart, 12)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		sampleSize = int64(binary.BigEndian.Uint32(head[4:8]))
+		count      = int64(binary.BigEndian.Uint32(head[8:12]))
+	)
+	if sampleSize != 0 {
+		return sampleSize * count, nil
+	}
+	if b.dataStart+12+count*4 > b.end {
+		return 0, ErrCorruptedData
+	}
+	table, err := readAt(r, b.dataStart+12, int(count*4))
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for i := 0; i < len(table); i += 4 {
+		total += int64(binary.BigEndian.Uint32(table[i:]))
+	}
+	return total, nil
+}
+
+func codecByFourCC(fourcc string) string {
+	switch fourcc {
+	case "avc1", "avc3":
+		return "h264"
+	case "hvc1", "hev1":
+		return "hevc"
+	case "vp08":
+		return "vp8"
+	case "vp09":
+		return "vp9"
+	case "av01":
+		return "av1"
+	case "mp4v":
+		return "mpeg4"
+	case "mp4a":
+		return "aac"
+	case "Opus":
+		return "opus"
+	case "fLaC":
+		return "flac"
+	case "ac-3":
+		return "ac3"
+	case "ec-3":
+		return "eac3"
+	case ".mp3":
+		return "mp3"
+	}
+	return strings.TrimSpace(strings.ToLower(fourcc))
+}
@@ -0,0 +1,279 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// Matroska/WebM element IDs
+const (
+	ebmlHeaderID       = 0x1A45DFA3
+	ebmlDocTypeID      = 0x4282
+	mkvSegmentID       = 0x18538067
+	mkvInfoID          = 0x1549A966
+	mkvTimecodeScaleID = 0x2AD7B1
+	mkvDurationID      = 0x4489
+	mkvTracksID        = 0x1654AE6B
+	mkvTrackEntryID    = 0xAE
+	mkvTrackTypeID     = 0x83
+	mkvCodecID         = 0x86
+	mkvVideoID         = 0xE0
+	mkvPixelWidthID    = 0xB0
+	mkvPixelHeightID   = 0xBA
+	mkvAudioID         = 0xE1
+	mkvSamplingFreqID  = 0xB5
+	mkvChannelsID      = 0x9F
+	mkvBitDepthID      = 0x6264
+	mkvClusterID       = 0x1F43B675
+)
+
+// ebmlElement is a single EBML element header
+type ebmlElement struct {
+	id        uint32
+	dataStart int64
+	end       int64 // -1 for the unknown size
+}
+
+func probeMatroska(r io.ReaderAt, size int64) (*Result, error) {
+	var (
+		res           = &Result{Format: FormatMatroska}
+		timecodeScale = uint64(1000000)
+		duration      float64
+	)
+	err := readElements(r, 0, size, func(el ebmlElement) (bool, error) {
+		switch el.id {
+		case ebmlHeaderID:
+			return true, readElements(r, el.dataStart, el.end, func(el ebmlElement) (bool, error) {
+				if el.id == ebmlDocTypeID {
+					data, err := readAt(r, el.dataStart, int(el.end-el.dataStart))
+					if err == nil && strings.TrimRight(string(data), "\x00") == "webm" {
+						res.Format = FormatWebM
+					}
+				}
+				return true, nil
+			})
+		case mkvSegmentID:
+			end := el.end
+			if end < 0 {
+				end = size
+			}
+			return false, readElements(r, el.dataStart, end, func(el ebmlElement) (bool, error) {
+				switch el.id {
+				case mkvInfoID:
+					return true, readElements(r, el.dataStart, el.end, func(el ebmlElement) (bool, error) {
+						var err error
+						switch el.id {
+						case mkvTimecodeScaleID:
+							timecodeScale, err = readEBMLUint(r, el)
+						case mkvDurationID:
+							duration, err = readEBMLFloat(r, el)
+						}
+						return true, err
+					})
+				case mkvTracksID:
+					return true, readElements(r, el.dataStart, el.end, func(el ebmlElement) (bool, error) {
+						if el.id != mkvTrackEntryID {
+							return true, nil
+						}
+						stream, err := parseTrackEntry(r, el)
+						if err == nil && stream != nil {
+							stream.Index = len(res.Streams)
+							res.Streams = append(res.Streams, stream)
+						}
+						return true, err
+					})
+				case mkvClusterID:
+					// Media data starts, all headers are already read
+					return false, nil
+				}
+				return true, nil
+			})
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Duration = duration * float64(timecodeScale) / 1e9
+	res.Bitrate = bitrate(size, res.Duration)
+	return res, nil
+}
+
+func parseTrackEntry(r io.ReaderAt, entry ebmlElement) (*Stream, error) {
+	stream := &Stream{}
+	err := readElements(r, entry.dataStart, entry.end, func(el ebmlElement) (bool, error) {
+		switch el.id {
+		case mkvTrackTypeID:
+			typ, err := readEBMLUint(r, el)
+			switch typ {
+			case 1:
+				stream.Type = StreamTypeVideo
+			case 2:
+				stream.Type = StreamTypeAudio
+			}
+			return true, err
+		case mkvCodecID:
+			data, err := readAt(r, el.dataStart, int(el.end-el.dataStart))
+			stream.Codec = codecByMatroskaID(strings.TrimRight(string(data), "\x00"))
+			return true, err
+		case mkvVideoID, mkvAudioID:
+			return true, readElements(r, el.dataStart, el.end, func(el ebmlElement) (bool, error) {
+				var (
+					val uint64
+					err error
+				)
+				switch el.id {
+				case mkvPixelWidthID:
+					val, err = readEBMLUint(r, el)
+					stream.Width = int(val)
+				case mkvPixelHeightID:
+					val, err = readEBMLUint(r, el)
+					stream.Height = int(val)
+				case mkvChannelsID:
+					val, err = readEBMLUint(r, el)
+					stream.Channels = int(val)
+				case mkvBitDepthID:
+					val, err = readEBMLUint(r, el)
+					stream.BitsPerSample = int(val)
+				case mkvSamplingFreqID:
+					var freq float64
+					freq, err = readEBMLFloat(r, el)
+					stream.SampleRate = int(freq)
+				}
+				return true, err
+			})
+		}
+		return true, nil
+	})
+	if err != nil || stream.Type == "" {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// readElements iterates over the sibling elements in the range [start, end).
+// The callback returns false to stop the iteration.
+func readElements(r io.ReaderAt, start, end int64, fn func(el ebmlElement) (bool, error)) error {
+	for offset := start; offset < end; {
+		id, idLen, err := readVint(r, offset, true)
+		if err != nil {
+			return err
+		}
+		size, sizeLen, err := readVint(r, offset+int64(idLen), false)
+		if err != nil {
+			return err
+		}
+		el := ebmlElement{id: uint32(id), dataStart: offset + int64(idLen+sizeLen), end: -1}
+		if size == unknownVintSize {
+			// Only the live streamed segment and its clusters have no size
+			if el.id != mkvSegmentID && el.id != mkvClusterID {
+				return ErrCorruptedData
+			}
+		} else {
+			if el.dataStart > end || size > uint64(end-el.dataStart) {
+				return ErrCorruptedData
+			}
+			el.end = el.dataStart + int64(size)
+		}
+		next, err := fn(el)
+		if err != nil || !next || el.end < 0 {
+			return err
+		}
+		offset = el.end
+	}
+	return nil
+}
+
+const unknownVintSize = math.MaxUint64
+
+// readVint reads the EBML variable length integer. IDs keep the length marker.
+func readVint(r io.ReaderAt, offset int64, keepMarker bool) (uint64, int, error) {
+	first, err := readAt(r, offset, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	length := bits.LeadingZeros8(first[0]) + 1
+	if length > 8 {
+		return 0, 0, ErrCorruptedData
+	}
+	data, err := readAt(r, offset, length)
+	if err != nil {
+		return 0, 0, err
+	}
+	var (
+		value   uint64
+		allOnes = true
+		mask    = byte(0xff >> length)
+	)
+	if keepMarker {
+		mask = 0xff
+	}
+	for i, b := range data {
+		if i == 0 {
+			b &= mask
+			allOnes = b == byte(0xff>>length)
+		} else {
+			allOnes = allOnes && b == 0xff
+		}
+		value = value<<8 | uint64(b)
+	}
+	if !keepMarker && allOnes {
+		return unknownVintSize, length, nil
+	}
+	return value, length, nil
+}
+
+func readEBMLUint(r io.ReaderAt, el ebmlElement) (uint64, error) {
+	size := int(el.end - el.dataStart)
+	if size > 8 {
+		return 0, ErrCorruptedData
+	}
+	data, err := readAt(r, el.dataStart, size)
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value, nil
+}
+
+func readEBMLFloat(r io.ReaderAt, el ebmlElement) (float64, error) {
+	if el.end-el.dataStart > 8 {
+		return 0, ErrCorruptedData
+	}
+	data, err := readAt(r, el.dataStart, int(el.end-el.dataStart))
+	if err != nil {
+		return 0, err
+	}
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	case 0:
+		return 0, nil
+	}
+	return 0, ErrCorruptedData
+}
+
+func codecByMatroskaID(codecID string) string {
+	switch {
+	case codecID == "V_MPEG4/ISO/AVC":
+		return "h264"
+	case codecID == "V_MPEGH/ISO/HEVC":
+		return "hevc"
+	case codecID == "A_MPEG/L3":
+		return "mp3"
+	case strings.HasPrefix(codecID, "A_AAC"):
+		return "aac"
+	case strings.HasPrefix(codecID, "A_PCM"):
+		return "pcm"
+	}
+	_, codec, _ := strings.Cut(codecID, "_")
+	return strings.ToLower(codec)
+}
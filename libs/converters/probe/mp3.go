@@ -0,0 +1,173 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// mp3SyncSearchLimit is the maximal amount of bytes scanned for the first frame
+const mp3SyncSearchLimit = 64 * 1024
+
+// Bitrates in kbit/s indexed by [version][layer][index]
+var mpegBitrates = [2][3][15]int{
+	{ // MPEG-1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{ // MPEG-2 & MPEG-2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+var mpegSampleRates = [3]int{44100, 48000, 32000}
+
+// mpegFrame is the parsed MPEG audio frame header
+type mpegFrame struct {
+	version    int // 1, 2 or 25 (MPEG-2.5)
+	layer      int // 1..3
+	bitrate    int // bits per second
+	sampleRate int
+	channels   int
+}
+
+func (f *mpegFrame) samplesPerFrame() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && f.version != 1:
+		return 576
+	}
+	return 1152
+}
+
+// sideInfoSize returns the size of the Layer III side information
+func (f *mpegFrame) sideInfoSize() int {
+	switch {
+	case f.version == 1 && f.channels == 1:
+		return 17
+	case f.version == 1:
+		return 32
+	case f.channels == 1:
+		return 9
+	}
+	return 17
+}
+
+func (f *mpegFrame) codec() string {
+	return [...]string{"mp1", "mp2", "mp3"}[f.layer-1]
+}
+
+func isMPEGFrameSync(head []byte) bool {
+	_, ok := parseMPEGFrame(head)
+	return ok
+}
+
+func parseMPEGFrame(head []byte) (*mpegFrame, bool) {
+	if len(head) < 4 || head[0] != 0xff || head[1]&0xe0 != 0xe0 {
+		return nil, false
+	}
+	var (
+		h            = binary.BigEndian.Uint32(head)
+		versionBits  = (h >> 19) & 3
+		layerBits    = (h >> 17) & 3
+		bitrateIndex = (h >> 12) & 0xf
+		rateIndex    = (h >> 10) & 3
+		frame        = &mpegFrame{channels: 2}
+	)
+	if versionBits == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return nil, false
+	}
+	frame.layer = 4 - int(layerBits)
+	frame.sampleRate = mpegSampleRates[rateIndex]
+	switch versionBits {
+	case 3:
+		frame.version = 1
+	case 2:
+		frame.version = 2
+		frame.sampleRate /= 2
+	default:
+		frame.version = 25
+		frame.sampleRate /= 4
+	}
+	table := 0
+	if frame.version != 1 {
+		table = 1
+	}
+	frame.bitrate = mpegBitrates[table][frame.layer-1][bitrateIndex] * 1000
+	if (h>>6)&3 == 3 {
+		frame.channels = 1
+	}
+	return frame, true
+}
+
+func probeMP3(r io.ReaderAt, offset, size int64) (*Result, error) {
+	buf, err := readAt(r, offset, int(min(mp3SyncSearchLimit, size-offset)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the first valid frame header
+	var frame *mpegFrame
+	pos := 0
+	for ; pos+4 <= len(buf); pos++ {
+		if frame, _ = parseMPEGFrame(buf[pos:]); frame != nil {
+			break
+		}
+	}
+	if frame == nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	var (
+		audioStart = offset + int64(pos)
+		audioSize  = size - audioStart
+		frames     int64
+		duration   float64
+	)
+	if tail, err := readAt(r, size-128, 3); err == nil && string(tail) == "TAG" {
+		audioSize -= 128 // ID3v1
+	}
+
+	// VBR files carry the total frames count in the Xing/Info or VBRI header
+	if xing := pos + 4 + frame.sideInfoSize(); xing+16 <= len(buf) &&
+		(bytes.HasPrefix(buf[xing:], []byte("Xing")) || bytes.HasPrefix(buf[xing:], []byte("Info"))) {
+		flags := binary.BigEndian.Uint32(buf[xing+4:])
+		next := xing + 8
+		if flags&1 != 0 {
+			frames = int64(binary.BigEndian.Uint32(buf[next:]))
+			next += 4
+		}
+		if flags&2 != 0 && next+4 <= len(buf) {
+			audioSize = int64(binary.BigEndian.Uint32(buf[next:]))
+		}
+	} else if vbri := pos + 4 + 32; vbri+18 <= len(buf) && bytes.HasPrefix(buf[vbri:], []byte("VBRI")) {
+		audioSize = int64(binary.BigEndian.Uint32(buf[vbri+10:]))
+		frames = int64(binary.BigEndian.Uint32(buf[vbri+14:]))
+	}
+
+	avgBitrate := int64(frame.bitrate)
+	if frames > 0 {
+		duration = float64(frames) * float64(frame.samplesPerFrame()) / float64(frame.sampleRate)
+		avgBitrate = bitrate(audioSize, duration)
+	} else {
+		duration = float64(audioSize) * 8 / float64(frame.bitrate)
+	}
+
+	return &Result{
+		Format:   FormatMP3,
+		Duration: duration,
+		Bitrate:  avgBitrate,
+		Streams: []*Stream{{
+			Type:       StreamTypeAudio,
+			Codec:      frame.codec(),
+			SampleRate: frame.sampleRate,
+			Channels:   frame.channels,
+			Duration:   duration,
+			Bitrate:    avgBitrate,
+		}},
+	}, nil
+}
@@ -0,0 +1,185 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+// Error list...
+var (
+	ErrUnsupportedFormat = errors.New("[probe] unsupported media format")
+	ErrCorruptedData     = errors.New("[probe] corrupted media data")
+)
+
+// Container formats
+const (
+	FormatMP4      = "mp4"
+	FormatMOV      = "mov"
+	FormatWebM     = "webm"
+	FormatMatroska = "matroska"
+	FormatMP3      = "mp3"
+	FormatWAV      = "wav"
+	FormatFLAC     = "flac"
+)
+
+// Stream types
+const (
+	StreamTypeVideo = "video"
+	StreamTypeAudio = "audio"
+)
+
+// Stream describes a single media track of the container
+type Stream struct {
+	Index         int     `json:"index"`
+	Type          string  `json:"type"`
+	Codec         string  `json:"codec"`
+	Width         int     `json:"width,omitempty"`
+	Height        int     `json:"height,omitempty"`
+	SampleRate    int     `json:"sample_rate,omitempty"`
+	Channels      int     `json:"channels,omitempty"`
+	BitsPerSample int     `json:"bits_per_sample,omitempty"`
+	Duration      float64 `json:"duration,omitempty"` // seconds
+	Bitrate       int64   `json:"bitrate,omitempty"`  // bits per second
+}
+
+// Result of the media probing
+type Result struct {
+	Format   string    `json:"format"`
+	Duration float64   `json:"duration"`           // seconds
+	Bitrate  int64     `json:"bitrate,omitempty"`  // overall bits per second
+	Rotation int       `json:"rotation,omitempty"` // clockwise degrees of the video
+	Streams  []*Stream `json:"streams,omitempty"`
+}
+
+// Stream returns the first stream of the given type or nil
+func (res *Result) Stream(typ string) *Stream {
+	for _, stream := range res.Streams {
+		if stream.Type == typ {
+			return stream
+		}
+	}
+	return nil
+}
+
+// Outputs returns the flat list of job outputs which could be used
+// in the if: conditions, e.g. ${{ probe.outputs.duration < 3600 }}
+func (res *Result) Outputs() map[string]any {
+	outputs := map[string]any{
+		"format":    res.Format,
+		"duration":  res.Duration,
+		"bitrate":   res.Bitrate,
+		"rotation":  res.Rotation,
+		"streams":   res.Streams,
+		"has_video": false,
+		"has_audio": false,
+	}
+	if video := res.Stream(StreamTypeVideo); video != nil {
+		outputs["has_video"] = true
+		outputs["video_codec"] = video.Codec
+		outputs["width"] = video.Width
+		outputs["height"] = video.Height
+	}
+	if audio := res.Stream(StreamTypeAudio); audio != nil {
+		outputs["has_audio"] = true
+		outputs["audio_codec"] = audio.Codec
+		outputs["sample_rate"] = audio.SampleRate
+		outputs["channels"] = audio.Channels
+	}
+	return outputs
+}
+
+// ItemMeta returns the standard media properties of the source file
+func (res *Result) ItemMeta() *models.ItemMeta {
+	meta := &models.ItemMeta{
+		Duration: int(math.Round(res.Duration)),
+	}
+	if res.Bitrate > 0 {
+		meta.Bitrate = strconv.FormatInt(res.Bitrate, 10)
+	}
+	if video := res.Stream(StreamTypeVideo); video != nil {
+		meta.Type = models.TypeVideo
+		meta.Codec = video.Codec
+		meta.Width, meta.Height = video.Width, video.Height
+	} else if audio := res.Stream(StreamTypeAudio); audio != nil {
+		meta.Type = models.TypeAudio
+		meta.Codec = audio.Codec
+	}
+	meta.SetAttribute("format", res.Format)
+	if res.Rotation != 0 {
+		meta.SetAttribute("rotation", res.Rotation)
+	}
+	return meta
+}
+
+// Probe detects the container format and parses its headers.
+// Only the headers are read, the media payload is skipped.
+func Probe(r io.ReaderAt, size int64) (*Result, error) {
+	var (
+		head   = make([]byte, 12)
+		offset = int64(0)
+	)
+	if _, err := r.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	// ID3v2 tag can prefix MP3 and FLAC streams
+	if bytes.HasPrefix(head, []byte("ID3")) {
+		offset = id3v2Size(head)
+		if _, err := r.ReadAt(head, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	switch {
+	case offset == 0 && isISOBMFF(head):
+		return probeISOBMFF(r, size)
+	case offset == 0 && binary.BigEndian.Uint32(head) == ebmlHeaderID:
+		return probeMatroska(r, size)
+	case offset == 0 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return probeWAV(r, size)
+	case string(head[:4]) == "fLaC":
+		return probeFLAC(r, offset, size)
+	case offset > 0 || isMPEGFrameSync(head):
+		return probeMP3(r, offset, size)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// id3v2Size returns the full size of the ID3v2 tag from its header
+func id3v2Size(head []byte) int64 {
+	size := int64(head[6]&0x7f)<<21 | int64(head[7]&0x7f)<<14 |
+		int64(head[8]&0x7f)<<7 | int64(head[9]&0x7f)
+	size += 10
+	if head[5]&0x10 != 0 { // footer present
+		size += 10
+	}
+	return size
+}
+
+func readAt(r io.ReaderAt, offset int64, n int) ([]byte, error) {
+	if n < 0 || offset < 0 {
+		return nil, ErrCorruptedData
+	}
+	buf := make([]byte, n)
+	cnt, err := r.ReadAt(buf, offset)
+	if cnt == n {
+		return buf, nil
+	}
+	if err == nil || err == io.EOF {
+		err = ErrCorruptedData
+	}
+	return nil, err
+}
+
+func bitrate(size int64, duration float64) int64 {
+	if duration <= 0 {
+		return 0
+	}
+	return int64(math.Round(float64(size) * 8 / duration))
+}
@@ -0,0 +1,361 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+func probeBytes(t *testing.T, data []byte) *Result {
+	t.Helper()
+	res, err := Probe(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	return res
+}
+
+func TestProbeWAV(t *testing.T) {
+	res := probeBytes(t, buildWAV(44100, 2, 16, 44100*4))
+	assert.Equal(t, FormatWAV, res.Format)
+	assert.InDelta(t, 1.0, res.Duration, 0.001)
+	assert.Equal(t, int64(1411200), res.Bitrate)
+	require.Len(t, res.Streams, 1)
+	assert.Equal(t, "pcm_s16le", res.Streams[0].Codec)
+	assert.Equal(t, 2, res.Streams[0].Channels)
+	assert.Equal(t, 44100, res.Streams[0].SampleRate)
+}
+
+func TestProbeFLAC(t *testing.T) {
+	res := probeBytes(t, buildFLAC(48000, 2, 24, 96000))
+	assert.Equal(t, FormatFLAC, res.Format)
+	assert.InDelta(t, 2.0, res.Duration, 0.001)
+	require.Len(t, res.Streams, 1)
+	assert.Equal(t, "flac", res.Streams[0].Codec)
+	assert.Equal(t, 48000, res.Streams[0].SampleRate)
+	assert.Equal(t, 2, res.Streams[0].Channels)
+	assert.Equal(t, 24, res.Streams[0].BitsPerSample)
+}
+
+func TestProbeMP3(t *testing.T) {
+	t.Run("xing", func(t *testing.T) {
+		res := probeBytes(t, buildMP3(true, 100, 4000))
+		assert.Equal(t, FormatMP3, res.Format)
+		assert.InDelta(t, 100*1152/44100.0, res.Duration, 0.001)
+		require.Len(t, res.Streams, 1)
+		assert.Equal(t, "mp3", res.Streams[0].Codec)
+		assert.Equal(t, 44100, res.Streams[0].SampleRate)
+		assert.Equal(t, 2, res.Streams[0].Channels)
+	})
+	t.Run("cbr", func(t *testing.T) {
+		data := buildMP3(false, 0, 16000)
+		res := probeBytes(t, data)
+		assert.InDelta(t, float64(len(data)-10)*8/128000, res.Duration, 0.001)
+		assert.Equal(t, int64(128000), res.Bitrate)
+	})
+}
+
+func TestProbeMP4(t *testing.T) {
+	res := probeBytes(t, buildMP4())
+	assert.Equal(t, FormatMP4, res.Format)
+	assert.InDelta(t, 10.0, res.Duration, 0.001)
+	assert.Equal(t, 90, res.Rotation)
+	require.Len(t, res.Streams, 2)
+
+	video := res.Stream(StreamTypeVideo)
+	require.NotNil(t, video)
+	assert.Equal(t, "h264", video.Codec)
+	assert.Equal(t, 1920, video.Width)
+	assert.Equal(t, 1080, video.Height)
+	assert.Equal(t, int64(300*1000*8/10), video.Bitrate)
+
+	audio := res.Stream(StreamTypeAudio)
+	require.NotNil(t, audio)
+	assert.Equal(t, "aac", audio.Codec)
+	assert.Equal(t, 44100, audio.SampleRate)
+	assert.Equal(t, 2, audio.Channels)
+	assert.InDelta(t, 10.0, audio.Duration, 0.001)
+}
+
+func TestProbeWebM(t *testing.T) {
+	res := probeBytes(t, buildWebM())
+	assert.Equal(t, FormatWebM, res.Format)
+	assert.InDelta(t, 5.0, res.Duration, 0.001)
+	require.Len(t, res.Streams, 2)
+	assert.Equal(t, &Stream{Index: 0, Type: StreamTypeVideo, Codec: "vp9", Width: 640, Height: 360}, res.Streams[0])
+	assert.Equal(t, &Stream{Index: 1, Type: StreamTypeAudio, Codec: "opus", SampleRate: 48000, Channels: 2}, res.Streams[1])
+}
+
+func TestProbeUnsupported(t *testing.T) {
+	data := []byte("plain text content")
+	_, err := Probe(bytes.NewReader(data), int64(len(data)))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestProbeMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "mp4 empty tkhd",
+			data: bytes.Join([][]byte{
+				mp4Box("ftyp", []byte("isom"), u32(512)),
+				mp4Box("moov", mp4Box("trak", mp4Box("tkhd"))),
+			}, nil),
+		},
+		{
+			name: "webm unknown size info",
+			data: bytes.Join([][]byte{
+				ebml(ebmlHeaderID, ebml(ebmlDocTypeID, []byte("webm"))),
+				ebmlUnknown(mkvSegmentID, ebmlUnknown(mkvInfoID, ebml(mkvDurationID, u32(0)))),
+			}, nil),
+		},
+		{
+			name: "webm unknown size track entry",
+			data: bytes.Join([][]byte{
+				ebml(ebmlHeaderID),
+				ebmlUnknown(mkvSegmentID, ebml(mkvTracksID, ebmlUnknown(mkvTrackEntryID))),
+			}, nil),
+		},
+		{
+			name: "webm unknown size video",
+			data: bytes.Join([][]byte{
+				ebml(ebmlHeaderID),
+				ebmlUnknown(mkvSegmentID, ebml(mkvTracksID, ebml(mkvTrackEntryID,
+					ebml(mkvTrackTypeID, []byte{1}),
+					ebmlUnknown(mkvVideoID, ebml(mkvPixelWidthID, u16(640))),
+				))),
+			}, nil),
+		},
+		{
+			name: "webm unknown size codec",
+			data: bytes.Join([][]byte{
+				ebml(ebmlHeaderID),
+				ebmlUnknown(mkvSegmentID, ebml(mkvTracksID, ebml(mkvTrackEntryID, ebmlUnknown(mkvCodecID)))),
+			}, nil),
+		},
+		{
+			name: "webm unknown size doctype",
+			data: ebml(ebmlHeaderID, ebmlUnknown(ebmlDocTypeID, []byte("webm"))),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := Probe(bytes.NewReader(test.data), int64(len(test.data)))
+			if err != nil {
+				assert.ErrorIs(t, err, ErrCorruptedData)
+				return
+			}
+			assert.Empty(t, res.Streams)
+		})
+	}
+}
+
+func FuzzProbe(f *testing.F) {
+	f.Add(buildWAV(44100, 2, 16, 64))
+	f.Add(buildFLAC(48000, 2, 24, 96000))
+	f.Add(buildMP3(true, 100, 512))
+	f.Add(buildMP4())
+	f.Add(buildWebM())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = Probe(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+func TestStepRunner(t *testing.T) {
+	runner := New()
+	step := &models.WorkflowStep{Name: "probe", Uses: UsesProbe}
+	assert.True(t, runner.CanRun(step))
+	assert.False(t, runner.CanRun(&models.WorkflowStep{Uses: "image/resize"}))
+
+	// Non-seekable input is spooled into a temporary file
+	out, err := runner.Run(context.Background(), step, workflow.StepInput{
+		Reader: io.MultiReader(bytes.NewReader(buildMP4())),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, out.Writer)
+	assert.Equal(t, 10.0, out.Outputs["duration"])
+	assert.Equal(t, "h264", out.Outputs["video_codec"])
+	assert.Equal(t, true, out.Outputs["has_audio"])
+	assert.Equal(t, 10, out.ItemMeta.Duration)
+	assert.Equal(t, "h264", out.ItemMeta.Codec)
+	assert.Equal(t, 1920, out.ItemMeta.Width)
+
+	// Result is usable in the if: conditions
+	state := &models.ProcessingState{Jobs: map[string]*models.JobState{
+		"probe": {Status: models.JobStatusCompleted, Outputs: out.Outputs},
+	}}
+	skip, err := workflow.EvaluateIf("${{ probe.outputs.duration < 3600 }}", state)
+	require.NoError(t, err)
+	assert.False(t, skip)
+}
+
+// ── Test media builders ───────────────────────────────────────────────────────
+
+func buildWAV(sampleRate, channels, bits, dataSize int) []byte {
+	var (
+		buf        bytes.Buffer
+		blockAlign = channels * bits / 8
+	)
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16), uint16(1), uint16(channels), uint32(sampleRate),
+		uint32(sampleRate * blockAlign), uint16(blockAlign), uint16(bits),
+	} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+func buildFLAC(sampleRate, channels, bits int, totalSamples int64) []byte {
+	info := make([]byte, flacStreamInfoSize)
+	info[10] = byte(sampleRate >> 12)
+	info[11] = byte(sampleRate >> 4)
+	info[12] = byte(sampleRate<<4) | byte(channels-1)<<1 | byte((bits-1)>>4)
+	info[13] = byte((bits-1)&0x0f)<<4 | byte(totalSamples>>32)
+	binary.BigEndian.PutUint32(info[14:], uint32(totalSamples))
+	data := append([]byte("fLaC\x80\x00\x00\x22"), info...)
+	return append(data, make([]byte, 1024)...)
+}
+
+func buildMP3(xing bool, frames, size int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("ID3\x04\x00\x00\x00\x00\x00\x00") // empty ID3v2 tag
+	buf.Write([]byte{0xff, 0xfb, 0x90, 0x64})          // MPEG-1 Layer III 128kbps 44.1kHz
+	if xing {
+		buf.Write(make([]byte, 32))
+		buf.WriteString("Xing")
+		_ = binary.Write(&buf, binary.BigEndian, []uint32{3, uint32(frames), uint32(size)})
+	}
+	buf.Write(make([]byte, size-buf.Len()+10))
+	return buf.Bytes()
+}
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint32(head, uint32(len(data)+8))
+	copy(head[4:], typ)
+	return append(head, data...)
+}
+
+func u32(vals ...uint32) []byte {
+	data := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.BigEndian.PutUint32(data[i*4:], v)
+	}
+	return data
+}
+
+func u16(vals ...uint16) []byte {
+	data := make([]byte, 2*len(vals))
+	for i, v := range vals {
+		binary.BigEndian.PutUint16(data[i*2:], v)
+	}
+	return data
+}
+
+func buildMP4() []byte {
+	var (
+		one      = uint32(1 << 16)
+		minusOne = uint32(math.MaxUint32 - 1<<16 + 1)
+		tkhd     = func(matrix []uint32, width, height uint32) []byte {
+			return mp4Box("tkhd", u32(0, 0, 0, 1, 0, 0), make([]byte, 16), u32(matrix...), u32(width<<16, height<<16))
+		}
+		mdhd = func(timescale, duration uint32) []byte {
+			return mp4Box("mdhd", u32(0, 0, 0, timescale, duration, 0))
+		}
+		hdlr = func(typ string) []byte {
+			return mp4Box("hdlr", u32(0, 0), []byte(typ), make([]byte, 12))
+		}
+		videoEntry = mp4Box("avc1", make([]byte, 6), u16(1), make([]byte, 16), u16(1920, 1080), make([]byte, 50))
+		audioEntry = mp4Box("mp4a", make([]byte, 6), u16(1), make([]byte, 8), u16(2, 16, 0, 0), u32(44100<<16))
+	)
+	video := mp4Box("trak",
+		tkhd([]uint32{0, one, 0, minusOne, 0, 0, 0, 0, 1 << 30}, 1920, 1080),
+		mp4Box("mdia", mdhd(90000, 900000), hdlr("vide"),
+			mp4Box("minf", mp4Box("stbl",
+				mp4Box("stsd", u32(0, 1), videoEntry),
+				mp4Box("stsz", u32(0, 1000, 300)),
+			)),
+		),
+	)
+	audio := mp4Box("trak",
+		tkhd([]uint32{one, 0, 0, 0, one, 0, 0, 0, 1 << 30}, 0, 0),
+		mp4Box("mdia", mdhd(44100, 441000), hdlr("soun"),
+			mp4Box("minf", mp4Box("stbl",
+				mp4Box("stsd", u32(0, 1), audioEntry),
+				mp4Box("stsz", u32(0, 0, 3), u32(100, 200, 300)),
+			)),
+		),
+	)
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom"), u32(512), []byte("isomavc1")),
+		mp4Box("moov", mp4Box("mvhd", u32(0, 0, 0, 1000, 10000), make([]byte, 80)), video, audio),
+		mp4Box("mdat", make([]byte, 256)),
+	}, nil)
+}
+
+func ebml(id uint32, payload ...[]byte) []byte {
+	var (
+		data = bytes.Join(payload, nil)
+		out  []byte
+	)
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(data))|1<<56) // 8-byte size vint
+	return append(append(out, size...), data...)
+}
+
+func ebmlUnknown(id uint32, payload ...[]byte) []byte {
+	head := ebml(id)
+	copy(head[len(head)-8:], []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	return append(head, bytes.Join(payload, nil)...)
+}
+
+func buildWebM() []byte {
+	f64 := func(v float64) []byte {
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, math.Float64bits(v))
+		return data
+	}
+	return bytes.Join([][]byte{
+		ebml(ebmlHeaderID, ebml(ebmlDocTypeID, []byte("webm"))),
+		ebmlUnknown(mkvSegmentID,
+			ebml(mkvInfoID,
+				ebml(mkvTimecodeScaleID, u32(1000000)),
+				ebml(mkvDurationID, f64(5000)),
+			),
+			ebml(mkvTracksID,
+				ebml(mkvTrackEntryID,
+					ebml(mkvTrackTypeID, []byte{1}),
+					ebml(mkvCodecID, []byte("V_VP9")),
+					ebml(mkvVideoID, ebml(mkvPixelWidthID, u16(640)), ebml(mkvPixelHeightID, u16(360))),
+				),
+				ebml(mkvTrackEntryID,
+					ebml(mkvTrackTypeID, []byte{2}),
+					ebml(mkvCodecID, []byte("A_OPUS")),
+					ebml(mkvAudioID, ebml(mkvSamplingFreqID, f64(48000)), ebml(mkvChannelsID, []byte{2})),
+				),
+			),
+			ebmlUnknown(mkvClusterID, make([]byte, 64)),
+		),
+	}, nil)
+}
@@ -0,0 +1,84 @@
+package probe
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+// UsesProbe is the uses value handled by this runner
+const UsesProbe = "probe"
+
+// StepRunner is a workflow.StepRunner which extracts the media information
+// from the source file headers.
+type StepRunner struct{}
+
+// New creates the probe StepRunner
+func New() *StepRunner {
+	return &StepRunner{}
+}
+
+// CanRun returns true for the `uses: probe` steps
+func (r *StepRunner) CanRun(step *models.WorkflowStep) bool {
+	return step.Uses == UsesProbe
+}
+
+// NeedsSeekableInput returns true, the container headers are read by offsets
+func (r *StepRunner) NeedsSeekableInput(_ *models.WorkflowStep) bool {
+	return true
+}
+
+// Run probes the source file and publishes the media information as job outputs.
+// The standard properties (duration, bitrate, codec, dimensions) are also
+// stored into the source item meta.
+func (r *StepRunner) Run(_ context.Context, step *models.WorkflowStep, in workflow.StepInput) (workflow.StepOutput, error) {
+	if in.Reader == nil {
+		return workflow.StepOutput{}, errors.Errorf("probe step %q: no input", step.Name)
+	}
+	reader, size, release, err := readerAt(in.Reader)
+	if err != nil {
+		return workflow.StepOutput{}, errors.Wrap(err, "probe input")
+	}
+	defer release()
+
+	res, err := Probe(reader, size)
+	if err != nil {
+		return workflow.StepOutput{}, errors.Wrapf(err, "probe step %q", step.Name)
+	}
+	return workflow.StepOutput{
+		ItemMeta: res.ItemMeta(),
+		Outputs:  res.Outputs(),
+	}, nil
+}
+
+// readerAt returns random access to the input, spooling non-seekable
+// streams into a temporary file when the runner is used outside the executor.
+func readerAt(in io.Reader) (io.ReaderAt, int64, func(), error) {
+	if ra, ok := in.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		if size, err := ra.Seek(0, io.SeekEnd); err == nil {
+			return ra, size, func() {}, nil
+		}
+	}
+	file, err := os.CreateTemp("", "apfs-probe-*")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	release := func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+	size, err := io.Copy(file, in)
+	if err != nil {
+		release()
+		return nil, 0, nil, err
+	}
+	return file, size, release, nil
+}
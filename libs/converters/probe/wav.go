@@ -0,0 +1,88 @@
+package probe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// WAVE format codes
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatALaw       = 0x0006
+	wavFormatMuLaw      = 0x0007
+	wavFormatExtensible = 0xFFFE
+)
+
+func probeWAV(r io.ReaderAt, size int64) (*Result, error) {
+	var (
+		stream   *Stream
+		byteRate int64
+		dataSize int64 = -1
+	)
+	for offset := int64(12); offset+8 <= size && (stream == nil || dataSize < 0); {
+		head, err := readAt(r, offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		var (
+			chunkSize = int64(binary.LittleEndian.Uint32(head[4:8]))
+			dataStart = offset + 8
+		)
+		switch string(head[:4]) {
+		case "fmt ":
+			data, err := readAt(r, dataStart, int(min(chunkSize, 26)))
+			if err != nil || len(data) < 16 {
+				return nil, ErrCorruptedData
+			}
+			format := binary.LittleEndian.Uint16(data[0:2])
+			if format == wavFormatExtensible && len(data) >= 26 {
+				// The real format code is the first part of the sub-format GUID
+				format = binary.LittleEndian.Uint16(data[24:26])
+			}
+			stream = &Stream{
+				Type:          StreamTypeAudio,
+				Channels:      int(binary.LittleEndian.Uint16(data[2:4])),
+				SampleRate:    int(binary.LittleEndian.Uint32(data[4:8])),
+				BitsPerSample: int(binary.LittleEndian.Uint16(data[14:16])),
+			}
+			stream.Codec = wavCodec(format, stream.BitsPerSample)
+			byteRate = int64(binary.LittleEndian.Uint32(data[8:12]))
+		case "data":
+			// Streamed files could have the size undefined
+			dataSize = min(chunkSize, size-dataStart)
+		}
+		offset = dataStart + chunkSize + chunkSize&1 // chunks are word aligned
+	}
+	if stream == nil {
+		return nil, ErrCorruptedData
+	}
+	if byteRate > 0 && dataSize > 0 {
+		stream.Duration = float64(dataSize) / float64(byteRate)
+	}
+	stream.Bitrate = byteRate * 8
+	return &Result{
+		Format:   FormatWAV,
+		Duration: stream.Duration,
+		Bitrate:  stream.Bitrate,
+		Streams:  []*Stream{stream},
+	}, nil
+}
+
+func wavCodec(format uint16, bitsPerSample int) string {
+	switch format {
+	case wavFormatPCM:
+		if bitsPerSample == 8 {
+			return "pcm_u8"
+		}
+		return fmt.Sprintf("pcm_s%dle", bitsPerSample)
+	case wavFormatFloat:
+		return fmt.Sprintf("pcm_f%dle", bitsPerSample)
+	case wavFormatALaw:
+		return "pcm_alaw"
+	case wavFormatMuLaw:
+		return "pcm_mulaw"
+	}
+	return fmt.Sprintf("wav_0x%04x", format)
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package proc
+
+import "errors"
+
+// haveFifo reports whether the process output can be piped
+const haveFifo = false
+
+func mkfifo(string) error { return errors.ErrUnsupported }
+
+func releaseFifo(string) {}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package proc
+
+import (
+	"os"
+	"syscall"
+)
+
+// haveFifo reports whether the process output can be piped
+const haveFifo = true
+
+func mkfifo(path string) error {
+	return syscall.Mkfifo(path, 0o600)
+}
+
+// releaseFifo unblocks the reader which still waits for the writer
+func releaseFifo(path string) {
+	if file, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+		_ = file.Close()
+	}
+}
@@ -0,0 +1,137 @@
+package proc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demdxx/plugeproc/manifest"
+	"github.com/pkg/errors"
+)
+
+const (
+	// logCapturePollInterval of the stderr file of the running script
+	logCapturePollInterval = 200 * time.Millisecond
+
+	// logCaptureTailSize of the stderr kept to describe the execution error
+	logCaptureTailSize = 1024
+)
+
+// logCapture writes the stderr of the step process into the step log.
+//
+// The stderr of the shell scripts is redirected into a temporary file which
+// is copied into the log while the process runs. For the docker and binary
+// procedures the stderr is taken from the execution error. The stdout of the
+// process is the step output and is not captured.
+type logCapture struct {
+	log  io.Writer
+	file *os.File
+	tail []byte
+
+	once sync.Once
+	done chan struct{}
+	wait chan struct{}
+}
+
+// newLogCapture returns the copy of m writing its stderr into the log.
+// The capture is nil if log is nil.
+func newLogCapture(m *manifest.Manifest, log io.Writer) (*manifest.Manifest, *logCapture, error) {
+	if log == nil {
+		return m, nil, nil
+	}
+	lc := &logCapture{log: log}
+	if m.Driver == manifest.DriverDocker || !m.ScriptMode || len(m.Command) != 1 {
+		return m, lc, nil
+	}
+	file, err := os.CreateTemp("", "apfs-step-log-*")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "step log")
+	}
+	lm := *m
+	lm.Command = manifest.CommandArg{"exec 2>>" + shellQuote(file.Name()) + "\n" + m.Command[0]}
+	lc.file = file
+	lc.done = make(chan struct{})
+	lc.wait = make(chan struct{})
+	go lc.run()
+	return &lm, lc, nil
+}
+
+func (lc *logCapture) run() {
+	defer close(lc.wait)
+	ticker := time.NewTicker(logCapturePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lc.done:
+			lc.copy()
+			return
+		case <-ticker.C:
+			lc.copy()
+		}
+	}
+}
+
+// copy the new data of the stderr file into the log
+func (lc *logCapture) copy() {
+	_, _ = io.Copy(lc, lc.file)
+}
+
+// Write the stderr data into the log keeping the tail
+func (lc *logCapture) Write(p []byte) (int, error) {
+	lc.tail = append(lc.tail, p...)
+	if len(lc.tail) > logCaptureTailSize {
+		lc.tail = append(lc.tail[:0], lc.tail[len(lc.tail)-logCaptureTailSize:]...)
+	}
+	return lc.log.Write(p)
+}
+
+// Close copies the rest of the stderr into the log and removes the file
+func (lc *logCapture) Close() (err error) {
+	if lc == nil || lc.file == nil {
+		return nil
+	}
+	lc.once.Do(func() {
+		close(lc.done)
+		<-lc.wait
+		err = lc.file.Close()
+		_ = os.Remove(lc.file.Name())
+	})
+	return err
+}
+
+// execError completes the capture of the finished process. The last line
+// of the stderr is added to the execution error, so it is visible in the
+// step state and is checked by the sandbox limits classification.
+func (lc *logCapture) execError(err error) error {
+	if lc == nil {
+		return err
+	}
+	if lc.file == nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			_, _ = lc.Write(exitErr.Stderr)
+		}
+	}
+	_ = lc.Close()
+	if err == nil {
+		return nil
+	}
+	if line := lastLine(lc.tail); line != "" && !strings.Contains(err.Error(), line) {
+		return fmt.Errorf("%w: %s", err, line)
+	}
+	return err
+}
+
+// lastLine returns the last non-empty line of the data
+func lastLine(data []byte) string {
+	data = bytes.TrimRight(data, "\r\n\t ")
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return string(bytes.TrimSpace(data))
+}
@@ -0,0 +1,62 @@
+package proc
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/demdxx/plugeproc/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/models"
+)
+
+func TestLogCaptureScriptStderr(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	var (
+		log  bytes.Buffer
+		step = &models.WorkflowStep{Name: "test", Run: "echo out\necho progress >&2\necho 'fatal: bad input' >&2\nexit 3"}
+	)
+	m, capture, err := newLogCapture(buildInlineManifest(step), &log)
+	require.NoError(t, err)
+	require.NotNil(t, capture)
+
+	out, err := exec.Command("bash", "-c", m.Command[0]).Output()
+	assert.Equal(t, "out\n", string(out), "stdout is the step output")
+
+	err = capture.execError(err)
+	require.Error(t, err)
+	assert.Equal(t, "progress\nfatal: bad input\n", log.String())
+	assert.True(t, strings.HasSuffix(err.Error(), ": fatal: bad input"), err.Error())
+
+	var exitErr *exec.ExitError
+	assert.ErrorAs(t, err, &exitErr)
+}
+
+func TestLogCaptureExitErrorStderr(t *testing.T) {
+	var (
+		log bytes.Buffer
+		m   = &manifest.Manifest{Driver: manifest.DriverDocker, ScriptMode: true, Command: manifest.CommandArg{"true"}}
+	)
+	nm, capture, err := newLogCapture(m, &log)
+	require.NoError(t, err)
+	assert.Same(t, m, nm, "docker manifest is not changed")
+
+	err = capture.execError(&exec.ExitError{Stderr: []byte("no such image\n")})
+	require.Error(t, err)
+	assert.Equal(t, "no such image\n", log.String())
+	assert.Contains(t, err.Error(), "no such image")
+}
+
+func TestLogCaptureDisabled(t *testing.T) {
+	m := buildInlineManifest(&models.WorkflowStep{Name: "test", Run: "true"})
+	nm, capture, err := newLogCapture(m, nil)
+	require.NoError(t, err)
+	assert.Nil(t, capture)
+	assert.Same(t, m, nm)
+	assert.NoError(t, capture.Close())
+}
@@ -0,0 +1,236 @@
+package proc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/demdxx/plugeproc/manifest"
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+const (
+	// outputDrainTimeout of the idle stdout pipe after the process exits,
+	// the pipe could be kept open by the background children of the script
+	outputDrainTimeout = time.Second
+
+	// outputCopyBuffer size of the pipe reads
+	outputCopyBuffer = 32 * 1024
+
+	// outputReleaseInterval of the attempts to unblock the pipe reader
+	outputReleaseInterval = 10 * time.Millisecond
+)
+
+// outputPipe receives the stdout of the process through a named pipe.
+//
+// The output is either spooled into a temporary file or streamed to the
+// next step of the job while the process runs. The max-output limit is
+// checked on every write, so the step is stopped as soon as the limit is
+// exceeded instead of after the process exits.
+type outputPipe struct {
+	dir   string
+	path  string
+	limit int64 // 0 means no limit
+	spool *tempFile
+
+	opened    chan *os.File
+	exited    atomic.Bool // the process exited, only its children could write
+	done      chan struct{}
+	err       error
+	discarded bool // the streamed output was closed by the consumer
+}
+
+// newOutputPipe creates the named pipe which the process stdout is
+// redirected to
+func newOutputPipe(limit int64) (*outputPipe, error) {
+	dir, err := os.MkdirTemp("", "apfs-step-stdout-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "sandbox output")
+	}
+	op := &outputPipe{dir: dir, path: filepath.Join(dir, "stdout"), limit: limit}
+	if err = mkfifo(op.path); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return op, nil
+}
+
+// pipeStdout returns the copy of the script manifest with the stdout
+// redirected into the pipe, the stdout of the sandboxed process is already
+// redirected. The pipe is nil if the stdout of the procedure can't be piped.
+func pipeStdout(m *manifest.Manifest, sb *sandbox) (*manifest.Manifest, *outputPipe, error) {
+	if sb != nil && sb.stdout != nil {
+		return m, sb.stdout, nil
+	}
+	if !haveFifo || m.Driver == manifest.DriverDocker || !m.ScriptMode ||
+		len(m.Command) != 1 || m.Output.Type == "file" {
+		return m, nil, nil
+	}
+	stdout, err := newOutputPipe(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	pm := *m
+	pm.Command = manifest.CommandArg{fmt.Sprintf("exec >%s\n%s", shellQuote(stdout.path), m.Command[0])}
+	return &pm, stdout, nil
+}
+
+// start spooling the pipe, stop is called when the output exceeds the limit
+func (op *outputPipe) start(stop context.CancelFunc) error {
+	file, err := os.CreateTemp("", "apfs-step-output-*")
+	if err != nil {
+		return errors.Wrap(err, "sandbox output")
+	}
+	op.spool = &tempFile{File: file}
+	op.opened = make(chan *os.File, 1)
+	op.done = make(chan struct{})
+	go op.run(op.spool, stop)
+	return nil
+}
+
+// stream starts passing the pipe data through the returned reader as the
+// process writes it, stop is called when the output exceeds the limit
+func (op *outputPipe) stream(stop context.CancelFunc) io.ReadCloser {
+	pr, pw := io.Pipe()
+	op.opened = make(chan *os.File, 1)
+	op.done = make(chan struct{})
+	go func() {
+		op.run(pw, stop)
+		_ = pw.CloseWithError(op.err)
+	}()
+	return pr
+}
+
+func (op *outputPipe) run(w io.Writer, stop context.CancelFunc) {
+	defer close(op.done)
+
+	// Blocks until the process opens the pipe for writing
+	pipe, err := os.Open(op.path)
+	op.opened <- pipe
+	if err != nil {
+		op.err = errors.Wrap(err, "sandbox output")
+		return
+	}
+	defer func() { _ = pipe.Close() }()
+
+	if op.limit > 0 {
+		w = &limitWriter{w: w, limit: op.limit, exceeded: stop}
+	}
+	err = op.copy(w, pipe)
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+	case errors.Is(err, io.ErrClosedPipe):
+		op.discarded = true
+	default:
+		op.err = err
+	}
+}
+
+// copy the pipe data until all the writers close the pipe. After the process
+// exits the reads wait for outputDrainTimeout in total, so the pipe kept open
+// by a background child doesn't block the step. The time of the consumer
+// writes isn't counted, the slow consumer still gets all the output.
+func (op *outputPipe) copy(w io.Writer, pipe *os.File) error {
+	var (
+		buf   = make([]byte, outputCopyBuffer)
+		drain = outputDrainTimeout
+	)
+	for {
+		start := time.Now()
+		if op.exited.Load() {
+			_ = pipe.SetReadDeadline(start.Add(drain))
+		}
+		n, err := pipe.Read(buf)
+		if op.exited.Load() {
+			drain -= time.Since(start)
+		}
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		switch {
+		case err == io.EOF:
+			return nil
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			// The unread output is reported instead of dropped
+			_ = pipe.SetReadDeadline(time.Now().Add(outputReleaseInterval))
+			if n, _ = pipe.Read(buf); n > 0 {
+				return errors.Errorf("sandbox output: the output is still written %s after the process exit", outputDrainTimeout)
+			}
+			return err
+		case err != nil:
+			return err
+		}
+	}
+}
+
+// wait for the output of the exited process
+func (op *outputPipe) wait() error {
+	ticker := time.NewTicker(outputReleaseInterval)
+	defer ticker.Stop()
+	for opened := false; !opened; {
+		// The process could exit before it opened the pipe
+		releaseFifo(op.path)
+		select {
+		case pipe := <-op.opened:
+			if pipe != nil {
+				// Unblock the read which waits for the idle background children
+				op.exited.Store(true)
+				_ = pipe.SetReadDeadline(time.Now().Add(outputDrainTimeout))
+			}
+			opened = true
+		case <-ticker.C:
+		}
+	}
+	<-op.done
+	if op.err != nil || op.spool == nil {
+		return op.err
+	}
+	_, err := op.spool.Seek(0, io.SeekStart)
+	return err
+}
+
+// reader returns the spooled output, the file is removed on Close
+func (op *outputPipe) reader() io.ReadCloser {
+	spool := op.spool
+	op.spool = nil
+	return spool
+}
+
+// release removes the pipe and the spooled output if it was not taken
+func (op *outputPipe) release() {
+	if op.spool != nil {
+		_ = op.spool.Close()
+		op.spool = nil
+	}
+	_ = os.RemoveAll(op.dir)
+}
+
+// limitWriter fails the write which exceeds the limit
+type limitWriter struct {
+	w        io.Writer
+	written  int64
+	limit    int64
+	once     sync.Once
+	exceeded func()
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	if lw.written+int64(len(p)) > lw.limit {
+		lw.once.Do(lw.exceeded)
+		return 0, workflow.NewLimitError(models.StepLimitOutput,
+			errors.Errorf("output size exceeds %d bytes", lw.limit))
+	}
+	n, err := lw.w.Write(p)
+	lw.written += int64(n)
+	return n, err
+}
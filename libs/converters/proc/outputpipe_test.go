@@ -0,0 +1,151 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/demdxx/plugeproc/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+// bashProc executes the script manifest the same way the shell driver does
+type bashProc struct {
+	m *manifest.Manifest
+}
+
+func (p bashProc) Exec(ctx context.Context, _ any, _ ...any) error {
+	return exec.CommandContext(ctx, "bash", "-c", p.m.Command[0]).Run()
+}
+
+// startStream runs the script streaming its stdout as the step artifact
+func startStream(t *testing.T, limits *models.WorkflowStepLimits, script string) workflow.StepOutput {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	step := &models.WorkflowStep{Name: "stream", Run: script, Limits: limits, With: map[string]any{"target": "out.bin"}}
+	m := buildInlineManifest(step)
+
+	var cleanup releaser
+	sb, err := newSandbox(step)
+	require.NoError(t, err)
+	if sb != nil {
+		cleanup.add(sb.release)
+		m, err = sb.manifest(m)
+		require.NoError(t, err)
+	}
+	m, stdout, err := pipeStdout(m, sb)
+	require.NoError(t, err)
+	require.NotNil(t, stdout)
+	if sb == nil {
+		cleanup.add(stdout.release)
+	}
+	out := New(nil).execStream(context.Background(), step, sb, stdout, bashProc{m: m}, nil, nil, cleanup.handover())
+	require.NotNil(t, out.Wait)
+	assert.Equal(t, "out.bin", out.TargetPath)
+	return out
+}
+
+func TestExecStream(t *testing.T) {
+	gate := filepath.Join(t.TempDir(), "gate")
+	out := startStream(t, nil, `printf head
+for i in $(seq 500); do [ -e '`+gate+`' ] && break; sleep 0.01; done
+[ -e '`+gate+`' ] || exit 1
+printf tail`)
+
+	// The head is read while the process still runs
+	head := make([]byte, 4)
+	_, err := io.ReadFull(out.Writer, head)
+	require.NoError(t, err)
+	assert.Equal(t, "head", string(head))
+	require.NoError(t, os.WriteFile(gate, nil, 0o600))
+
+	tail, err := io.ReadAll(out.Writer)
+	require.NoError(t, err)
+	assert.Equal(t, "tail", string(tail))
+	closeOutput(out)
+	require.NoError(t, out.Wait())
+}
+
+func TestExecStreamFailure(t *testing.T) {
+	out := startStream(t, nil, "printf partial\nexit 3")
+	data, err := io.ReadAll(out.Writer)
+	require.NoError(t, err)
+	assert.Equal(t, "partial", string(data))
+	closeOutput(out)
+
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(out.Wait(), &exitErr))
+	assert.Equal(t, 3, exitErr.ExitCode())
+}
+
+func TestExecStreamOutputLimit(t *testing.T) {
+	out := startStream(t, &models.WorkflowStepLimits{MaxOutput: "1KB"}, "while :; do echo 0123456789; done")
+	_, err := io.ReadAll(out.Writer)
+	require.Error(t, err)
+	closeOutput(out)
+
+	var lerr *workflow.LimitError
+	require.True(t, errors.As(out.Wait(), &lerr))
+	assert.Equal(t, models.StepLimitOutput, lerr.Limit)
+}
+
+func TestExecStreamConsumerStopped(t *testing.T) {
+	out := startStream(t, nil, "while :; do echo 0123456789; done")
+	_, err := io.ReadFull(out.Writer, make([]byte, 64))
+	require.NoError(t, err)
+
+	// The consumer failure is reported by the consumer itself
+	closeOutput(out)
+	assert.NoError(t, out.Wait())
+}
+
+func closeOutput(out workflow.StepOutput) {
+	if closer, ok := out.Writer.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+func TestExecStreamSlowConsumer(t *testing.T) {
+	const size = 100000
+	out := startStream(t, nil, "head -c "+strconv.Itoa(size)+" /dev/zero")
+
+	// The producer exits long before the consumer reads the rest of the output
+	var (
+		buf  = make([]byte, 2048)
+		read int
+	)
+	for {
+		n, err := out.Writer.Read(buf)
+		read += n
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		time.Sleep(40 * time.Millisecond)
+	}
+	assert.Equal(t, size, read)
+	closeOutput(out)
+	require.NoError(t, out.Wait())
+}
+
+func TestExecStreamBackgroundWriter(t *testing.T) {
+	// The orphan writes after the process exit, its output can't be drained
+	out := startStream(t, nil, "(sleep 0.5; while :; do echo late; sleep 0.001; done) &\nprintf hi")
+	data, err := io.ReadAll(out.Writer)
+	require.Error(t, err)
+	assert.Contains(t, string(data), "hi")
+	closeOutput(out)
+	require.Error(t, out.Wait())
+}
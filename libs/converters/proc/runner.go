@@ -61,21 +61,57 @@ func (r *StepRunner) CanRun(step *models.WorkflowStep) bool {
 }
 
 // Run executes the step.
+//
+// The stdout of a script which is stored as the step artifact is streamed
+// while the script runs, the result of the step is reported by
+// StepOutput.Wait then.
 func (r *StepRunner) Run(ctx context.Context, step *models.WorkflowStep, in workflow.StepInput) (workflow.StepOutput, error) {
 	m, err := r.resolveManifest(step)
 	if err != nil {
 		return workflow.StepOutput{}, err
 	}
 
-	p, err := plugeproc.New(m)
+	// The resources are released when the step is done, the streaming step
+	// hands them over to the running process
+	var cleanup releaser
+	defer cleanup.release()
+
+	sb, err := newSandbox(step)
 	if err != nil {
-		return workflow.StepOutput{}, errors.Wrap(err, "build proc")
+		return workflow.StepOutput{}, err
+	}
+	if sb != nil {
+		cleanup.add(sb.release)
+		if m, err = sb.manifest(m); err != nil {
+			return workflow.StepOutput{}, errors.Wrapf(err, "step %q", step.Name)
+		}
 	}
-	defer func() { _ = p.Release() }()
 
 	targetMeta := withString(step.With, "target-meta", "")
 	targetPath := withString(step.With, "target", "")
 
+	var stdout *outputPipe
+	if targetMeta == "" && targetPath != "" {
+		if m, stdout, err = pipeStdout(m, sb); err != nil {
+			return workflow.StepOutput{}, err
+		}
+		if stdout != nil && (sb == nil || sb.stdout != stdout) {
+			cleanup.add(stdout.release)
+		}
+	}
+
+	m, capture, err := newLogCapture(m, in.Log)
+	if err != nil {
+		return workflow.StepOutput{}, err
+	}
+	cleanup.add(func() { _ = capture.Close() })
+
+	p, err := plugeproc.New(m)
+	if err != nil {
+		return workflow.StepOutput{}, errors.Wrap(err, "build proc")
+	}
+	cleanup.add(func() { _ = p.Release() })
+
 	// Allocate the output receiver.
 	// Named procedures may use file-type output (temp file). Inline scripts
 	// always write to stdout so we always capture into a buffer or ReadCloser.
@@ -97,10 +133,25 @@ func (r *StepRunner) Run(ctx context.Context, step *models.WorkflowStep, in work
 		return workflow.StepOutput{}, err
 	}
 
-	if err := p.Exec(ctx, execTarget, params...); err != nil {
+	if stdout != nil {
+		return r.execStream(ctx, step, sb, stdout, p, params, capture, cleanup.handover()), nil
+	}
+
+	if err := r.exec(ctx, sb, p, execTarget, params, capture); err != nil {
 		return workflow.StepOutput{}, errors.Wrapf(err, "exec step %q", step.Name)
 	}
 
+	if sb != nil {
+		if targetMeta != "" {
+			err = sb.checkBuffer(&outBuf)
+		} else if outRC != nil || sb.stdout != nil {
+			outRC, err = sb.limitReader(outRC)
+		}
+		if err != nil {
+			return workflow.StepOutput{}, errors.Wrapf(err, "exec step %q", step.Name)
+		}
+	}
+
 	so := workflow.StepOutput{Outputs: map[string]any{}}
 
 	if targetMeta != "" {
@@ -115,6 +166,13 @@ func (r *StepRunner) Run(ctx context.Context, step *models.WorkflowStep, in work
 		so.Outputs[targetMeta] = raw
 		so.ItemMeta = &models.ItemMeta{}
 		so.ItemMeta.SetExt(targetMeta, raw)
+	} else if outRC != nil && targetPath == "" && in.Log != nil {
+		// The output which is not stored as the artifact goes to the step log
+		_, err = io.Copy(in.Log, outRC)
+		_ = outRC.Close()
+		if err != nil {
+			return so, errors.Wrap(err, "step log")
+		}
 	} else if outRC != nil {
 		so.Writer = outRC
 		if targetPath != "" {
@@ -128,6 +186,83 @@ func (r *StepRunner) Run(ctx context.Context, step *models.WorkflowStep, in work
 	return so, nil
 }
 
+// procExecer executes the procedure
+type procExecer interface {
+	Exec(ctx context.Context, target any, params ...any) error
+}
+
+// exec runs the procedure within the sandbox limits if any
+func (r *StepRunner) exec(ctx context.Context, sb *sandbox, p procExecer, target any, params []any, capture *logCapture) error {
+	if sb == nil {
+		return capture.execError(p.Exec(ctx, target, params...))
+	}
+	stepCtx, cancel := sb.context(ctx)
+	defer cancel()
+	if err := sb.start(cancel); err != nil {
+		return err
+	}
+	err := capture.execError(p.Exec(stepCtx, target, params...))
+	// The output limit stops the process, so it is the reason of the failure
+	if outErr := sb.wait(); outErr != nil {
+		return outErr
+	}
+	return sb.execError(ctx, stepCtx, err)
+}
+
+// execStream starts the procedure in background and returns its stdout as
+// the step artifact. The resources of the step are released by release
+// once the process exits.
+func (r *StepRunner) execStream(ctx context.Context, step *models.WorkflowStep, sb *sandbox, stdout *outputPipe, p procExecer, params []any, capture *logCapture, release func()) workflow.StepOutput {
+	var (
+		stepCtx context.Context
+		cancel  context.CancelFunc
+	)
+	if sb != nil {
+		stepCtx, cancel = sb.context(ctx)
+	} else {
+		stepCtx, cancel = context.WithCancel(ctx)
+	}
+	var (
+		reader = stdout.stream(cancel)
+		result = make(chan error, 1)
+	)
+	go func() {
+		defer release()
+		defer cancel()
+
+		// The stdout is redirected into the pipe, the target gets nothing
+		var rc io.ReadCloser
+		err := capture.execError(p.Exec(stepCtx, &rc, params...))
+		if rc != nil {
+			_ = rc.Close()
+		}
+		switch outErr := stdout.wait(); {
+		case outErr != nil:
+			err = outErr
+		case stdout.discarded:
+			// The consumer stopped reading, its failure is reported instead
+			err = nil
+		case sb != nil:
+			err = sb.execError(ctx, stepCtx, err)
+		}
+		if err != nil {
+			err = errors.Wrapf(err, "exec step %q", step.Name)
+		}
+		result <- err
+	}()
+
+	targetPath := withString(step.With, "target", "")
+	im := &models.ItemMeta{}
+	im.UpdateName(targetPath)
+	return workflow.StepOutput{
+		Writer:     reader,
+		TargetPath: targetPath,
+		ItemMeta:   im,
+		Outputs:    map[string]any{},
+		Wait:       func() error { return <-result },
+	}
+}
+
 // resolveManifest returns the plugeproc manifest for the given step, either
 // by building it from the inline run: block or by looking it up from the store.
 func (r *StepRunner) resolveManifest(step *models.WorkflowStep) (*manifest.Manifest, error) {
@@ -268,6 +403,27 @@ func withBool(with map[string]any, key string, def bool) bool {
 	return def
 }
 
+// releaser runs the release functions in the reverse order
+type releaser []func()
+
+func (r *releaser) add(fn func()) {
+	*r = append(*r, fn)
+}
+
+func (r *releaser) release() {
+	for i := len(*r) - 1; i >= 0; i-- {
+		(*r)[i]()
+	}
+	*r = nil
+}
+
+// handover moves the release functions into the returned one
+func (r *releaser) handover() func() {
+	fns := *r
+	*r = nil
+	return fns.release
+}
+
 // sortedWithKeys returns the non-reserved keys of with in sorted order.
 func sortedWithKeys(with map[string]any, reserved map[string]bool) []string {
 	keys := make([]string, 0, len(with))
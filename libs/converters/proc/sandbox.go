@@ -0,0 +1,318 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/demdxx/plugeproc/manifest"
+	"github.com/pkg/errors"
+
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+// sandboxScriptEOF is the heredoc delimiter used to embed the step script
+// when it has to be started in a new network namespace.
+const sandboxScriptEOF = "__APFS_SANDBOX_SCRIPT__"
+
+// sandboxArgv0 is the $0 of the bash wrapper of the exec procedures
+const sandboxArgv0 = "apfs-sandbox"
+
+// sigXCPU is sent on the soft RLIMIT_CPU, bash reports it as exit code 152
+const sigXCPU = syscall.Signal(24)
+
+var (
+	envNameRe    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	exitStatusRe = regexp.MustCompile(`exit status (\d+)`)
+)
+
+// sandbox enforces models.WorkflowStepLimits for a single step execution.
+//
+// Process limits (CPU, memory, environment, working directory, network) are
+// applied by a bash prelude injected in front of the step script or the
+// exec command. The stdout of the process is redirected into the pipe read
+// by the runner, which enforces the output limit while the process writes.
+// The wall-clock limit is enforced by the runner itself.
+type sandbox struct {
+	limits  *models.WorkflowStepLimits
+	workdir string
+	stdout  *outputPipe
+}
+
+// newSandbox prepares the sandbox of the step. Returns nil if the step
+// declares no limits.
+func newSandbox(step *models.WorkflowStep) (*sandbox, error) {
+	if step.Limits == nil {
+		return nil, nil
+	}
+	for _, name := range step.Limits.Env {
+		if !envNameRe.MatchString(name) {
+			return nil, errors.Errorf("step %q: invalid env variable name %q", step.Name, name)
+		}
+	}
+	sb := &sandbox{limits: step.Limits}
+	if step.Limits.IsolateWorkdir {
+		dir, err := os.MkdirTemp("", "apfs-step-*")
+		if err != nil {
+			return nil, errors.Wrap(err, "sandbox workdir")
+		}
+		sb.workdir = dir
+	}
+	return sb, nil
+}
+
+// release removes the isolated working directory and the output pipe
+func (sb *sandbox) release() {
+	if sb == nil {
+		return
+	}
+	if sb.workdir != "" {
+		_ = os.RemoveAll(sb.workdir)
+	}
+	if sb.stdout != nil {
+		sb.stdout.release()
+	}
+}
+
+// context applies the wall-clock limit
+func (sb *sandbox) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := sb.limits.Timeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// manifest returns the copy of m with the sandbox prelude injected into the
+// script, the exec procedures are started by the bash wrapper running the
+// prelude. Process limits can't be applied to docker manifests.
+func (sb *sandbox) manifest(m *manifest.Manifest) (*manifest.Manifest, error) {
+	if m.Driver == manifest.DriverDocker {
+		if sb.limits.HasProcessLimits() {
+			return nil, errors.New("process limits are not supported by docker steps, only timeout-seconds and max-output")
+		}
+		return m, nil
+	}
+	// The stdout is piped only if it is the step output, the output
+	// files of the procedures are checked after the process exits
+	pipeStdout := sb.limits.MaxOutputBytes() > 0 && m.Output.Type != "file" && haveFifo
+	if !sb.limits.HasProcessLimits() && !pipeStdout {
+		return m, nil
+	}
+	if len(m.Command) == 0 || (m.ScriptMode && len(m.Command) != 1) {
+		return nil, errors.Errorf("procedure %q has no single command to sandbox", m.Name)
+	}
+	if pipeStdout {
+		stdout, err := newOutputPipe(sb.limits.MaxOutputBytes())
+		if err != nil {
+			return nil, err
+		}
+		sb.stdout = stdout
+	}
+	sm := *m
+	if m.ScriptMode {
+		sm.Command = manifest.CommandArg{sb.script(m.Command[0])}
+	} else {
+		sm.Command = append(manifest.CommandArg{"bash", "-c", sb.command(), sandboxArgv0}, m.Command...)
+	}
+	return &sm, nil
+}
+
+// script wraps the step script with the limits prelude
+func (sb *sandbox) script(script string) string {
+	var buf strings.Builder
+	sb.prelude(&buf)
+	if !sb.limits.NoNetwork {
+		buf.WriteString(script)
+		return buf.String()
+	}
+	// Network isolation is best effort: it requires unprivileged user namespaces
+	fmt.Fprintf(&buf, "__apfs_script=$(cat <<'%[1]s'\n%[2]s\n%[1]s\n)\n", sandboxScriptEOF, strings.TrimRight(script, "\n"))
+	buf.WriteString("if command -v unshare >/dev/null 2>&1 && unshare -rn true >/dev/null 2>&1; then\n")
+	buf.WriteString("  exec unshare -rn bash -c \"$__apfs_script\"\n")
+	buf.WriteString("fi\n")
+	buf.WriteString("eval \"$__apfs_script\"\n")
+	return buf.String()
+}
+
+// command is the bash wrapper script which applies the limits prelude and
+// replaces itself with the command passed in the arguments
+func (sb *sandbox) command() string {
+	var buf strings.Builder
+	sb.prelude(&buf)
+	if sb.limits.NoNetwork {
+		buf.WriteString("if command -v unshare >/dev/null 2>&1 && unshare -rn true >/dev/null 2>&1; then\n")
+		buf.WriteString("  exec unshare -rn \"$@\"\n")
+		buf.WriteString("fi\n")
+	}
+	buf.WriteString("exec \"$@\"\n")
+	return buf.String()
+}
+
+// prelude writes the commands which apply the process limits
+func (sb *sandbox) prelude(buf *strings.Builder) {
+	buf.WriteString("set -e\n")
+	if sb.stdout != nil {
+		fmt.Fprintf(buf, "exec >%s\n", shellQuote(sb.stdout.path))
+	}
+	if sb.limits.CPUSeconds > 0 {
+		// SIGXCPU on the soft limit, the hard one is a SIGKILL fallback
+		fmt.Fprintf(buf, "ulimit -S -t %d\nulimit -H -t %d\n", sb.limits.CPUSeconds, sb.limits.CPUSeconds+1)
+	}
+	if mem := sb.limits.MemoryBytes(); mem > 0 {
+		fmt.Fprintf(buf, "ulimit -v %d\n", max(mem/1024, 1))
+	}
+	if sb.workdir != "" {
+		fmt.Fprintf(buf, "cd %[1]s\nexport HOME=%[1]s TMPDIR=%[1]s\n", shellQuote(sb.workdir))
+	}
+	if sb.limits.Env != nil {
+		keep := append([]string{"PATH"}, sb.limits.Env...)
+		if sb.workdir != "" {
+			keep = append(keep, "HOME", "TMPDIR")
+		}
+		buf.WriteString("for __apfs_var in $(compgen -e); do\n")
+		fmt.Fprintf(buf, "  case \"$__apfs_var\" in %s) ;; *) unset \"$__apfs_var\" ;; esac\n", strings.Join(keep, "|"))
+		buf.WriteString("done\nunset __apfs_var\n")
+	}
+	buf.WriteString("set +e\n")
+}
+
+// start the reading of the process output, stop is called when the output
+// exceeds the limit
+func (sb *sandbox) start(stop context.CancelFunc) error {
+	if sb.stdout == nil {
+		return nil
+	}
+	return sb.stdout.start(stop)
+}
+
+// wait for the output of the exited process
+func (sb *sandbox) wait() error {
+	if sb.stdout == nil {
+		return nil
+	}
+	return sb.stdout.wait()
+}
+
+// execError classifies the execution error, returning workflow.LimitError
+// when the process was stopped by one of the sandbox limits.
+// stepCtx is the context created by sandbox.context and parent is its parent.
+func (sb *sandbox) execError(parent, stepCtx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if sb.limits.Timeout() > 0 && parent.Err() == nil &&
+		errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return workflow.NewLimitError(models.StepLimitWallClock, err)
+	}
+	// The process killed by the cancellation is not a limit violation
+	if parent.Err() != nil || stepCtx.Err() != nil {
+		return err
+	}
+	if sig, ok := exitSignal(err); ok {
+		switch sig {
+		case sigXCPU, syscall.SIGKILL: // soft and hard RLIMIT_CPU
+			if sb.limits.CPUSeconds > 0 {
+				return workflow.NewLimitError(models.StepLimitCPU, err)
+			}
+		case syscall.SIGSEGV, syscall.SIGABRT, syscall.SIGBUS: // failed allocation under RLIMIT_AS
+			if sb.limits.MemoryBytes() > 0 {
+				return workflow.NewLimitError(models.StepLimitMemory, err)
+			}
+		}
+	}
+	return err
+}
+
+// exitSignal returns the signal which terminated the process. The bash
+// scripts report the signal of their command as the exit code 128+n.
+func exitSignal(err error) (syscall.Signal, bool) {
+	code := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return status.Signal(), true
+		}
+		code = exitErr.ExitCode()
+	} else if match := exitStatusRe.FindStringSubmatch(err.Error()); match != nil {
+		code, _ = strconv.Atoi(match[1])
+	}
+	if code > 128 && code < 128+65 {
+		return syscall.Signal(code - 128), true
+	}
+	return 0, false
+}
+
+// checkBuffer validates the size of the output captured into the buffer.
+// The piped output replaces the content of the buffer.
+func (sb *sandbox) checkBuffer(buf *bytes.Buffer) error {
+	if sb.stdout != nil {
+		buf.Reset()
+		_, err := buf.ReadFrom(sb.stdout.spool)
+		return err
+	}
+	if maxOutput := sb.limits.MaxOutputBytes(); maxOutput > 0 && int64(buf.Len()) > maxOutput {
+		return workflow.NewLimitError(models.StepLimitOutput,
+			errors.Errorf("output size %d exceeds %d bytes", buf.Len(), maxOutput))
+	}
+	return nil
+}
+
+// limitReader spools the process output into a temporary file, failing as
+// soon as the output exceeds the limit. The returned reader removes the
+// file on Close. The piped output replaces rc.
+func (sb *sandbox) limitReader(rc io.ReadCloser) (io.ReadCloser, error) {
+	if sb.stdout != nil {
+		if rc != nil {
+			_ = rc.Close()
+		}
+		return sb.stdout.reader(), nil
+	}
+	maxOutput := sb.limits.MaxOutputBytes()
+	if maxOutput <= 0 {
+		return rc, nil
+	}
+	defer func() { _ = rc.Close() }()
+
+	file, err := os.CreateTemp("", "apfs-step-output-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "sandbox output")
+	}
+	spool := &tempFile{File: file}
+	n, err := io.Copy(file, io.LimitReader(rc, maxOutput+1))
+	if err == nil && n > maxOutput {
+		err = workflow.NewLimitError(models.StepLimitOutput,
+			errors.Errorf("output size exceeds %d bytes", maxOutput))
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = spool.Close()
+		return nil, err
+	}
+	return spool, nil
+}
+
+// tempFile is the os.File removed on Close
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
+
+// shellQuote quotes the value for the POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
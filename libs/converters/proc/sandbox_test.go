@@ -0,0 +1,243 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demdxx/plugeproc/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apfs-io/apfs/internal/workflow"
+	"github.com/apfs-io/apfs/models"
+)
+
+func newTestSandbox(t *testing.T, limits *models.WorkflowStepLimits) *sandbox {
+	t.Helper()
+	sb, err := newSandbox(&models.WorkflowStep{Name: "test", Limits: limits})
+	require.NoError(t, err)
+	require.NotNil(t, sb)
+	t.Cleanup(sb.release)
+	return sb
+}
+
+// runSandboxScript executes the wrapped script the same way the shell driver does
+func runSandboxScript(t *testing.T, ctx context.Context, sb *sandbox, script string) (string, error) {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	cmd := exec.CommandContext(ctx, "bash", "-c", sb.script(script))
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func TestSandboxNoLimits(t *testing.T) {
+	sb, err := newSandbox(&models.WorkflowStep{Name: "plain"})
+	require.NoError(t, err)
+	assert.Nil(t, sb)
+}
+
+func TestSandboxInvalidEnvName(t *testing.T) {
+	_, err := newSandbox(&models.WorkflowStep{
+		Name:   "bad",
+		Limits: &models.WorkflowStepLimits{Env: []string{"OK", "NOT;OK"}},
+	})
+	require.Error(t, err)
+}
+
+func TestSandboxEnvAndWorkdir(t *testing.T) {
+	t.Setenv("APFS_ALLOWED", "yes")
+	t.Setenv("APFS_SECRET", "leak")
+
+	sb := newTestSandbox(t, &models.WorkflowStepLimits{
+		Env:            []string{"APFS_ALLOWED"},
+		IsolateWorkdir: true,
+	})
+	require.DirExists(t, sb.workdir)
+
+	out, err := runSandboxScript(t, context.Background(), sb,
+		`echo "$(pwd)|$HOME|${APFS_ALLOWED:-}|${APFS_SECRET:-}"`)
+	require.NoError(t, err)
+	assert.Equal(t, sb.workdir+"|"+sb.workdir+"|yes|", strings.TrimSpace(out))
+
+	sb.release()
+	assert.NoDirExists(t, sb.workdir)
+}
+
+func TestSandboxNoNetworkKeepsScript(t *testing.T) {
+	sb := newTestSandbox(t, &models.WorkflowStepLimits{NoNetwork: true})
+	out, err := runSandboxScript(t, context.Background(), sb, "echo \"a b\"\necho '$HOME'\n")
+	require.NoError(t, err)
+	assert.Equal(t, "a b\n$HOME\n", out)
+}
+
+func TestSandboxCPULimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip CPU limit test in short mode")
+	}
+	sb := newTestSandbox(t, &models.WorkflowStepLimits{CPUSeconds: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := runSandboxScript(t, ctx, sb, "while :; do :; done")
+	require.Error(t, err)
+
+	var lerr *workflow.LimitError
+	require.True(t, errors.As(sb.execError(ctx, ctx, err), &lerr), err.Error())
+	assert.Equal(t, models.StepLimitCPU, lerr.Limit)
+}
+
+func TestSandboxWallClockLimit(t *testing.T) {
+	sb := newTestSandbox(t, &models.WorkflowStepLimits{TimeoutSeconds: 1})
+	parent := context.Background()
+	stepCtx, cancel := context.WithDeadline(parent, time.Now().Add(-time.Second))
+	defer cancel()
+
+	var lerr *workflow.LimitError
+	err := sb.execError(parent, stepCtx, errors.New("signal: killed"))
+	require.True(t, errors.As(err, &lerr))
+	assert.Equal(t, models.StepLimitWallClock, lerr.Limit)
+
+	// Cancellation of the job itself is not a limit violation
+	canceled, cancelParent := context.WithCancel(parent)
+	cancelParent()
+	err = sb.execError(canceled, stepCtx, errors.New("signal: killed"))
+	assert.False(t, errors.As(err, &lerr))
+}
+
+func TestSandboxMemoryError(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	sb := newTestSandbox(t, &models.WorkflowStepLimits{Memory: "64MB"})
+	ctx := context.Background()
+
+	// The failed allocation aborts the process
+	err := exec.Command("bash", "-c", "kill -ABRT $$").Run()
+	require.Error(t, err)
+	var lerr *workflow.LimitError
+	require.True(t, errors.As(sb.execError(ctx, ctx, err), &lerr), err.Error())
+	assert.Equal(t, models.StepLimitMemory, lerr.Limit)
+
+	// The script reports the signal of its command by the exit code
+	err = sb.execError(ctx, ctx, errors.New("exit status 139"))
+	require.True(t, errors.As(err, &lerr))
+	assert.Equal(t, models.StepLimitMemory, lerr.Limit)
+
+	// The allocation error printed by the process is a regular failure
+	err = sb.execError(ctx, ctx, errors.New("exit status 1: magick: Cannot allocate memory"))
+	assert.False(t, errors.As(err, &lerr))
+}
+
+func TestSandboxExecCommand(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	t.Setenv("APFS_ALLOWED", "yes")
+	t.Setenv("APFS_SECRET", "leak")
+
+	sb := newTestSandbox(t, &models.WorkflowStepLimits{Env: []string{"APFS_ALLOWED"}, IsolateWorkdir: true})
+	m, err := sb.manifest(&manifest.Manifest{Name: "env", Command: manifest.CommandArg{"sh", "-c", `echo "$(pwd)|${APFS_ALLOWED:-}|${APFS_SECRET:-}|$1"`, "sh", "a b"}})
+	require.NoError(t, err)
+	require.Equal(t, "bash", m.Command[0])
+
+	out, err := exec.Command(m.Command[0], m.Command[1:]...).Output()
+	require.NoError(t, err)
+	assert.Equal(t, sb.workdir+"|yes||a b", strings.TrimSpace(string(out)))
+
+	// Docker steps can't be sandboxed
+	_, err = sb.manifest(&manifest.Manifest{Driver: manifest.DriverDocker})
+	assert.Error(t, err)
+}
+
+func TestSandboxOutputLimit(t *testing.T) {
+	sb := newTestSandbox(t, &models.WorkflowStepLimits{MaxOutput: "1KB"})
+
+	rc, err := sb.limitReader(io.NopCloser(strings.NewReader(strings.Repeat("x", 1024))))
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Len(t, data, 1024)
+	name := rc.(*tempFile).Name()
+	require.NoError(t, rc.Close())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+
+	var lerr *workflow.LimitError
+	_, err = sb.limitReader(io.NopCloser(strings.NewReader(strings.Repeat("x", 1025))))
+	require.True(t, errors.As(err, &lerr))
+	assert.Equal(t, models.StepLimitOutput, lerr.Limit)
+
+	err = sb.checkBuffer(bytes.NewBufferString(strings.Repeat("x", 2048)))
+	require.True(t, errors.As(err, &lerr))
+	assert.Equal(t, models.StepLimitOutput, lerr.Limit)
+}
+
+func TestSandboxOutputPipe(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	run := func(t *testing.T, script string) (io.ReadCloser, error) {
+		sb := newTestSandbox(t, &models.WorkflowStepLimits{MaxOutput: "1KB"})
+		m, err := sb.manifest(&manifest.Manifest{ScriptMode: true, Command: manifest.CommandArg{script}})
+		require.NoError(t, err)
+		require.NotNil(t, sb.stdout)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		stepCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		require.NoError(t, sb.start(stop))
+		_ = exec.CommandContext(stepCtx, "bash", "-c", m.Command[0]).Run()
+		if err := sb.wait(); err != nil {
+			return nil, err
+		}
+		require.NoError(t, ctx.Err())
+		return sb.limitReader(nil)
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+		rc, err := run(t, "printf hello")
+		require.NoError(t, err)
+		defer func() { _ = rc.Close() }()
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("process is stopped", func(t *testing.T) {
+		// The endless output is stopped as soon as it exceeds the limit
+		_, err := run(t, "while :; do echo 0123456789; done")
+		var lerr *workflow.LimitError
+		require.True(t, errors.As(err, &lerr), "%v", err)
+		assert.Equal(t, models.StepLimitOutput, lerr.Limit)
+	})
+
+	t.Run("background child", func(t *testing.T) {
+		// The orphan keeps the pipe open, the rest of its output is dropped
+		start := time.Now()
+		rc, err := run(t, "(sleep 5; echo late) &\nprintf hi")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		assert.Equal(t, "hi", string(data))
+		assert.Less(t, time.Since(start), 4*time.Second)
+	})
+
+	t.Run("no output", func(t *testing.T) {
+		// The prelude is not reached, the pipe is never opened
+		rc, err := run(t, "exit 3\n"+strings.Repeat("#", 1<<20))
+		require.NoError(t, err)
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		assert.Empty(t, data)
+	})
+}
@@ -1,6 +1,10 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 // EventType value
 type EventType string
@@ -15,15 +19,46 @@ const (
 	UpdateEventType    EventType = "update"
 	ProcessedEventType EventType = "processed"
 	DeleteEventType    EventType = "delete"
+
+	// Job events of the workflow jobs
+	JobStartedEventType   EventType = "job.started"
+	JobCompletedEventType EventType = "job.completed"
+	JobFailedEventType    EventType = "job.failed"
 )
 
+// IsJobEvent reports whether the event is about the single workflow job
+func (t EventType) IsJobEvent() bool {
+	return strings.HasPrefix(string(t), "job.")
+}
+
 // Event produced by storage
 //
 //easyjson:json
 type Event struct {
+	// ID of the event, unique for every published event
+	ID string `json:"id,omitempty"`
+
 	Type   EventType `json:"type"`
+	Time   time.Time `json:"time,omitzero"`
 	Error  string    `json:"error,omitempty"`
 	Object *Object   `json:"object,omitempty"`
+
+	// JobID of the job events
+	JobID string `json:"job_id,omitempty"`
+
+	// WorkflowVersion of the object processing
+	WorkflowVersion string `json:"workflow_version,omitempty"`
+
+	// State is the snapshot of the processing state of the processed and
+	// the job events
+	State *ProcessingState `json:"state,omitempty"`
+
+	// CorrelationID is shared by all events caused by the same upload or
+	// the same processing request
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// TraceContext of the span which produced the event (W3C traceparent)
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // IsError object
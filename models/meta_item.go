@@ -7,6 +7,20 @@ import (
 	"time"
 )
 
+// Well-known attribute keys shared between converters and the protocol layer.
+const (
+	// AttributeBlurHash holds the BlurHash placeholder string of an image.
+	AttributeBlurHash = "blurhash"
+	// AttributeThumbHash holds the base64-encoded ThumbHash placeholder of an image.
+	AttributeThumbHash = "thumbhash"
+	// AttributeTier holds the name of the storage tier the file is placed on.
+	// The file is on the default storage when it is empty.
+	AttributeTier = "tier"
+)
+
+// RoleOriginal is the ItemMeta.Role of the source file.
+const RoleOriginal = "original"
+
 // ItemMeta holds metadata for a single file (original or derived artifact)
 // within an object scope.
 //
@@ -114,6 +128,25 @@ func (m *ItemMeta) GetAttribute(key string) any {
 	return m.Attributes[key]
 }
 
+// BlurHash returns the BlurHash placeholder or "" when it was not computed.
+func (m *ItemMeta) BlurHash() string {
+	s, _ := m.GetAttribute(AttributeBlurHash).(string)
+	return s
+}
+
+// Tier returns the storage tier of the file or "" for the default storage.
+func (m *ItemMeta) Tier() string {
+	s, _ := m.GetAttribute(AttributeTier).(string)
+	return s
+}
+
+// ThumbHash returns the base64-encoded ThumbHash placeholder or "" when it
+// was not computed.
+func (m *ItemMeta) ThumbHash() string {
+	s, _ := m.GetAttribute(AttributeThumbHash).(string)
+	return s
+}
+
 // SetExt supports nested dot-notation paths for backward compatibility
 // (e.g. "video.codec" creates {"video": {"codec": value}}).
 // For simple keys prefer SetAttribute.
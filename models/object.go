@@ -31,7 +31,7 @@ type Object struct {
 
 	ContentType string                          `json:"content_type"`
 	Type        ObjectType                      `json:"type"`
-	Tags        gosql.NullableJSONArray[string] `json:"tags,omitempty"`
+	Tags        gosql.NullableJSONArray[string] `json:"tags,omitempty" gorm:"type:text"`
 	Meta        gosql.NullableJSON[Meta]        `json:"meta,omitempty"`
 	Workflow    gosql.NullableJSON[Workflow]    `json:"workflow,omitempty"`
 	Size        uint64                          `json:"size"` // Size in bytes
@@ -53,6 +53,14 @@ func (o *Object) TableName() string {
 	return "object"
 }
 
+// WorkflowVersion returns the version of the object workflow
+func (o *Object) WorkflowVersion() string {
+	if o == nil || o.Workflow.Data == nil {
+		return ""
+	}
+	return o.Workflow.Data.Version
+}
+
 // IncompleteJobs returns the list of workflow jobs whose output targets
 // are not yet present in the object's meta items.
 func (o *Object) IncompleteJobs() (resp []string) {
@@ -11,13 +11,15 @@ const (
 	ProcessingStatusCompleted ProcessingStatus = "completed"
 	ProcessingStatusPartial   ProcessingStatus = "partial" // some jobs failed with on-failure:continue
 	ProcessingStatusFailed    ProcessingStatus = "failed"
+	ProcessingStatusCancelled ProcessingStatus = "cancelled" // stopped by CancelProcessing
 )
 
 func (s ProcessingStatus) String() string { return string(s) }
 
 // IsTerminal reports whether the status represents a final (non-running) state.
 func (s ProcessingStatus) IsTerminal() bool {
-	return s == ProcessingStatusCompleted || s == ProcessingStatusPartial || s == ProcessingStatusFailed
+	return s == ProcessingStatusCompleted || s == ProcessingStatusPartial ||
+		s == ProcessingStatusFailed || s == ProcessingStatusCancelled
 }
 
 // IsSuccess reports whether processing finished without critical errors.
@@ -52,6 +54,9 @@ const (
 	StepStatusCompleted StepStatus = "completed"
 	StepStatusFailed    StepStatus = "failed"
 	StepStatusSkipped   StepStatus = "skipped"
+
+	// StepStatusLimitExceeded marks a step stopped by one of its sandbox limits
+	StepStatusLimitExceeded StepStatus = "limit_exceeded"
 )
 
 func (s StepStatus) String() string { return string(s) }
@@ -71,6 +76,13 @@ type ProcessingState struct {
 	StartedAt       time.Time            `json:"started_at"`
 	UpdatedAt       time.Time            `json:"updated_at"`
 	FinishedAt      *time.Time           `json:"finished_at,omitempty"`
+
+	// TraceID of the trace the processing started in
+	TraceID string `json:"trace_id,omitempty"`
+
+	// CancelledAt is the time the processing was cancelled, the workers stop
+	// the running jobs of the cancelled processing
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
 }
 
 // NewProcessingState creates an initial pending state for objectID.
@@ -160,6 +172,8 @@ func (ps *ProcessingState) ComputeStatus() {
 	}
 	total := len(ps.Jobs)
 	switch {
+	case ps.CancelledAt != nil && running == 0:
+		ps.Status = ProcessingStatusCancelled
 	case running > 0 || (pending > 0 && completed+failed+skipped > 0):
 		ps.Status = ProcessingStatusRunning
 	case pending == total:
@@ -173,6 +187,22 @@ func (ps *ProcessingState) ComputeStatus() {
 	}
 }
 
+// NextAttemptAt returns the earliest time of the scheduled job retries
+// or nil if no retry is scheduled
+func (ps *ProcessingState) NextAttemptAt() *time.Time {
+	if ps == nil {
+		return nil
+	}
+	var next *time.Time
+	for _, j := range ps.Jobs {
+		if j != nil && j.Status == JobStatusPending && j.NextAttemptAt != nil &&
+			(next == nil || j.NextAttemptAt.Before(*next)) {
+			next = j.NextAttemptAt
+		}
+	}
+	return next
+}
+
 // JobState is the runtime state of one job in the processing DAG.
 type JobState struct {
 	Status     JobStatus      `json:"status"`
@@ -184,6 +214,13 @@ type JobState struct {
 	StartedAt  *time.Time     `json:"started_at,omitempty"`
 	FinishedAt *time.Time     `json:"finished_at,omitempty"`
 	Progress   float64        `json:"progress,omitempty"`
+
+	// NextAttemptAt is the time the scheduled retry of the pending job starts
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+
+	// Fence is the fencing token of the job lease held by the worker.
+	// A worker with a lower token lost the lease and must not write the state.
+	Fence uint64 `json:"fence,omitempty"`
 }
 
 // MarkStarted transitions the job to running state.
@@ -192,6 +229,7 @@ func (j *JobState) MarkStarted(worker string) {
 	j.Status = JobStatusRunning
 	j.Worker = worker
 	j.StartedAt = &now
+	j.NextAttemptAt = nil
 }
 
 // MarkCompleted transitions the job to completed state.
@@ -233,6 +271,22 @@ func (j *JobState) ResetForRetry() {
 	j.Error = ""
 	j.Steps = nil
 	j.Progress = 0
+	j.NextAttemptAt = nil
+}
+
+// ScheduleRetry prepares the job for another attempt started not before at.
+// The error of the failed attempt is kept until the job starts.
+func (j *JobState) ScheduleRetry(at time.Time, err error) {
+	j.ResetForRetry()
+	j.NextAttemptAt = &at
+	if err != nil {
+		j.Error = err.Error()
+	}
+}
+
+// IsDue reports whether the pending job can be started at the time
+func (j *JobState) IsDue(now time.Time) bool {
+	return j.NextAttemptAt == nil || !j.NextAttemptAt.After(now)
 }
 
 // StepState is the runtime state of one step within a job.
@@ -241,4 +295,10 @@ type StepState struct {
 	Status     StepStatus `json:"status"`
 	DurationMs int64      `json:"duration_ms,omitempty"`
 	Error      string     `json:"error,omitempty"`
+
+	// Limit is the sandbox limit violated by the step (StepStatusLimitExceeded)
+	Limit StepLimit `json:"limit,omitempty"`
+
+	// LogPath of the step output log inside the object scope
+	LogPath string `json:"log_path,omitempty"`
 }
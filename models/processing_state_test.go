@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -103,6 +104,21 @@ func TestProcessingState_ComputeStatus_MixedRunning(t *testing.T) {
 	assert.Equal(t, ProcessingStatusRunning, ps.Status)
 }
 
+func TestProcessingState_ComputeStatus_Cancelled(t *testing.T) {
+	now := time.Now()
+	ps := NewProcessingState("obj", "2", []string{"a", "b"})
+	ps.CancelledAt = &now
+	ps.Jobs["a"].Status = JobStatusRunning
+	ps.Jobs["b"].MarkSkipped("processing cancelled")
+	ps.ComputeStatus()
+	// The running job is not stopped yet
+	assert.Equal(t, ProcessingStatusRunning, ps.Status)
+
+	ps.Jobs["a"].MarkFailed(nil)
+	ps.ComputeStatus()
+	assert.Equal(t, ProcessingStatusCancelled, ps.Status)
+}
+
 // ── ProcessingStatus helpers ──────────────────────────────────────────────────
 
 func TestProcessingStatus_IsTerminal(t *testing.T) {
@@ -111,6 +127,7 @@ func TestProcessingStatus_IsTerminal(t *testing.T) {
 	assert.True(t, ProcessingStatusCompleted.IsTerminal())
 	assert.True(t, ProcessingStatusPartial.IsTerminal())
 	assert.True(t, ProcessingStatusFailed.IsTerminal())
+	assert.True(t, ProcessingStatusCancelled.IsTerminal())
 }
 
 func TestProcessingStatus_IsSuccess(t *testing.T) {
@@ -41,6 +41,17 @@ type Workflow struct {
 	// A validation failure returns an error to the caller immediately.
 	Validate *WorkflowValidate `json:"validate,omitempty" yaml:"validate,omitempty"`
 
+	// Quota limits the size, the number of objects and the upload rate of
+	// the group. Checked during Upload before any file is persisted.
+	Quota *WorkflowQuota `json:"quota,omitempty" yaml:"quota,omitempty"`
+
+	// Transform is the allow-list of on-the-fly image transformations
+	// served by the _transform HTTP endpoint.
+	Transform *WorkflowTransform `json:"transform,omitempty" yaml:"transform,omitempty"`
+
+	// Notify lists the webhooks notified on the processing lifecycle events.
+	Notify *WorkflowNotify `json:"notify,omitempty" yaml:"notify,omitempty"`
+
 	// Jobs is the processing DAG. Keys are job IDs; order of execution is
 	// determined by the needs graph, not by map iteration order.
 	Jobs map[string]*WorkflowJob `json:"jobs,omitempty" yaml:"jobs,omitempty"`
@@ -147,6 +158,10 @@ type WorkflowJob struct {
 	// Accepted values: "fail" (default), "continue", "retry:N".
 	OnFailure string `json:"on_failure,omitempty" yaml:"on-failure,omitempty"`
 
+	// Retry schedules the retries of the failed job with the backoff delay.
+	// Overrides the "retry:N" on-failure value.
+	Retry *WorkflowRetry `json:"retry,omitempty" yaml:"retry,omitempty"`
+
 	// If is a Go-template-style expression evaluated against the outputs of
 	// upstream jobs. When the expression evaluates to false the job is skipped.
 	// Example: "${{ probe.outputs.duration < 3600 }}"
@@ -190,6 +205,7 @@ type WorkflowStep struct {
 	Run    string              `json:"run,omitempty"    yaml:"run,omitempty"`
 	With   map[string]any      `json:"with,omitempty"   yaml:"with,omitempty"`
 	Docker *WorkflowStepDocker `json:"docker,omitempty" yaml:"docker,omitempty"`
+	Limits *WorkflowStepLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
 }
 
 // WorkflowStepDocker holds Docker-specific configuration for a step whose
@@ -402,6 +418,12 @@ func matchContentType(ct, pattern string) bool {
 	return false
 }
 
+// ParseSize converts human-readable sizes ("2GB", "500MB", "1024") to bytes.
+// Returns 0 if the value is empty or invalid.
+func ParseSize(s string) int64 {
+	return parseSizeString(s)
+}
+
 // parseSizeString converts human-readable sizes ("2GB", "500MB", "1024") to bytes.
 func parseSizeString(s string) int64 {
 	s = strings.TrimSpace(s)
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+)
+
+// StepLimit names a sandbox limit which could be violated by a step process
+type StepLimit string
+
+// Sandbox limit names reported in StepState.Limit
+const (
+	StepLimitCPU       StepLimit = "cpu"
+	StepLimitMemory    StepLimit = "memory"
+	StepLimitOutput    StepLimit = "output"
+	StepLimitWallClock StepLimit = "wall-clock"
+)
+
+func (l StepLimit) String() string { return string(l) }
+
+// WorkflowStepLimits declares the sandbox restrictions of a shell/exec step.
+//
+//	limits:
+//	  cpu-seconds: 30
+//	  memory: 512MB
+//	  max-output: 100MB
+//	  timeout-seconds: 120
+//	  env: [LANG, MAGICK_THREAD_LIMIT]
+//	  isolate-workdir: true
+//	  no-network: true
+type WorkflowStepLimits struct {
+	// CPUSeconds is the maximum CPU time of the step process (RLIMIT_CPU).
+	CPUSeconds int `json:"cpu_seconds,omitempty" yaml:"cpu-seconds,omitempty"`
+
+	// Memory is the maximum virtual memory of the step process (e.g. "512MB").
+	Memory string `json:"memory,omitempty" yaml:"memory,omitempty"`
+
+	// MaxOutput is the maximum size of the produced output (e.g. "100MB").
+	MaxOutput string `json:"max_output,omitempty" yaml:"max-output,omitempty"`
+
+	// TimeoutSeconds is the wall-clock timeout of the step.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty" yaml:"timeout-seconds,omitempty"`
+
+	// Env is the allow-list of the worker environment variables visible to the
+	// step. When set, every other variable (except PATH) is removed.
+	Env []string `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// IsolateWorkdir runs the step inside a fresh temporary directory which
+	// is also used as HOME and TMPDIR and removed after the step.
+	IsolateWorkdir bool `json:"isolate_workdir,omitempty" yaml:"isolate-workdir,omitempty"`
+
+	// NoNetwork runs the step in a new network namespace (unshare -rn)
+	// when the host supports unprivileged user namespaces.
+	NoNetwork bool `json:"no_network,omitempty" yaml:"no-network,omitempty"`
+}
+
+// MemoryBytes parses Memory and returns bytes. Returns 0 if not set or invalid.
+func (l *WorkflowStepLimits) MemoryBytes() int64 {
+	if l == nil {
+		return 0
+	}
+	return parseSizeString(l.Memory)
+}
+
+// MaxOutputBytes parses MaxOutput and returns bytes. Returns 0 if not set or invalid.
+func (l *WorkflowStepLimits) MaxOutputBytes() int64 {
+	if l == nil {
+		return 0
+	}
+	return parseSizeString(l.MaxOutput)
+}
+
+// Timeout returns the wall-clock limit as a time.Duration
+func (l *WorkflowStepLimits) Timeout() time.Duration {
+	if l == nil || l.TimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(l.TimeoutSeconds) * time.Second
+}
+
+// HasProcessLimits returns true if any limit must be applied to the
+// process itself (as opposed to the ones enforced by the runner).
+func (l *WorkflowStepLimits) HasProcessLimits() bool {
+	return l != nil && (l.CPUSeconds > 0 || l.MemoryBytes() > 0 ||
+		l.Env != nil || l.IsolateWorkdir || l.NoNetwork)
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Notification events of the webhooks
+const (
+	// NotifyProcessed is sent when the processing of the object succeeded
+	NotifyProcessed = "processed"
+
+	// NotifyFailed is sent when the processing of the object failed
+	NotifyFailed = "failed"
+
+	// NotifyDeleted is sent when the object or its items are deleted
+	NotifyDeleted = "deleted"
+
+	// NotifyJobCompleted is sent when the workflow job completed
+	NotifyJobCompleted = "job.completed"
+
+	// NotifyJobFailed is sent when the workflow job failed
+	NotifyJobFailed = "job.failed"
+)
+
+// Default webhook delivery values
+const (
+	DefaultWebhookMaxRetries = 5
+	DefaultWebhookTimeout    = 10 * time.Second
+)
+
+// WorkflowNotify lists the HTTP webhooks notified on the processing
+// lifecycle events of the group objects.
+//
+// Example:
+//
+//	notify:
+//	  webhooks:
+//	    - url: https://example.com/hooks/apfs
+//	      secret: s3cr3t
+//	      events: [processed, failed, job.completed]
+//	      headers: { X-Tenant: acme }
+//	      max_retries: 5
+//	      timeout: 10s
+type WorkflowNotify struct {
+	Webhooks []*WorkflowWebhook `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+}
+
+// WorkflowWebhook is the HTTP endpoint receiving the event payloads by POST
+type WorkflowWebhook struct {
+	// URL of the endpoint
+	URL string `json:"url" yaml:"url"`
+
+	// Secret is the HMAC-SHA256 key of the payload signature, the payload
+	// is not signed if empty
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// Events sent to the endpoint, all events if empty
+	Events []string `json:"events,omitempty" yaml:"events,omitempty"`
+
+	// Headers added to the requests
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// MaxRetries of the failed delivery (5 by default, -1 disables retries)
+	MaxRetries int `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+
+	// Timeout of one request (e.g. "10s")
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Hooks returns the webhooks subscribed to the event
+func (n *WorkflowNotify) Hooks(event string) []*WorkflowWebhook {
+	if n == nil {
+		return nil
+	}
+	var hooks []*WorkflowWebhook
+	for _, hook := range n.Webhooks {
+		if hook != nil && hook.URL != "" && hook.Accepts(event) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// Accepts reports whether the webhook is subscribed to the event
+func (h *WorkflowWebhook) Accepts(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if strings.EqualFold(strings.TrimSpace(e), event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Retries returns the maximum number of the delivery retries
+func (h *WorkflowWebhook) Retries() int {
+	switch {
+	case h.MaxRetries < 0:
+		return 0
+	case h.MaxRetries == 0:
+		return DefaultWebhookMaxRetries
+	}
+	return h.MaxRetries
+}
+
+// RequestTimeout returns the timeout of one delivery request
+func (h *WorkflowWebhook) RequestTimeout() time.Duration {
+	d := parseDurationOr(h.Timeout, DefaultWebhookTimeout)
+	if d <= 0 {
+		return DefaultWebhookTimeout
+	}
+	return d
+}
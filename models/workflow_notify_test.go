@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowNotify_Hooks(t *testing.T) {
+	var (
+		all    = &WorkflowWebhook{URL: "http://a"}
+		jobs   = &WorkflowWebhook{URL: "http://b", Events: []string{"job.completed", " Job.Failed "}}
+		noURL  = &WorkflowWebhook{Events: []string{"processed"}}
+		notify = &WorkflowNotify{Webhooks: []*WorkflowWebhook{all, jobs, noURL, nil}}
+	)
+	assert.Equal(t, []*WorkflowWebhook{all}, notify.Hooks(NotifyProcessed))
+	assert.Equal(t, []*WorkflowWebhook{all, jobs}, notify.Hooks(NotifyJobFailed))
+	assert.Nil(t, (*WorkflowNotify)(nil).Hooks(NotifyProcessed))
+}
+
+func TestWorkflowWebhook_Defaults(t *testing.T) {
+	hook := &WorkflowWebhook{}
+	assert.Equal(t, DefaultWebhookMaxRetries, hook.Retries())
+	assert.Equal(t, DefaultWebhookTimeout, hook.RequestTimeout())
+
+	hook = &WorkflowWebhook{MaxRetries: -1, Timeout: "3s"}
+	assert.Zero(t, hook.Retries())
+	assert.Equal(t, 3*time.Second, hook.RequestTimeout())
+}
@@ -0,0 +1,36 @@
+package models
+
+// WorkflowQuota limits the usage of the group. The limits are checked during
+// Upload before any file is persisted, zero values are not limited.
+//
+// Example:
+//
+//	quota:
+//	  max_bytes: 50GB
+//	  max_objects: 100000
+//	  uploads_per_minute: 60
+type WorkflowQuota struct {
+	// MaxBytes is the maximum total size of the group: originals and
+	// derived items (e.g. "50GB", "500MB", "1024").
+	MaxBytes string `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+
+	// MaxObjects is the maximum number of the objects in the group.
+	MaxObjects int64 `json:"max_objects,omitempty" yaml:"max_objects,omitempty"`
+
+	// UploadsPerMinute is the maximum number of uploads per minute of one
+	// principal (the caller identity or its address).
+	UploadsPerMinute int64 `json:"uploads_per_minute,omitempty" yaml:"uploads_per_minute,omitempty"`
+}
+
+// MaxBytesValue parses MaxBytes and returns bytes. Returns 0 if not set or invalid.
+func (q *WorkflowQuota) MaxBytesValue() int64 {
+	if q == nil {
+		return 0
+	}
+	return parseSizeString(q.MaxBytes)
+}
+
+// IsEmpty reports whether the quota has no limits.
+func (q *WorkflowQuota) IsEmpty() bool {
+	return q == nil || (q.MaxBytesValue() <= 0 && q.MaxObjects <= 0 && q.UploadsPerMinute <= 0)
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retry backoff strategies
+const (
+	RetryBackoffNone        = "none"
+	RetryBackoffConstant    = "constant"
+	RetryBackoffLinear      = "linear"
+	RetryBackoffExponential = "exponential"
+)
+
+// Retry conditions of WorkflowRetry.On
+const (
+	// RetryOnTimeout matches the job timeout and the step wall-clock limit
+	RetryOnTimeout = "timeout"
+
+	// RetryOnExitCode is the prefix of the step process exit code condition
+	// (e.g. "exit-code:75")
+	RetryOnExitCode = "exit-code:"
+)
+
+// Default retry policy values
+const (
+	DefaultRetryMax      = 3
+	DefaultRetryInitial  = 10 * time.Second
+	DefaultRetryMaxDelay = 10 * time.Minute
+)
+
+// WorkflowRetry is the retry policy of the failed job. The retries are
+// scheduled after the backoff delay instead of the immediate rerun.
+//
+//	retry:
+//	  max: 5
+//	  backoff: exponential
+//	  initial: 10s
+//	  max-delay: 10m
+//	  on: [timeout, exit-code:75]
+//
+// The job which is still failed after max retries follows on-failure.
+type WorkflowRetry struct {
+	// Max is the maximum number of retries (3 by default)
+	Max int `json:"max,omitempty" yaml:"max,omitempty"`
+
+	// Backoff strategy of the delay: "none", "constant", "linear" or
+	// "exponential" (default)
+	Backoff string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+
+	// Initial is the delay of the first retry (e.g. "10s")
+	Initial string `json:"initial,omitempty" yaml:"initial,omitempty"`
+
+	// MaxDelay caps the delay of the retries (e.g. "10m")
+	MaxDelay string `json:"max_delay,omitempty" yaml:"max-delay,omitempty"`
+
+	// On lists the failures which are retried: "timeout" or "exit-code:N".
+	// Every failure is retried when empty.
+	On []string `json:"on,omitempty" yaml:"on,omitempty"`
+}
+
+// MaxRetries returns the maximum number of retries
+func (r *WorkflowRetry) MaxRetries() int {
+	switch {
+	case r == nil:
+		return 0
+	case r.Max <= 0:
+		return DefaultRetryMax
+	}
+	return r.Max
+}
+
+// InitialDelay returns the delay of the first retry
+func (r *WorkflowRetry) InitialDelay() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return parseDurationOr(r.Initial, DefaultRetryInitial)
+}
+
+// MaxDelayDuration returns the maximum delay of the retry
+func (r *WorkflowRetry) MaxDelayDuration() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return parseDurationOr(r.MaxDelay, DefaultRetryMaxDelay)
+}
+
+// Delay returns the delay before the retry number attempt (1-based)
+func (r *WorkflowRetry) Delay(attempt int) time.Duration {
+	if r == nil || attempt < 1 {
+		return 0
+	}
+	var (
+		initial = r.InitialDelay()
+		delay   time.Duration
+	)
+	switch strings.ToLower(r.Backoff) {
+	case RetryBackoffNone:
+		return 0
+	case RetryBackoffConstant:
+		delay = initial
+	case RetryBackoffLinear:
+		delay = initial * time.Duration(attempt)
+	default: // exponential
+		delay = initial
+		for i := 1; i < attempt && delay < r.MaxDelayDuration(); i++ {
+			delay *= 2
+		}
+	}
+	return min(delay, r.MaxDelayDuration())
+}
+
+// Matches reports whether the failure is retried by the policy.
+// exitCode is the exit code of the failed step process or -1 if unknown.
+func (r *WorkflowRetry) Matches(timeout bool, exitCode int) bool {
+	if r == nil {
+		return false
+	}
+	if len(r.On) == 0 {
+		return true
+	}
+	for _, cond := range r.On {
+		cond = strings.TrimSpace(cond)
+		if cond == RetryOnTimeout && timeout {
+			return true
+		}
+		if code, ok := strings.CutPrefix(cond, RetryOnExitCode); ok && exitCode >= 0 {
+			if n, err := strconv.Atoi(strings.TrimSpace(code)); err == nil && n == exitCode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RetryPolicy returns the retry policy of the job. The policy of the
+// "retry:N" on-failure value reruns the job immediately.
+// Returns nil if the failed job is not retried.
+func (j *WorkflowJob) RetryPolicy() *WorkflowRetry {
+	switch {
+	case j == nil:
+		return nil
+	case j.Retry != nil:
+		return j.Retry
+	}
+	if fp := j.FailurePolicy(); fp&0x0F == FailurePolicyRetry {
+		return &WorkflowRetry{Max: fp.MaxRetries(), Backoff: RetryBackoffNone}
+	}
+	return nil
+}
+
+func parseDurationOr(s string, def time.Duration) time.Duration {
+	if s = strings.TrimSpace(s); s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowRetry_Delay(t *testing.T) {
+	tests := []struct {
+		name  string
+		retry *WorkflowRetry
+		want  []time.Duration // delays of the attempts 1..n
+	}{
+		{"nil", nil, []time.Duration{0, 0}},
+		{"exponential", &WorkflowRetry{Backoff: "exponential", Initial: "10s", MaxDelay: "1m"},
+			[]time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}},
+		{"default", &WorkflowRetry{},
+			[]time.Duration{DefaultRetryInitial, 2 * DefaultRetryInitial}},
+		{"linear", &WorkflowRetry{Backoff: "linear", Initial: "5s", MaxDelay: "12s"},
+			[]time.Duration{5 * time.Second, 10 * time.Second, 12 * time.Second}},
+		{"constant", &WorkflowRetry{Backoff: "constant", Initial: "3s"},
+			[]time.Duration{3 * time.Second, 3 * time.Second}},
+		{"none", &WorkflowRetry{Backoff: "none", Initial: "3s"}, []time.Duration{0, 0}},
+		{"invalid durations", &WorkflowRetry{Initial: "soon", MaxDelay: "-1s"},
+			[]time.Duration{DefaultRetryInitial}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, want := range tc.want {
+				assert.Equal(t, want, tc.retry.Delay(i+1), "attempt %d", i+1)
+			}
+		})
+	}
+}
+
+func TestWorkflowRetry_Matches(t *testing.T) {
+	retry := &WorkflowRetry{On: []string{"timeout", "exit-code:75", "exit-code: 69"}}
+	assert.True(t, retry.Matches(true, -1))
+	assert.True(t, retry.Matches(false, 75))
+	assert.True(t, retry.Matches(false, 69))
+	assert.False(t, retry.Matches(false, 1))
+	assert.False(t, retry.Matches(false, -1))
+
+	assert.True(t, (&WorkflowRetry{}).Matches(false, -1), "every failure is retried without conditions")
+	assert.False(t, (*WorkflowRetry)(nil).Matches(true, 75))
+}
+
+func TestWorkflowJob_RetryPolicy(t *testing.T) {
+	assert.Nil(t, (&WorkflowJob{OnFailure: "fail"}).RetryPolicy())
+
+	policy := (&WorkflowJob{OnFailure: "retry:2"}).RetryPolicy()
+	require.NotNil(t, policy)
+	assert.Equal(t, 2, policy.MaxRetries())
+	assert.Zero(t, policy.Delay(1), "on-failure retry is immediate")
+
+	retry := &WorkflowRetry{Max: 5}
+	assert.Same(t, retry, (&WorkflowJob{OnFailure: "retry:2", Retry: retry}).RetryPolicy())
+	assert.Equal(t, DefaultRetryMax, (&WorkflowRetry{}).MaxRetries())
+}
+
+func TestJobState_ScheduleRetry(t *testing.T) {
+	var (
+		now = time.Now()
+		js  = &JobState{Status: JobStatusRunning, Worker: "w1"}
+	)
+	js.ScheduleRetry(now.Add(time.Minute), assert.AnError)
+	assert.Equal(t, JobStatusPending, js.Status)
+	assert.Equal(t, assert.AnError.Error(), js.Error)
+	assert.False(t, js.IsDue(now))
+	assert.True(t, js.IsDue(now.Add(time.Minute)))
+
+	ps := &ProcessingState{Jobs: map[string]*JobState{"a": js, "b": {Status: JobStatusPending}}}
+	require.NotNil(t, ps.NextAttemptAt())
+	assert.Equal(t, now.Add(time.Minute), *ps.NextAttemptAt())
+
+	js.MarkStarted("w2")
+	assert.Nil(t, js.NextAttemptAt)
+	assert.Nil(t, ps.NextAttemptAt())
+}
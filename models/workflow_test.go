@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -113,6 +114,76 @@ func TestWorkflowValidate_SizeBytes(t *testing.T) {
 	assert.Equal(t, int64(1024), (&WorkflowValidate{MaxSize: "1024"}).MaxSizeBytes())
 }
 
+// ── WorkflowTransform ─────────────────────────────────────────────────────────
+
+func TestWorkflowTransform_Match(t *testing.T) {
+	tr := &WorkflowTransform{Presets: map[string]*TransformPreset{
+		"card":  {Width: 300, Height: 200, Fit: "cover", Format: "webp"},
+		"thumb": {Width: 64, Height: 64, Format: "jpeg"},
+	}}
+
+	name, preset := tr.Match(&TransformPreset{Width: 300, Height: 200, Format: "webp"})
+	assert.Equal(t, "card", name)
+	assert.NotNil(t, preset)
+
+	name, _ = tr.Match(&TransformPreset{Width: 64, Height: 64, Fit: "COVER", Format: "jpg"})
+	assert.Equal(t, "thumb", name)
+
+	name, preset = tr.Match(&TransformPreset{Width: 301, Height: 200, Format: "webp"})
+	assert.Empty(t, name)
+	assert.Nil(t, preset)
+
+	assert.Nil(t, (*WorkflowTransform)(nil).Preset("card"))
+	assert.Equal(t, tr.Presets["thumb"], tr.Preset("thumb"))
+}
+
+func TestTransformPreset_TargetName(t *testing.T) {
+	assert.Equal(t, "_transform-300x200-cover.webp",
+		(&TransformPreset{Width: 300, Height: 200, Format: "webp"}).TargetName("jpg"))
+	assert.Equal(t, "_transform-64x0-contain-q80.png",
+		(&TransformPreset{Width: 64, Fit: "contain", Quality: 80}).TargetName(".png"))
+	assert.True(t, IsTransformTarget("_transform-64x0-contain-q80.png"))
+	assert.False(t, IsTransformTarget("thumb.png"))
+}
+
+func TestTransformPreset_Validate(t *testing.T) {
+	assert.NoError(t, (&TransformPreset{Width: 10}).Validate())
+	assert.Error(t, (&TransformPreset{}).Validate())
+	assert.Error(t, (&TransformPreset{Width: 10, Fit: "crop"}).Validate())
+	assert.Error(t, (&TransformPreset{Width: 10, Quality: 101}).Validate())
+	assert.NoError(t, (&TransformPreset{Width: 10, Format: "jpeg"}).Validate())
+	assert.Error(t, (&TransformPreset{Width: 10, Format: "svg"}).Validate())
+	assert.Error(t, (*TransformPreset)(nil).Validate())
+}
+
+func TestWorkflowTransform_Validate(t *testing.T) {
+	assert.NoError(t, (*WorkflowTransform)(nil).Validate())
+	assert.NoError(t, (&WorkflowTransform{Presets: map[string]*TransformPreset{"card": {Width: 10, Format: "webp"}}}).Validate())
+	err := (&WorkflowTransform{Presets: map[string]*TransformPreset{
+		"card": {Width: 10}, "thumb": {Width: 10, Fit: "crop"},
+	}}).Validate()
+	assert.ErrorContains(t, err, `"thumb"`)
+}
+
+// ── WorkflowStepLimits ────────────────────────────────────────────────────────
+
+func TestWorkflowStepLimits(t *testing.T) {
+	var nilLimits *WorkflowStepLimits
+	assert.Zero(t, nilLimits.MemoryBytes())
+	assert.Zero(t, nilLimits.Timeout())
+	assert.False(t, nilLimits.HasProcessLimits())
+
+	limits := &WorkflowStepLimits{Memory: "512MB", MaxOutput: "1KB", TimeoutSeconds: 30}
+	assert.Equal(t, int64(512*1024*1024), limits.MemoryBytes())
+	assert.Equal(t, int64(1024), limits.MaxOutputBytes())
+	assert.Equal(t, 30*time.Second, limits.Timeout())
+	assert.True(t, limits.HasProcessLimits())
+
+	// Wall-clock and output limits are enforced by the runner
+	assert.False(t, (&WorkflowStepLimits{MaxOutput: "1MB", TimeoutSeconds: 5}).HasProcessLimits())
+	assert.True(t, (&WorkflowStepLimits{Env: []string{}}).HasProcessLimits())
+}
+
 // ── FailurePolicy ─────────────────────────────────────────────────────────────
 
 func TestParseFailurePolicy(t *testing.T) {
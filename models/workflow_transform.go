@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Transform fit modes
+const (
+	// TransformFitCover scales the image to fill the box and crops the overflow.
+	TransformFitCover = "cover"
+	// TransformFitContain scales the image to fit into the box keeping the aspect ratio.
+	TransformFitContain = "contain"
+	// TransformFitFill stretches the image to the exact box size.
+	TransformFitFill = "fill"
+)
+
+// TransformNamePrefix is the name prefix of derived artifacts created by
+// on-the-fly transformations.
+const TransformNamePrefix = "_transform-"
+
+// IsTransformTarget reports whether name refers to an on-the-fly
+// transformation artifact.
+func IsTransformTarget(name string) bool {
+	return strings.HasPrefix(name, TransformNamePrefix)
+}
+
+// WorkflowTransform declares the on-the-fly image transformations which
+// can be requested via the _transform HTTP endpoint. Any parameter
+// combination which does not match one of the presets is rejected.
+//
+// Example:
+//
+//	transform:
+//	  presets:
+//	    card:  { width: 300, height: 200, fit: cover, format: webp }
+//	    thumb: { width: 64, height: 64 }
+type WorkflowTransform struct {
+	Presets map[string]*TransformPreset `json:"presets,omitempty" yaml:"presets,omitempty"`
+}
+
+// Preset returns the preset by name or nil.
+func (t *WorkflowTransform) Preset(name string) *TransformPreset {
+	if t == nil || name == "" {
+		return nil
+	}
+	return t.Presets[name]
+}
+
+// Validate checks every preset of the allow-list.
+func (t *WorkflowTransform) Validate() error {
+	if t == nil {
+		return nil
+	}
+	for _, name := range t.names() {
+		if err := t.Presets[name].Validate(); err != nil {
+			return fmt.Errorf("transform preset %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Match returns the name of the first preset (in name order) which is equal
+// to the requested parameters, or "" when the request is not allowed.
+func (t *WorkflowTransform) Match(req *TransformPreset) (string, *TransformPreset) {
+	if t == nil || req == nil {
+		return "", nil
+	}
+	for _, name := range t.names() {
+		if preset := t.Presets[name]; preset.Equal(req) {
+			return name, preset
+		}
+	}
+	return "", nil
+}
+
+func (t *WorkflowTransform) names() []string {
+	names := make([]string, 0, len(t.Presets))
+	for name := range t.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TransformPreset describes a single allowed image transformation.
+type TransformPreset struct {
+	// Width and Height of the target box; zero keeps the aspect ratio.
+	Width  int `json:"width,omitempty"  yaml:"width,omitempty"`
+	Height int `json:"height,omitempty" yaml:"height,omitempty"`
+
+	// Fit is one of "cover" (default), "contain" or "fill".
+	Fit string `json:"fit,omitempty" yaml:"fit,omitempty"`
+
+	// Format is the target image format (jpeg, png, webp, gif).
+	// Empty keeps the format of the original.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Quality of the JPEG encoding (1..100), zero means the encoder default.
+	Quality int `json:"quality,omitempty" yaml:"quality,omitempty"`
+}
+
+// FitMode returns the fit mode with the default applied.
+func (p *TransformPreset) FitMode() string {
+	if p == nil || p.Fit == "" {
+		return TransformFitCover
+	}
+	return strings.ToLower(p.Fit)
+}
+
+// FormatExt returns the normalised file extension of the target format
+// or "" to keep the original format.
+func (p *TransformPreset) FormatExt() string {
+	if p == nil {
+		return ""
+	}
+	switch format := strings.ToLower(strings.TrimPrefix(p.Format, ".")); format {
+	case "jpg", "jpeg":
+		return "jpg"
+	default:
+		return format
+	}
+}
+
+// Equal compares two presets after normalisation of the defaults.
+func (p *TransformPreset) Equal(other *TransformPreset) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.Width == other.Width &&
+		p.Height == other.Height &&
+		p.FitMode() == other.FitMode() &&
+		p.FormatExt() == other.FormatExt() &&
+		p.Quality == other.Quality
+}
+
+// Validate checks the preset parameters.
+func (p *TransformPreset) Validate() error {
+	if p == nil {
+		return fmt.Errorf("transform: empty preset")
+	}
+	if p.Width < 0 || p.Height < 0 || (p.Width == 0 && p.Height == 0) {
+		return fmt.Errorf("transform: invalid size %dx%d", p.Width, p.Height)
+	}
+	switch p.FitMode() {
+	case TransformFitCover, TransformFitContain, TransformFitFill:
+	default:
+		return fmt.Errorf("transform: unsupported fit %q", p.Fit)
+	}
+	if p.Quality < 0 || p.Quality > 100 {
+		return fmt.Errorf("transform: invalid quality %d", p.Quality)
+	}
+	switch p.FormatExt() {
+	case "", "jpg", "png", "gif", "webp", "tiff", "bmp":
+	default:
+		return fmt.Errorf("transform: unsupported format %q", p.Format)
+	}
+	return nil
+}
+
+// TargetName returns the deterministic artifact name of the transformation,
+// e.g. "_transform-300x200-cover.webp". srcExt is used when the preset keeps
+// the original format.
+func (p *TransformPreset) TargetName(srcExt string) string {
+	ext := p.FormatExt()
+	if ext == "" {
+		ext = strings.TrimPrefix(srcExt, ".")
+	}
+	return p.baseName() + "." + ext
+}
+
+func (p *TransformPreset) baseName() string {
+	name := fmt.Sprintf("%s%dx%d-%s", TransformNamePrefix, p.Width, p.Height, p.FitMode())
+	if p.Quality > 0 {
+		name += fmt.Sprintf("-q%d", p.Quality)
+	}
+	return name
+}